@@ -496,7 +496,7 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	sender, err := types.Sender(types.NewEIP155Signer(b.config.ChainID), tx)
+	sender, err := types.Sender(types.LatestSigner(b.config), tx)
 	if err != nil {
 		panic(fmt.Errorf("invalid transaction: %v", err))
 	}
@@ -649,9 +649,11 @@ func (m callmsg) GasPrice() *big.Int                   { return m.CallMsg.GasPri
 func (m callmsg) FeeCurrency() *common.Address         { return m.CallMsg.FeeCurrency }
 func (m callmsg) GatewayFeeRecipient() *common.Address { return m.CallMsg.GatewayFeeRecipient }
 func (m callmsg) GatewayFee() *big.Int                 { return m.CallMsg.GatewayFee }
+func (m callmsg) FeePayer() *common.Address            { return m.CallMsg.FeePayer }
 func (m callmsg) Gas() uint64                          { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int                      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte                         { return m.CallMsg.Data }
+func (m callmsg) AccessList() types.AccessList         { return m.CallMsg.AccessList }
 
 // filterBackend implements filters.Backend to support filtering for logs without
 // taking bloom-bits acceleration structures into account.
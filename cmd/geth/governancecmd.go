@@ -0,0 +1,219 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/celo-org/celo-blockchain/cmd/utils"
+	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/contract_comm"
+	"github.com/celo-org/celo-blockchain/contract_comm/governance"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	governanceDryRunBlockFlag = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Block whose state the proposal is executed on (default: head block)",
+	}
+	governanceDryRunJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the report as JSON",
+	}
+
+	governanceCommand = cli.Command{
+		Name:     "governance",
+		Usage:    "Governance tools",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "dry-run",
+				Usage:     "Preview the effects of a Governance proposal",
+				ArgsUsage: "<proposal file>",
+				Action:    utils.MigrateFlags(governanceDryRun),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AlfajoresFlag,
+					utils.BaklavaFlag,
+					utils.CacheFlag,
+					configFileFlag,
+					governanceDryRunBlockFlag,
+					governanceDryRunJSONFlag,
+				},
+				Description: `
+    geth governance dry-run --datadir <datadir> [--block <number>] proposal.json
+
+Executes the transactions of a proposal on behalf of the Governance contract,
+on top of the state of a block of a stopped node, and reports for each of them
+whether it succeeded and the events it emitted. It then reports the storage
+slots changed in the contracts listed in the registry, and the changes to the
+protocol parameters read by the node from the core contracts (gas price
+minimums, fee currency and transfer whitelists, validators, epoch rewards...).
+
+The proposal file is a JSON list of transactions:
+
+    [{"destination": "0x...", "value": "0", "data": "0x..."}]
+
+Unlike Governance, which reverts the whole proposal if any transaction fails,
+the transactions following a failed one are still executed. The command fails
+if any transaction failed. The changes made by the proposal aren't written to
+the database.`,
+			},
+		},
+	}
+)
+
+func governanceDryRun(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	var proposal governance.Proposal
+	if err := readJSONFile(ctx.Args().First(), &proposal); err != nil {
+		utils.Fatalf("Failed to read proposal: %v", err)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	config := rawdb.ReadChainConfig(chainDb, rawdb.ReadCanonicalHash(chainDb, 0))
+	if config == nil {
+		utils.Fatalf("No chain found in the datadir")
+	}
+	// The chain is only the context of the contract_comm calls. Unlike a BlockChain, which
+	// may repair its head on startup, it only reads the headers and states from the database.
+	headers, err := core.NewHeaderChain(chainDb, config, mockEngine.NewFaker(), func() bool { return false })
+	if err != nil {
+		utils.Fatalf("Failed to load the chain: %v", err)
+	}
+	chain := &dryRunChain{HeaderChain: headers, db: state.NewDatabase(chainDb)}
+	contract_comm.SetInternalEVMHandler(chain)
+	defer contract_comm.UnsetInternalEVMHandler(chain)
+
+	header := chain.CurrentHeader()
+	if ctx.IsSet(governanceDryRunBlockFlag.Name) {
+		number := ctx.Uint64(governanceDryRunBlockFlag.Name)
+		if header = chain.GetHeaderByNumber(number); header == nil {
+			utils.Fatalf("Block %d not found", number)
+		}
+	}
+	statedb, err := state.New(header.Root, chain.db, nil)
+	if err != nil {
+		utils.Fatalf("State of block %d not available (only archive nodes keep old states): %v", header.Number, err)
+	}
+
+	result, err := governance.DryRun(proposal, header, statedb)
+	if err != nil {
+		utils.Fatalf("Failed to execute the proposal: %v", err)
+	}
+	if ctx.Bool(governanceDryRunJSONFlag.Name) {
+		blob, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode the report: %v", err)
+		}
+		fmt.Println(string(blob))
+	} else {
+		printDryRun(result)
+	}
+	if !result.Success {
+		return errors.New("proposal execution failed")
+	}
+	return nil
+}
+
+// dryRunChain is the read only chain context of the contract calls of a dry run.
+type dryRunChain struct {
+	*core.HeaderChain
+	db state.Database
+}
+
+// GetVMConfig returns the default vm configuration.
+func (c *dryRunChain) GetVMConfig() *vm.Config {
+	return &vm.Config{}
+}
+
+// State returns the state of the head block.
+func (c *dryRunChain) State() (*state.StateDB, error) {
+	return state.New(c.CurrentHeader().Root, c.db, nil)
+}
+
+func printDryRun(result *governance.Result) {
+	fmt.Printf("Proposal executed by Governance %s on block %d, %d gas used\n", result.Governance.Hex(), result.Block, result.GasUsed)
+	for _, step := range result.Steps {
+		status := "OK"
+		if !step.Success {
+			status = "FAILED: " + step.Error
+		}
+		fmt.Printf("\nTransaction %d to %s: %s\n", step.Index, contractName(step.Destination.Hex(), step.Contract), status)
+		fmt.Printf("  Gas used: %d\n", step.GasUsed)
+		for _, event := range step.Events {
+			topics := make([]string, len(event.Topics))
+			for i, topic := range event.Topics {
+				topics[i] = topic.Hex()
+			}
+			fmt.Printf("  Event from %s: topics [%s] data %s\n", contractName(event.Address.Hex(), event.Contract), strings.Join(topics, ", "), event.Data)
+		}
+	}
+
+	fmt.Println("\nStorage changes:")
+	if len(result.StorageDiffs) == 0 {
+		fmt.Println("  None")
+	}
+	for _, diff := range result.StorageDiffs {
+		fmt.Printf("  %s:\n", contractName(diff.Address.Hex(), diff.Contract))
+		for _, slot := range diff.Slots {
+			key := slot.Key.Hex()
+			if slot.Hashed {
+				key = "hash " + key
+			}
+			fmt.Printf("    %s: %s -> %s\n", key, slot.Before.Hex(), slot.After.Hex())
+		}
+	}
+
+	fmt.Println("\nParameter changes:")
+	if len(result.Parameters) == 0 {
+		fmt.Println("  None")
+	}
+	for _, change := range result.Parameters {
+		fmt.Printf("  %s: %s -> %s\n", change.Name, change.Before, change.After)
+	}
+	names := make([]string, 0, len(result.After.Errors))
+	for name := range result.After.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s: can't be read after the proposal: %s\n", name, result.After.Errors[name])
+	}
+}
+
+func contractName(address, name string) string {
+	if name == "" {
+		return address
+	}
+	return fmt.Sprintf("%s (%s)", name, address)
+}
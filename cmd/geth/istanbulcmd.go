@@ -0,0 +1,354 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/cmd/utils"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/core"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/epochproof"
+	gethcore "github.com/celo-org/celo-blockchain/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	istanbulReplayDivergedFlag = cli.BoolFlag{
+		Name:  "diverged",
+		Usage: "Only report the entries for which the replayed state differs from the recorded one",
+	}
+
+	verifyEpochsGenesisFlag = cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Genesis JSON file defining the chain config and the trusted validator set (defaults to mainnet)",
+	}
+	verifyEpochsTrustedFlag = cli.StringFlag{
+		Name:  "trusted",
+		Usage: "Trusted state JSON file to start the verification from, instead of the genesis validator set",
+	}
+	verifyEpochsOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the trusted state at the end of the proof to",
+	}
+
+	verifyEpochsCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyEpochs),
+		Name:      "verify-epochs",
+		Usage:     "Verify an epoch proof obtained with istanbul_getEpochProof",
+		ArgsUsage: "<proof file>",
+		Flags: []cli.Flag{
+			verifyEpochsGenesisFlag,
+			verifyEpochsTrustedFlag,
+			verifyEpochsOutputFlag,
+		},
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+    geth verify-epochs --genesis genesis.json proof.json
+
+Verifies offline that the epoch blocks of the proof were committed by the validators
+of their epoch, and that these validators signed the validator set of the next epoch,
+starting from the validator set of the genesis block (or from a trusted state written
+by a previous verification with --output). It prints the validator set at the end of
+the last epoch of the proof.`,
+	}
+
+	istanbulCommand = cli.Command{
+		Name:     "istanbul",
+		Usage:    "Istanbul consensus tools",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "replay",
+				Usage:     "Replay an Istanbul message journal and report each state transition",
+				ArgsUsage: "<journal file or directory>",
+				Action:    utils.MigrateFlags(istanbulReplay),
+				Flags: []cli.Flag{
+					istanbulReplayDivergedFlag,
+				},
+				Description: `
+    geth istanbul replay <datadir>/celo/istanbuljournal
+
+Feeds the messages and events recorded by a node running with --istanbul.journal
+back into an Istanbul core backed by a mock backend, and prints the state
+transition caused by each entry. Entries for which the replayed state differs
+from the recorded one are marked as DIVERGED.
+
+The replay starts at the first checkpoint of the journal, and restarts at any
+checkpoint whose state differs from the replayed one (e.g. after the node was
+restarted).`,
+			},
+			{
+				Name:      "export-slashing-protection",
+				Usage:     "Export the consensus messages signed by the validator",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(exportSlashingProtection),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					configFileFlag,
+				},
+				Description: `
+    geth istanbul export-slashing-protection --datadir <datadir> protection.json
+
+Writes the slashing protection database of a stopped validator, which records
+the view and digest of every consensus message signed by the validator, to a
+JSON interchange file. Import it on the new host before starting the validator
+there, to prevent it from signing messages conflicting with the ones signed on
+the old host.`,
+			},
+			{
+				Name:      "import-slashing-protection",
+				Usage:     "Import the consensus messages signed by the validator on another host",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(importSlashingProtection),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					configFileFlag,
+				},
+				Description: `
+    geth istanbul import-slashing-protection --datadir <datadir> protection.json
+
+Merges a JSON interchange file written by export-slashing-protection into the
+slashing protection database of a stopped validator. The validator refuses to
+sign messages conflicting with the imported ones, and doesn't sign anything
+for sequences lower than the highest imported one.`,
+			},
+		},
+	}
+)
+
+func istanbulReplay(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	entries, err := core.ReadJournal(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read journal: %v", err)
+	}
+	onlyDiverged := ctx.Bool(istanbulReplayDivergedFlag.Name)
+
+	var (
+		replayer *core.Replayer
+		diverged int
+	)
+	defer func() {
+		if replayer != nil {
+			replayer.Close()
+		}
+	}()
+	for i, entry := range entries {
+		if entry.Kind == core.JournalCheckpoint && (replayer == nil || !replayer.State().Equal(entry.After)) {
+			if replayer != nil {
+				replayer.Close()
+			}
+			config, err := core.ReplayConfig(entry)
+			if err != nil {
+				utils.Fatalf("Failed to obtain istanbul config from checkpoint %d: %v", i, err)
+			}
+			if replayer, err = core.NewReplayer(entry, config); err != nil {
+				utils.Fatalf("Failed to start replay from checkpoint %d: %v", i, err)
+			}
+			fmt.Printf("%6d %s checkpoint address=%s head=%d state=%v\n", i, entryTime(entry), entry.Chain.Address.Hex(), entry.Chain.Head.Number, replayer.State())
+			continue
+		}
+		if replayer == nil {
+			continue
+		}
+
+		step, err := replayer.Apply(entry)
+		if err != nil {
+			utils.Fatalf("Failed to replay entry %d: %v", i, err)
+		}
+		if step.Diverged() {
+			diverged++
+		} else if onlyDiverged {
+			continue
+		}
+		fmt.Println(formatReplayStep(i, step))
+	}
+	fmt.Printf("Replayed %d entries, %d diverged\n", len(entries), diverged)
+	return nil
+}
+
+func entryTime(entry *core.JournalEntry) string {
+	return time.Unix(0, int64(entry.Time)).UTC().Format("15:04:05.000")
+}
+
+func formatReplayStep(i int, step *core.ReplayStep) string {
+	entry := step.Entry
+	var b strings.Builder
+	fmt.Fprintf(&b, "%6d %s %-17s", i, entryTime(entry), entry.Kind)
+	switch entry.Kind {
+	case core.JournalMessage, core.JournalBacklogMessage, core.JournalSent:
+		var msg istanbul.Message
+		if err := msg.FromPayload(entry.Payload, nil); err == nil {
+			fmt.Fprintf(&b, " %s from=%s", msgCodeName(msg.Code), msg.Address.Hex())
+		}
+	case core.JournalTimeout, core.JournalResendRoundChange:
+		fmt.Fprintf(&b, " view=%v", entry.View)
+	case core.JournalVerify:
+		fmt.Fprintf(&b, " hash=%x", entry.Payload)
+	}
+	fmt.Fprintf(&b, " %v -> %v", entry.Before, step.State)
+	if step.Diverged() {
+		fmt.Fprintf(&b, " DIVERGED (recorded %v)", entry.After)
+	}
+	if entry.Err != "" {
+		fmt.Fprintf(&b, " recorded_err=%q", entry.Err)
+	}
+	if step.Err != nil && step.Err.Error() != entry.Err {
+		fmt.Fprintf(&b, " replay_err=%q", step.Err)
+	}
+	for _, msg := range step.Sent {
+		fmt.Fprintf(&b, " sent=%s", msgCodeName(msg.Code))
+	}
+	if step.Committed != nil {
+		fmt.Fprintf(&b, " committed=%d/%s", step.Committed.Number(), step.Committed.Hash().TerminalString())
+	}
+	return b.String()
+}
+
+func msgCodeName(code uint64) string {
+	switch code {
+	case istanbul.MsgPreprepare:
+		return "PREPREPARE"
+	case istanbul.MsgPrepare:
+		return "PREPARE"
+	case istanbul.MsgCommit:
+		return "COMMIT"
+	case istanbul.MsgRoundChange:
+		return "ROUNDCHANGE"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", code)
+	}
+}
+
+func openSlashingProtectionDB(ctx *cli.Context) core.SlashingProtectionDB {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	_, cfg := makeConfigNode(ctx)
+	db, err := core.OpenSlashingProtectionDB(cfg.Eth.Istanbul.SlashingProtectionDBPath)
+	if err != nil {
+		utils.Fatalf("Failed to open slashing protection database: %v", err)
+	}
+	return db
+}
+
+func exportSlashingProtection(ctx *cli.Context) error {
+	db := openSlashingProtectionDB(ctx)
+	defer db.Close()
+
+	interchange, err := db.Export()
+	if err != nil {
+		utils.Fatalf("Failed to export slashing protection data: %v", err)
+	}
+	blob, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode slashing protection data: %v", err)
+	}
+	if err := ioutil.WriteFile(ctx.Args().First(), blob, 0644); err != nil {
+		utils.Fatalf("Failed to write slashing protection data: %v", err)
+	}
+	fmt.Printf("Exported %d signed messages of validator %s\n", len(interchange.SignedMessages), interchange.Metadata.Validator.Hex())
+	return nil
+}
+
+func importSlashingProtection(ctx *cli.Context) error {
+	db := openSlashingProtectionDB(ctx)
+	defer db.Close()
+
+	interchange := new(core.SlashingProtectionInterchange)
+	if err := readJSONFile(ctx.Args().First(), interchange); err != nil {
+		utils.Fatalf("Failed to read slashing protection data: %v", err)
+	}
+	if err := db.Import(interchange); err != nil {
+		utils.Fatalf("Failed to import slashing protection data: %v", err)
+	}
+	fmt.Printf("Imported %d signed messages of validator %s\n", len(interchange.SignedMessages), interchange.Metadata.Validator.Hex())
+	return nil
+}
+
+func verifyEpochs(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	genesis := gethcore.MainnetGenesisBlock()
+	if path := ctx.String(verifyEpochsGenesisFlag.Name); path != "" {
+		genesis = new(gethcore.Genesis)
+		if err := readJSONFile(path, genesis); err != nil {
+			utils.Fatalf("Failed to read genesis file: %v", err)
+		}
+	}
+	config := genesis.Config
+	if config == nil || config.Istanbul == nil {
+		utils.Fatalf("Genesis has no istanbul config")
+	}
+
+	var (
+		trusted *epochproof.TrustedState
+		err     error
+	)
+	if path := ctx.String(verifyEpochsTrustedFlag.Name); path != "" {
+		trusted = new(epochproof.TrustedState)
+		if err := readJSONFile(path, trusted); err != nil {
+			utils.Fatalf("Failed to read trusted state: %v", err)
+		}
+	} else if trusted, err = epochproof.GenesisTrustedState(genesis.ToBlock(nil).Header()); err != nil {
+		utils.Fatalf("Failed to obtain the genesis validators: %v", err)
+	}
+
+	proof := new(epochproof.Proof)
+	if err := readJSONFile(ctx.Args().First(), proof); err != nil {
+		utils.Fatalf("Failed to read proof: %v", err)
+	}
+	state, err := epochproof.Verify(config, trusted, proof)
+	if err != nil {
+		utils.Fatalf("Invalid proof: %v", err)
+	}
+
+	fmt.Printf("Verified epochs %d to %d\n", proof.From, proof.To)
+	fmt.Printf("Epoch %d ended at block %x with %d validators:\n", state.Epoch, state.Hash, len(state.Validators))
+	for i, v := range state.Validators {
+		fmt.Printf("%4d %s\n", i, v.Address.Hex())
+	}
+	if path := ctx.String(verifyEpochsOutputFlag.Name); path != "" {
+		blob, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode trusted state: %v", err)
+		}
+		if err := ioutil.WriteFile(path, blob, 0644); err != nil {
+			utils.Fatalf("Failed to write trusted state: %v", err)
+		}
+	}
+	return nil
+}
+
+func readJSONFile(path string, value interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(value)
+}
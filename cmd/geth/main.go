@@ -83,6 +83,10 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolPrivatePeersFlag,
+		utils.GpoBlocksFlag,
+		utils.GpoPercentileFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
@@ -124,6 +128,8 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerTxOrderingFlag,
+		utils.MinerSenderGasCapFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -149,6 +155,11 @@ var (
 		utils.IstanbulProposerPolicyFlag,
 		utils.IstanbulLookbackWindowFlag,
 		utils.IstanbulReplicaFlag,
+		utils.IstanbulReplicaAutoPromoteFlag,
+		utils.IstanbulReplicaAutoPromoteDelayFlag,
+		utils.IstanbulJournalFlag,
+		utils.IstanbulJournalMaxFileSizeFlag,
+		utils.IstanbulJournalMaxFilesFlag,
 		utils.AnnounceQueryEnodeGossipPeriodFlag,
 		utils.AnnounceAggressiveQueryEnodeGossipOnEnablementFlag,
 		utils.PingIPFromPacketFlag,
@@ -161,6 +172,8 @@ var (
 		utils.ProxyEnodeURLPairsFlag,
 		utils.ProxyEnodeURLPairsLegacyFlag,
 		utils.ProxyAllowPrivateIPFlag,
+		utils.ProxyHealthCheckPeriodFlag,
+		utils.ProxyHealthCheckMaxFailuresFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -236,6 +249,11 @@ func init() {
 		dumpConfigCommand,
 		// See retesteth.go
 		retestethCommand,
+		// See istanbulcmd.go
+		istanbulCommand,
+		verifyEpochsCommand,
+		// See governancecmd.go
+		governanceCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -119,6 +119,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolPrivateLifetimeFlag,
+			utils.TxPoolPrivatePeersFlag,
+		},
+	},
+	{
+		Name: "GAS PRICE ORACLE",
+		Flags: []cli.Flag{
+			utils.GpoBlocksFlag,
+			utils.GpoPercentileFlag,
 		},
 	},
 	{
@@ -201,6 +210,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerTxOrderingFlag,
+			utils.MinerSenderGasCapFlag,
 		},
 	},
 	{
@@ -234,6 +245,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IstanbulProposerPolicyFlag,
 			utils.IstanbulLookbackWindowFlag,
 			utils.IstanbulReplicaFlag,
+			utils.IstanbulReplicaAutoPromoteFlag,
+			utils.IstanbulReplicaAutoPromoteDelayFlag,
+			utils.IstanbulJournalFlag,
+			utils.IstanbulJournalMaxFileSizeFlag,
+			utils.IstanbulJournalMaxFilesFlag,
 		},
 	},
 	{
@@ -253,6 +269,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ProxyEnodeURLPairsFlag,
 			utils.ProxyEnodeURLPairsLegacyFlag,
 			utils.ProxyAllowPrivateIPFlag,
+			utils.ProxyHealthCheckPeriodFlag,
+			utils.ProxyHealthCheckMaxFailuresFlag,
 		},
 	},
 	{
@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/celo-org/celo-blockchain/core/rawdb"
 	"github.com/celo-org/celo-blockchain/ethclient"
 	"github.com/celo-org/celo-blockchain/internal/fileutils"
 	"golang.org/x/sync/errgroup"
@@ -55,10 +56,9 @@ func init() {
 		createGenesisCommand,
 		createGenesisConfigCommand,
 		createGenesisFromConfigCommand,
+		createGenesisFromChainCommand,
 		initValidatorsCommand,
 		runValidatorsCommand,
-		// initNodesCommand,
-		// runNodesCommand,
 		loadBotCommand,
 		envCommand,
 	}
@@ -71,7 +71,7 @@ func main() {
 var templateFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:  "template",
-		Usage: "Optional template to use (default: local)",
+		Usage: "Optional template to use: local, loadtest or topology (default: local)",
 	},
 	cli.IntFlag{
 		Name:  "validators",
@@ -81,6 +81,26 @@ var templateFlags = []cli.Flag{
 		Name:  "dev.accounts",
 		Usage: "Number of developer accounts",
 	},
+	cli.IntFlag{
+		Name:  "proxies",
+		Usage: "Number of proxies in front of each validator",
+	},
+	cli.IntFlag{
+		Name:  "fullnodes",
+		Usage: "Number of non validating full nodes",
+	},
+	cli.IntFlag{
+		Name:  "lightservers",
+		Usage: "Number of full nodes serving light clients",
+	},
+	cli.IntFlag{
+		Name:  "lightclients",
+		Usage: "Number of light clients",
+	},
+	cli.IntFlag{
+		Name:  "ultralightclients",
+		Usage: "Number of ultralight clients",
+	},
 	cli.Uint64Flag{
 		Name:  "blockperiod",
 		Usage: "Seconds between each block",
@@ -120,6 +140,16 @@ var loadTestTPSFlag = cli.IntFlag{
 	Value: 20,
 }
 
+var loadTestProfileFlag = cli.StringFlag{
+	Name:  "profile",
+	Usage: "JSON or YAML file with the weighted mix of workloads to run (default: cUSD transfers)",
+}
+
+var loadTestDurationFlag = cli.DurationFlag{
+	Name:  "duration",
+	Usage: "Duration of the load test, after which a summary is reported (default: until stopped)",
+}
+
 var createGenesisCommand = cli.Command{
 	Name:      "genesis",
 	Usage:     "Creates genesis.json from a template and overrides",
@@ -146,9 +176,24 @@ var createGenesisFromConfigCommand = cli.Command{
 	Flags:     []cli.Flag{buildpathFlag},
 }
 
+var createGenesisFromChainCommand = cli.Command{
+	Name:      "genesis-from-chain",
+	Usage:     "Creates genesis.json from the state of an existing chain, a template and overrides",
+	Action:    createGenesisFromChain,
+	ArgsUsage: "",
+	Flags: append(
+		[]cli.Flag{
+			newEnvFlag,
+			cli.StringFlag{Name: "chaindata", Usage: "Chaindata directory of the chain to fork, synced in full or archive mode (geth must be stopped)"},
+			cli.Uint64Flag{Name: "number", Usage: "Block whose state is forked (default: head block)"},
+			cli.BoolFlag{Name: "replace-owners", Usage: "Make the admin account own the core contracts, and the developer accounts own the multisigs"},
+		},
+		templateFlags...),
+}
+
 var initValidatorsCommand = cli.Command{
 	Name:      "validator-init",
-	Usage:     "Setup all validators nodes",
+	Usage:     "Setup all nodes (validators, proxies, full nodes and light clients)",
 	ArgsUsage: "[envdir]",
 	Action:    validatorInit,
 	Flags:     []cli.Flag{gethPathFlag},
@@ -156,37 +201,22 @@ var initValidatorsCommand = cli.Command{
 
 var runValidatorsCommand = cli.Command{
 	Name:      "validator-run",
-	Usage:     "Runs the testnet",
+	Usage:     "Runs the testnet, with all its nodes",
 	ArgsUsage: "[envdir]",
 	Action:    validatorRun,
 	Flags: []cli.Flag{
 		gethPathFlag,
 		cli.BoolFlag{Name: "init", Usage: "Init nodes before running them"},
+		cli.BoolFlag{Name: "inprocess", Usage: "Run the validators within mycelo, keeping their chain in memory, instead of running geth"},
 	},
 }
 
-// var initNodesCommand = cli.Command{
-// 	Name:      "node-init",
-// 	Usage:     "Setup all tx nodes",
-// 	ArgsUsage: "[envdir]",
-// 	Action:    nodeInit,
-// 	Flags:     []cli.Flag{gethPathFlag},
-// }
-
-// var runNodesCommand = cli.Command{
-// 	Name:      "run",
-// 	Usage:     "Runs the tx nodes",
-// 	ArgsUsage: "[envdir]",
-// 	Action:    nodeRun,
-// 	Flags:     []cli.Flag{gethPathFlag},
-// }
-
 var loadBotCommand = cli.Command{
 	Name:      "load-bot",
 	Usage:     "Runs the load bot on the environment",
 	ArgsUsage: "[envdir]",
 	Action:    loadBot,
-	Flags:     []cli.Flag{loadTestTPSFlag},
+	Flags:     []cli.Flag{loadTestTPSFlag, loadTestProfileFlag, loadTestDurationFlag},
 }
 
 func readWorkdir(ctx *cli.Context) (string, error) {
@@ -252,6 +282,21 @@ func envFromTemplate(ctx *cli.Context, workdir string) (*env.Environment, *genes
 	if ctx.IsSet("mnemonic") {
 		env.Config.Mnemonic = ctx.String("mnemonic")
 	}
+	if ctx.IsSet("proxies") {
+		env.Config.ProxiesPerValidator = ctx.Int("proxies")
+	}
+	if ctx.IsSet("fullnodes") {
+		env.Config.FullNodes = ctx.Int("fullnodes")
+	}
+	if ctx.IsSet("lightservers") {
+		env.Config.LightServers = ctx.Int("lightservers")
+	}
+	if ctx.IsSet("lightclients") {
+		env.Config.LightClients = ctx.Int("lightclients")
+	}
+	if ctx.IsSet("ultralightclients") {
+		env.Config.UltralightClients = ctx.Int("ultralightclients")
+	}
 
 	// Create the accounts after the env overrides are set
 	err = env.Refresh()
@@ -318,6 +363,67 @@ func createGenesis(ctx *cli.Context) error {
 	return env.SaveGenesis(genesis)
 }
 
+func createGenesisFromChain(ctx *cli.Context) error {
+	if !ctx.IsSet("chaindata") {
+		return fmt.Errorf("missing --chaindata flag")
+	}
+	chaindata := ctx.String("chaindata")
+	db, err := rawdb.NewLevelDBDatabaseWithFreezer(chaindata, 512, 256, path.Join(chaindata, "ancient"), "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	number := ctx.Uint64("number")
+	if !ctx.IsSet("number") {
+		head := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db))
+		if head == nil {
+			return fmt.Errorf("no head block in %s", chaindata)
+		}
+		number = *head
+	}
+
+	var workdir string
+	if ctx.IsSet("newenv") {
+		workdir = ctx.String("newenv")
+		if !fileutils.FileExists(workdir) {
+			os.MkdirAll(workdir, os.ModePerm)
+		}
+	} else {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	env, genesisConfig, err := envFromTemplate(ctx, workdir)
+	if err != nil {
+		return err
+	}
+
+	// Without developer accounts, the admin account owns the multisigs too
+	owners := env.DeveloperAccounts()
+	if !ctx.Bool("replace-owners") {
+		owners = nil
+	} else if len(owners) == 0 {
+		owners = append(owners, env.AdminAccount())
+	}
+
+	log.Info("Forking chain", "chaindata", chaindata, "number", number)
+	genesis, err := genesis.GenerateGenesisFromChain(db, number, env.AdminAccount(), env.ValidatorAccounts(), owners, genesisConfig)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("newenv") {
+		if err = env.Save(); err != nil {
+			return err
+		}
+	}
+
+	return env.SaveGenesis(genesis)
+}
+
 func createGenesisConfig(ctx *cli.Context) error {
 	workdir, err := readWorkdir(ctx)
 	if err != nil {
@@ -386,6 +492,11 @@ func validatorRun(ctx *cli.Context) error {
 		return err
 	}
 
+	if ctx.IsSet("inprocess") {
+		cluster := cluster.NewInProcess(env, &cluster.InProcessConfig{IPC: true})
+		return cluster.Run(withExitSignals(context.Background()))
+	}
+
 	gethPath, err := readGethPath(ctx)
 	if err != nil {
 		return err
@@ -405,43 +516,6 @@ func validatorRun(ctx *cli.Context) error {
 	return group.Wait()
 }
 
-// // TODO: Make this run a full node, not a validator node
-// func nodeInit(ctx *cli.Context) error {
-// 	env, err := readEnv(ctx)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	gethPath, err := readGethPath(ctx)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	cluster := cluster.New(env, gethPath)
-// 	return cluster.Init()
-// }
-
-// // TODO: Make this run a full node, not a validator node
-// func nodeRun(ctx *cli.Context) error {
-// 	env, err := readEnv(ctx)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	gethPath, err := readGethPath(ctx)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	cluster := cluster.New(env, gethPath)
-
-// 	group, runCtx := errgroup.WithContext(withExitSignals(context.Background()))
-
-// 	group.Go(func() error { return cluster.Run(runCtx) })
-
-// 	return group.Wait()
-// }
-
 func loadBot(ctx *cli.Context) error {
 	env, err := readEnv(ctx)
 	if err != nil {
@@ -451,7 +525,14 @@ func loadBot(ctx *cli.Context) error {
 	verbosityLevel := ctx.GlobalInt("verbosity")
 	verbose := verbosityLevel >= 4
 
-	runCtx := context.Background()
+	var profile *loadbot.Profile
+	if path := ctx.String(loadTestProfileFlag.Name); path != "" {
+		if profile, err = loadbot.LoadProfile(path); err != nil {
+			return err
+		}
+	}
+
+	runCtx := withExitSignals(context.Background())
 
 	var clients []*ethclient.Client
 	for i := 0; i < env.Config.InitialValidators; i++ {
@@ -463,11 +544,17 @@ func loadBot(ctx *cli.Context) error {
 		clients = append(clients, client)
 	}
 
-	return loadbot.Start(runCtx, &loadbot.Config{
+	report, err := loadbot.Start(runCtx, &loadbot.Config{
 		Accounts:              env.DeveloperAccounts(),
 		Amount:                big.NewInt(10000000),
 		TransactionsPerSecond: ctx.Int("tps"),
 		Clients:               clients,
 		Verbose:               verbose,
+		Profile:               profile,
+		Duration:              ctx.Duration(loadTestDurationFlag.Name),
 	})
+	if report != nil {
+		fmt.Printf("\nLoad test summary\n%s", report)
+	}
+	return err
 }
@@ -23,6 +23,8 @@ func templateFromString(templateStr string) template {
 		return localEnv{}
 	case "loadtest":
 		return loadtestEnv{}
+	case "topology":
+		return topologyEnv{}
 	}
 	return localEnv{}
 }
@@ -60,6 +62,7 @@ func (e localEnv) createGenesisConfig(env *env.Environment) (*genesis.Config, er
 	genesisConfig.Hardforks = genesis.HardforkConfig{
 		ChurritoBlock: common.Big0,
 		DonutBlock:    common.Big0,
+		EspressoBlock: common.Big0,
 	}
 
 	genesisConfig.Blockchain.UptimeLookbackWindow = int64(genesisConfig.Istanbul.LookbackWindow)
@@ -130,6 +133,7 @@ func (e loadtestEnv) createGenesisConfig(env *env.Environment) (*genesis.Config,
 	genesisConfig.Hardforks = genesis.HardforkConfig{
 		ChurritoBlock: common.Big0,
 		DonutBlock:    common.Big0,
+		EspressoBlock: common.Big0,
 	}
 
 	genesisConfig.Blockchain.UptimeLookbackWindow = int64(genesisConfig.Istanbul.LookbackWindow)
@@ -173,3 +177,20 @@ func (e loadtestEnv) createGenesisConfig(env *env.Environment) (*genesis.Config,
 
 	return genesisConfig, nil
 }
+
+// topologyEnv is the local env with every kind of node: validators behind
+// proxies, full nodes, light servers and light clients
+type topologyEnv struct{ localEnv }
+
+func (e topologyEnv) createEnv(workdir string) (*env.Environment, error) {
+	env, err := e.localEnv.createEnv(workdir)
+	if err != nil {
+		return nil, err
+	}
+	env.Config.ProxiesPerValidator = 1
+	env.Config.FullNodes = 1
+	env.Config.LightServers = 1
+	env.Config.LightClients = 1
+	env.Config.UltralightClients = 1
+	return env, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/simulation"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var istanbulCommand = cli.Command{
+	Name:  "istanbul",
+	Usage: "run an istanbul consensus scenario in a local simulation",
+	Description: `
+Runs a network of istanbul validators in process, injects the faults of the scenario
+and prints the block times, round changes and finality of the committed blocks.
+The scenario is either a built-in scenario or a JSON file.`,
+	Action: runIstanbul,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "scenario",
+			Value: "healthy",
+			Usage: fmt.Sprintf("built-in scenario (%s)", strings.Join(scenarioNames(), ", ")),
+		},
+		cli.StringFlag{
+			Name:  "file",
+			Usage: "JSON file of the scenario, instead of a built-in scenario",
+		},
+		cli.IntFlag{
+			Name:  "validators",
+			Usage: "number of validators, overriding the scenario",
+		},
+		cli.Uint64Flag{
+			Name:  "blocks",
+			Usage: "number of blocks, overriding the scenario",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Value: 10 * time.Minute,
+			Usage: "maximum duration of the scenario",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as JSON",
+		},
+	},
+}
+
+func scenarioNames() []string {
+	names := make([]string, 0, len(simulation.Scenarios))
+	for name := range simulation.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runIstanbul(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	var scenario simulation.Scenario
+	if path := ctx.String("file"); path != "" {
+		s, err := simulation.LoadScenario(path)
+		if err != nil {
+			return err
+		}
+		scenario = *s
+	} else {
+		s, ok := simulation.Scenarios[ctx.String("scenario")]
+		if !ok {
+			return fmt.Errorf("unknown scenario %q", ctx.String("scenario"))
+		}
+		scenario = *s
+	}
+	if ctx.IsSet("validators") {
+		scenario.Validators = ctx.Int("validators")
+	}
+	if ctx.IsSet("blocks") {
+		scenario.Blocks = ctx.Uint64("blocks")
+	}
+
+	runCtx, cancel := context.WithTimeout(context.Background(), ctx.Duration("timeout"))
+	defer cancel()
+	report, err := simulation.Run(runCtx, &scenario)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		enc := json.NewEncoder(ctx.App.Writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.Write(ctx.App.Writer)
+}
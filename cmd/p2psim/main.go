@@ -34,6 +34,11 @@
 //     $ p2psim node connect node01 node02
 //     Connected node01 to node02
 //
+// The istanbul command runs an istanbul consensus scenario in process instead,
+// without using the simulation HTTP API:
+//
+//     $ p2psim istanbul --scenario partition
+//
 package main
 
 import (
@@ -179,6 +184,7 @@ func main() {
 				},
 			},
 		},
+		istanbulCommand,
 	}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
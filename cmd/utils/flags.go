@@ -43,6 +43,7 @@ import (
 	"github.com/celo-org/celo-blockchain/crypto"
 	"github.com/celo-org/celo-blockchain/eth"
 	"github.com/celo-org/celo-blockchain/eth/downloader"
+	"github.com/celo-org/celo-blockchain/eth/gasprice"
 	"github.com/celo-org/celo-blockchain/ethdb"
 	"github.com/celo-org/celo-blockchain/ethstats"
 	"github.com/celo-org/celo-blockchain/graphql"
@@ -355,6 +356,26 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPrivateLifetimeFlag = cli.Uint64Flag{
+		Name:  "txpool.privatelifetime",
+		Usage: "Maximum number of blocks private transactions are kept in the pool",
+		Value: eth.DefaultConfig.TxPool.PrivateLifetime,
+	}
+	TxPoolPrivatePeersFlag = cli.StringFlag{
+		Name:  "txpool.privatepeers",
+		Usage: "Comma separated enode URLs of the trusted peers private transactions are relayed to and accepted from",
+	}
+	// Gas price oracle settings
+	GpoBlocksFlag = cli.IntFlag{
+		Name:  "gpo.blocks",
+		Usage: "Number of recent blocks to check for gas prices",
+		Value: eth.DefaultConfig.GPO.Blocks,
+	}
+	GpoPercentileFlag = cli.IntFlag{
+		Name:  "gpo.percentile",
+		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
+		Value: eth.DefaultConfig.GPO.Percentile,
+	}
 
 	// Performance tuning settings
 
@@ -460,6 +481,15 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerTxOrderingFlag = cli.StringFlag{
+		Name:  "miner.txordering",
+		Usage: `Ordering strategy of the transactions of mined blocks ("price" or "sendercap")`,
+		Value: eth.DefaultConfig.Miner.TxOrdering,
+	}
+	MinerSenderGasCapFlag = cli.Uint64Flag{
+		Name:  "miner.sendergascap",
+		Usage: `Maximum gas of the transactions of a sender in a mined block (with the "sendercap" ordering)`,
+	}
 
 	// Account settings
 
@@ -787,6 +817,30 @@ var (
 		Name:  "istanbul.replica",
 		Usage: "Run this node as a validator replica. Must be paired with --mine. Use the RPCs to enable participation in consensus.",
 	}
+	IstanbulReplicaAutoPromoteFlag = cli.Uint64Flag{
+		Name:  "istanbul.replica.autopromote",
+		Usage: "Number of consecutive blocks not signed by the validator after which a replica promotes itself, and a primary stops validating if another node signs with its key (0 = disabled)",
+		Value: eth.DefaultConfig.Istanbul.ReplicaAutoPromoteMissedBlocks,
+	}
+	IstanbulReplicaAutoPromoteDelayFlag = cli.Uint64Flag{
+		Name:  "istanbul.replica.autopromotedelay",
+		Usage: "Number of blocks a replica waits before validating after deciding to promote itself",
+		Value: eth.DefaultConfig.Istanbul.ReplicaAutoPromoteDelay,
+	}
+	IstanbulJournalFlag = cli.BoolFlag{
+		Name:  "istanbul.journal",
+		Usage: "Record the Istanbul messages received and sent by this node into a rotating journal within the data directory",
+	}
+	IstanbulJournalMaxFileSizeFlag = cli.Uint64Flag{
+		Name:  "istanbul.journal.maxfilesize",
+		Usage: "Size (in bytes) after which the Istanbul journal is rotated to a new file",
+		Value: eth.DefaultConfig.Istanbul.JournalMaxFileSize,
+	}
+	IstanbulJournalMaxFilesFlag = cli.Uint64Flag{
+		Name:  "istanbul.journal.maxfiles",
+		Usage: "Number of Istanbul journal files to keep",
+		Value: eth.DefaultConfig.Istanbul.JournalMaxFiles,
+	}
 
 	// Announce settings
 
@@ -836,6 +890,16 @@ var (
 		Name:  "proxy.allowprivateip",
 		Usage: "Specifies whether private IP is allowed for external facing proxy enodeURL",
 	}
+	ProxyHealthCheckPeriodFlag = cli.Uint64Flag{
+		Name:  "proxy.healthcheckperiod",
+		Usage: "Time duration (in seconds) between the proxied validator's health checks of its proxies (0 to disable)",
+		Value: eth.DefaultConfig.Istanbul.ProxyHealthCheckPeriod,
+	}
+	ProxyHealthCheckMaxFailuresFlag = cli.Uint64Flag{
+		Name:  "proxy.healthcheckmaxfailures",
+		Usage: "Number of consecutive failed health checks after which a proxy's remote validators are reassigned to the other proxies",
+		Value: eth.DefaultConfig.Istanbul.ProxyHealthCheckMaxFailures,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1376,6 +1440,20 @@ func setDataDir(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
+	// If we are running the light client, apply another group
+	// settings for gas oracle.
+	if light {
+		*cfg = gasprice.DefaultLightConfig
+	}
+	if ctx.GlobalIsSet(GpoBlocksFlag.Name) {
+		cfg.Blocks = ctx.GlobalInt(GpoBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(GpoPercentileFlag.Name) {
+		cfg.Percentile = ctx.GlobalInt(GpoPercentileFlag.Name)
+	}
+}
+
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLocalsFlag.Name) {
 		locals := strings.Split(ctx.GlobalString(TxPoolLocalsFlag.Name), ",")
@@ -1417,6 +1495,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPrivateLifetimeFlag.Name) {
+		cfg.PrivateLifetime = ctx.GlobalUint64(TxPoolPrivateLifetimeFlag.Name)
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1450,6 +1531,15 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerTxOrderingFlag.Name) {
+		cfg.TxOrdering = ctx.GlobalString(MinerTxOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerSenderGasCapFlag.Name) {
+		cfg.SenderGasCap = ctx.GlobalUint64(MinerSenderGasCapFlag.Name)
+	}
+	if _, err := miner.NewTxOrderingStrategy(cfg); err != nil {
+		Fatalf("Invalid miner transaction ordering: %v", err)
+	}
 }
 
 func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
@@ -1475,6 +1565,23 @@ func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
+// setPrivateTxPeers creates the list of trusted peers private transactions are
+// relayed to from the command line flags.
+func setPrivateTxPeers(ctx *cli.Context, cfg *eth.Config) {
+	urls := ctx.GlobalString(TxPoolPrivatePeersFlag.Name)
+	if urls == "" {
+		return
+	}
+	cfg.PrivateTxPeers = nil
+	for _, url := range splitAndTrim(urls) {
+		node, err := enode.Parse(enode.ValidSchemes, url)
+		if err != nil {
+			Fatalf("Invalid private transaction peer %s: %v", url, err)
+		}
+		cfg.PrivateTxPeers = append(cfg.PrivateTxPeers, node)
+	}
+}
+
 func setIstanbul(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(IstanbulRequestTimeoutFlag.Name) {
 		log.Warn("Flag value is ignored, and obtained from genesis config", "flag", IstanbulRequestTimeoutFlag.Name)
@@ -1492,8 +1599,28 @@ func setIstanbul(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	cfg.Istanbul.ValidatorEnodeDBPath = stack.ResolvePath(cfg.Istanbul.ValidatorEnodeDBPath)
 	cfg.Istanbul.VersionCertificateDBPath = stack.ResolvePath(cfg.Istanbul.VersionCertificateDBPath)
 	cfg.Istanbul.RoundStateDBPath = stack.ResolvePath(cfg.Istanbul.RoundStateDBPath)
+	cfg.Istanbul.EquivocationDBPath = stack.ResolvePath(cfg.Istanbul.EquivocationDBPath)
+	cfg.Istanbul.SlashingProtectionDBPath = stack.ResolvePath(cfg.Istanbul.SlashingProtectionDBPath)
+	if ctx.GlobalBool(IstanbulJournalFlag.Name) && cfg.Istanbul.JournalPath == "" {
+		cfg.Istanbul.JournalPath = "istanbuljournal"
+	}
+	if cfg.Istanbul.JournalPath != "" {
+		cfg.Istanbul.JournalPath = stack.ResolvePath(cfg.Istanbul.JournalPath)
+	}
+	if ctx.GlobalIsSet(IstanbulJournalMaxFileSizeFlag.Name) {
+		cfg.Istanbul.JournalMaxFileSize = ctx.GlobalUint64(IstanbulJournalMaxFileSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulJournalMaxFilesFlag.Name) {
+		cfg.Istanbul.JournalMaxFiles = ctx.GlobalUint64(IstanbulJournalMaxFilesFlag.Name)
+	}
 	cfg.Istanbul.Validator = ctx.GlobalIsSet(MiningEnabledFlag.Name) || ctx.GlobalIsSet(DeveloperFlag.Name)
 	cfg.Istanbul.Replica = ctx.GlobalIsSet(IstanbulReplicaFlag.Name)
+	if ctx.GlobalIsSet(IstanbulReplicaAutoPromoteFlag.Name) {
+		cfg.Istanbul.ReplicaAutoPromoteMissedBlocks = ctx.GlobalUint64(IstanbulReplicaAutoPromoteFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulReplicaAutoPromoteDelayFlag.Name) {
+		cfg.Istanbul.ReplicaAutoPromoteDelay = ctx.GlobalUint64(IstanbulReplicaAutoPromoteDelayFlag.Name)
+	}
 }
 
 func setProxyP2PConfig(ctx *cli.Context, proxyCfg *p2p.Config) {
@@ -1598,6 +1725,13 @@ func SetProxyConfig(ctx *cli.Context, nodeCfg *node.Config, ethCfg *eth.Config)
 		if !ctx.GlobalBool(NoDiscoverFlag.Name) {
 			Fatalf("Option --%s must be used if option --%s is used", NoDiscoverFlag.Name, ProxiedFlag.Name)
 		}
+
+		if ctx.GlobalIsSet(ProxyHealthCheckPeriodFlag.Name) {
+			ethCfg.Istanbul.ProxyHealthCheckPeriod = ctx.GlobalUint64(ProxyHealthCheckPeriodFlag.Name)
+		}
+		if ctx.GlobalIsSet(ProxyHealthCheckMaxFailuresFlag.Name) {
+			ethCfg.Istanbul.ProxyHealthCheckMaxFailures = ctx.GlobalUint64(ProxyHealthCheckMaxFailuresFlag.Name)
+		}
 	}
 }
 
@@ -1687,6 +1821,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setTxPool(ctx, &cfg.TxPool)
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
+	setPrivateTxPeers(ctx, cfg)
 	setIstanbul(ctx, stack, cfg)
 	setLes(ctx, cfg)
 
@@ -1695,6 +1830,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
 	}
+	setGPO(ctx, &cfg.GPO, !cfg.SyncMode.SyncFullBlockChain())
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -17,8 +17,10 @@
 package backend
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/celo-org/celo-blockchain/common"
@@ -27,8 +29,9 @@ import (
 	vet "github.com/celo-org/celo-blockchain/consensus/istanbul/backend/internal/enodes"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul/backend/internal/replica"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul/core"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/epochproof"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul/proxy"
-	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/uptime"
 	"github.com/celo-org/celo-blockchain/core/types"
 	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
 	"github.com/celo-org/celo-blockchain/p2p/enode"
@@ -121,7 +124,7 @@ func (api *API) GetProposer(sequence *rpc.BlockNumber, round *uint64) (common.Ad
 	if round == nil {
 		round = new(uint64)
 	}
-	proposer := validator.GetProposerSelector(api.istanbul.config.ProposerPolicy)(valSet, previousProposer, *round)
+	proposer := api.istanbul.proposerSelector(valSet, previousProposer, *round)
 	return proposer.Address(), nil
 }
 
@@ -186,6 +189,260 @@ func (api *API) ForceRoundChange() (bool, error) {
 	return true, nil
 }
 
+// GetEquivocationEvidence retrieves the evidence collected for validators that signed conflicting
+// PREPARE/COMMIT messages for a sequence within the given range (both ends inclusive).
+func (api *API) GetEquivocationEvidence(from, to *rpc.BlockNumber) ([]*core.EquivocationEvidenceSummary, error) {
+	fromSeq, toSeq := uint64(0), uint64(math.MaxUint64)
+	if from != nil && *from > 0 {
+		fromSeq = uint64(*from)
+	}
+	if to != nil && *to >= 0 {
+		toSeq = uint64(*to)
+	}
+
+	evidence, err := api.istanbul.core.GetEquivocationEvidence(fromSeq, toSeq)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]*core.EquivocationEvidenceSummary, 0, len(evidence))
+	for _, ev := range evidence {
+		summary, err := ev.Summary()
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// EquivocationEvidence creates a subscription that is notified each time a validator is
+// detected signing conflicting PREPARE/COMMIT messages.
+func (api *API) EquivocationEvidence(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.EquivocationEvidenceEvent)
+		sub := api.istanbul.core.SubscribeEquivocationEvidence(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-events:
+				summary, err := event.Evidence.Summary()
+				if err != nil {
+					api.istanbul.logger.Error("Failed to summarize equivocation evidence", "err", err)
+					continue
+				}
+				notifier.Notify(rpcSub.ID, summary)
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ValidatorUptime is the signing history of a validator within a range of blocks of an epoch.
+type ValidatorUptime struct {
+	Address common.Address `json:"address"`
+	// SignedBlocks and MissedBlocks count the blocks within the range whose parent seal
+	// includes (or not) the validator's signature.
+	SignedBlocks    uint64 `json:"signedBlocks"`
+	MissedBlocks    uint64 `json:"missedBlocks"`
+	LastSignedBlock uint64 `json:"lastSignedBlock"`
+	// UpBlocks is the number of blocks of the epoch's monitoring window, up to the end of
+	// the range, in which the validator was considered up.
+	UpBlocks uint64 `json:"upBlocks"`
+	// Up indicates if the validator is considered up at the end of the range.
+	Up bool `json:"up"`
+	// Score is the uptime score (as a fixidity value) the validator would get if the
+	// epoch ended at the end of the range.
+	Score *big.Int `json:"score"`
+}
+
+// BlockSigners lists the validators whose signatures are included in a block's parent seal.
+type BlockSigners struct {
+	Number  uint64           `json:"number"`
+	Signers []common.Address `json:"signers"`
+}
+
+// UptimeHistory is the per validator signing history for a range of blocks of an epoch.
+type UptimeHistory struct {
+	Epoch            uint64             `json:"epoch"`
+	FromBlock        uint64             `json:"fromBlock"`
+	ToBlock          uint64             `json:"toBlock"`
+	LookbackWindow   uint64             `json:"lookbackWindow"`
+	MonitoringWindow uptime.Window      `json:"monitoringWindow"`
+	Validators       []*ValidatorUptime `json:"validators"`
+	Blocks           []*BlockSigners    `json:"blocks"`
+}
+
+// GetValidatorUptime retrieves the signing history of the elected validators, either for a whole
+// epoch or for a range of blocks (both ends inclusive) within a single epoch. If the epoch is not
+// given, the range defaults to the blocks of the current epoch up to the latest block.
+func (api *API) GetValidatorUptime(epoch *uint64, fromBlock, toBlock *rpc.BlockNumber) (*UptimeHistory, error) {
+	epochSize := api.istanbul.EpochSize()
+	head := api.chain.CurrentHeader()
+	if head == nil {
+		return nil, errUnknownBlock
+	}
+
+	var from, to uint64
+	if epoch != nil {
+		if *epoch == 0 {
+			return nil, errInvalidEpoch
+		}
+		first, err := istanbul.GetEpochFirstBlockNumber(*epoch, epochSize)
+		if err != nil {
+			return nil, err
+		}
+		from, to = first, istanbul.GetEpochLastBlockNumber(*epoch, epochSize)
+		if to > head.Number.Uint64() {
+			to = head.Number.Uint64()
+		}
+	} else {
+		to = head.Number.Uint64()
+		if toBlock != nil && *toBlock >= 0 {
+			to = uint64(*toBlock)
+		}
+		from = istanbul.MustGetEpochFirstBlockGivenBlockNumber(to, epochSize)
+		if fromBlock != nil && *fromBlock >= 0 {
+			from = uint64(*fromBlock)
+		}
+	}
+	if from == 0 || from > to || to > head.Number.Uint64() {
+		return nil, errInvalidBlockRange
+	}
+	epochNumber := istanbul.GetEpochNumber(from, epochSize)
+	if istanbul.GetEpochNumber(to, epochSize) != epochNumber {
+		return nil, errInvalidBlockRange
+	}
+
+	// The validators elected for the epoch are the ones of the last block of the previous epoch
+	first, _ := istanbul.GetEpochFirstBlockNumber(epochNumber, epochSize)
+	lastOfPrevious := api.chain.GetHeaderByNumber(first - 1)
+	if lastOfPrevious == nil {
+		return nil, errUnknownBlock
+	}
+	validators := api.istanbul.GetValidators(lastOfPrevious.Number, lastOfPrevious.Hash())
+
+	toHeader := api.chain.GetHeaderByNumber(to)
+	if toHeader == nil {
+		return nil, errUnknownBlock
+	}
+	lookbackWindow := api.lookbackWindowAt(toHeader)
+
+	history, err := uptime.NewHistory(epochNumber, epochSize, lookbackWindow, len(validators))
+	if err != nil {
+		return nil, err
+	}
+	result := &UptimeHistory{
+		Epoch:            epochNumber,
+		FromBlock:        from,
+		ToBlock:          to,
+		LookbackWindow:   lookbackWindow,
+		MonitoringWindow: history.MonitoringWindow(),
+		Validators:       make([]*ValidatorUptime, len(validators)),
+		Blocks:           make([]*BlockSigners, 0, to-from+1),
+	}
+	for i, val := range validators {
+		result.Validators[i] = &ValidatorUptime{Address: val.Address()}
+	}
+
+	for number := first; number <= to; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		bitmap, err := history.ProcessHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if number < from || bitmap == nil {
+			continue
+		}
+		signers := &BlockSigners{Number: number, Signers: []common.Address{}}
+		for i, val := range validators {
+			if bitmap.Bit(i) == 1 {
+				signers.Signers = append(signers.Signers, val.Address())
+				result.Validators[i].SignedBlocks++
+			} else {
+				result.Validators[i].MissedBlocks++
+			}
+		}
+		result.Blocks = append(result.Blocks, signers)
+	}
+
+	scores := history.Scores()
+	for i, entry := range history.Uptime().Entries {
+		result.Validators[i].LastSignedBlock = entry.LastSignedBlock
+		result.Validators[i].UpBlocks = entry.UpBlocks
+		result.Validators[i].Up = history.IsUp(i)
+		result.Validators[i].Score = scores[i]
+	}
+	return result, nil
+}
+
+// lookbackWindowAt returns the lookback window in place at the given header, using the default
+// lookback window as a fallback if the state for the header is not available.
+func (api *API) lookbackWindowAt(header *types.Header) uint64 {
+	state, err := api.istanbul.stateAt(header.Hash())
+	if err == nil {
+		return api.istanbul.LookbackWindow(header, state)
+	}
+	firstBlockOfEpoch := istanbul.MustGetEpochFirstBlockGivenBlockNumber(header.Number.Uint64(), api.istanbul.EpochSize())
+	cip21Activated := api.chain.Config().IsDonut(new(big.Int).SetUint64(firstBlockOfEpoch))
+	return uptime.ComputeLookbackWindow(
+		api.istanbul.EpochSize(),
+		api.istanbul.config.DefaultLookbackWindow,
+		cip21Activated,
+		func() (uint64, error) { return 0, err },
+	)
+}
+
+// maxEpochProofEpochs is the maximum number of epochs that can be requested in a single epoch proof
+const maxEpochProofEpochs = 1024
+
+// GetEpochProof retrieves the proof of the validator set changes from epoch `from` to epoch `to` (both
+// included), made of the last header of each epoch with its validator set diff and epoch validator set
+// seal. It can be verified from the validator set of epoch `from - 1` with the epochproof package.
+func (api *API) GetEpochProof(from, to uint64) (*epochproof.Proof, error) {
+	if from == 0 || to < from {
+		return nil, errInvalidEpoch
+	}
+	if to-from >= maxEpochProofEpochs {
+		return nil, fmt.Errorf("too many epochs requested, the maximum is %d", maxEpochProofEpochs)
+	}
+	epochSize := api.istanbul.EpochSize()
+	proof := &epochproof.Proof{From: from, To: to, Epochs: make([]*epochproof.Epoch, 0, to-from+1)}
+	for epoch := from; epoch <= to; epoch++ {
+		number := istanbul.GetEpochLastBlockNumber(epoch, epochSize)
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		block := api.chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return nil, errUnknownBlock
+		}
+		epochProof, err := epochproof.NewEpoch(header, epochSize, block.EpochSnarkData())
+		if err != nil {
+			return nil, fmt.Errorf("epoch %d: %v", epoch, err)
+		}
+		proof.Epochs = append(proof.Epochs, epochProof)
+	}
+	return proof, nil
+}
+
 // Proxies retrieves all the proxied validator's proxies' info
 func (api *API) GetProxiesInfo() ([]*proxy.ProxyInfo, error) {
 	if api.istanbul.IsProxiedValidator() {
@@ -207,6 +464,15 @@ func (api *API) GetProxiesInfo() ([]*proxy.ProxyInfo, error) {
 	}
 }
 
+// GetProxiesHealth retrieves the result of the health checks of the proxied validator's proxies
+func (api *API) GetProxiesHealth() ([]*proxy.ProxyHealthInfo, error) {
+	if api.istanbul.IsProxiedValidator() {
+		return api.istanbul.proxiedValidatorEngine.GetProxiesHealth()
+	} else {
+		return nil, proxy.ErrNodeNotProxiedValidator
+	}
+}
+
 // ProxiedValidators retrieves all of the proxies connected proxied validators.
 // Note that we plan to support validators per proxy in the future, so this function
 // is plural and returns an array of proxied validators.  This is to prevent
@@ -42,6 +42,7 @@ import (
 	"github.com/celo-org/celo-blockchain/core"
 	"github.com/celo-org/celo-blockchain/core/state"
 	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/crypto"
 	"github.com/celo-org/celo-blockchain/ethdb"
 	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/log"
@@ -80,6 +81,14 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 	if err != nil {
 		logger.Crit("Failed to create known messages cache", "err", err)
 	}
+	votingWeights, err := lru.NewARC(inmemoryVotingWeights)
+	if err != nil {
+		logger.Crit("Failed to create voting weights cache", "err", err)
+	}
+	proposerSelector, err := validator.GetConfiguredProposerSelector(config)
+	if err != nil {
+		logger.Crit("Failed to get proposer selector", "err", err)
+	}
 	backend := &Backend{
 		config:                             config,
 		istanbulEventMux:                   new(event.TypeMux),
@@ -87,6 +96,8 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 		db:                                 db,
 		commitCh:                           make(chan *types.Block, 1),
 		recentSnapshots:                    recentSnapshots,
+		votingWeights:                      votingWeights,
+		proposerSelector:                   proposerSelector,
 		coreStarted:                        false,
 		announceRunning:                    false,
 		peerRecentMessages:                 peerRecentMessages,
@@ -129,6 +140,9 @@ func New(config *istanbul.Config, db ethdb.Database) consensus.Istanbul {
 		if err != nil {
 			logger.Crit("Can't open ReplicaStateDB", "err", err, "dbpath", config.ReplicaStateDBPath)
 		}
+		if config.ReplicaAutoPromoteMissedBlocks > 0 {
+			rs.EnableCoordination(config.ReplicaAutoPromoteMissedBlocks, config.ReplicaAutoPromoteDelay)
+		}
 		backend.replicaState = rs
 	} else {
 		backend.replicaState = nil
@@ -200,6 +214,10 @@ type Backend struct {
 	// Snapshots for recent blocks to speed up reorgs
 	recentSnapshots *lru.ARCCache
 
+	// Validator voting weights by epoch, for the proposer selectors that use them
+	votingWeights    *lru.ARCCache
+	proposerSelector istanbul.ProposerSelector
+
 	// event subscription for ChainHeadEvent event
 	broadcaster consensus.Broadcaster
 
@@ -404,6 +422,17 @@ func (sb *Backend) Close() error {
 			errs = append(errs, err)
 		}
 	}
+	sb.coreMu.Lock()
+	if sb.coreStarted {
+		if err := sb.core.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+		sb.coreStarted = false
+	}
+	if err := sb.core.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	sb.coreMu.Unlock()
 	var concatenatedErrs error
 	for i, err := range errs {
 		if i == 0 {
@@ -721,13 +750,79 @@ func (sb *Backend) validatorRandomnessAtBlockNumber(number uint64, hash common.H
 	return random.BlockRandomness(header, state, lastBlockInPreviousEpoch)
 }
 
+// validatorVotingWeightsAtBlockNumber calls into the EVM to get the voting weight of each of the validators
+// at the last block of the previous epoch. The voting weight of a validator is the total of votes received by
+// its group, split evenly among the group's members in the validator set.
+func (sb *Backend) validatorVotingWeightsAtBlockNumber(number uint64, valSet istanbul.ValidatorSet) ([]*big.Int, error) {
+	lastBlockInPreviousEpoch := number
+	if number > 0 {
+		lastBlockInPreviousEpoch = number - istanbul.GetNumberWithinEpoch(number, sb.config.Epoch)
+	}
+	header := sb.chain.GetHeaderByNumber(lastBlockInPreviousEpoch)
+	if header == nil {
+		return nil, errNoBlockHeader
+	}
+
+	// Weights are computed once per epoch block and validator set
+	addresses := make([]common.Address, valSet.Size())
+	keyData := header.Hash().Bytes()
+	for i, val := range valSet.List() {
+		addresses[i] = val.Address()
+		keyData = append(keyData, addresses[i].Bytes()...)
+	}
+	key := crypto.Keccak256Hash(keyData)
+	if weights, ok := sb.votingWeights.Get(key); ok {
+		return weights.([]*big.Int), nil
+	}
+
+	state, err := sb.stateAt(header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	groupVotes, err := election.GetGroupVoteTotals(header, state)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]common.Address, len(addresses))
+	groupMembers := make(map[common.Address]int64)
+	for i, address := range addresses {
+		group, err := validators.GetMembershipInLastEpoch(header, state, address)
+		if err != nil {
+			return nil, err
+		}
+		groups[i] = group
+		groupMembers[group]++
+	}
+	weights := make([]*big.Int, len(addresses))
+	for i, group := range groups {
+		weights[i] = new(big.Int)
+		if votes, ok := groupVotes[group]; ok {
+			weights[i].Div(votes, big.NewInt(groupMembers[group]))
+		}
+	}
+	sb.votingWeights.Add(key, weights)
+	return weights, nil
+}
+
 func (sb *Backend) getOrderedValidators(number uint64, hash common.Hash) istanbul.ValidatorSet {
 	valSet := sb.getValidators(number, hash)
 	if valSet.Size() == 0 {
 		return valSet
 	}
 
-	if sb.config.ProposerPolicy == istanbul.ShuffledRoundRobin {
+	if validator.SelectorUsesWeights(sb.config) {
+		weights, err := sb.validatorVotingWeightsAtBlockNumber(number, valSet)
+		if err != nil {
+			if err == comm_errors.ErrRegistryContractNotDeployed {
+				sb.logger.Debug("Failed to set voting weights for proposer selection", "block_number", number, "hash", hash, "error", err)
+			} else {
+				sb.logger.Warn("Failed to set voting weights for proposer selection", "block_number", number, "hash", hash, "error", err)
+			}
+		}
+		valSet.SetWeights(weights)
+	}
+
+	if sb.config.ProposerPolicy == istanbul.ShuffledRoundRobin || sb.config.ProposerSelector != "" {
 		seed, err := sb.validatorRandomnessAtBlockNumber(number, hash)
 		if err != nil {
 			if err == comm_errors.ErrRegistryContractNotDeployed {
@@ -1007,6 +1102,14 @@ func (sb *Backend) IsPrimary() bool {
 	return false
 }
 
+// CheckSigningView returns an error if the replica state doesn't allow signing the view.
+func (sb *Backend) CheckSigningView(view *istanbul.View) error {
+	if sb.replicaState != nil {
+		return sb.replicaState.CheckAndStoreSignedView(view)
+	}
+	return nil
+}
+
 // UpdateReplicaState updates the replica state with the latest seq.
 func (sb *Backend) UpdateReplicaState(seq *big.Int) {
 	if sb.replicaState != nil {
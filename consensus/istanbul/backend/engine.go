@@ -46,7 +46,9 @@ const (
 	inmemorySnapshots             = 128 // Number of recent vote snapshots to keep in memory
 	inmemoryPeers                 = 40
 	inmemoryMessages              = 1024
+	inmemoryVotingWeights         = 4 // Number of epochs for which to keep the validator voting weights in memory
 	mobileAllowedClockSkew uint64 = 5
+	maxObservedBlockAge           = time.Minute // Age after which blocks missed by the primary are ignored by the replica coordination
 )
 
 var (
@@ -85,6 +87,11 @@ var (
 	errUnauthorizedAnnounceMessage = errors.New("unauthorized announce message")
 	// errNotAValidator is returned when the node is not configured as a validator
 	errNotAValidator = errors.New("Not configured as a validator")
+	// errInvalidEpoch is returned when the uptime history or an epoch proof is requested for the genesis epoch
+	errInvalidEpoch = errors.New("invalid epoch")
+	// errInvalidBlockRange is returned when the uptime history is requested for a range of blocks
+	// that is empty, not yet mined or spans more than one epoch
+	errInvalidBlockRange = errors.New("invalid block range")
 )
 
 var (
@@ -636,6 +643,9 @@ func (sb *Backend) updateReplicaStateLoop(bc *ethCore.BlockChain) {
 				consensusBlock := new(big.Int).Add(chainEvent.Block.Number(), common.Big1)
 				sb.replicaState.NewChainHead(consensusBlock)
 			}
+			if sb.replicaState != nil && sb.config.ReplicaAutoPromoteMissedBlocks > 0 {
+				sb.observeParentSeal(chainEvent.Block)
+			}
 		case err := <-chainEventSub.Err():
 			log.Error("Error in istanbul's subscription to the blockchain's chain event", "err", err)
 			return
@@ -643,6 +653,33 @@ func (sb *Backend) updateReplicaStateLoop(bc *ethCore.BlockChain) {
 	}
 }
 
+// observeParentSeal updates the replica coordination state with whether this validator's key
+// signed the parent of the given block, if the validator was elected for it.
+func (sb *Backend) observeParentSeal(child *types.Block) {
+	number := child.NumberU64()
+	if number <= 1 {
+		return
+	}
+	childExtra, err := types.ExtractIstanbulExtra(child.Header())
+	if err != nil {
+		return
+	}
+	parentHeader := sb.chain.GetHeader(child.ParentHash(), number-1)
+	if parentHeader == nil {
+		return
+	}
+	index, _ := sb.getValidators(number-2, parentHeader.ParentHash).GetByAddress(sb.Address())
+	if index < 0 {
+		return
+	}
+	signed := childExtra.ParentAggregatedSeal.Bitmap.Bit(index) == 1
+	// Blocks imported while syncing don't tell whether the primary is currently signing
+	if !signed && time.Since(time.Unix(int64(child.Time()), 0)) > maxObservedBlockAge {
+		return
+	}
+	sb.replicaState.ObserveSignedBlock(parentHeader.Number, signed)
+}
+
 // SetBlockProcessors implements consensus.Istanbul.SetBlockProcessors
 func (sb *Backend) SetBlockProcessors(hasBadBlock func(common.Hash) bool,
 	processBlock func(*types.Block, *state.StateDB) (types.Receipts, []*types.Log, uint64, error),
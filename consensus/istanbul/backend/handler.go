@@ -23,7 +23,7 @@ import (
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/consensus"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
-	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	istanbulCore "github.com/celo-org/celo-blockchain/consensus/istanbul/core"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/p2p"
@@ -65,11 +65,14 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		case istanbul.ConsensusMsg:
 			fallthrough
 		case istanbul.EnodeCertificateMsg:
+			fallthrough
+		case istanbul.ProxyHealthCheckMsg:
 			// This will handle the following messages:
 			// 1) ValEnodesShareMsg
 			// 2) FwdMsg
 			// 3) ConsensusMsg
 			// 4) EnodeCertificateMsg
+			// 5) ProxyHealthCheckMsg
 			// No error on skipped messages
 			return sb.proxyEngine.HandleMsg(peer, msg.Code, data)
 		case istanbul.DelegateSignMsg:
@@ -95,6 +98,7 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		// Handle messages as primary validator
 		switch msg.Code {
 		case istanbul.ConsensusMsg:
+			sb.observeConsensusMsg(data)
 			go sb.istanbulEventMux.Post(istanbul.MessageEvent{
 				Payload: data,
 			})
@@ -122,6 +126,12 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		case istanbul.ValidatorHandshakeMsg:
 			logger.Warn("Received unexpected Istanbul validator handshake message")
 			return true, nil
+		case istanbul.ProxyHealthReportMsg:
+			if sb.IsProxiedValidator() {
+				return sb.proxiedValidatorEngine.HandleMsg(peer, msg.Code, data)
+			}
+			logger.Warn("Received unexpected proxy health report message", "peer", peer)
+			return true, nil
 		default:
 			logger.Error("Unhandled istanbul message as primary", "address", addr, "peer's enodeURL", peer.Node().String(), "ethMsgCode", msg.Code)
 			return false, nil
@@ -130,7 +140,8 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		// Handle messages as replica validator
 		switch msg.Code {
 		case istanbul.ConsensusMsg:
-			// Ignore consensus messages
+			// Ignore consensus messages, except to monitor the primary
+			sb.observeConsensusMsg(data)
 			return true, nil
 		case istanbul.DelegateSignMsg:
 			if sb.shouldHandleDelegateSign(peer) {
@@ -155,6 +166,12 @@ func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg, peer consensus.Pe
 		case istanbul.ValidatorHandshakeMsg:
 			logger.Warn("Received unexpected Istanbul validator handshake message")
 			return true, nil
+		case istanbul.ProxyHealthReportMsg:
+			if sb.IsProxiedValidator() {
+				return sb.proxiedValidatorEngine.HandleMsg(peer, msg.Code, data)
+			}
+			logger.Warn("Received unexpected proxy health report message", "peer", peer)
+			return true, nil
 		default:
 			logger.Error("Unhandled istanbul message as replica", "address", addr, "peer's enodeURL", peer.Node().String(), "ethMsgCode", msg.Code)
 			return false, nil
@@ -213,6 +230,23 @@ func (sb *Backend) NewWork() error {
 	return nil
 }
 
+// observeConsensusMsg updates the replica coordination state if the consensus message was signed
+// with this validator's key by another node
+func (sb *Backend) observeConsensusMsg(payload []byte) {
+	if sb.replicaState == nil || sb.config.ReplicaAutoPromoteMissedBlocks == 0 {
+		return
+	}
+	msg := new(istanbul.Message)
+	if err := msg.FromPayload(payload, istanbul.GetSignatureAddress); err != nil || msg.Address != sb.Address() {
+		return
+	}
+	view, err := istanbulCore.ExtractMessageView(msg)
+	if err != nil || view == nil {
+		return
+	}
+	sb.replicaState.ObserveConsensusMessage(view)
+}
+
 // UpdateMetricsForParentOfBlock maintains metrics around the *parent* of the supplied block.
 // To figure out if this validator signed the parent block:
 // * First check the grandparent's validator set. If not elected, it didn't.
@@ -280,7 +314,7 @@ func (sb *Backend) UpdateMetricsForParentOfBlock(child *types.Block) {
 		// to re-propose an existing block, thus not placing it's own signature on it.
 		gpAuthor := sb.AuthorForBlock(number - 2)
 		for i := int64(0); i < missedRounds; i++ {
-			proposer := validator.GetProposerSelector(sb.config.ProposerPolicy)(gpValSet, gpAuthor, uint64(i))
+			proposer := sb.proposerSelector(gpValSet, gpAuthor, uint64(i))
 			if sb.Address() == proposer.Address() {
 				sb.blocksMissedRoundsAsProposerMeter.Mark(1)
 				break
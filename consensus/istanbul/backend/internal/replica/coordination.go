@@ -0,0 +1,283 @@
+// Copyright 2020 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/log"
+)
+
+// In coordination mode, a replica monitors the blocks committed while its validator is elected, and
+// promotes itself once the validator's key hasn't signed `autoPromoteMissedBlocks` consecutive blocks.
+// It starts validating `autoPromoteDelay` blocks later, so that a primary that recovers in the meantime
+// cancels the promotion.
+//
+// Fencing guarantees that the old primary stops signing if the promoted replica takes over:
+//   - every view signed by this node is recorded in the replica state DB before the message is sent,
+//     and views older than the last signed one are never signed again (even after a restart).
+//   - a primary that sees the validator's key signing a block or a consensus message it didn't sign
+//     itself stops validating, and refuses to sign anything until it's made primary again through the
+//     management RPC.
+
+var (
+	// errFenced is returned when signing after another node was found signing with the validator's key
+	errFenced = errors.New("signing is fenced: another node is signing with this validator's key")
+	// errOldView is returned when signing a view older than the last view signed by this node
+	errOldView = errors.New("view is older than the last signed view")
+)
+
+// EnableCoordination enables the coordination mode
+func (rs *replicaStateImpl) EnableCoordination(missedBlocks, promotionDelay uint64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.guardMu.Lock()
+	defer rs.guardMu.Unlock()
+	rs.autoPromoteMissedBlocks = missedBlocks
+	rs.autoPromoteDelay = promotionDelay
+}
+
+func (rs *replicaStateImpl) coordinationEnabled() bool {
+	return rs.autoPromoteMissedBlocks > 0
+}
+
+func (rs *replicaStateImpl) isPrimary() bool {
+	return rs.state == primaryPermanent || rs.state == primaryInRange
+}
+
+// CheckAndStoreSignedView returns an error if this node is fenced, or if the view is older than the last
+// signed view. Otherwise it persists the view as the last signed view.
+func (rs *replicaStateImpl) CheckAndStoreSignedView(view *istanbul.View) error {
+	rs.guardMu.Lock()
+	defer rs.guardMu.Unlock()
+
+	if !rs.coordinationEnabled() {
+		return nil
+	}
+	if rs.fenced {
+		return errFenced
+	}
+	if rs.lastSignedView != nil && view.Sequence.Cmp(rs.lastSignedView.Sequence) < 0 {
+		return fmt.Errorf("%v: have %v, last signed %v", errOldView, view, rs.lastSignedView)
+	}
+	if rs.lastSignedView == nil || view.Cmp(rs.lastSignedView) > 0 {
+		signed := &istanbul.View{Sequence: new(big.Int).Set(view.Sequence), Round: new(big.Int).Set(view.Round)}
+		if err := rs.rsdb.StoreSigningGuard(rs.fenced, signed); err != nil {
+			return fmt.Errorf("Error when saving rsdb in CheckAndStoreSignedView. err: %v", err)
+		}
+		rs.lastSignedView = signed
+	}
+	return nil
+}
+
+// ObserveSignedBlock updates the missed blocks count of a replica, and fences a primary if the block
+// was signed by another node.
+func (rs *replicaStateImpl) ObserveSignedBlock(blockNumber *big.Int, signed bool) {
+	if rs.observeSignedBlock(blockNumber, signed) {
+		rs.stopFencedCore()
+	}
+}
+
+// observeSignedBlock implements ObserveSignedBlock under the lock, and returns whether this node was fenced
+func (rs *replicaStateImpl) observeSignedBlock(blockNumber *big.Int, signed bool) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.coordinationEnabled() {
+		return false
+	}
+
+	if rs.isPrimary() {
+		if signed && rs.signedByAnother(&istanbul.View{Sequence: blockNumber, Round: new(big.Int)}) {
+			rs.fence(fmt.Sprintf("block %v was signed by another node", blockNumber))
+			return true
+		}
+		return false
+	}
+
+	if signed {
+		rs.primaryAlive(blockNumber)
+		return false
+	}
+	rs.missedBlocks++
+	log.Debug("Primary missed block", "func", "ObserveSignedBlock", "seq", blockNumber, "missedBlocks", rs.missedBlocks)
+	if rs.missedBlocks >= rs.autoPromoteMissedBlocks {
+		rs.autoPromote(blockNumber)
+	}
+	return false
+}
+
+// ObserveConsensusMessage resets the missed blocks count of a replica, and fences a primary if the
+// message was signed by another node.
+func (rs *replicaStateImpl) ObserveConsensusMessage(view *istanbul.View) {
+	if rs.observeConsensusMessage(view) {
+		rs.stopFencedCore()
+	}
+}
+
+// observeConsensusMessage implements ObserveConsensusMessage under the lock, and returns whether this
+// node was fenced
+func (rs *replicaStateImpl) observeConsensusMessage(view *istanbul.View) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.coordinationEnabled() {
+		return false
+	}
+
+	if rs.isPrimary() {
+		if rs.signedByAnother(view) {
+			rs.fence(fmt.Sprintf("consensus message for view %v was signed by another node", view))
+			return true
+		}
+		return false
+	}
+
+	// Ignore messages for old sequences, which may have been sent before the primary failed
+	if rs.headSeq == nil || view.Sequence.Cmp(rs.headSeq) >= 0 {
+		rs.primaryAlive(view.Sequence)
+	}
+	return false
+}
+
+// signedByAnother returns whether the view was signed with the validator's key while this node was
+// primary, but not by this node.  Views at or before the last signed view are ambiguous, and assumed
+// to be signed by this node.
+func (rs *replicaStateImpl) signedByAnother(view *istanbul.View) bool {
+	if rs.validatingSince != nil && view.Sequence.Cmp(rs.validatingSince) < 0 {
+		// Signed by the old primary before this node started validating
+		return false
+	}
+
+	rs.guardMu.Lock()
+	defer rs.guardMu.Unlock()
+	if rs.lastSignedView == nil {
+		// Without a known start, this node may have signed the view before the guard existed
+		return rs.validatingSince != nil
+	}
+	return view.Cmp(rs.lastSignedView) > 0
+}
+
+// primaryAlive resets the missed blocks count, and cancels a pending automatic promotion
+func (rs *replicaStateImpl) primaryAlive(seq *big.Int) {
+	rs.missedBlocks = 0
+	if !rs.autoPromoting {
+		return
+	}
+
+	logger := log.New("func", "primaryAlive", "seq", seq)
+	oldStart := rs.startValidatingBlock
+	rs.state = replicaPermanent
+	rs.startValidatingBlock = nil
+	rs.autoPromoting = false
+	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
+		rs.state = replicaWaiting
+		rs.startValidatingBlock = oldStart
+		rs.autoPromoting = true
+		logger.Error("Error when saving rsdb when cancelling automatic promotion", "err", err)
+		return
+	}
+	logger.Info("Primary is signing again, cancelled automatic promotion", "start", oldStart)
+}
+
+// autoPromote schedules this replica to start validating after the promotion delay
+func (rs *replicaStateImpl) autoPromote(blockNumber *big.Int) {
+	logger := log.New("func", "autoPromote", "seq", blockNumber)
+	if rs.state != replicaPermanent {
+		// Already waiting to start, either automatically or at a block chosen by the operator
+		return
+	}
+
+	rs.guardMu.Lock()
+	fenced := rs.fenced
+	rs.guardMu.Unlock()
+	if fenced {
+		logger.Warn("Not promoting fenced replica", "missedBlocks", rs.missedBlocks)
+		return
+	}
+
+	start := new(big.Int).Add(blockNumber, common.Big1)
+	if rs.headSeq != nil && rs.headSeq.Cmp(start) > 0 {
+		start.Set(rs.headSeq)
+	}
+	start.Add(start, new(big.Int).SetUint64(rs.autoPromoteDelay))
+	if rs.stopValidatingBlock != nil && start.Cmp(rs.stopValidatingBlock) >= 0 {
+		logger.Warn("Not promoting replica past its stop validating block", "start", start, "stop", rs.stopValidatingBlock)
+		return
+	}
+
+	rs.state = replicaWaiting
+	rs.startValidatingBlock = start
+	rs.autoPromoting = true
+	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
+		rs.state = replicaPermanent
+		rs.startValidatingBlock = nil
+		rs.autoPromoting = false
+		logger.Error("Error when saving rsdb in automatic promotion", "err", err)
+		return
+	}
+	logger.Warn("Primary missed too many blocks, promoting replica", "missedBlocks", rs.missedBlocks, "start", start)
+}
+
+// fence prevents this node from signing, and makes it a replica. The core must then be stopped with
+// stopFencedCore, once the lock is released.
+func (rs *replicaStateImpl) fence(reason string) {
+	logger := log.New("func", "fence")
+	logger.Error("Another node is signing with this validator's key, stopping validating", "reason", reason)
+
+	rs.guardMu.Lock()
+	rs.fenced = true
+	if err := rs.rsdb.StoreSigningGuard(rs.fenced, rs.lastSignedView); err != nil {
+		logger.Error("Error when saving rsdb in fence", "err", err)
+	}
+	rs.guardMu.Unlock()
+
+	rs.state = replicaPermanent
+	rs.startValidatingBlock = nil
+	rs.stopValidatingBlock = nil
+	rs.validatingSince = nil
+	rs.missedBlocks = 0
+	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
+		logger.Error("Error when saving rsdb in fence", "err", err)
+	}
+}
+
+// stopFencedCore stops the core after this node was fenced. Neither lock may be held, since the core
+// may be waiting for them to check whether it's primary or to sign. The fenced core can't sign anymore,
+// so the node stays fenced even if the core fails to stop.
+func (rs *replicaStateImpl) stopFencedCore() {
+	if err := rs.stopFn(); err != nil {
+		log.Error("Error stopping core", "func", "stopFencedCore", "err", err)
+	}
+}
+
+// resetCoordination clears the coordination state after the operator changed the replica state
+func (rs *replicaStateImpl) resetCoordination() {
+	rs.missedBlocks = 0
+	rs.autoPromoting = false
+
+	rs.guardMu.Lock()
+	defer rs.guardMu.Unlock()
+	if rs.fenced {
+		rs.fenced = false
+		if err := rs.rsdb.StoreSigningGuard(rs.fenced, rs.lastSignedView); err != nil {
+			log.Error("Error when saving rsdb in resetCoordination", "err", err)
+		}
+	}
+}
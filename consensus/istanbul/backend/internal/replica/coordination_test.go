@@ -0,0 +1,335 @@
+// Copyright 2020 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package replica
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+)
+
+func newView(seq, round int64) *istanbul.View {
+	return &istanbul.View{Sequence: big.NewInt(seq), Round: big.NewInt(round)}
+}
+
+func newCoordinatedState(t *testing.T, isReplica bool, running *bool) *replicaStateImpl {
+	start := func() error { *running = true; return nil }
+	stop := func() error { *running = false; return nil }
+	*running = !isReplica
+	rsState, err := NewState(isReplica, "", start, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rsState.(*replicaStateImpl)
+	rs.EnableCoordination(3, 2)
+	return rs
+}
+
+func TestAutoPromotion(t *testing.T) {
+	t.Run("promotes after missed blocks", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, true, &running)
+
+		for seq := int64(10); seq < 13; seq++ {
+			rs.NewChainHead(big.NewInt(seq + 1))
+			rs.ObserveSignedBlock(big.NewInt(seq), false)
+		}
+		if rs.state != replicaWaiting || !rs.autoPromoting {
+			t.Fatalf("expected replica to be waiting to be promoted, have %v", rs.state)
+		}
+		// Head is 13, and the promotion is delayed by 2 blocks
+		if rs.startValidatingBlock.Cmp(big.NewInt(15)) != 0 {
+			t.Errorf("start validating block mismatch: have %v, want 15", rs.startValidatingBlock)
+		}
+		if err := rs.CheckRSDB(); err != nil {
+			t.Errorf("expected RSDB to be the same, err: %v", err)
+		}
+
+		rs.NewChainHead(big.NewInt(14))
+		if running || rs.IsPrimary() {
+			t.Errorf("expected replica before the start validating block")
+		}
+		rs.NewChainHead(big.NewInt(15))
+		if !running || !rs.IsPrimary() || rs.autoPromoting {
+			t.Errorf("expected to be promoted at the start validating block")
+		}
+		if rs.validatingSince.Cmp(big.NewInt(15)) != 0 {
+			t.Errorf("validating since mismatch: have %v, want 15", rs.validatingSince)
+		}
+	})
+
+	t.Run("signed blocks reset missed blocks", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, true, &running)
+
+		rs.ObserveSignedBlock(big.NewInt(1), false)
+		rs.ObserveSignedBlock(big.NewInt(2), false)
+		rs.ObserveSignedBlock(big.NewInt(3), true)
+		rs.ObserveSignedBlock(big.NewInt(4), false)
+		rs.ObserveSignedBlock(big.NewInt(5), false)
+		if rs.state != replicaPermanent || rs.missedBlocks != 2 {
+			t.Errorf("expected replica with 2 missed blocks, have %v with %d", rs.state, rs.missedBlocks)
+		}
+	})
+
+	t.Run("recovered primary cancels promotion", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, true, &running)
+
+		rs.NewChainHead(big.NewInt(4))
+		for seq := int64(1); seq < 4; seq++ {
+			rs.ObserveSignedBlock(big.NewInt(seq), false)
+		}
+		if rs.state != replicaWaiting {
+			t.Fatalf("expected replica to be waiting to be promoted, have %v", rs.state)
+		}
+
+		// Messages for old sequences are ignored
+		rs.ObserveConsensusMessage(newView(3, 0))
+		if rs.state != replicaWaiting {
+			t.Fatalf("expected replica to be waiting to be promoted, have %v", rs.state)
+		}
+		rs.ObserveConsensusMessage(newView(4, 1))
+		if rs.state != replicaPermanent || rs.startValidatingBlock != nil || rs.autoPromoting {
+			t.Errorf("expected promotion to be cancelled, have %v", rs.state)
+		}
+		if err := rs.CheckRSDB(); err != nil {
+			t.Errorf("expected RSDB to be the same, err: %v", err)
+		}
+	})
+
+	t.Run("start block set by the operator is kept", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, true, &running)
+
+		if err := rs.SetStartValidatingBlock(big.NewInt(100)); err != nil {
+			t.Fatal(err)
+		}
+		for seq := int64(1); seq < 4; seq++ {
+			rs.ObserveSignedBlock(big.NewInt(seq), false)
+		}
+		rs.ObserveSignedBlock(big.NewInt(4), true)
+		if rs.state != replicaWaiting || rs.startValidatingBlock.Cmp(big.NewInt(100)) != 0 {
+			t.Errorf("expected replica waiting to start at 100, have %v at %v", rs.state, rs.startValidatingBlock)
+		}
+	})
+
+	t.Run("disabled coordination", func(t *testing.T) {
+		rsState, _ := NewState(true, "", noop, noop)
+		rs := rsState.(*replicaStateImpl)
+		for seq := int64(1); seq < 100; seq++ {
+			rs.ObserveSignedBlock(big.NewInt(seq), false)
+		}
+		if rs.state != replicaPermanent {
+			t.Errorf("expected permanent replica, have %v", rs.state)
+		}
+	})
+}
+
+func TestFencing(t *testing.T) {
+	t.Run("block signed by another node", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, false, &running)
+
+		if err := rs.CheckAndStoreSignedView(newView(10, 0)); err != nil {
+			t.Fatal(err)
+		}
+		rs.ObserveSignedBlock(big.NewInt(10), true)
+		if !rs.IsPrimary() {
+			t.Fatalf("expected block signed by this node to be ignored")
+		}
+
+		rs.ObserveSignedBlock(big.NewInt(11), true)
+		if rs.IsPrimary() || running || !rs.fenced {
+			t.Fatalf("expected primary to be fenced")
+		}
+		if err := rs.CheckAndStoreSignedView(newView(12, 0)); err != errFenced {
+			t.Errorf("error mismatch: have %v, want %v", err, errFenced)
+		}
+		if !rs.Summary().Fenced {
+			t.Errorf("expected summary to report fencing")
+		}
+
+		// A fenced replica is not promoted automatically
+		for seq := int64(12); seq < 20; seq++ {
+			rs.ObserveSignedBlock(big.NewInt(seq), false)
+		}
+		if rs.state != replicaPermanent {
+			t.Errorf("expected fenced node to stay replica, have %v", rs.state)
+		}
+
+		// Made primary by the operator
+		if err := rs.MakePrimary(); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.CheckAndStoreSignedView(newView(20, 0)); err != nil {
+			t.Errorf("error mismatch: have %v, want nil", err)
+		}
+	})
+
+	t.Run("consensus message signed by another node", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, false, &running)
+
+		if err := rs.CheckAndStoreSignedView(newView(10, 1)); err != nil {
+			t.Fatal(err)
+		}
+		rs.ObserveConsensusMessage(newView(10, 1))
+		rs.ObserveConsensusMessage(newView(9, 3))
+		if !rs.IsPrimary() {
+			t.Fatalf("expected messages for signed views to be ignored")
+		}
+		rs.ObserveConsensusMessage(newView(10, 2))
+		if rs.IsPrimary() || running || !rs.fenced {
+			t.Fatalf("expected primary to be fenced")
+		}
+	})
+
+	t.Run("promoted replica ignores blocks signed by the old primary", func(t *testing.T) {
+		var running bool
+		rs := newCoordinatedState(t, true, &running)
+
+		rs.NewChainHead(big.NewInt(20))
+		if err := rs.MakePrimary(); err != nil {
+			t.Fatal(err)
+		}
+		rs.ObserveSignedBlock(big.NewInt(19), true)
+		if !rs.IsPrimary() {
+			t.Fatalf("expected block signed before the promotion to be ignored")
+		}
+		rs.ObserveSignedBlock(big.NewInt(20), true)
+		if rs.IsPrimary() || !rs.fenced {
+			t.Fatalf("expected block signed after the promotion to fence the node")
+		}
+	})
+}
+
+func TestSignedViewGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replicastate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "replicastate")
+
+	rsState, err := NewState(false, path, noop, noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsState.EnableCoordination(3, 2)
+	for _, view := range []*istanbul.View{newView(5, 0), newView(5, 2), newView(6, 0), newView(6, 0)} {
+		if err := rsState.CheckAndStoreSignedView(view); err != nil {
+			t.Errorf("view %v: have %v, want nil", view, err)
+		}
+	}
+	// Other rounds of the last signed sequence can still be signed
+	if err := rsState.CheckAndStoreSignedView(newView(6, 1)); err != nil {
+		t.Errorf("error mismatch: have %v, want nil", err)
+	}
+	if err := rsState.CheckAndStoreSignedView(newView(5, 3)); err == nil {
+		t.Errorf("expected old view to be rejected")
+	}
+	rsState.Close()
+
+	// The guard survives restarts
+	rsState, err = NewState(false, path, noop, noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsState.Close()
+	rsState.EnableCoordination(3, 2)
+	if view := rsState.Summary().LastSignedView; view == nil || view.Cmp(newView(6, 1)) != 0 {
+		t.Errorf("last signed view mismatch: have %v, want %v", view, newView(6, 1))
+	}
+	if err := rsState.CheckAndStoreSignedView(newView(5, 0)); err == nil {
+		t.Errorf("expected old view to be rejected after restart")
+	}
+}
+
+// runningCore mocks a core whose handler checks whether the node is primary, and which waits for the
+// handler to return when it's stopped, as the istanbul core does.
+type runningCore struct {
+	rs      State
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+func (c *runningCore) start() error {
+	c.stopped, c.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(c.done)
+		<-c.stopped
+		// The handler was starting a new sequence while the core was stopped
+		c.rs.IsPrimaryForSeq(big.NewInt(100))
+	}()
+	return nil
+}
+
+func (c *runningCore) stop() error {
+	close(c.stopped)
+	<-c.done
+	return nil
+}
+
+func TestFencingRunningCore(t *testing.T) {
+	observe := map[string]func(t *testing.T, rs State){
+		"block signed by another node":   func(t *testing.T, rs State) { rs.ObserveSignedBlock(big.NewInt(11), true) },
+		"message signed by another node": func(t *testing.T, rs State) { rs.ObserveConsensusMessage(newView(11, 0)) },
+		"stop validating block": func(t *testing.T, rs State) {
+			if err := rs.SetStopValidatingBlock(big.NewInt(11)); err != nil {
+				t.Error(err)
+			}
+			rs.NewChainHead(big.NewInt(11))
+		},
+	}
+	for name, fn := range observe {
+		t.Run(name, func(t *testing.T) {
+			core := new(runningCore)
+			rsState, err := NewState(false, "", core.start, core.stop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rsState.EnableCoordination(3, 2)
+			core.rs = rsState
+			core.start()
+			if err := rsState.CheckAndStoreSignedView(newView(10, 0)); err != nil {
+				t.Fatal(err)
+			}
+
+			stopped := make(chan struct{})
+			go func() {
+				fn(t, rsState)
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				// The state can't be closed while the lock is held
+				t.Fatalf("stopping the core deadlocked")
+			}
+			if rsState.IsPrimary() {
+				t.Errorf("expected the node to be a replica")
+			}
+			rsState.Close()
+		})
+	}
+}
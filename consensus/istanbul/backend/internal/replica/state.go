@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/log"
 	"github.com/celo-org/celo-blockchain/rlp"
 	lvlerrors "github.com/syndtr/goleveldb/leveldb/errors"
@@ -67,6 +68,16 @@ type State interface {
 	// Internal functions
 	// Updates replica state given the current block undergoing consensus.
 	NewChainHead(blockNumber *big.Int)
+	// Enables the automatic promotion of this replica when the primary stops signing, and the fencing
+	// of this node when another node signs with the same validator key.
+	EnableCoordination(missedBlocks, promotionDelay uint64)
+	// Records a view about to be signed, and returns an error if this node must not sign it.
+	CheckAndStoreSignedView(view *istanbul.View) error
+	// Updates the coordination state with whether the validator's key signed the given committed block.
+	ObserveSignedBlock(blockNumber *big.Int, signed bool)
+	// Updates the coordination state with a consensus message signed by the validator's key that was
+	// received from another node.
+	ObserveConsensusMessage(view *istanbul.View)
 	// Closes the replica state database.
 	Close() error
 
@@ -87,6 +98,19 @@ type replicaStateImpl struct {
 
 	startFn func() error
 	stopFn  func() error
+
+	// Coordination mode, enabled if autoPromoteMissedBlocks > 0 (see coordination.go). The config is set under both locks.
+	autoPromoteMissedBlocks uint64   // Number of consecutive blocks the primary must miss for this replica to promote itself
+	autoPromoteDelay        uint64   // Number of blocks to wait before starting to validate after deciding to promote
+	missedBlocks            uint64   // Number of consecutive blocks not signed by the validator's key
+	autoPromoting           bool     // Whether the start validating block was set by an automatic promotion
+	headSeq                 *big.Int // The latest sequence known to be undergoing consensus
+	validatingSince         *big.Int // The sequence from which this node validates, if it became primary since it started
+
+	// Signing guard. Uses its own lock since it is checked by the core, which may be stopped while holding mu.
+	guardMu        *sync.Mutex
+	fenced         bool           // Whether another node was found signing with the validator's key
+	lastSignedView *istanbul.View // The highest view signed by this node
 }
 
 // NewState creates a replicaState in the given replica state and opens or creates the replica state DB at `path`.
@@ -116,6 +140,11 @@ func NewState(isReplica bool, path string, startFn, stopFn func() error) (State,
 	rs.rsdb = db
 	rs.startFn = startFn
 	rs.stopFn = stopFn
+	rs.guardMu = new(sync.Mutex)
+	if rs.fenced, rs.lastSignedView, err = db.GetSigningGuard(); err != nil && err != lvlerrors.ErrNotFound {
+		log.Warn("Can't read signing guard from ReplicaStateDB at startup", "err", err, "dbpath", path)
+		return nil, err
+	}
 	if err := db.StoreReplicaState(rs); err != nil {
 		log.Warn("Can't store replica state to ReplicaStateDB", "err", err)
 		return rs, err
@@ -130,47 +159,72 @@ func (rs *replicaStateImpl) Close() error {
 	return rs.rsdb.Close()
 }
 
-// NewChainHead updates replica state and starts/stops the core if needed
+// NewChainHead updates replica state and starts/stops the core if needed.  The core is started or
+// stopped without holding the lock, since the core may be waiting for it to check whether it's primary.
 func (rs *replicaStateImpl) NewChainHead(blockNumber *big.Int) {
 	logger := log.New("func", "NewChainHead", "seq", blockNumber)
-	switch rs.state {
+
+	rs.mu.Lock()
+	rs.headSeq = blockNumber
+	state := rs.state
+	startValidatingBlock := rs.startValidatingBlock
+	stopValidatingBlock := rs.stopValidatingBlock
+	rs.mu.Unlock()
+
+	switch state {
 	case primaryInRange:
-		if blockNumber.Cmp(rs.stopValidatingBlock) >= 0 {
+		if blockNumber.Cmp(stopValidatingBlock) >= 0 {
 			logger.Info("About to stop validating")
 			if err := rs.stopFn(); err != nil {
 				logger.Warn("Error stopping core", "err", err)
 				return
 			}
-			oldState := rs.state
-			oldStart := rs.startValidatingBlock
-			oldStop := rs.stopValidatingBlock
 
+			rs.mu.Lock()
+			if rs.state != state {
+				// Changed while stopping the core (e.g. through the management RPC)
+				rs.mu.Unlock()
+				return
+			}
 			rs.state = replicaPermanent
 			rs.startValidatingBlock = nil
 			rs.stopValidatingBlock = nil
 
-			if err := rs.rsdb.StoreReplicaState(rs); err != nil {
+			err := rs.rsdb.StoreReplicaState(rs)
+			if err != nil {
+				rs.state = state
+				rs.startValidatingBlock = startValidatingBlock
+				rs.stopValidatingBlock = stopValidatingBlock
+			}
+			rs.mu.Unlock()
+
+			if err != nil {
 				if startErr := rs.startFn(); startErr != nil {
 					// Stopped, but could not restart
 					logger.Crit("Error when saving rsdb in NewChainHead in transition to replica. Tried to restart core, but that also failed", "rsdb_err", err, "start_err", startErr)
 					return
 				}
-				rs.state = oldState
-				rs.startValidatingBlock = oldStart
-				rs.stopValidatingBlock = oldStop
 				logger.Crit("Error when saving rsdb in NewChainHead in transition to replica. Rolled back transition.", "err", err)
 			}
 		}
 	case replicaWaiting:
-		if blockNumber.Cmp(rs.startValidatingBlock) >= 0 {
+		if blockNumber.Cmp(startValidatingBlock) >= 0 {
 			logger.Info("About to start validating")
 			if err := rs.startFn(); err != nil {
 				logger.Warn("Error starting core", "err", err)
 				return
 			}
-			oldState := rs.state
-			oldStart := rs.startValidatingBlock
-			oldStop := rs.stopValidatingBlock
+
+			rs.mu.Lock()
+			if rs.state != state {
+				// Changed while starting the core (e.g. through the management RPC)
+				rs.mu.Unlock()
+				return
+			}
+			oldValidatingSince := rs.validatingSince
+			oldAutoPromoting := rs.autoPromoting
+			rs.validatingSince = rs.startValidatingBlock
+			rs.autoPromoting = false
 
 			if rs.stopValidatingBlock == nil {
 				logger.Info("Switching to primary (permanent)")
@@ -182,16 +236,22 @@ func (rs *replicaStateImpl) NewChainHead(blockNumber *big.Int) {
 				rs.state = primaryInRange
 			}
 
-			if err := rs.rsdb.StoreReplicaState(rs); err != nil {
+			err := rs.rsdb.StoreReplicaState(rs)
+			if err != nil {
+				rs.state = state
+				rs.startValidatingBlock = startValidatingBlock
+				rs.stopValidatingBlock = stopValidatingBlock
+				rs.validatingSince = oldValidatingSince
+				rs.autoPromoting = oldAutoPromoting
+			}
+			rs.mu.Unlock()
+
+			if err != nil {
 				if stopErr := rs.stopFn(); stopErr != nil {
 					// Started, but could not stop
 					logger.Crit("Error when saving rsdb in NewChainHead in transition to primary. Tried to stop core, but that also failed", "rsdb_err", err, "stop_err", stopErr)
 					return
 				}
-				rs.state = oldState
-				rs.startValidatingBlock = oldStart
-				rs.stopValidatingBlock = oldStop
-
 				logger.Crit("Error when saving rsdb in NewChainHead in transition to primary. Rolled back transition.", "err", err)
 			}
 		}
@@ -225,6 +285,8 @@ func (rs *replicaStateImpl) SetStartValidatingBlock(blockNumber *big.Int) error
 		return fmt.Errorf("Can't change set start validating block when primary (%v)", rs.state)
 	}
 	rs.startValidatingBlock = blockNumber
+	// A start block set manually is never cancelled by the coordination mode.
+	rs.resetCoordination()
 
 	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
 		rs.state = oldState
@@ -289,6 +351,7 @@ func (rs *replicaStateImpl) MakeReplica() error {
 	rs.startValidatingBlock = nil
 	rs.stopValidatingBlock = nil
 	rs.state = replicaPermanent
+	rs.resetCoordination()
 
 	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
 		if startErr := rs.startFn(); startErr != nil {
@@ -320,6 +383,10 @@ func (rs *replicaStateImpl) MakePrimary() error {
 	rs.startValidatingBlock = nil
 	rs.stopValidatingBlock = nil
 	rs.state = primaryPermanent
+	if oldState == replicaPermanent || oldState == replicaWaiting {
+		rs.validatingSince = rs.headSeq
+	}
+	rs.resetCoordination()
 
 	if err := rs.rsdb.StoreReplicaState(rs); err != nil {
 		if stopErr := rs.stopFn(); stopErr != nil {
@@ -363,10 +430,15 @@ func (rs *replicaStateImpl) IsPrimaryForSeq(seq *big.Int) bool {
 }
 
 type ReplicaStateSummary struct {
-	State                string   `json:"state"`
-	IsPrimary            bool     `json:"isPrimary"`
-	StartValidatingBlock *big.Int `json:"startValidatingBlock"`
-	StopValidatingBlock  *big.Int `json:"stopValidatingBlock"`
+	State                string         `json:"state"`
+	IsPrimary            bool           `json:"isPrimary"`
+	StartValidatingBlock *big.Int       `json:"startValidatingBlock"`
+	StopValidatingBlock  *big.Int       `json:"stopValidatingBlock"`
+	Coordination         bool           `json:"coordination"`
+	AutoPromoting        bool           `json:"autoPromoting"`
+	MissedBlocks         uint64         `json:"missedBlocks"`
+	Fenced               bool           `json:"fenced"`
+	LastSignedView       *istanbul.View `json:"lastSignedView"`
 }
 
 func (rs *replicaStateImpl) Summary() *ReplicaStateSummary {
@@ -378,8 +450,16 @@ func (rs *replicaStateImpl) Summary() *ReplicaStateSummary {
 		IsPrimary:            rs.state == primaryPermanent || rs.state == primaryInRange,
 		StartValidatingBlock: rs.startValidatingBlock,
 		StopValidatingBlock:  rs.stopValidatingBlock,
+		Coordination:         rs.autoPromoteMissedBlocks > 0,
+		AutoPromoting:        rs.autoPromoting,
+		MissedBlocks:         rs.missedBlocks,
 	}
 
+	rs.guardMu.Lock()
+	summary.Fenced = rs.fenced
+	summary.LastSignedView = rs.lastSignedView
+	rs.guardMu.Unlock()
+
 	return summary
 }
 
@@ -17,11 +17,13 @@
 package replica
 
 import (
+	"math/big"
 	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul/backend/internal/db"
 	"github.com/celo-org/celo-blockchain/log"
 	"github.com/celo-org/celo-blockchain/rlp"
@@ -30,7 +32,8 @@ import (
 // Keys in the node database.
 const (
 	replicaStateDBVersion = 1
-	replicaStateKey       = "replicaState" // Info about start/stop state
+	replicaStateKey       = "replicaState"   // Info about start/stop state
+	signingGuardKey       = "lastSignedView" // Last view signed by this node, and whether it is fenced
 
 )
 
@@ -103,3 +106,56 @@ func (rsdb *ReplicaStateDB) StoreReplicaState(rs State) error {
 
 	return err
 }
+
+type signingGuardRLP struct {
+	Fenced             bool
+	LastSignedSequence *big.Int // Zero if this node never signed a view
+	LastSignedRound    *big.Int
+}
+
+// GetSigningGuard returns whether this node is fenced and the last view it signed (nil if it never signed)
+func (rsdb *ReplicaStateDB) GetSigningGuard() (bool, *istanbul.View, error) {
+	rsdb.lock.Lock()
+	defer rsdb.lock.Unlock()
+
+	rawEntry, err := rsdb.gdb.Get([]byte(signingGuardKey))
+	if err != nil {
+		return false, nil, err
+	}
+
+	var entry signingGuardRLP
+	if err = rlp.DecodeBytes(rawEntry, &entry); err != nil {
+		return false, nil, err
+	}
+	if entry.LastSignedSequence.Sign() == 0 {
+		return entry.Fenced, nil, nil
+	}
+	return entry.Fenced, &istanbul.View{Sequence: entry.LastSignedSequence, Round: entry.LastSignedRound}, nil
+}
+
+// StoreSigningGuard will store whether this node is fenced and the last view it signed
+func (rsdb *ReplicaStateDB) StoreSigningGuard(fenced bool, lastSignedView *istanbul.View) error {
+	rsdb.lock.Lock()
+	defer rsdb.lock.Unlock()
+	logger := rsdb.logger.New("func", "StoreSigningGuard")
+
+	entry := signingGuardRLP{Fenced: fenced, LastSignedSequence: new(big.Int), LastSignedRound: new(big.Int)}
+	if lastSignedView != nil {
+		entry.LastSignedSequence = lastSignedView.Sequence
+		entry.LastSignedRound = lastSignedView.Round
+	}
+	entryBytes, err := rlp.EncodeToBytes(&entry)
+	if err != nil {
+		logger.Error("Failed to save signing guard", "reason", "rlp encoding", "err", err)
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(signingGuardKey), entryBytes)
+	err = rsdb.gdb.Write(batch)
+	if err != nil {
+		logger.Error("Failed to save signing guard", "reason", "levelDB write", "err", err)
+	}
+
+	return err
+}
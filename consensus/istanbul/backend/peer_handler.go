@@ -163,3 +163,8 @@ func (sb *Backend) AddPeer(node *enode.Node, purpose p2p.PurposeFlag) {
 func (sb *Backend) RemovePeer(node *enode.Node, purpose p2p.PurposeFlag) {
 	sb.p2pserver.RemovePeer(node, purpose)
 }
+
+// PeerCount returns the number of connected peers
+func (sb *Backend) PeerCount() int {
+	return len(sb.broadcaster.FindPeers(nil, p2p.AnyPurpose))
+}
@@ -247,6 +247,8 @@ func TestValSetChange(t *testing.T) {
 		config.ValidatorEnodeDBPath = ""
 		config.VersionCertificateDBPath = ""
 		config.RoundStateDBPath = ""
+		config.EquivocationDBPath = ""
+		config.SlashingProtectionDBPath = ""
 		if tt.epoch != 0 {
 			config.Epoch = tt.epoch
 		}
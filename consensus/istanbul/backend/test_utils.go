@@ -43,6 +43,8 @@ func newBlockChainWithKeys(isProxy bool, proxiedValAddress common.Address, isPro
 	config.ValidatorEnodeDBPath = ""
 	config.VersionCertificateDBPath = ""
 	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = ""
+	config.SlashingProtectionDBPath = ""
 	config.Proxy = isProxy
 	config.ProxiedValidatorAddress = proxiedValAddress
 	config.Proxied = isProxied
@@ -46,15 +46,25 @@ type Config struct {
 	MaxResendRoundChangeTimeout uint64         `toml:",omitempty"` // Maximum interval with which to resend RoundChange messages for same round
 	BlockPeriod                 uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
 	ProposerPolicy              ProposerPolicy `toml:",omitempty"` // The policy for proposer selection
+	ProposerSelector            string         `toml:",omitempty"` // The name of a registered proposer selector, which overrides ProposerPolicy if set
 	Epoch                       uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
 	DefaultLookbackWindow       uint64         `toml:",omitempty"` // The default value for how many blocks in a row a validator must miss to be considered "down"
 	ReplicaStateDBPath          string         `toml:",omitempty"` // The location for the validator replica state DB
 	ValidatorEnodeDBPath        string         `toml:",omitempty"` // The location for the validator enodes DB
 	VersionCertificateDBPath    string         `toml:",omitempty"` // The location for the signed announce version DB
 	RoundStateDBPath            string         `toml:",omitempty"` // The location for the round states DB
+	EquivocationDBPath          string         `toml:",omitempty"` // The location for the equivocation evidence DB
+	SlashingProtectionDBPath    string         `toml:",omitempty"` // The location for the DB of the messages signed by this validator
+	JournalPath                 string         `toml:",omitempty"` // The location for the consensus message journal (disabled if empty)
+	JournalMaxFileSize          uint64         `toml:",omitempty"` // The size (in bytes) after which the journal is rotated to a new file
+	JournalMaxFiles             uint64         `toml:",omitempty"` // The number of journal files to keep
 	Validator                   bool           `toml:",omitempty"` // Specified if this node is configured to validate  (specifically if --mine command line is set)
 	Replica                     bool           `toml:",omitempty"` // Specified if this node is configured to be a replica
 
+	// Replica Coordination Configs
+	ReplicaAutoPromoteMissedBlocks uint64 `toml:",omitempty"` // The number of consecutive blocks not signed by the validator after which a replica promotes itself (disabled if 0)
+	ReplicaAutoPromoteDelay        uint64 `toml:",omitempty"` // The number of blocks a replica waits before validating after deciding to promote itself
+
 	// Proxy Configs
 	Proxy                   bool           `toml:",omitempty"` // Specifies if this node is a proxy
 	ProxiedValidatorAddress common.Address `toml:",omitempty"` // The address of the proxied validator
@@ -63,6 +73,9 @@ type Config struct {
 	Proxied      bool           `toml:",omitempty"` // Specifies if this node is proxied
 	ProxyConfigs []*ProxyConfig `toml:",omitempty"` // The set of proxy configs for this proxied validator at startup
 
+	ProxyHealthCheckPeriod      uint64 `toml:",omitempty"` // Time duration (in seconds) between proxy health checks (disabled if 0)
+	ProxyHealthCheckMaxFailures uint64 `toml:",omitempty"` // The number of consecutive failed health checks after which a proxy is considered unhealthy
+
 	// Announce Configs
 	AnnounceQueryEnodeGossipPeriod                 uint64 `toml:",omitempty"` // Time duration (in seconds) between gossiped query enode messages
 	AnnounceAggressiveQueryEnodeGossipOnEnablement bool   `toml:",omitempty"` // Specifies if this node should aggressively query enodes on announce enablement
@@ -89,10 +102,19 @@ var DefaultConfig = &Config{
 	ValidatorEnodeDBPath:           "validatorenodes",
 	VersionCertificateDBPath:       "versioncertificates",
 	RoundStateDBPath:               "roundstates",
+	EquivocationDBPath:             "equivocations",
+	SlashingProtectionDBPath:       "slashingprotection",
+	JournalPath:                    "",
+	JournalMaxFileSize:             64 * 1024 * 1024,
+	JournalMaxFiles:                8,
 	Validator:                      false,
 	Replica:                        false,
+	ReplicaAutoPromoteMissedBlocks: 0,
+	ReplicaAutoPromoteDelay:        5,
 	Proxy:                          false,
 	Proxied:                        false,
+	ProxyHealthCheckPeriod:         10,
+	ProxyHealthCheckMaxFailures:    3,
 	AnnounceQueryEnodeGossipPeriod: 300, // 5 minutes
 	AnnounceAggressiveQueryEnodeGossipOnEnablement: true,
 	AnnounceAdditionalValidatorsToGossip:           10,
@@ -120,6 +142,7 @@ func ApplyParamsChainConfigToConfig(chainConfig *params.ChainConfig, config *Con
 		return fmt.Errorf("istanbul.lookbackwindow must be less than istanbul.epoch-2")
 	}
 	config.ProposerPolicy = ProposerPolicy(chainConfig.Istanbul.ProposerPolicy)
+	config.ProposerSelector = chainConfig.Istanbul.ProposerSelector
 
 	return nil
 }
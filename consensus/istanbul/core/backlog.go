@@ -144,7 +144,7 @@ func newMsgBacklog(msgProcessor func(*istanbul.Message), checkMessage func(msgCo
 func (c *msgBacklogImpl) store(msg *istanbul.Message) {
 	logger := c.logger.New("func", "store", "from", msg.Address, "cur_seq", c.currentView.Sequence, "cur_round", c.currentView.Round)
 
-	view, err := extractMessageView(msg)
+	view, err := ExtractMessageView(msg)
 
 	if err != nil {
 		return
@@ -284,7 +284,7 @@ func (c *msgBacklogImpl) processBacklog() {
 			c.processBacklogForSeq(seq, func(msg *istanbul.Message) bool {
 				processedMsgsConsidered++
 
-				view, err := extractMessageView(msg)
+				view, err := ExtractMessageView(msg)
 
 				if err != nil {
 					logger.Warn("Error decoding msg", "err", err)
@@ -337,7 +337,8 @@ func toPriority(msgCode uint64, view *istanbul.View) int64 {
 	return -int64(view.Round.Uint64()*10 + uint64(msgPriority[msgCode]))
 }
 
-func extractMessageView(msg *istanbul.Message) (*istanbul.View, error) {
+// ExtractMessageView returns the view of a consensus message
+func ExtractMessageView(msg *istanbul.Message) (*istanbul.View, error) {
 	var v *istanbul.View
 	switch msg.Code {
 	case istanbul.MsgPreprepare:
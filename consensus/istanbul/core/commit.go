@@ -54,20 +54,9 @@ func (c *core) generateEpochValidatorSetData(blockNumber uint64, round uint8, bl
 		return nil, nil, false, errNotLastBlockInEpoch
 	}
 
-	// Serialize the public keys for the validators in the validator set.
-	blsPubKeys := []blscrypto.SerializedPublicKey{}
-	for _, v := range newValSet.List() {
-		blsPubKeys = append(blsPubKeys, v.BLSPublicKey())
-	}
-
-	maxNonSigners := uint32(newValSet.Size() - newValSet.MinQuorumSize())
-
 	// Before the Donut fork, use the snark data encoding with epoch entropy.
 	if !c.backend.ChainConfig().IsDonut(big.NewInt(int64(blockNumber))) {
-		message, extraData, err := blscrypto.EncodeEpochSnarkData(
-			blsPubKeys, maxNonSigners,
-			uint16(istanbul.GetEpochNumber(blockNumber, c.config.Epoch)),
-		)
+		message, extraData, err := EncodeEpochValidatorSetData(blockNumber, c.config.Epoch, round, blockHash, common.Hash{}, newValSet, false)
 		// This is before the Donut hardfork, so signify this doesn't use CIP22.
 		return message, extraData, false, err
 	}
@@ -78,16 +67,35 @@ func (c *core) generateEpochValidatorSetData(blockNumber uint64, round uint8, bl
 		return nil, nil, false, errors.New("unknown block")
 	}
 
-	maxNonSigners = maxValidators - uint32(newValSet.MinQuorumSize())
-	message, extraData, err := blscrypto.EncodeEpochSnarkDataCIP22(
+	message, extraData, err := EncodeEpochValidatorSetData(blockNumber, c.config.Epoch, round, blockHash, parentEpochBlockHash, newValSet, true)
+	// This is after the Donut hardfork, so signify this uses CIP22.
+	return message, extraData, true, err
+}
+
+// EncodeEpochValidatorSetData serializes the epoch data signed by the validators in the epoch validator set
+// seal of the last block of an epoch, for the new validator set. The block hash and the hash of the last block
+// of the previous epoch are only used with the CIP22 encoding (after the Donut hardfork).
+func EncodeEpochValidatorSetData(blockNumber, epochSize uint64, round uint8, blockHash, parentEpochBlockHash common.Hash, newValSet istanbul.ValidatorSet, cip22 bool) ([]byte, []byte, error) {
+	// Serialize the public keys for the validators in the validator set.
+	blsPubKeys := []blscrypto.SerializedPublicKey{}
+	for _, v := range newValSet.List() {
+		blsPubKeys = append(blsPubKeys, v.BLSPublicKey())
+	}
+	epochIndex := uint16(istanbul.GetEpochNumber(blockNumber, epochSize))
+
+	if !cip22 {
+		maxNonSigners := uint32(newValSet.Size() - newValSet.MinQuorumSize())
+		return blscrypto.EncodeEpochSnarkData(blsPubKeys, maxNonSigners, epochIndex)
+	}
+
+	maxNonSigners := maxValidators - uint32(newValSet.MinQuorumSize())
+	return blscrypto.EncodeEpochSnarkDataCIP22(
 		blsPubKeys, maxNonSigners, maxValidators,
-		uint16(istanbul.GetEpochNumber(blockNumber, c.config.Epoch)),
+		epochIndex,
 		round,
 		blscrypto.EpochEntropyFromHash(blockHash),
 		blscrypto.EpochEntropyFromHash(parentEpochBlockHash),
 	)
-	// This is after the Donut hardfork, so signify this uses CIP22.
-	return message, extraData, true, err
 }
 
 func (c *core) broadcastCommit(sub *istanbul.Subject) {
@@ -102,6 +102,9 @@ type CoreBackend interface {
 
 	IsPrimaryForSeq(seq *big.Int) bool
 	UpdateReplicaState(seq *big.Int)
+
+	// CheckSigningView returns an error if this node must not sign messages for the given view
+	CheckSigningView(view *istanbul.View) error
 }
 
 type core struct {
@@ -136,6 +139,19 @@ type core struct {
 
 	// the timer to record consensus duration (from accepting a preprepare to final committed stage)
 	consensusTimer metrics.Timer
+
+	// equivocation (double signing) detection
+	equivocations      *equivocationIndex
+	edb                EquivocationDB
+	equivocationFeed   event.Feed
+	equivocationsMeter metrics.Meter
+
+	// slashing protection of the messages signed by this validator
+	spdb SlashingProtectionDB
+
+	// journal of the handled events and sent messages, nil if disabled
+	journal            *journal
+	journalSentEntries []*JournalEntry // messages sent while handling the current event
 }
 
 // New creates an Istanbul consensus core
@@ -144,12 +160,24 @@ func New(backend CoreBackend, config *istanbul.Config) Engine {
 	if err != nil {
 		log.Crit("Failed to open RoundStateDB", "err", err)
 	}
+	edb, err := newEquivocationDB(config.EquivocationDBPath)
+	if err != nil {
+		log.Crit("Failed to open EquivocationDB", "err", err)
+	}
+	spdb, err := OpenSlashingProtectionDB(config.SlashingProtectionDBPath)
+	if err != nil {
+		log.Crit("Failed to open SlashingProtectionDB", "err", err)
+	}
+	selectProposer, err := validator.GetConfiguredProposerSelector(config)
+	if err != nil {
+		log.Crit("Failed to get proposer selector", "err", err)
+	}
 
 	c := &core{
 		config:             config,
 		address:            backend.Address(),
 		logger:             log.New(),
-		selectProposer:     validator.GetProposerSelector(config.ProposerPolicy),
+		selectProposer:     selectProposer,
 		handlerWg:          new(sync.WaitGroup),
 		backend:            backend,
 		pendingRequests:    prque.New(nil),
@@ -157,6 +185,10 @@ func New(backend CoreBackend, config *istanbul.Config) Engine {
 		consensusTimestamp: time.Time{},
 		rsdb:               rsdb,
 		consensusTimer:     metrics.NewRegisteredTimer("consensus/istanbul/core/consensus", nil),
+		equivocations:      newEquivocationIndex(defaultEquivocationIndexSequences),
+		edb:                edb,
+		equivocationsMeter: metrics.NewRegisteredMeter("consensus/istanbul/core/equivocations", nil),
+		spdb:               spdb,
 	}
 	msgBacklog := newMsgBacklog(
 		func(msg *istanbul.Message) {
@@ -200,6 +232,14 @@ func (c *core) ParentCommits() MessageSet {
 	return c.current.ParentCommits()
 }
 
+func (c *core) GetEquivocationEvidence(fromSeq, toSeq uint64) ([]*EquivocationEvidence, error) {
+	return c.edb.GetEvidence(fromSeq, toSeq)
+}
+
+func (c *core) SubscribeEquivocationEvidence(ch chan<- EquivocationEvidenceEvent) event.Subscription {
+	return c.equivocationFeed.Subscribe(ch)
+}
+
 func (c *core) ForceRoundChange() {
 	// timeout current DesiredView
 	view := &istanbul.View{Sequence: c.current.Sequence(), Round: c.current.DesiredRound()}
@@ -338,12 +378,33 @@ func (c *core) unicast(msg *istanbul.Message, addr common.Address) {
 func (c *core) sendMsgTo(msg *istanbul.Message, addresses []common.Address) {
 	logger := c.newLogger("func", "sendMsgTo")
 
+	view, err := ExtractMessageView(msg)
+	if err != nil {
+		logger.Error("Failed to extract message view", "m", msg, "err", err)
+		return
+	}
+	if err := c.backend.CheckSigningView(view); err != nil {
+		logger.Error("Refusing to sign message", "m", msg, "err", err)
+		return
+	}
+	signed, err := signedMessageOf(msg, view)
+	if err != nil {
+		logger.Error("Failed to decode message", "m", msg, "err", err)
+		return
+	}
+	if err := c.spdb.CheckAndRecord(c.address, signed); err != nil {
+		logger.Error("Refusing to sign message, slashing protection failed", "m", msg, "err", err)
+		return
+	}
+
 	payload, err := c.finalizeMessage(msg)
 	if err != nil {
 		logger.Error("Failed to finalize message", "m", msg, "err", err)
 		return
 	}
 
+	c.journalSent(payload, addresses)
+
 	// Send payload to the specified addresses
 	if err := c.backend.Multicast(addresses, payload, istanbul.ConsensusMsg, true); err != nil {
 		logger.Error("Failed to send message", "m", msg, "err", err)
@@ -771,6 +832,7 @@ func (c *core) verifyProposal(proposal istanbul.Proposal) (time.Duration, error)
 
 		duration, err := c.backend.Verify(proposal)
 		logger.Trace("proposal verify return values", "duration", duration, "err", err)
+		c.journalVerify(proposal.Hash(), duration, err)
 
 		// Don't cache the verification status if it's a future block
 		if err != consensus.ErrFutureBlock {
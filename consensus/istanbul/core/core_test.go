@@ -18,7 +18,10 @@ package core
 
 import (
 	"errors"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -224,3 +227,35 @@ func TestEpochSnarkData(t *testing.T) {
 	}
 
 }
+
+func TestCloseReleasesDatabases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "istanbul-core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, blsKeys, _ := generateValidators(1)
+	sys := newTestSystem(1, 0, blsKeys)
+	config := *istanbul.DefaultConfig
+	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = filepath.Join(dir, "equivocations")
+	config.SlashingProtectionDBPath = filepath.Join(dir, "slashingprotection")
+
+	c := New(sys.NewBackend(0, nil), &config)
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close the engine: %v", err)
+	}
+
+	// The databases can only be reopened once the engine released them
+	edb, err := newEquivocationDB(config.EquivocationDBPath)
+	if err != nil {
+		t.Fatalf("failed to reopen EquivocationDB: %v", err)
+	}
+	defer edb.Close()
+	spdb, err := OpenSlashingProtectionDB(config.SlashingProtectionDBPath)
+	if err != nil {
+		t.Fatalf("failed to reopen SlashingProtectionDB: %v", err)
+	}
+	defer spdb.Close()
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+)
+
+// defaultEquivocationIndexSequences is the number of sequences for which signed
+// PREPARE and COMMIT messages are kept in memory to detect equivocations.
+const defaultEquivocationIndexSequences = 3
+
+// EquivocationEvidence is a self-contained record proving that a validator signed two
+// conflicting PREPARE/COMMIT messages for the same view. It includes both signed messages
+// and the validator set that was in charge of that sequence, so that the signatures can be
+// verified without access to the chain.
+type EquivocationEvidence struct {
+	View       *istanbul.View
+	Validator  common.Address
+	First      *istanbul.Message
+	Second     *istanbul.Message
+	Validators []istanbul.ValidatorData
+}
+
+// EquivocationEvidenceSummary is the user facing representation of an EquivocationEvidence.
+type EquivocationEvidenceSummary struct {
+	Sequence     *big.Int         `json:"sequence"`
+	Round        *big.Int         `json:"round"`
+	Validator    common.Address   `json:"validator"`
+	FirstCode    uint64           `json:"firstCode"`
+	FirstDigest  common.Hash      `json:"firstDigest"`
+	First        hexutil.Bytes    `json:"first"`
+	SecondCode   uint64           `json:"secondCode"`
+	SecondDigest common.Hash      `json:"secondDigest"`
+	Second       hexutil.Bytes    `json:"second"`
+	Validators   []common.Address `json:"validators"`
+}
+
+// Summary returns the user facing representation of the evidence. The signed messages are
+// returned RLP encoded, as they were received from the network.
+func (ev *EquivocationEvidence) Summary() (*EquivocationEvidenceSummary, error) {
+	first, err := ev.First.Payload()
+	if err != nil {
+		return nil, err
+	}
+	second, err := ev.Second.Payload()
+	if err != nil {
+		return nil, err
+	}
+	firstSubject, err := subjectFromPrepareOrCommit(ev.First)
+	if err != nil {
+		return nil, err
+	}
+	secondSubject, err := subjectFromPrepareOrCommit(ev.Second)
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]common.Address, len(ev.Validators))
+	for i, val := range ev.Validators {
+		validators[i] = val.Address
+	}
+
+	return &EquivocationEvidenceSummary{
+		Sequence:     ev.View.Sequence,
+		Round:        ev.View.Round,
+		Validator:    ev.Validator,
+		FirstCode:    ev.First.Code,
+		FirstDigest:  firstSubject.Digest,
+		First:        first,
+		SecondCode:   ev.Second.Code,
+		SecondDigest: secondSubject.Digest,
+		Second:       second,
+		Validators:   validators,
+	}, nil
+}
+
+// subjectFromPrepareOrCommit decodes the subject of a PREPARE or COMMIT message.
+func subjectFromPrepareOrCommit(msg *istanbul.Message) (*istanbul.Subject, error) {
+	switch msg.Code {
+	case istanbul.MsgPrepare:
+		var subject *istanbul.Subject
+		if err := msg.Decode(&subject); err != nil {
+			return nil, errFailedDecodePrepare
+		}
+		return subject, nil
+	case istanbul.MsgCommit:
+		var committedSubject *istanbul.CommittedSubject
+		if err := msg.Decode(&committedSubject); err != nil {
+			return nil, errFailedDecodeCommit
+		}
+		return committedSubject.Subject, nil
+	}
+	return nil, errInvalidMessage
+}
+
+type equivocationKey struct {
+	round     uint64
+	validator common.Address
+}
+
+type equivocationEntry struct {
+	msg      *istanbul.Message
+	digest   common.Hash
+	reported bool
+}
+
+// equivocationIndex keeps the first PREPARE or COMMIT message signed by every validator for
+// each (sequence, round), for a bounded number of sequences.
+type equivocationIndex struct {
+	maxSequences int
+	sequences    map[uint64]map[equivocationKey]*equivocationEntry
+}
+
+func newEquivocationIndex(maxSequences int) *equivocationIndex {
+	return &equivocationIndex{
+		maxSequences: maxSequences,
+		sequences:    make(map[uint64]map[equivocationKey]*equivocationEntry),
+	}
+}
+
+// add indexes the message for the given subject. If the same validator already signed a
+// message for a different digest on the same view (and it was not already reported), the
+// previously signed message is returned.
+func (idx *equivocationIndex) add(subject *istanbul.Subject, msg *istanbul.Message) *istanbul.Message {
+	seq := subject.View.Sequence.Uint64()
+	entries, ok := idx.sequences[seq]
+	if !ok {
+		if !idx.makeRoomFor(seq) {
+			return nil
+		}
+		entries = make(map[equivocationKey]*equivocationEntry)
+		idx.sequences[seq] = entries
+	}
+
+	key := equivocationKey{round: subject.View.Round.Uint64(), validator: msg.Address}
+	entry, ok := entries[key]
+	if !ok {
+		entries[key] = &equivocationEntry{msg: msg.Copy(), digest: subject.Digest}
+		return nil
+	}
+	if entry.digest == subject.Digest || entry.reported {
+		return nil
+	}
+	entry.reported = true
+	return entry.msg
+}
+
+// makeRoomFor evicts the oldest sequence if the index is full. It returns false if seq is
+// older than every sequence currently indexed and therefore should not be indexed.
+func (idx *equivocationIndex) makeRoomFor(seq uint64) bool {
+	if len(idx.sequences) < idx.maxSequences {
+		return true
+	}
+	oldest := seq
+	for s := range idx.sequences {
+		if s < oldest {
+			oldest = s
+		}
+	}
+	if oldest == seq {
+		return false
+	}
+	delete(idx.sequences, oldest)
+	return true
+}
+
+// checkEquivocation indexes a PREPARE or COMMIT message that has already been authenticated
+// and, if it conflicts with a previous message from the same validator, stores and publishes
+// the evidence.
+func (c *core) checkEquivocation(msg *istanbul.Message) {
+	logger := c.newLogger("func", "checkEquivocation", "from", msg.Address)
+
+	subject, err := subjectFromPrepareOrCommit(msg)
+	if err != nil || subject.View == nil || subject.View.Sequence == nil || subject.View.Round == nil {
+		return
+	}
+	// Only messages for the current sequence are indexed, since the validator set used
+	// to authenticate them is the one attached to the evidence.
+	if subject.View.Sequence.Cmp(c.current.Sequence()) != 0 {
+		return
+	}
+
+	previous := c.equivocations.add(subject, msg)
+	if previous == nil {
+		return
+	}
+
+	validators := make([]istanbul.ValidatorData, 0, c.current.ValidatorSet().Size())
+	for _, val := range c.current.ValidatorSet().List() {
+		validators = append(validators, istanbul.ValidatorData{Address: val.Address(), BLSPublicKey: val.BLSPublicKey()})
+	}
+	evidence := &EquivocationEvidence{
+		View:       &istanbul.View{Sequence: new(big.Int).Set(subject.View.Sequence), Round: new(big.Int).Set(subject.View.Round)},
+		Validator:  msg.Address,
+		First:      previous,
+		Second:     msg.Copy(),
+		Validators: validators,
+	}
+
+	logger.Warn("Detected equivocation", "msg_seq", subject.View.Sequence, "msg_round", subject.View.Round, "first_code", previous.Code, "second_code", msg.Code)
+	c.equivocationsMeter.Mark(1)
+	if err := c.edb.StoreEvidence(evidence); err != nil {
+		logger.Error("Failed to store equivocation evidence", "err", err)
+	}
+	c.equivocationFeed.Send(EquivocationEvidenceEvent{Evidence: evidence})
+}
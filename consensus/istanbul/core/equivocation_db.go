@@ -0,0 +1,123 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	evidenceKey = "ev" // Database Key Prefix for EquivocationEvidence
+)
+
+// EquivocationDB persists the equivocation evidence collected by the core
+type EquivocationDB interface {
+	StoreEvidence(ev *EquivocationEvidence) error
+	// GetEvidence returns the evidence for sequences in the range [fromSeq, toSeq]
+	GetEvidence(fromSeq, toSeq uint64) ([]*EquivocationEvidence, error)
+	Close() error
+}
+
+type equivocationDBImpl struct {
+	db     *leveldb.DB
+	logger log.Logger
+}
+
+func newEquivocationDB(path string) (EquivocationDB, error) {
+	logger := log.New("func", "newEquivocationDB", "type", "equivocationDB", "edb_path", path)
+
+	logger.Info("Open equivocation evidence db")
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = newMemoryDB()
+	} else {
+		db, err = newPersistentDB(path)
+	}
+
+	if err != nil {
+		logger.Error("Failed to open equivocation evidence db", "err", err)
+		return nil, err
+	}
+
+	return &equivocationDBImpl{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+// StoreEvidence stores the evidence in a Map<(seq, round, validator), evidence> schema.
+func (edb *equivocationDBImpl) StoreEvidence(ev *EquivocationEvidence) error {
+	entryBytes, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		return err
+	}
+	return edb.db.Put(evidence2Key(ev), entryBytes, nil)
+}
+
+func (edb *equivocationDBImpl) GetEvidence(fromSeq, toSeq uint64) ([]*EquivocationEvidence, error) {
+	start := seq2EvidenceKey(fromSeq)
+	var limit []byte
+	if toSeq < math.MaxUint64 {
+		limit = seq2EvidenceKey(toSeq + 1)
+	} else {
+		limit = util.BytesPrefix([]byte(evidenceKey)).Limit
+	}
+
+	iter := edb.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer iter.Release()
+
+	evidence := []*EquivocationEvidence{}
+	for iter.Next() {
+		var entry EquivocationEvidence
+		if err := rlp.DecodeBytes(iter.Value(), &entry); err != nil {
+			return nil, err
+		}
+		evidence = append(evidence, &entry)
+	}
+	return evidence, iter.Error()
+}
+
+func (edb *equivocationDBImpl) Close() error {
+	return edb.db.Close()
+}
+
+// seq2EvidenceKey returns the key prefix for all the evidence of a sequence.
+// The key format is [ prefix . BigEndian(Sequence) . BigEndian(Round) . Validator ]
+// so that entries are sorted by view.
+func seq2EvidenceKey(seq uint64) []byte {
+	prefix := []byte(evidenceKey)
+	buff := make([]byte, len(prefix)+8)
+
+	copy(buff, prefix)
+	binary.BigEndian.PutUint64(buff[len(prefix):], seq)
+	return buff
+}
+
+func evidence2Key(ev *EquivocationEvidence) []byte {
+	buff := seq2EvidenceKey(ev.View.Sequence.Uint64())
+	round := make([]byte, 8)
+	binary.BigEndian.PutUint64(round, ev.View.Round.Uint64())
+	buff = append(buff, round...)
+	return append(buff, ev.Validator.Bytes()...)
+}
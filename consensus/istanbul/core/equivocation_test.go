@@ -0,0 +1,126 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+)
+
+func TestEquivocationDetection(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+
+	v0 := sys.backends[0]
+	v1 := sys.backends[1]
+	r0 := v0.engine.(*core)
+	view := newView(1, 0)
+	r0.current = newTestRoundState(view, v0.peers)
+
+	events := make(chan EquivocationEvidenceEvent, 1)
+	sub := r0.SubscribeEquivocationEvidence(events)
+	defer sub.Unsubscribe()
+
+	_, val := v0.peers.GetByAddress(v1.Address())
+	handle := func(msg istanbul.Message) {
+		// Messages for a digest other than the current proposal are rejected by the core,
+		// but they must still be indexed.
+		r0.handleCheckedMsg(&msg, val)
+	}
+
+	prepare, err := v1.getPrepareMessage(*view, common.HexToHash("0x01"))
+	finishOnError(t, err)
+	handle(prepare)
+	// Receiving the same message again is not an equivocation
+	handle(prepare)
+
+	commit, err := v1.getCommitMessage(*view, newTestProposal())
+	finishOnError(t, err)
+	handle(commit)
+
+	select {
+	case ev := <-events:
+		if ev.Evidence.Validator != v1.Address() {
+			t.Errorf("validator mismatch: have %v, want %v", ev.Evidence.Validator, v1.Address())
+		}
+		if ev.Evidence.View.Cmp(view) != 0 {
+			t.Errorf("view mismatch: have %v, want %v", ev.Evidence.View, view)
+		}
+		if ev.Evidence.First.Code != istanbul.MsgPrepare || ev.Evidence.Second.Code != istanbul.MsgCommit {
+			t.Errorf("message codes mismatch: have (%v, %v)", ev.Evidence.First.Code, ev.Evidence.Second.Code)
+		}
+		if len(ev.Evidence.Validators) != v0.peers.Size() {
+			t.Errorf("validator set size mismatch: have %v, want %v", len(ev.Evidence.Validators), v0.peers.Size())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected an equivocation event")
+	}
+
+	// Further conflicting messages for the same view are not reported again
+	other, err := v1.getPrepareMessage(*view, common.HexToHash("0x02"))
+	finishOnError(t, err)
+	handle(other)
+
+	evidence, err := r0.GetEquivocationEvidence(0, math.MaxUint64)
+	finishOnError(t, err)
+	if len(evidence) != 1 {
+		t.Fatalf("evidence count mismatch: have %v, want 1", len(evidence))
+	}
+	if _, err := evidence[0].Summary(); err != nil {
+		t.Errorf("error mismatch: have %v, want nil", err)
+	}
+
+	evidence, err = r0.GetEquivocationEvidence(2, 10)
+	finishOnError(t, err)
+	if len(evidence) != 0 {
+		t.Errorf("evidence count mismatch: have %v, want 0", len(evidence))
+	}
+}
+
+func TestEquivocationIndexEviction(t *testing.T) {
+	addr := common.HexToAddress("0x1234")
+	idx := newEquivocationIndex(2)
+	add := func(seq uint64, digest common.Hash) *istanbul.Message {
+		return idx.add(&istanbul.Subject{View: newView(seq, 0), Digest: digest}, &istanbul.Message{Code: istanbul.MsgPrepare, Address: addr})
+	}
+
+	add(1, common.HexToHash("0x01"))
+	add(2, common.HexToHash("0x01"))
+	add(3, common.HexToHash("0x01"))
+	if len(idx.sequences) != 2 {
+		t.Fatalf("indexed sequences mismatch: have %v, want 2", len(idx.sequences))
+	}
+	if _, ok := idx.sequences[1]; ok {
+		t.Errorf("oldest sequence should have been evicted")
+	}
+	// Sequences older than the ones indexed are ignored
+	if add(1, common.HexToHash("0x02")) != nil {
+		t.Errorf("evicted sequence should not report an equivocation")
+	}
+	if add(3, common.HexToHash("0x02")) == nil {
+		t.Errorf("expected an equivocation for sequence 3")
+	}
+	if add(3, common.HexToHash("0x03")) != nil {
+		t.Errorf("equivocation should be reported only once")
+	}
+	if idx.sequences[2][equivocationKey{round: 0, validator: addr}].digest != common.HexToHash("0x01") {
+		t.Errorf("sequence 2 entry should be untouched")
+	}
+}
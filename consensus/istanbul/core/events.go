@@ -30,3 +30,9 @@ type resendRoundChangeEvent struct {
 type timeoutAndMoveToNextRoundEvent struct {
 	view *istanbul.View
 }
+
+// EquivocationEvidenceEvent is sent to subscribers when a validator is detected
+// signing conflicting messages for the same view
+type EquivocationEvidenceEvent struct {
+	Evidence *EquivocationEvidence
+}
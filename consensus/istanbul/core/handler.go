@@ -34,6 +34,15 @@ func (c *core) Start() error {
 
 	c.current = roundState
 	c.roundChangeSet = newRoundChangeSet(c.current.ValidatorSet())
+	// Every run is journaled to a new file, starting with a checkpoint
+	if c.config.JournalPath != "" {
+		if c.journal, err = newJournal(c.config.JournalPath, c.config.JournalMaxFileSize, c.config.JournalMaxFiles); err != nil {
+			return err
+		}
+	}
+	if c.journal != nil {
+		c.writeJournalCheckpoint()
+	}
 
 	// Reset the Round Change timer for the current round to timeout.
 	// (If we've restored RoundState such that we are in StateWaitingForRoundChange,
@@ -60,10 +69,26 @@ func (c *core) Stop() error {
 	// Make sure the handler goroutine exits
 	c.handlerWg.Wait()
 
+	if c.journal != nil {
+		if err := c.journal.Close(); err != nil {
+			c.logger.Error("Failed to close istanbul journal", "err", err)
+		}
+		c.journal = nil
+	}
+
 	c.current = nil
 	return nil
 }
 
+// Close implements core.Engine.Close
+func (c *core) Close() error {
+	edbErr := c.edb.Close()
+	if err := c.spdb.Close(); err != nil {
+		return err
+	}
+	return edbErr
+}
+
 // ----------------------------------------------------------------------------
 
 // Subscribe both internal and external events
@@ -112,19 +137,24 @@ func (c *core) handleEvents() {
 				r := &istanbul.Request{
 					Proposal: ev.Proposal,
 				}
-				err := c.handleRequest(r)
-				if err == errFutureMessage {
-					c.storeRequestMsg(r)
-				}
+				c.journalEvent(c.journalRequest(r), func() error {
+					err := c.handleRequest(r)
+					if err == errFutureMessage {
+						c.storeRequestMsg(r)
+					}
+					return err
+				})
 			case istanbul.MessageEvent:
-				if err := c.handleMsg(ev.Payload); err != nil && err != errFutureMessage && err != errOldMessage {
+				err := c.journalEvent(journalMessage(JournalMessage, ev.Payload), func() error { return c.handleMsg(ev.Payload) })
+				if err != nil && err != errFutureMessage && err != errOldMessage {
 					logger.Warn("Error in handling istanbul message", "err", err)
 				}
 			case backlogEvent:
 				if payload, err := ev.msg.Payload(); err != nil {
 					logger.Error("Error in retrieving payload from istanbul message that was sent from a backlog event", "err", err)
 				} else {
-					if err := c.handleMsg(payload); err != nil && err != errFutureMessage && err != errOldMessage {
+					err := c.journalEvent(journalMessage(JournalBacklogMessage, payload), func() error { return c.handleMsg(payload) })
+					if err != nil && err != errFutureMessage && err != errOldMessage {
 						logger.Warn("Error in handling istanbul message that was sent from a backlog event", "err", err)
 					}
 				}
@@ -135,11 +165,13 @@ func (c *core) handleEvents() {
 			}
 			switch ev := event.Data.(type) {
 			case timeoutAndMoveToNextRoundEvent:
-				if err := c.handleTimeoutAndMoveToNextRound(ev.view); err != nil {
+				entry := &JournalEntry{Kind: JournalTimeout, View: ev.view}
+				if err := c.journalEvent(entry, func() error { return c.handleTimeoutAndMoveToNextRound(ev.view) }); err != nil {
 					logger.Error("Error on handleTimeoutAndMoveToNextRound", "err", err)
 				}
 			case resendRoundChangeEvent:
-				if err := c.handleResendRoundChangeEvent(ev.view); err != nil {
+				entry := &JournalEntry{Kind: JournalResendRoundChange, View: ev.view}
+				if err := c.journalEvent(entry, func() error { return c.handleResendRoundChangeEvent(ev.view) }); err != nil {
 					logger.Error("Error on handleResendRoundChangeEvent", "err", err)
 				}
 			}
@@ -149,7 +181,7 @@ func (c *core) handleEvents() {
 			}
 			switch event.Data.(type) {
 			case istanbul.FinalCommittedEvent:
-				if err := c.handleFinalCommitted(); err != nil {
+				if err := c.journalEvent(c.journalFinalCommitted(), c.handleFinalCommitted); err != nil {
 					logger.Error("Error on handleFinalCommit", "err", err)
 				}
 			}
@@ -197,6 +229,10 @@ func (c *core) handleCheckedMsg(msg *istanbul.Message, src istanbul.Validator) e
 		return err
 	}
 
+	if msg.Code == istanbul.MsgPrepare || msg.Code == istanbul.MsgCommit {
+		c.checkEquivocation(msg)
+	}
+
 	switch msg.Code {
 	case istanbul.MsgPreprepare:
 		return catchFutureMessages(c.handlePreprepare(msg))
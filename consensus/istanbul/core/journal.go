@@ -0,0 +1,460 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/rlp"
+)
+
+// JournalEntryKind identifies the event recorded by a JournalEntry
+type JournalEntryKind uint64
+
+const (
+	// JournalCheckpoint records the chain and round state the entries that follow are applied on
+	JournalCheckpoint JournalEntryKind = iota
+	// JournalMessage records an istanbul message received from the network (or from self)
+	JournalMessage
+	// JournalBacklogMessage records a message that was replayed from the backlog
+	JournalBacklogMessage
+	// JournalRequest records a proposal handed to the core by the miner
+	JournalRequest
+	// JournalTimeout records a round change timeout
+	JournalTimeout
+	// JournalResendRoundChange records a timeout to resend the round change message
+	JournalResendRoundChange
+	// JournalFinalCommitted records a new block being inserted in the chain
+	JournalFinalCommitted
+	// JournalVerify records the result of verifying a proposal
+	JournalVerify
+	// JournalSent records a message sent by the core
+	JournalSent
+)
+
+func (k JournalEntryKind) String() string {
+	switch k {
+	case JournalCheckpoint:
+		return "Checkpoint"
+	case JournalMessage:
+		return "Message"
+	case JournalBacklogMessage:
+		return "BacklogMessage"
+	case JournalRequest:
+		return "Request"
+	case JournalTimeout:
+		return "Timeout"
+	case JournalResendRoundChange:
+		return "ResendRoundChange"
+	case JournalFinalCommitted:
+		return "FinalCommitted"
+	case JournalVerify:
+		return "Verify"
+	case JournalSent:
+		return "Sent"
+	default:
+		return "Unknown"
+	}
+}
+
+// JournalState is the part of the round state that identifies a state transition
+type JournalState struct {
+	Sequence     *big.Int
+	Round        *big.Int
+	DesiredRound *big.Int
+	State        State
+}
+
+func (s JournalState) String() string {
+	return fmt.Sprintf("{Seq: %v, Round: %v, DesiredRound: %v, State: %v}", s.Sequence, s.Round, s.DesiredRound, s.State)
+}
+
+// Equal reports whether both states represent the same view and state.
+func (s JournalState) Equal(other JournalState) bool {
+	return bigEqual(s.Sequence, other.Sequence) && bigEqual(s.Round, other.Round) &&
+		bigEqual(s.DesiredRound, other.DesiredRound) && s.State == other.State
+}
+
+func bigEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// JournalChain is the information about the chain the core obtains from its backend, which
+// is required to replay a journal without access to the chain.
+type JournalChain struct {
+	Address          common.Address
+	Head             *types.Header
+	HeadAuthor       common.Address
+	Validators       istanbul.ValidatorSetData // validators for the block after the head
+	ParentValidators istanbul.ValidatorSetData // validators that signed the head
+	RoundState       []byte                    // RLP encoded round state (checkpoint only)
+	ChainConfig      []byte                    // JSON encoded chain config (checkpoint only)
+}
+
+// JournalEntry is a record of an event handled (or a message sent) by the core, together with
+// the state transition it caused.
+type JournalEntry struct {
+	Kind       JournalEntryKind
+	Time       uint64           // Unix time in nanoseconds
+	Signer     common.Address   // Signer of a received message
+	Recipients []common.Address // Recipients of a sent message
+	Payload    []byte           // Signed message payload, RLP encoded proposal or verified proposal hash
+	View       *istanbul.View   `rlp:"nil"` // View of a timeout
+	Delay      uint64           // Delay in nanoseconds returned when verifying a future proposal
+	Err        string
+	Before     JournalState
+	After      JournalState
+	Chain      *JournalChain `rlp:"nil"`
+}
+
+// journal is an append only log of JournalEntries, rotated once files reach a maximum size.
+type journal struct {
+	dir      string
+	maxSize  uint64
+	maxFiles uint64
+
+	file   *os.File
+	index  uint64
+	size   uint64
+	logger log.Logger
+}
+
+const journalFilePattern = "journal-%08d.rlp"
+
+func newJournal(dir string, maxSize uint64, maxFiles uint64) (*journal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	files, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		logger:   log.New("type", "istanbulJournal", "dir", dir),
+	}
+	// Every run starts a new file, so that each file begins with a checkpoint
+	if len(files) > 0 {
+		fmt.Sscanf(filepath.Base(files[len(files)-1]), journalFilePattern, &j.index)
+		j.index++
+	}
+	return j, j.open()
+}
+
+// journalFiles returns the journal files within dir, sorted from oldest to newest.
+func journalFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "journal-*.rlp"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (j *journal) open() error {
+	file, err := os.OpenFile(filepath.Join(j.dir, fmt.Sprintf(journalFilePattern, j.index)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	j.file, j.size = file, 0
+
+	// Remove the files that exceed the retention limit
+	files, err := journalFiles(j.dir)
+	if err != nil {
+		return err
+	}
+	for j.maxFiles > 0 && uint64(len(files)) > j.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			j.logger.Warn("Failed to remove old journal file", "file", files[0], "err", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// full returns whether the current file reached its maximum size.
+func (j *journal) full() bool { return j.maxSize > 0 && j.size >= j.maxSize }
+
+// rotate closes the current file and starts a new one.
+func (j *journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.index++
+	return j.open()
+}
+
+func (j *journal) write(entry *JournalEntry) error {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	n, err := j.file.Write(data)
+	j.size += uint64(n)
+	return err
+}
+
+func (j *journal) Close() error {
+	return j.file.Close()
+}
+
+// ReadJournal reads the entries of a journal file, or of every journal file within a directory
+// (from oldest to newest).
+func ReadJournal(path string) ([]*JournalEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = journalFiles(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []*JournalEntry
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		stream := rlp.NewStream(bytes.NewReader(data), uint64(len(data)))
+		for {
+			entry := new(JournalEntry)
+			if err := stream.Decode(entry); err == io.EOF {
+				break
+			} else if err != nil {
+				// The node may have been stopped while writing the last entry
+				log.Warn("Discarding truncated journal entries", "file", file, "err", err)
+				break
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// journalState returns the current view and state of the core
+func (c *core) journalState() JournalState {
+	if c.current == nil {
+		return JournalState{}
+	}
+	return JournalState{
+		Sequence:     new(big.Int).Set(c.current.Sequence()),
+		Round:        new(big.Int).Set(c.current.Round()),
+		DesiredRound: new(big.Int).Set(c.current.DesiredRound()),
+		State:        c.current.State(),
+	}
+}
+
+// journalChain returns the chain information the core obtains from the backend. The round state
+// and chain config are only included for checkpoints.
+func (c *core) journalChain(checkpoint bool) (*JournalChain, error) {
+	head, headAuthor := c.backend.GetCurrentHeadBlockAndAuthor()
+	if head == nil {
+		return nil, errInvalidProposal
+	}
+	chain := &JournalChain{
+		Address:          c.address,
+		Head:             head.Header(),
+		HeadAuthor:       headAuthor,
+		Validators:       validatorSetData(c.backend.Validators(head)),
+		ParentValidators: validatorSetData(c.backend.ParentBlockValidators(head)),
+	}
+	if !checkpoint {
+		return chain, nil
+	}
+	if c.current != nil {
+		var current RoundState = c.current
+		if decorator, ok := current.(*rsSaveDecorator); ok {
+			current = decorator.rs
+		}
+		roundState, err := rlp.EncodeToBytes(current)
+		if err != nil {
+			return nil, err
+		}
+		chain.RoundState = roundState
+	}
+	chainConfig, err := json.Marshal(c.backend.ChainConfig())
+	if err != nil {
+		return nil, err
+	}
+	chain.ChainConfig = chainConfig
+	return chain, nil
+}
+
+func validatorSetData(valSet istanbul.ValidatorSet) istanbul.ValidatorSetData {
+	return istanbul.ValidatorSetData{
+		Validators: validator.MapValidatorsToData(valSet.List()),
+		Randomness: valSet.GetRandomness(),
+	}
+}
+
+// writeJournalCheckpoint records the chain and round state, so that the entries that follow
+// can be replayed.
+func (c *core) writeJournalCheckpoint() {
+	chain, err := c.journalChain(true)
+	if err != nil {
+		c.logger.Error("Failed to create journal checkpoint", "err", err)
+		return
+	}
+	state := c.journalState()
+	c.writeJournalEntry(&JournalEntry{
+		Kind:   JournalCheckpoint,
+		Time:   uint64(time.Now().UnixNano()),
+		Before: state,
+		After:  state,
+		Chain:  chain,
+	})
+}
+
+func (c *core) writeJournalEntry(entry *JournalEntry) {
+	if entry.Kind != JournalCheckpoint && c.journal.full() {
+		if err := c.journal.rotate(); err != nil {
+			c.logger.Error("Failed to rotate istanbul journal", "err", err)
+			return
+		}
+		c.writeJournalCheckpoint()
+	}
+	if err := c.journal.write(entry); err != nil {
+		c.logger.Error("Failed to write istanbul journal entry", "kind", entry.Kind, "err", err)
+	}
+}
+
+// journalEvent runs the handler for an event and, if the journal is enabled, records the event
+// alongside the state transition it caused.
+func (c *core) journalEvent(entry *JournalEntry, handle func() error) error {
+	if c.journal == nil {
+		return handle()
+	}
+	entry.Time = uint64(time.Now().UnixNano())
+	entry.Before = c.journalState()
+	c.journalSentEntries = []*JournalEntry{}
+	err := handle()
+	entry.After = c.journalState()
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	c.writeJournalEntry(entry)
+
+	// Messages sent while handling the event are recorded after it, as they are its output
+	for _, sent := range c.journalSentEntries {
+		sent.Before, sent.After = entry.After, entry.After
+		c.writeJournalEntry(sent)
+	}
+	c.journalSentEntries = nil
+	return err
+}
+
+// journalMessage returns a journal entry for a received message payload
+func journalMessage(kind JournalEntryKind, payload []byte) *JournalEntry {
+	entry := &JournalEntry{Kind: kind, Payload: payload}
+	var msg istanbul.Message
+	if err := msg.FromPayload(payload, nil); err == nil {
+		entry.Signer = msg.Address
+	}
+	return entry
+}
+
+// journalRequest returns a journal entry for a request
+func (c *core) journalRequest(request *istanbul.Request) *JournalEntry {
+	entry := &JournalEntry{Kind: JournalRequest}
+	if c.journal == nil {
+		return entry
+	}
+	payload, err := rlp.EncodeToBytes(request.Proposal)
+	if err != nil {
+		c.logger.Error("Failed to encode request for the istanbul journal", "err", err)
+	}
+	entry.Payload = payload
+	return entry
+}
+
+// journalFinalCommitted returns a journal entry for a new block being inserted in the chain,
+// which includes the new chain head.
+func (c *core) journalFinalCommitted() *JournalEntry {
+	entry := &JournalEntry{Kind: JournalFinalCommitted}
+	if c.journal == nil {
+		return entry
+	}
+	chain, err := c.journalChain(false)
+	if err != nil {
+		c.logger.Error("Failed to obtain the chain head for the istanbul journal", "err", err)
+	}
+	entry.Chain = chain
+	return entry
+}
+
+// journalSent records a message sent by the core
+func (c *core) journalSent(payload []byte, addresses []common.Address) {
+	if c.journal == nil {
+		return
+	}
+	state := c.journalState()
+	entry := &JournalEntry{
+		Kind:       JournalSent,
+		Time:       uint64(time.Now().UnixNano()),
+		Recipients: addresses,
+		Payload:    payload,
+		Before:     state,
+		After:      state,
+	}
+	if c.journalSentEntries != nil {
+		c.journalSentEntries = append(c.journalSentEntries, entry)
+		return
+	}
+	c.writeJournalEntry(entry)
+}
+
+// journalVerify records the result of verifying a proposal with the backend
+func (c *core) journalVerify(hash common.Hash, delay time.Duration, err error) {
+	if c.journal == nil {
+		return
+	}
+	state := c.journalState()
+	entry := &JournalEntry{
+		Kind:    JournalVerify,
+		Time:    uint64(time.Now().UnixNano()),
+		Payload: hash.Bytes(),
+		Delay:   uint64(delay),
+		Before:  state,
+		After:   state,
+	}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	c.writeJournalEntry(entry)
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/rlp"
+)
+
+// makeBlockWithExtra returns a block whose header carries an (empty) istanbul extra, which the
+// replay backend needs to obtain the validators of the next block.
+func makeBlockWithExtra(t *testing.T, number int64) *types.Block {
+	extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{
+		RemovedValidators:    big.NewInt(0),
+		Seal:                 []byte{},
+		AggregatedSeal:       types.IstanbulAggregatedSeal{Bitmap: big.NewInt(0), Signature: []byte{}, Round: big.NewInt(0)},
+		ParentAggregatedSeal: types.IstanbulAggregatedSeal{Bitmap: big.NewInt(0), Signature: []byte{}, Round: big.NewInt(0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{
+		Number: big.NewInt(number),
+		Extra:  append(make([]byte, types.IstanbulExtraVanity), extra...),
+	}
+	return types.NewBlock(header, nil, nil, nil)
+}
+
+func TestJournalRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "istanbul-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sys := NewMutedTestSystemWithBackend(4, 1)
+	recorder := sys.backends[1].engine.(*core)
+	config := *recorder.config
+	config.JournalPath = dir
+	recorder.config = &config
+
+	close := sys.Run(true)
+	sys.backends[0].NewRequest(makeBlockWithExtra(t, 1))
+	<-time.After(1 * time.Second)
+	sys.backends[0].NewRequest(makeBlockWithExtra(t, 2))
+	<-time.After(1 * time.Second)
+	close()
+	if recorder.journal != nil {
+		t.Errorf("journal should be closed when the core stops")
+	}
+
+	if committed := len(sys.backends[1].committedMsgs); committed != 2 {
+		t.Fatalf("committed blocks mismatch: have %v, want 2", committed)
+	}
+
+	entries, err := ReadJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || entries[0].Kind != JournalCheckpoint {
+		t.Fatalf("journal should start with a checkpoint")
+	}
+
+	replayer, err := NewReplayer(entries[0], recorder.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayer.Close()
+
+	var committed []istanbul.Proposal
+	kinds := make(map[JournalEntryKind]int)
+	for _, entry := range entries[1:] {
+		kinds[entry.Kind]++
+		step, err := replayer.Apply(entry)
+		if err != nil {
+			t.Fatalf("failed to apply %v entry: %v", entry.Kind, err)
+		}
+		if step.Diverged() {
+			t.Fatalf("replay diverged on %v entry: have %v, want %v (err %v)", entry.Kind, step.State, entry.After, step.Err)
+		}
+		if step.Committed != nil {
+			committed = append(committed, step.Committed)
+		}
+	}
+
+	for _, kind := range []JournalEntryKind{JournalMessage, JournalSent, JournalVerify, JournalFinalCommitted} {
+		if kinds[kind] == 0 {
+			t.Errorf("expected %v entries in the journal", kind)
+		}
+	}
+	if len(committed) != 2 {
+		t.Fatalf("replayed commits mismatch: have %v, want 2", len(committed))
+	}
+	for i, proposal := range committed {
+		if proposal.Number().Cmp(big.NewInt(int64(i+1))) != 0 {
+			t.Errorf("replayed commit number mismatch: have %v, want %v", proposal.Number(), i+1)
+		}
+	}
+}
+
+func TestJournalRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "istanbul-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := newJournal(dir, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if j.full() {
+			if err := j.rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := j.write(&JournalEntry{Kind: JournalTimeout, Time: uint64(i), View: newView(1, uint64(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	files, err := journalFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("journal files mismatch: have %v, want 2", len(files))
+	}
+	entries, err := ReadJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Time != 2 || entries[1].Time != 3 {
+		t.Fatalf("expected the last two entries to be kept, have %v entries", len(entries))
+	}
+	if entries[1].View.Round.Uint64() != 3 {
+		t.Errorf("view mismatch: have %v, want round 3", entries[1].View)
+	}
+
+	// A new journal in the same directory starts a new file
+	j, err = newJournal(dir, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	if files, _ := journalFiles(dir); files[len(files)-1] == files[0] || len(files) != 2 {
+		t.Errorf("expected a new journal file to be created")
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/core/types"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/event"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+)
+
+// errNotACheckpoint is returned when a replay is started from an entry that is not a checkpoint
+var errNotACheckpoint = errors.New("journal entry is not a checkpoint")
+
+// ReplayStep is the result of replaying a journal entry
+type ReplayStep struct {
+	Entry *JournalEntry
+	// State is the state of the replayed core after applying the entry
+	State JournalState
+	// Err is the error returned by the replayed core when handling the entry
+	Err error
+	// Sent are the messages the replayed core attempted to send while handling the entry
+	Sent []*istanbul.Message
+	// Committed is the proposal the replayed core committed while handling the entry, if any
+	Committed istanbul.Proposal
+}
+
+// Diverged reports whether the replayed core ended up in a different state than the recorded one.
+func (s *ReplayStep) Diverged() bool {
+	return !s.State.Equal(s.Entry.After)
+}
+
+// Replayer feeds the entries of a journal into a core backed by a replayBackend, so that the state
+// transitions of a node can be reproduced offline.
+type Replayer struct {
+	core    *core
+	backend *replayBackend
+}
+
+// NewReplayer creates a Replayer that starts from the given checkpoint. Only the database paths
+// of the config are ignored, since the replayed core always uses in-memory databases.
+func NewReplayer(checkpoint *JournalEntry, config *istanbul.Config) (*Replayer, error) {
+	if checkpoint.Kind != JournalCheckpoint || checkpoint.Chain == nil {
+		return nil, errNotACheckpoint
+	}
+	backend, err := newReplayBackend(checkpoint.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	replayConfig := *config
+	replayConfig.RoundStateDBPath = ""
+	replayConfig.EquivocationDBPath = ""
+	replayConfig.SlashingProtectionDBPath = ""
+	replayConfig.JournalPath = ""
+	c := New(backend, &replayConfig).(*core)
+
+	// Restore the round state the node had at the checkpoint
+	if len(checkpoint.Chain.RoundState) > 0 {
+		var roundState roundStateImpl
+		if err := rlp.DecodeBytes(checkpoint.Chain.RoundState, &roundState); err != nil {
+			return nil, err
+		}
+		if err := c.rsdb.UpdateLastRoundState(&roundState); err != nil {
+			return nil, err
+		}
+	}
+	if c.current, err = c.createRoundState(); err != nil {
+		return nil, err
+	}
+	c.roundChangeSet = newRoundChangeSet(c.current.ValidatorSet())
+	c.backlog.updateState(c.CurrentView(), c.current.State())
+
+	return &Replayer{core: c, backend: backend}, nil
+}
+
+// ReplayConfig returns the istanbul config to replay a journal recorded with the given checkpoint.
+func ReplayConfig(checkpoint *JournalEntry) (*istanbul.Config, error) {
+	if checkpoint.Kind != JournalCheckpoint || checkpoint.Chain == nil {
+		return nil, errNotACheckpoint
+	}
+	var chainConfig params.ChainConfig
+	if err := json.Unmarshal(checkpoint.Chain.ChainConfig, &chainConfig); err != nil {
+		return nil, err
+	}
+	config := *istanbul.DefaultConfig
+	if chainConfig.Istanbul != nil {
+		if err := istanbul.ApplyParamsChainConfigToConfig(&chainConfig, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &config, nil
+}
+
+// State returns the current state of the replayed core
+func (r *Replayer) State() JournalState {
+	return r.core.journalState()
+}
+
+// Apply feeds an entry into the replayed core. Entries must be applied in the order they were
+// recorded. Sent messages are not applied, since they are the output of the recorded core.
+func (r *Replayer) Apply(entry *JournalEntry) (*ReplayStep, error) {
+	r.backend.sent = nil
+	r.backend.committed = nil
+
+	var err error
+	switch entry.Kind {
+	case JournalCheckpoint:
+		// Checkpoints within a run only refresh the chain, since the round state is kept in memory
+		if entry.Chain != nil {
+			err = r.backend.setChain(entry.Chain)
+		}
+	case JournalMessage, JournalBacklogMessage:
+		err = r.core.handleMsg(entry.Payload)
+	case JournalRequest:
+		var block types.Block
+		if err := rlp.DecodeBytes(entry.Payload, &block); err != nil {
+			return nil, err
+		}
+		request := &istanbul.Request{Proposal: &block}
+		if err = r.core.handleRequest(request); err == errFutureMessage {
+			r.core.storeRequestMsg(request)
+		}
+	case JournalTimeout:
+		err = r.core.handleTimeoutAndMoveToNextRound(entry.View)
+	case JournalResendRoundChange:
+		err = r.core.handleResendRoundChangeEvent(entry.View)
+	case JournalFinalCommitted:
+		if entry.Chain != nil {
+			if err := r.backend.setChain(entry.Chain); err != nil {
+				return nil, err
+			}
+		}
+		err = r.core.handleFinalCommitted()
+	case JournalVerify:
+		var verifyErr error
+		if entry.Err != "" {
+			verifyErr = errors.New(entry.Err)
+			if entry.Err == consensus.ErrFutureBlock.Error() {
+				verifyErr = consensus.ErrFutureBlock
+			}
+		}
+		r.backend.verified[common.BytesToHash(entry.Payload)] = verifyResult{delay: time.Duration(entry.Delay), err: verifyErr}
+	case JournalSent:
+	default:
+		return nil, fmt.Errorf("unknown journal entry kind %d", entry.Kind)
+	}
+
+	step := &ReplayStep{
+		Entry: entry,
+		State: r.core.journalState(),
+		Err:   err,
+		Sent:  r.backend.sent,
+	}
+	if len(r.backend.committed) > 0 {
+		step.Committed = r.backend.committed[len(r.backend.committed)-1]
+	}
+	return step, nil
+}
+
+// Close releases the resources of the replayed core
+func (r *Replayer) Close() {
+	r.core.stopAllTimers()
+	r.core.rsdb.Close()
+	r.core.edb.Close()
+	r.core.spdb.Close()
+}
+
+type verifyResult struct {
+	delay time.Duration
+	err   error
+}
+
+// replayBackend is a CoreBackend that answers the core with the chain information recorded in a
+// journal, and captures the messages and proposals sent by the core instead of acting on them.
+type replayBackend struct {
+	address     common.Address
+	chainConfig *params.ChainConfig
+	mux         *event.TypeMux
+
+	head             *types.Block
+	headAuthor       common.Address
+	validators       istanbul.ValidatorSet
+	parentValidators istanbul.ValidatorSet
+
+	verified  map[common.Hash]verifyResult
+	sent      []*istanbul.Message
+	committed []istanbul.Proposal
+}
+
+func newReplayBackend(chain *JournalChain) (*replayBackend, error) {
+	chainConfig := new(params.ChainConfig)
+	if err := json.Unmarshal(chain.ChainConfig, chainConfig); err != nil {
+		return nil, err
+	}
+	b := &replayBackend{
+		address:     chain.Address,
+		chainConfig: chainConfig,
+		mux:         new(event.TypeMux),
+		verified:    make(map[common.Hash]verifyResult),
+	}
+	return b, b.setChain(chain)
+}
+
+func newValidatorSetFromData(data istanbul.ValidatorSetData) istanbul.ValidatorSet {
+	valSet := validator.NewSet(data.Validators)
+	valSet.SetRandomness(data.Randomness)
+	return valSet
+}
+
+func (b *replayBackend) setChain(chain *JournalChain) error {
+	if chain.Head == nil {
+		return errInvalidProposal
+	}
+	b.head = types.NewBlockWithHeader(chain.Head)
+	b.headAuthor = chain.HeadAuthor
+	b.validators = newValidatorSetFromData(chain.Validators)
+	b.parentValidators = newValidatorSetFromData(chain.ParentValidators)
+	return nil
+}
+
+func (b *replayBackend) Address() common.Address          { return b.address }
+func (b *replayBackend) ChainConfig() *params.ChainConfig { return b.chainConfig }
+func (b *replayBackend) EventMux() *event.TypeMux         { return b.mux }
+
+// Validators returns the validators for the block after the given proposal
+func (b *replayBackend) Validators(proposal istanbul.Proposal) istanbul.ValidatorSet {
+	if proposal.Number().Cmp(b.head.Number()) < 0 {
+		return b.parentValidators
+	}
+	return b.validators
+}
+
+// ParentBlockValidators returns the validators that sign the given proposal
+func (b *replayBackend) ParentBlockValidators(proposal istanbul.Proposal) istanbul.ValidatorSet {
+	if proposal.Number().Cmp(b.head.Number()) <= 0 {
+		return b.parentValidators
+	}
+	return b.validators
+}
+
+func (b *replayBackend) NextBlockValidators(proposal istanbul.Proposal) (istanbul.ValidatorSet, error) {
+	istExtra, err := types.ExtractIstanbulExtra(proposal.Header())
+	if err != nil {
+		return nil, err
+	}
+	valSet := b.ParentBlockValidators(proposal).Copy()
+	if len(istExtra.AddedValidators) == 0 && istExtra.RemovedValidators.BitLen() == 0 {
+		return valSet, nil
+	}
+	addedValidators, err := istanbul.CombineIstanbulExtraToValidatorData(istExtra.AddedValidators, istExtra.AddedValidatorsPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	if !valSet.RemoveValidators(istExtra.RemovedValidators) || !valSet.AddValidators(addedValidators) {
+		return nil, fmt.Errorf("could not obtain next block validators")
+	}
+	return valSet, nil
+}
+
+func (b *replayBackend) Gossip(payload []byte, ethMsgCode uint64) error { return nil }
+
+func (b *replayBackend) Multicast(addresses []common.Address, payload []byte, ethMsgCode uint64, sendToSelf bool) error {
+	msg := new(istanbul.Message)
+	if err := msg.FromPayload(payload, nil); err != nil {
+		return err
+	}
+	b.sent = append(b.sent, msg)
+	return nil
+}
+
+func (b *replayBackend) Commit(proposal istanbul.Proposal, aggregatedSeal types.IstanbulAggregatedSeal, aggregatedEpochValidatorSetSeal types.IstanbulEpochValidatorSetSeal) error {
+	b.committed = append(b.committed, proposal)
+	return nil
+}
+
+// Verify returns the recorded verification result, and accepts proposals that the recorded
+// core did not need to verify.
+func (b *replayBackend) Verify(proposal istanbul.Proposal) (time.Duration, error) {
+	if result, ok := b.verified[proposal.Hash()]; ok {
+		return result.delay, result.err
+	}
+	return 0, nil
+}
+
+// Sign returns an empty signature, since the journal does not include the validator's keys.
+// The messages signed by the recorded core are replayed from the journal instead.
+func (b *replayBackend) Sign(data []byte) ([]byte, error) {
+	return make([]byte, 65), nil
+}
+
+func (b *replayBackend) SignBLS(data []byte, extra []byte, useComposite, cip22 bool) (blscrypto.SerializedSignature, error) {
+	return blscrypto.SerializedSignature{}, nil
+}
+
+func (b *replayBackend) CheckSignature(data []byte, addr common.Address, sig []byte) error {
+	return nil
+}
+
+func (b *replayBackend) GetCurrentHeadBlock() istanbul.Proposal { return b.head }
+
+func (b *replayBackend) GetCurrentHeadBlockAndAuthor() (istanbul.Proposal, common.Address) {
+	return b.head, b.headAuthor
+}
+
+func (b *replayBackend) LastSubject() (istanbul.Subject, error) {
+	istExtra, err := types.ExtractIstanbulExtra(b.head.Header())
+	if err != nil {
+		return istanbul.Subject{}, err
+	}
+	lastView := &istanbul.View{Sequence: b.head.Number(), Round: istExtra.AggregatedSeal.Round}
+	return istanbul.Subject{View: lastView, Digest: b.head.Hash()}, nil
+}
+
+func (b *replayBackend) HasBlock(hash common.Hash, number *big.Int) bool {
+	return b.head.Hash() == hash && b.head.Number().Cmp(number) == 0
+}
+
+func (b *replayBackend) AuthorForBlock(number uint64) common.Address {
+	if b.head.NumberU64() == number {
+		return b.headAuthor
+	}
+	return common.ZeroAddress
+}
+
+func (b *replayBackend) HashForBlock(number uint64) common.Hash {
+	if b.head.NumberU64() == number {
+		return b.head.Hash()
+	}
+	return common.Hash{}
+}
+
+func (b *replayBackend) IsPrimaryForSeq(seq *big.Int) bool          { return true }
+func (b *replayBackend) UpdateReplicaState(seq *big.Int)            {}
+func (b *replayBackend) CheckSigningView(view *istanbul.View) error { return nil }
@@ -0,0 +1,359 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	lvlerrors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// SlashingProtectionInterchangeVersion is the version of the slashing protection interchange format
+	SlashingProtectionInterchangeVersion = "1"
+
+	slashingProtectionDBVersion = 1
+	spVersionKey                = "version"      // Version of the database, which is never flushed
+	spValidatorKey              = "validator"    // Address of the validator whose messages are recorded
+	spLowWatermarkKey           = "lowWatermark" // Sequence below which nothing can be signed
+	signedMsgKey                = "sm"           // Database Key Prefix for SignedMessage
+
+	// Number of sequences for which the signed messages are kept.  Older messages are pruned, and
+	// replaced by the low watermark.
+	slashingProtectionSequencesToKeep = 1000
+)
+
+var (
+	// errDoubleSign is returned when signing a message that conflicts with a message previously signed
+	errDoubleSign = errors.New("conflicts with a previously signed message")
+	// errBelowLowWatermark is returned when signing a message for a sequence that was pruned or imported
+	errBelowLowWatermark = errors.New("sequence is below the slashing protection low watermark")
+	// errValidatorMismatch is returned when the slashing protection data belongs to another validator
+	errValidatorMismatch = errors.New("slashing protection data belongs to another validator")
+
+	// conflictingDigest is recorded when importing a message that conflicts with a recorded one, so
+	// that neither can be signed anymore
+	conflictingDigest = common.BytesToHash(bytes.Repeat([]byte{0xff}, common.HashLength))
+)
+
+// SignedMessage identifies a consensus message signed by a validator.  ROUND CHANGE messages are
+// recorded with an empty digest, since they never conflict.
+type SignedMessage struct {
+	Sequence uint64      `json:"sequence"`
+	Round    uint64      `json:"round"`
+	Code     uint64      `json:"code"`
+	Digest   common.Hash `json:"digest"`
+}
+
+// SlashingProtectionMetadata describes the content of a slashing protection interchange
+type SlashingProtectionMetadata struct {
+	Version      string         `json:"interchangeFormatVersion"`
+	Validator    common.Address `json:"validator"`
+	LowWatermark uint64         `json:"lowWatermark"` // Sequence below which the validator must not sign
+}
+
+// SlashingProtectionInterchange is the format used to move the slashing protection data of a
+// validator between hosts
+type SlashingProtectionInterchange struct {
+	Metadata       SlashingProtectionMetadata `json:"metadata"`
+	SignedMessages []*SignedMessage           `json:"signedMessages"`
+}
+
+// SlashingProtectionDB records the consensus messages signed by the validator, and refuses to sign
+// messages that conflict with them
+type SlashingProtectionDB interface {
+	// CheckAndRecord returns an error if the message conflicts with a message previously signed by
+	// the validator, and records it otherwise
+	CheckAndRecord(validator common.Address, msg *SignedMessage) error
+	// Export returns the interchange of all the recorded messages
+	Export() (*SlashingProtectionInterchange, error)
+	// Import merges an interchange into the recorded messages
+	Import(interchange *SlashingProtectionInterchange) error
+	Close() error
+}
+
+type slashingProtectionDBImpl struct {
+	db     *leveldb.DB
+	mu     sync.Mutex
+	logger log.Logger
+}
+
+// OpenSlashingProtectionDB opens or creates the slashing protection database at path.  If no path
+// is given an in-memory, temporary database is constructed.
+func OpenSlashingProtectionDB(path string) (SlashingProtectionDB, error) {
+	logger := log.New("func", "OpenSlashingProtectionDB", "type", "slashingProtectionDB", "spdb_path", path)
+
+	logger.Info("Open slashing protection db")
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = newMemoryDB()
+	} else {
+		db, err = leveldb.OpenFile(path, &opt.Options{OpenFilesCacheCapacity: 5})
+	}
+	if err != nil {
+		logger.Error("Failed to open slashing protection db", "err", err)
+		return nil, err
+	}
+
+	// Unlike the other istanbul databases, this one is never flushed on a version change
+	version, err := db.Get([]byte(spVersionKey), nil)
+	switch {
+	case err == lvlerrors.ErrNotFound:
+		err = db.Put([]byte(spVersionKey), encodeUint64(slashingProtectionDBVersion), nil)
+	case err == nil && (len(version) != 8 || binary.BigEndian.Uint64(version) != slashingProtectionDBVersion):
+		err = fmt.Errorf("unsupported slashing protection db version %x", version)
+	}
+	if err != nil {
+		db.Close()
+		logger.Error("Failed to open slashing protection db", "err", err)
+		return nil, err
+	}
+
+	return &slashingProtectionDBImpl{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func (spdb *slashingProtectionDBImpl) CheckAndRecord(validator common.Address, msg *SignedMessage) error {
+	spdb.mu.Lock()
+	defer spdb.mu.Unlock()
+
+	recorded, err := spdb.getValidator()
+	if err != nil {
+		return err
+	}
+	if recorded != nil && *recorded != validator {
+		return fmt.Errorf("%v: have %v, recorded %v", errValidatorMismatch, validator.Hex(), recorded.Hex())
+	}
+	lowWatermark, err := spdb.getLowWatermark()
+	if err != nil {
+		return err
+	}
+	if msg.Sequence < lowWatermark {
+		return fmt.Errorf("%v: have %d, low watermark %d", errBelowLowWatermark, msg.Sequence, lowWatermark)
+	}
+
+	key := signedMessage2Key(msg)
+	digest, err := spdb.db.Get(key, nil)
+	if err == nil {
+		if common.BytesToHash(digest) != msg.Digest {
+			return fmt.Errorf("%v: sequence %d round %d code %d digest %v, recorded digest %x", errDoubleSign, msg.Sequence, msg.Round, msg.Code, msg.Digest.Hex(), digest)
+		}
+		return nil
+	} else if err != lvlerrors.ErrNotFound {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	if recorded == nil {
+		batch.Put([]byte(spValidatorKey), validator.Bytes())
+	}
+	batch.Put(key, msg.Digest.Bytes())
+	if msg.Sequence >= lowWatermark+2*slashingProtectionSequencesToKeep {
+		spdb.prune(batch, msg.Sequence-slashingProtectionSequencesToKeep)
+	}
+	return spdb.db.Write(batch, nil)
+}
+
+// prune deletes the messages older than the new low watermark
+func (spdb *slashingProtectionDBImpl) prune(batch *leveldb.Batch, lowWatermark uint64) {
+	iter := spdb.db.NewIterator(&util.Range{Start: []byte(signedMsgKey), Limit: seq2SignedMessageKey(lowWatermark)}, nil)
+	defer iter.Release()
+	count := 0
+	for iter.Next() {
+		batch.Delete(iter.Key())
+		count++
+	}
+	batch.Put([]byte(spLowWatermarkKey), encodeUint64(lowWatermark))
+	spdb.logger.Debug("Pruned signed messages", "count", count, "lowWatermark", lowWatermark)
+}
+
+func (spdb *slashingProtectionDBImpl) Export() (*SlashingProtectionInterchange, error) {
+	spdb.mu.Lock()
+	defer spdb.mu.Unlock()
+
+	validator, err := spdb.getValidator()
+	if err != nil {
+		return nil, err
+	}
+	lowWatermark, err := spdb.getLowWatermark()
+	if err != nil {
+		return nil, err
+	}
+	interchange := &SlashingProtectionInterchange{
+		Metadata:       SlashingProtectionMetadata{Version: SlashingProtectionInterchangeVersion, LowWatermark: lowWatermark},
+		SignedMessages: []*SignedMessage{},
+	}
+	if validator != nil {
+		interchange.Metadata.Validator = *validator
+	}
+
+	iter := spdb.db.NewIterator(util.BytesPrefix([]byte(signedMsgKey)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		msg, err := key2SignedMessage(iter.Key(), iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		interchange.SignedMessages = append(interchange.SignedMessages, msg)
+	}
+	return interchange, iter.Error()
+}
+
+// Import merges the interchange into the database.  Imported messages that conflict with recorded
+// ones can't be signed anymore, and the low watermark is raised to the highest imported sequence so
+// that the validator only signs once it caught up with the host the interchange was exported from.
+func (spdb *slashingProtectionDBImpl) Import(interchange *SlashingProtectionInterchange) error {
+	spdb.mu.Lock()
+	defer spdb.mu.Unlock()
+
+	if interchange.Metadata.Version != SlashingProtectionInterchangeVersion {
+		return fmt.Errorf("unsupported slashing protection interchange version %q", interchange.Metadata.Version)
+	}
+	validator, err := spdb.getValidator()
+	if err != nil {
+		return err
+	}
+	if validator != nil && *validator != interchange.Metadata.Validator {
+		return fmt.Errorf("%v: have %v, recorded %v", errValidatorMismatch, interchange.Metadata.Validator.Hex(), validator.Hex())
+	}
+	lowWatermark, err := spdb.getLowWatermark()
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(spValidatorKey), interchange.Metadata.Validator.Bytes())
+	newLowWatermark := interchange.Metadata.LowWatermark
+	for _, msg := range interchange.SignedMessages {
+		if msg.Sequence > newLowWatermark {
+			newLowWatermark = msg.Sequence
+		}
+		key := signedMessage2Key(msg)
+		digest, err := spdb.db.Get(key, nil)
+		if err == lvlerrors.ErrNotFound {
+			batch.Put(key, msg.Digest.Bytes())
+		} else if err != nil {
+			return err
+		} else if common.BytesToHash(digest) != msg.Digest {
+			spdb.logger.Warn("Imported signed message conflicts with a recorded one", "seq", msg.Sequence, "round", msg.Round, "code", msg.Code)
+			batch.Put(key, conflictingDigest.Bytes())
+		}
+	}
+	if newLowWatermark > lowWatermark {
+		batch.Put([]byte(spLowWatermarkKey), encodeUint64(newLowWatermark))
+	}
+	return spdb.db.Write(batch, nil)
+}
+
+func (spdb *slashingProtectionDBImpl) Close() error {
+	return spdb.db.Close()
+}
+
+func (spdb *slashingProtectionDBImpl) getValidator() (*common.Address, error) {
+	raw, err := spdb.db.Get([]byte(spValidatorKey), nil)
+	if err == lvlerrors.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	validator := common.BytesToAddress(raw)
+	return &validator, nil
+}
+
+func (spdb *slashingProtectionDBImpl) getLowWatermark() (uint64, error) {
+	raw, err := spdb.db.Get([]byte(spLowWatermarkKey), nil)
+	if err == lvlerrors.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(raw) != 8 {
+		return 0, errors.New("invalid slashing protection low watermark")
+	}
+	return binary.BigEndian.Uint64(raw), nil
+}
+
+func encodeUint64(n uint64) []byte {
+	buff := make([]byte, 8)
+	binary.BigEndian.PutUint64(buff, n)
+	return buff
+}
+
+// seq2SignedMessageKey returns the key prefix for all the messages signed for a sequence.
+// The key format is [ prefix . BigEndian(Sequence) . BigEndian(Round) . BigEndian(Code) ]
+// so that entries are sorted by view.
+func seq2SignedMessageKey(seq uint64) []byte {
+	return append([]byte(signedMsgKey), encodeUint64(seq)...)
+}
+
+func signedMessage2Key(msg *SignedMessage) []byte {
+	buff := seq2SignedMessageKey(msg.Sequence)
+	buff = append(buff, encodeUint64(msg.Round)...)
+	return append(buff, encodeUint64(msg.Code)...)
+}
+
+func key2SignedMessage(key, digest []byte) (*SignedMessage, error) {
+	key = key[len(signedMsgKey):]
+	if len(key) != 24 || len(digest) != common.HashLength {
+		return nil, errors.New("invalid signed message entry")
+	}
+	return &SignedMessage{
+		Sequence: binary.BigEndian.Uint64(key[:8]),
+		Round:    binary.BigEndian.Uint64(key[8:16]),
+		Code:     binary.BigEndian.Uint64(key[16:]),
+		Digest:   common.BytesToHash(digest),
+	}, nil
+}
+
+// signedMessageOf returns the identity of a consensus message recorded by the slashing protection db
+func signedMessageOf(msg *istanbul.Message, view *istanbul.View) (*SignedMessage, error) {
+	signed := &SignedMessage{Sequence: view.Sequence.Uint64(), Round: view.Round.Uint64(), Code: msg.Code}
+	switch msg.Code {
+	case istanbul.MsgPreprepare:
+		var p *istanbul.Preprepare
+		if err := msg.Decode(&p); err != nil {
+			return nil, err
+		}
+		signed.Digest = p.Proposal.Hash()
+	case istanbul.MsgPrepare:
+		var s *istanbul.Subject
+		if err := msg.Decode(&s); err != nil {
+			return nil, err
+		}
+		signed.Digest = s.Digest
+	case istanbul.MsgCommit:
+		var cs *istanbul.CommittedSubject
+		if err := msg.Decode(&cs); err != nil {
+			return nil, err
+		}
+		signed.Digest = cs.Subject.Digest
+	}
+	return signed, nil
+}
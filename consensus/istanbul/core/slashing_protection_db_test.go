@@ -0,0 +1,181 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+)
+
+func newTestSlashingProtectionDB(t *testing.T) SlashingProtectionDB {
+	spdb, err := OpenSlashingProtectionDB("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spdb
+}
+
+func TestSlashingProtectionConflicts(t *testing.T) {
+	spdb := newTestSlashingProtectionDB(t)
+	defer spdb.Close()
+	validator := common.HexToAddress("0x1")
+
+	commit := &SignedMessage{Sequence: 10, Round: 0, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xa")}
+	if err := spdb.CheckAndRecord(validator, commit); err != nil {
+		t.Fatalf("first commit: have %v, want nil", err)
+	}
+	// Signing the same message again is allowed
+	if err := spdb.CheckAndRecord(validator, commit); err != nil {
+		t.Errorf("same commit: have %v, want nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		msg     *SignedMessage
+		wantErr bool
+	}{
+		{"conflicting commit", &SignedMessage{Sequence: 10, Round: 0, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xb")}, true},
+		{"prepare for another digest", &SignedMessage{Sequence: 10, Round: 0, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xb")}, false},
+		{"commit at another round", &SignedMessage{Sequence: 10, Round: 1, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xb")}, false},
+		{"commit at another sequence", &SignedMessage{Sequence: 11, Round: 0, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xb")}, false},
+		{"round change", &SignedMessage{Sequence: 10, Round: 0, Code: istanbul.MsgRoundChange}, false},
+	}
+	for _, tt := range tests {
+		if err := spdb.CheckAndRecord(validator, tt.msg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: have %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if err := spdb.CheckAndRecord(common.HexToAddress("0x2"), &SignedMessage{Sequence: 12}); err == nil {
+		t.Errorf("expected messages of another validator to be rejected")
+	}
+}
+
+func TestSlashingProtectionPruning(t *testing.T) {
+	spdb := newTestSlashingProtectionDB(t)
+	defer spdb.Close()
+	validator := common.HexToAddress("0x1")
+
+	for _, seq := range []uint64{1, 2, 2 * slashingProtectionSequencesToKeep} {
+		if err := spdb.CheckAndRecord(validator, &SignedMessage{Sequence: seq, Code: istanbul.MsgPrepare}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	interchange, err := spdb.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interchange.Metadata.LowWatermark != slashingProtectionSequencesToKeep || len(interchange.SignedMessages) != 1 {
+		t.Fatalf("have low watermark %d with %d messages, want %d with 1", interchange.Metadata.LowWatermark, len(interchange.SignedMessages), slashingProtectionSequencesToKeep)
+	}
+	if err := spdb.CheckAndRecord(validator, &SignedMessage{Sequence: 2, Code: istanbul.MsgPrepare}); err == nil {
+		t.Errorf("expected pruned message to be rejected")
+	}
+}
+
+func TestSlashingProtectionInterchange(t *testing.T) {
+	validator := common.HexToAddress("0x1")
+	oldHost := newTestSlashingProtectionDB(t)
+	defer oldHost.Close()
+	for _, msg := range []*SignedMessage{
+		{Sequence: 10, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xa")},
+		{Sequence: 10, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xa")},
+		{Sequence: 11, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xb")},
+	} {
+		if err := oldHost.CheckAndRecord(validator, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exported, err := oldHost.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var interchange SlashingProtectionInterchange
+	if err := json.Unmarshal(blob, &interchange); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new host already signed a conflicting commit at sequence 11
+	newHost := newTestSlashingProtectionDB(t)
+	defer newHost.Close()
+	if err := newHost.CheckAndRecord(validator, &SignedMessage{Sequence: 11, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xc")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := newHost.Import(&interchange); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		msg     *SignedMessage
+		wantErr bool
+	}{
+		{"below imported sequences", &SignedMessage{Sequence: 10, Round: 1, Code: istanbul.MsgPrepare}, true},
+		{"imported prepare", &SignedMessage{Sequence: 11, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xb")}, true},
+		{"recorded prepare", &SignedMessage{Sequence: 11, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xc")}, true},
+		{"next round", &SignedMessage{Sequence: 11, Round: 1, Code: istanbul.MsgPrepare, Digest: common.HexToHash("0xd")}, false},
+	}
+	for _, tt := range tests {
+		if err := newHost.CheckAndRecord(validator, tt.msg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: have %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Data of another validator can't be imported
+	interchange.Metadata.Validator = common.HexToAddress("0x2")
+	if err := newHost.Import(&interchange); err == nil {
+		t.Errorf("expected import of another validator's data to fail")
+	}
+}
+
+func TestSlashingProtectionPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slashingprotection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "slashingprotection")
+	validator := common.HexToAddress("0x1")
+
+	spdb, err := OpenSlashingProtectionDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spdb.CheckAndRecord(validator, &SignedMessage{Sequence: 10, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xa")}); err != nil {
+		t.Fatal(err)
+	}
+	spdb.Close()
+
+	spdb, err = OpenSlashingProtectionDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spdb.Close()
+	if err := spdb.CheckAndRecord(validator, &SignedMessage{Sequence: 10, Code: istanbul.MsgCommit, Digest: common.HexToHash("0xb")}); err == nil {
+		t.Errorf("expected conflicting commit to be rejected after restart")
+	}
+}
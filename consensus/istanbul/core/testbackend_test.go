@@ -264,6 +264,8 @@ func (self *testSystemBackend) ParentBlockValidators(proposal istanbul.Proposal)
 
 func (self *testSystemBackend) UpdateReplicaState(seq *big.Int) { /* pass */ }
 
+func (self *testSystemBackend) CheckSigningView(view *istanbul.View) error { return nil }
+
 func (self *testSystemBackend) finalizeAndReturnMessage(msg *istanbul.Message) (istanbul.Message, error) {
 	message := new(istanbul.Message)
 	data, err := self.engine.(*core).finalizeMessage(msg)
@@ -439,6 +441,8 @@ func newTestSystemWithBackend(n, f uint64) *testSystem {
 	config := *istanbul.DefaultConfig
 	config.ProposerPolicy = istanbul.RoundRobin
 	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = ""
+	config.SlashingProtectionDBPath = ""
 	config.RequestTimeout = 300
 	config.TimeoutBackoffFactor = 100
 	config.MinResendRoundChangeTimeout = 1000
@@ -471,6 +475,8 @@ func NewTestSystemWithBackendDonut(n, f, epoch uint64, donutBlock int64) *testSy
 	config := *istanbul.DefaultConfig
 	config.ProposerPolicy = istanbul.RoundRobin
 	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = ""
+	config.SlashingProtectionDBPath = ""
 	config.RequestTimeout = 300
 	config.TimeoutBackoffFactor = 100
 	config.MinResendRoundChangeTimeout = 1000
@@ -19,12 +19,15 @@ package core
 import (
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/rlp"
 )
 
 type Engine interface {
 	Start() error
 	Stop() error
+	// Close releases the databases of a stopped engine
+	Close() error
 	// CurrentView returns the current view or nil if none
 	CurrentView() *istanbul.View
 	// CurrentRoundState returns the current roundState or nil if none
@@ -34,6 +37,10 @@ type Engine interface {
 	ParentCommits() MessageSet
 	// ForceRoundChange will force round change to the current desiredRound + 1
 	ForceRoundChange()
+	// GetEquivocationEvidence returns the stored equivocation evidence for sequences in [fromSeq, toSeq]
+	GetEquivocationEvidence(fromSeq, toSeq uint64) ([]*EquivocationEvidence, error)
+	// SubscribeEquivocationEvidence subscribes to newly detected equivocations
+	SubscribeEquivocationEvidence(ch chan<- EquivocationEvidenceEvent) event.Subscription
 }
 
 // State represents the IBFT state
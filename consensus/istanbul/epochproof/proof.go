@@ -0,0 +1,185 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package epochproof implements compact proofs of the validator set changes between
+// two epochs, made of the epoch-boundary headers and their epoch validator set seals,
+// and their verification from a trusted validator set.
+package epochproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/core/types"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+)
+
+// ValidatorDiff is the change of the validator set encoded in the istanbul extra of an epoch block
+type ValidatorDiff struct {
+	AddedValidators           []common.Address `json:"addedValidators"`
+	AddedValidatorsPublicKeys []hexutil.Bytes  `json:"addedValidatorsPublicKeys"`
+	RemovedValidators         *hexutil.Big     `json:"removedValidators"`
+}
+
+// EpochSeal is the aggregated epoch validator set seal of an epoch block, signed by the
+// validators of the epoch over the SNARK-friendly encoding of the next validator set
+type EpochSeal struct {
+	Bitmap    *hexutil.Big  `json:"bitmap"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// Epoch is the proof of the validator set change at the end of an epoch
+type Epoch struct {
+	Number        uint64         `json:"number"`
+	Header        *types.Header  `json:"header"`
+	ValidatorDiff *ValidatorDiff `json:"validatorDiff"`
+	Seal          *EpochSeal     `json:"epochSeal"`
+}
+
+// Proof is the chain of epoch proofs for the epochs From to To (both included)
+type Proof struct {
+	From   uint64   `json:"from"`
+	To     uint64   `json:"to"`
+	Epochs []*Epoch `json:"epochs"`
+}
+
+// NewEpoch creates the proof of the epoch that ends with the given header
+func NewEpoch(header *types.Header, epochSize uint64, snarkData *types.EpochSnarkData) (*Epoch, error) {
+	number := header.Number.Uint64()
+	if number == 0 || !istanbul.IsLastBlockOfEpoch(number, epochSize) {
+		return nil, errNotEpochBlock
+	}
+	if snarkData == nil || snarkData.Bitmap == nil || len(snarkData.Signature) == 0 {
+		return nil, errMissingEpochSeal
+	}
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	return &Epoch{
+		Number:        istanbul.GetEpochNumber(number, epochSize),
+		Header:        header,
+		ValidatorDiff: newValidatorDiff(extra),
+		Seal: &EpochSeal{
+			Bitmap:    (*hexutil.Big)(new(big.Int).Set(snarkData.Bitmap)),
+			Signature: common.CopyBytes(snarkData.Signature),
+		},
+	}, nil
+}
+
+func newValidatorDiff(extra *types.IstanbulExtra) *ValidatorDiff {
+	diff := &ValidatorDiff{
+		AddedValidators:           extra.AddedValidators,
+		AddedValidatorsPublicKeys: make([]hexutil.Bytes, len(extra.AddedValidatorsPublicKeys)),
+		RemovedValidators:         (*hexutil.Big)(new(big.Int)),
+	}
+	for i, key := range extra.AddedValidatorsPublicKeys {
+		diff.AddedValidatorsPublicKeys[i] = common.CopyBytes(key[:])
+	}
+	if extra.RemovedValidators != nil {
+		diff.RemovedValidators = (*hexutil.Big)(new(big.Int).Set(extra.RemovedValidators))
+	}
+	return diff
+}
+
+// equal indicates if both validator diffs are the same
+func (d *ValidatorDiff) equal(other *ValidatorDiff) bool {
+	if len(d.AddedValidators) != len(other.AddedValidators) || len(d.AddedValidatorsPublicKeys) != len(other.AddedValidatorsPublicKeys) {
+		return false
+	}
+	for i := range d.AddedValidators {
+		if d.AddedValidators[i] != other.AddedValidators[i] {
+			return false
+		}
+	}
+	for i := range d.AddedValidatorsPublicKeys {
+		if !bytes.Equal(d.AddedValidatorsPublicKeys[i], other.AddedValidatorsPublicKeys[i]) {
+			return false
+		}
+	}
+	if d.RemovedValidators == nil || other.RemovedValidators == nil {
+		return d.RemovedValidators == other.RemovedValidators
+	}
+	return d.RemovedValidators.ToInt().Cmp(other.RemovedValidators.ToInt()) == 0
+}
+
+// trustedValidator is the JSON encoding of a validator in a TrustedState
+type trustedValidator struct {
+	Address      common.Address `json:"address"`
+	BLSPublicKey hexutil.Bytes  `json:"blsPublicKey"`
+}
+
+// TrustedState is a validator set known to be valid at the last block of an epoch (or the genesis
+// block), from which proofs of the following epochs can be verified.
+type TrustedState struct {
+	Epoch      uint64
+	Hash       common.Hash
+	Validators []istanbul.ValidatorData
+}
+
+type trustedStateJSON struct {
+	Epoch      uint64             `json:"epoch"`
+	Hash       common.Hash        `json:"hash"`
+	Validators []trustedValidator `json:"validators"`
+}
+
+// GenesisTrustedState returns the trusted state defined by the validators of the genesis block
+func GenesisTrustedState(genesis *types.Header) (*TrustedState, error) {
+	if genesis.Number.Sign() != 0 {
+		return nil, errors.New("not a genesis header")
+	}
+	extra, err := types.ExtractIstanbulExtra(genesis)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := istanbul.CombineIstanbulExtraToValidatorData(extra.AddedValidators, extra.AddedValidatorsPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &TrustedState{Epoch: 0, Hash: genesis.Hash(), Validators: validators}, nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (s *TrustedState) MarshalJSON() ([]byte, error) {
+	enc := trustedStateJSON{Epoch: s.Epoch, Hash: s.Hash, Validators: make([]trustedValidator, len(s.Validators))}
+	for i, v := range s.Validators {
+		enc.Validators[i] = trustedValidator{Address: v.Address, BLSPublicKey: common.CopyBytes(v.BLSPublicKey[:])}
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *TrustedState) UnmarshalJSON(input []byte) error {
+	var dec trustedStateJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	s.Epoch, s.Hash = dec.Epoch, dec.Hash
+	s.Validators = make([]istanbul.ValidatorData, len(dec.Validators))
+	for i, v := range dec.Validators {
+		if len(v.BLSPublicKey) != blscrypto.PUBLICKEYBYTES {
+			return errInvalidPublicKey
+		}
+		s.Validators[i].Address = v.Address
+		copy(s.Validators[i].BLSPublicKey[:], v.BLSPublicKey)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package epochproof
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	istanbulCore "github.com/celo-org/celo-blockchain/consensus/istanbul/core"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/core/types"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/params"
+)
+
+var (
+	// errNotEpochBlock is returned when a proof is requested for a block that doesn't end an epoch
+	errNotEpochBlock = errors.New("not the last block of an epoch")
+	// errMissingEpochSeal is returned when the epoch validator set seal of an epoch block is not available
+	errMissingEpochSeal = errors.New("missing epoch validator set seal")
+	// errInvalidPublicKey is returned when a BLS public key has the wrong length
+	errInvalidPublicKey = errors.New("invalid BLS public key length")
+	// errInvalidValidatorDiff is returned when the validator diff of an epoch doesn't match its header
+	errInvalidValidatorDiff = errors.New("validator diff does not match the header")
+	// errInsufficientSeals is returned when a seal is signed by less than a quorum of the validators
+	errInsufficientSeals = errors.New("not enough signatures in seal")
+)
+
+// Verify checks that the proof extends the trusted state, i.e. that each epoch block of the proof was
+// committed by a quorum of the validators of its epoch, which also signed the validator set of the next
+// epoch. It returns the trusted state at the end of the last epoch of the proof.
+func Verify(config *params.ChainConfig, trusted *TrustedState, proof *Proof) (*TrustedState, error) {
+	if config.Istanbul == nil || config.Istanbul.Epoch == 0 {
+		return nil, errors.New("missing istanbul epoch size in chain config")
+	}
+	if proof.From != trusted.Epoch+1 {
+		return nil, fmt.Errorf("proof starts at epoch %d, but the trusted state is at epoch %d", proof.From, trusted.Epoch)
+	}
+	if proof.To < proof.From || uint64(len(proof.Epochs)) != proof.To-proof.From+1 {
+		return nil, fmt.Errorf("proof should contain %d epochs, has %d", proof.To-proof.From+1, len(proof.Epochs))
+	}
+
+	state := trusted
+	for _, epoch := range proof.Epochs {
+		next, err := VerifyEpoch(config, state, epoch)
+		if err != nil {
+			return nil, fmt.Errorf("epoch %d: %v", epoch.Number, err)
+		}
+		state = next
+	}
+	return state, nil
+}
+
+// VerifyEpoch checks the proof of the epoch following the trusted state, and returns the trusted
+// state at the end of that epoch.
+func VerifyEpoch(config *params.ChainConfig, trusted *TrustedState, epoch *Epoch) (*TrustedState, error) {
+	epochSize := config.Istanbul.Epoch
+	if epoch.Number != trusted.Epoch+1 {
+		return nil, fmt.Errorf("expected epoch %d", trusted.Epoch+1)
+	}
+	if epoch.Header == nil || epoch.Seal == nil || epoch.Seal.Bitmap == nil {
+		return nil, errors.New("incomplete epoch proof")
+	}
+	header := epoch.Header
+	number := header.Number.Uint64()
+	if number != istanbul.GetEpochLastBlockNumber(epoch.Number, epochSize) {
+		return nil, fmt.Errorf("header %d is not the last block of the epoch", number)
+	}
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	if epoch.ValidatorDiff != nil && !epoch.ValidatorDiff.equal(newValidatorDiff(extra)) {
+		return nil, errInvalidValidatorDiff
+	}
+
+	if extra.AggregatedSeal.Round == nil {
+		return nil, errors.New("missing aggregated seal")
+	}
+
+	// The header must have been committed by the validators of the epoch
+	valSet := validator.NewSet(trusted.Validators)
+	hash := header.Hash()
+	seal := istanbulCore.PrepareCommittedSeal(hash, extra.AggregatedSeal.Round)
+	if err := verifyAggregatedSignature(valSet, extra.AggregatedSeal.Bitmap, seal, []byte{}, extra.AggregatedSeal.Signature, false, false); err != nil {
+		return nil, fmt.Errorf("invalid aggregated seal: %v", err)
+	}
+
+	// Apply the validator diff in the same way the istanbul snapshots do
+	added, err := istanbul.CombineIstanbulExtraToValidatorData(extra.AddedValidators, extra.AddedValidatorsPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	newValSet := valSet.Copy()
+	if !newValSet.RemoveValidators(extra.RemovedValidators) || !newValSet.AddValidators(added) {
+		return nil, errors.New("invalid validator set diff")
+	}
+
+	// The validators of the epoch must also have signed the new validator set
+	cip22 := config.IsDonut(header.Number)
+	var parentEpochHash common.Hash
+	if cip22 {
+		parentEpochHash = trusted.Hash
+	}
+	message, extraData, err := istanbulCore.EncodeEpochValidatorSetData(number, epochSize, uint8(extra.AggregatedSeal.Round.Uint64()), hash, parentEpochHash, newValSet, cip22)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyAggregatedSignature(valSet, epoch.Seal.Bitmap.ToInt(), message, extraData, epoch.Seal.Signature, true, cip22); err != nil {
+		return nil, fmt.Errorf("invalid epoch validator set seal: %v", err)
+	}
+
+	return &TrustedState{
+		Epoch:      epoch.Number,
+		Hash:       hash,
+		Validators: validator.MapValidatorsToData(newValSet.List()),
+	}, nil
+}
+
+// verifyAggregatedSignature checks that the signature aggregates the signatures of at least a quorum
+// of the validators in the bitmap
+func verifyAggregatedSignature(valSet istanbul.ValidatorSet, bitmap *big.Int, message, extraData, signature []byte, compositeHasher, cip22 bool) error {
+	if bitmap == nil || len(signature) != types.IstanbulExtraBlsSignature {
+		return errors.New("malformed seal")
+	}
+	if bitmap.BitLen() > valSet.Size() {
+		return errors.New("bitmap has signers outside of the validator set")
+	}
+	publicKeys := []blscrypto.SerializedPublicKey{}
+	for i := 0; i < valSet.Size(); i++ {
+		if bitmap.Bit(i) == 1 {
+			publicKeys = append(publicKeys, valSet.GetByIndex(uint64(i)).BLSPublicKey())
+		}
+	}
+	if len(publicKeys) < valSet.MinQuorumSize() {
+		return errInsufficientSeals
+	}
+	return blscrypto.VerifyAggregatedSignature(publicKeys, message, extraData, signature, compositeHasher, cip22)
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package epochproof
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	istanbulCore "github.com/celo-org/celo-blockchain/consensus/istanbul/core"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/crypto"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+	"github.com/celo-org/celo-bls-go/bls"
+)
+
+const testEpochSize = 10
+
+type testValidator struct {
+	key  *ecdsa.PrivateKey
+	data istanbul.ValidatorData
+}
+
+func newTestValidator(t *testing.T) *testValidator {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blsKey, err := blscrypto.ECDSAToBLS(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blsPublicKey, err := blscrypto.PrivateToPublic(blsKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testValidator{key: key, data: istanbul.ValidatorData{Address: crypto.PubkeyToAddress(key.PublicKey), BLSPublicKey: blsPublicKey}}
+}
+
+func (v *testValidator) sign(t *testing.T, message, extraData []byte, compositeHasher, cip22 bool) []byte {
+	blsKey, err := blscrypto.ECDSAToBLS(v.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := bls.DeserializePrivateKey(blsKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer privateKey.Destroy()
+	signature, err := privateKey.SignMessage(message, extraData, compositeHasher, cip22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signature.Destroy()
+	serialized, err := signature.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serialized
+}
+
+// aggregate returns the aggregated signature of the signers (by index in validators) and its bitmap
+func aggregate(t *testing.T, validators []*testValidator, signers []int, message, extraData []byte, compositeHasher, cip22 bool) (*big.Int, []byte) {
+	bitmap := new(big.Int)
+	signatures := make([][]byte, 0, len(signers))
+	for _, i := range signers {
+		bitmap.SetBit(bitmap, i, 1)
+		signatures = append(signatures, validators[i].sign(t, message, extraData, compositeHasher, cip22))
+	}
+	signature, err := blscrypto.AggregateSignatures(signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bitmap, signature
+}
+
+func makeHeader(t *testing.T, number uint64, extra *types.IstanbulExtra) *types.Header {
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Header{
+		Number: new(big.Int).SetUint64(number),
+		Extra:  append(make([]byte, types.IstanbulExtraVanity), payload...),
+	}
+}
+
+func emptySeal() types.IstanbulAggregatedSeal {
+	return types.IstanbulAggregatedSeal{Bitmap: new(big.Int), Signature: []byte{}, Round: new(big.Int)}
+}
+
+type testChain struct {
+	config     *params.ChainConfig
+	genesis    *types.Header
+	validators []*testValidator
+	epochs     []*Epoch
+}
+
+// newTestChain creates a genesis block with 4 validators, and the proofs of the given number of epochs,
+// at the end of each of which the last validator is replaced by a new one.
+func newTestChain(t *testing.T, donut bool, epochs int) *testChain {
+	config := &params.ChainConfig{Istanbul: &params.IstanbulConfig{Epoch: testEpochSize}}
+	if donut {
+		config.DonutBlock = common.Big0
+	}
+	validators := []*testValidator{newTestValidator(t), newTestValidator(t), newTestValidator(t), newTestValidator(t)}
+
+	genesisExtra := &types.IstanbulExtra{RemovedValidators: new(big.Int), Seal: []byte{}, AggregatedSeal: emptySeal(), ParentAggregatedSeal: emptySeal()}
+	for _, v := range validators {
+		genesisExtra.AddedValidators = append(genesisExtra.AddedValidators, v.data.Address)
+		genesisExtra.AddedValidatorsPublicKeys = append(genesisExtra.AddedValidatorsPublicKeys, v.data.BLSPublicKey)
+	}
+	chain := &testChain{config: config, genesis: makeHeader(t, 0, genesisExtra), validators: validators}
+
+	parentHash := chain.genesis.Hash()
+	for e := uint64(1); e <= uint64(epochs); e++ {
+		// Replace the last validator
+		added := newTestValidator(t)
+		extra := &types.IstanbulExtra{
+			AddedValidators:           []common.Address{added.data.Address},
+			AddedValidatorsPublicKeys: []blscrypto.SerializedPublicKey{added.data.BLSPublicKey},
+			RemovedValidators:         big.NewInt(1 << 3),
+			Seal:                      []byte{},
+			AggregatedSeal:            emptySeal(),
+			ParentAggregatedSeal:      emptySeal(),
+		}
+		number := istanbul.GetEpochLastBlockNumber(e, testEpochSize)
+		hash := makeHeader(t, number, extra).Hash()
+
+		round := big.NewInt(1)
+		signers := []int{0, 1, 3}
+		bitmap, signature := aggregate(t, chain.validators, signers, istanbulCore.PrepareCommittedSeal(hash, round), []byte{}, false, false)
+		extra.AggregatedSeal = types.IstanbulAggregatedSeal{Bitmap: bitmap, Signature: signature, Round: round}
+		header := makeHeader(t, number, extra)
+		if header.Hash() != hash {
+			t.Fatalf("aggregated seal should not change the header hash")
+		}
+
+		next := append(append([]*testValidator{}, chain.validators[:3]...), added)
+		newValSet := validator.NewSet(dataOf(next))
+		var parentEpochHash common.Hash
+		if donut {
+			parentEpochHash = parentHash
+		}
+		message, extraData, err := istanbulCore.EncodeEpochValidatorSetData(number, testEpochSize, uint8(round.Uint64()), hash, parentEpochHash, newValSet, donut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		epochBitmap, epochSignature := aggregate(t, chain.validators, signers, message, extraData, true, donut)
+		epoch, err := NewEpoch(header, testEpochSize, &types.EpochSnarkData{Bitmap: epochBitmap, Signature: epochSignature})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain.epochs = append(chain.epochs, epoch)
+		chain.validators = next
+		parentHash = hash
+	}
+	return chain
+}
+
+func dataOf(validators []*testValidator) []istanbul.ValidatorData {
+	data := make([]istanbul.ValidatorData, len(validators))
+	for i, v := range validators {
+		data[i] = v.data
+	}
+	return data
+}
+
+func (c *testChain) proof() *Proof {
+	return &Proof{From: 1, To: uint64(len(c.epochs)), Epochs: c.epochs}
+}
+
+func TestVerify(t *testing.T) {
+	for _, donut := range []bool{false, true} {
+		chain := newTestChain(t, donut, 3)
+		trusted, err := GenesisTrustedState(chain.genesis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state, err := Verify(chain.config, trusted, chain.proof())
+		if err != nil {
+			t.Fatalf("donut=%v: failed to verify proof: %v", donut, err)
+		}
+		if state.Epoch != 3 || state.Hash != chain.epochs[2].Header.Hash() {
+			t.Errorf("donut=%v: trusted state mismatch: have epoch %d (%x)", donut, state.Epoch, state.Hash)
+		}
+		if len(state.Validators) != len(chain.validators) {
+			t.Fatalf("donut=%v: validators mismatch: have %d, want %d", donut, len(state.Validators), len(chain.validators))
+		}
+		for i, v := range chain.validators {
+			if state.Validators[i] != v.data {
+				t.Errorf("donut=%v: validator %d mismatch: have %v, want %v", donut, i, state.Validators[i].Address, v.data.Address)
+			}
+		}
+
+		// Proofs can be verified incrementally from a previous trusted state
+		proof := &Proof{From: 3, To: 3, Epochs: chain.epochs[2:]}
+		intermediate, err := Verify(chain.config, trusted, &Proof{From: 1, To: 2, Epochs: chain.epochs[:2]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verify(chain.config, intermediate, proof); err != nil {
+			t.Errorf("donut=%v: failed to verify proof from intermediate state: %v", donut, err)
+		}
+		if _, err := Verify(chain.config, trusted, proof); err == nil {
+			t.Errorf("donut=%v: expected proof not starting at the trusted state to fail", donut)
+		}
+	}
+}
+
+func TestVerifyJSON(t *testing.T) {
+	chain := newTestChain(t, true, 2)
+	trusted, err := GenesisTrustedState(chain.genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := json.Marshal(chain.proof())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proof Proof
+	if err := json.Unmarshal(blob, &proof); err != nil {
+		t.Fatal(err)
+	}
+	blob, err = json.Marshal(trusted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedTrusted TrustedState
+	if err := json.Unmarshal(blob, &decodedTrusted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(chain.config, &decodedTrusted, &proof); err != nil {
+		t.Fatalf("failed to verify decoded proof: %v", err)
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(chain *testChain, trusted *TrustedState)
+	}{
+		{"untrusted validators", func(chain *testChain, trusted *TrustedState) {
+			trusted.Validators[0] = newTestValidator(t).data
+		}},
+		{"tampered validator diff", func(chain *testChain, trusted *TrustedState) {
+			chain.epochs[1].ValidatorDiff.AddedValidators[0] = common.Address{1}
+		}},
+		{"tampered header", func(chain *testChain, trusted *TrustedState) {
+			chain.epochs[1].Header.GasUsed = 1
+		}},
+		{"insufficient epoch seal signers", func(chain *testChain, trusted *TrustedState) {
+			chain.epochs[0].Seal.Bitmap.ToInt().SetBit(chain.epochs[0].Seal.Bitmap.ToInt(), 3, 0)
+		}},
+		{"epoch seal from other epoch", func(chain *testChain, trusted *TrustedState) {
+			chain.epochs[1].Seal = chain.epochs[0].Seal
+		}},
+		{"missing epoch", func(chain *testChain, trusted *TrustedState) {
+			chain.epochs = chain.epochs[1:]
+		}},
+	}
+	for _, tt := range tests {
+		chain := newTestChain(t, true, 2)
+		trusted, err := GenesisTrustedState(chain.genesis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.tamper(chain, trusted)
+		if _, err := Verify(chain.config, trusted, chain.proof()); err == nil {
+			t.Errorf("%s: expected verification to fail", tt.name)
+		}
+	}
+}
@@ -25,6 +25,8 @@ const (
 	Celo64 = 64 // eth/63 + the istanbul messages
 	Celo65 = 65 // incorporates changes from eth/64 (EIP)
 	Celo66 = 66 // incorporates changes from eth/65 (EIP-2464)
+	Celo67 = 67 // adds the proxy health check messages
+	Celo68 = 68 // adds the private transactions message
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
@@ -32,7 +34,7 @@ const ProtocolName = "istanbul"
 
 // ProtocolVersions are the supported versions of the istanbul protocol (first is primary).
 // (First is primary in the sense that it's the most current one supported, not in the sense of IsPrimary() below)
-var ProtocolVersions = []uint{Celo66, Celo65, Celo64}
+var ProtocolVersions = []uint{Celo68, Celo67, Celo66, Celo65, Celo64}
 
 // Returns whether this version of Istanbul should have Primary: true (a legacy property that was needed to work
 // around an upstream bug in the LES protocol which prevented two LES servers from connecting to each other).
@@ -43,7 +45,7 @@ func IsPrimary(version uint) bool {
 }
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = map[uint]uint64{Celo64: 22, Celo65: 27, Celo66: 27}
+var ProtocolLengths = map[uint]uint64{Celo64: 22, Celo65: 27, Celo66: 27, Celo67: 27, Celo68: 28}
 
 // Message codes for istanbul related messages
 // If you want to add a code, you need to increment the protocolLengths Array size
@@ -57,10 +59,12 @@ const (
 	VersionCertificatesMsg = 0x16
 	EnodeCertificateMsg    = 0x17
 	ValidatorHandshakeMsg  = 0x18
+	ProxyHealthCheckMsg    = 0x19
+	ProxyHealthReportMsg   = 0x1a
 )
 
 func IsIstanbulMsg(msg p2p.Msg) bool {
-	return msg.Code >= ConsensusMsg && msg.Code <= ValidatorHandshakeMsg
+	return msg.Code >= ConsensusMsg && msg.Code <= ProxyHealthReportMsg
 }
 
 // IsGossipedMsg specifies which messages should be gossiped throughout the network (as opposed to directly sent to a peer).
@@ -17,6 +17,8 @@
 package proxy
 
 import (
+	"sync/atomic"
+
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/consensus"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
@@ -100,8 +102,12 @@ func (p *proxyEngine) handleForwardMsg(peer consensus.Peer, payload []byte) (boo
 	logger.Trace("Forwarding a message", "msg code", fwdMsg.Code)
 	if err := p.backend.Multicast(fwdMsg.DestAddresses, fwdMsg.Msg, fwdMsg.Code, false); err != nil {
 		logger.Error("Error in multicasting a forwarded message", "error", err)
+		atomic.AddUint64(&p.failedForwards, 1)
+		failedForwardsMeter.Mark(1)
 		return true, err
 	}
+	atomic.AddUint64(&p.forwardedMsgs, 1)
+	forwardedMsgsMeter.Mark(1)
 
 	return true, nil
 }
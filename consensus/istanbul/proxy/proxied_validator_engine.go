@@ -26,6 +26,7 @@ import (
 	"github.com/celo-org/celo-blockchain/log"
 	"github.com/celo-org/celo-blockchain/p2p"
 	"github.com/celo-org/celo-blockchain/p2p/enode"
+	"github.com/celo-org/celo-blockchain/rlp"
 )
 
 // BackendForProxiedValidatorEngine provides the Istanbul backend application specific functions for Istanbul proxied validator engine
@@ -113,6 +114,8 @@ type proxiedValidatorEngine struct {
 	sendFwdMsgsCh chan *fwdMsgInfo // Used to send a forward message to all of the proxies
 
 	newBlockchainEpoch chan struct{} // Used to notify to the thread that a new blockchain epoch has started
+
+	proxyHealthReports chan *proxyHealthReportInfo // Used to notify to the thread of the health reports received from the proxies
 }
 
 // proxiedValThreadOpFunc is a function type to define operations executed with run's local state as parameters.
@@ -141,6 +144,7 @@ func NewProxiedValidatorEngine(backend BackendForProxiedValidatorEngine, config
 		sendEnodeCertsCh:        make(chan map[enode.ID]*istanbul.EnodeCertMsg),
 		sendFwdMsgsCh:           make(chan *fwdMsgInfo),
 		newBlockchainEpoch:      make(chan struct{}),
+		proxyHealthReports:      make(chan *proxyHealthReportInfo, 10),
 	}
 
 	return pv, nil
@@ -372,6 +376,55 @@ func (pv *proxiedValidatorEngine) NewEpoch() error {
 	return nil
 }
 
+// HandleMsg handles the messages sent by the proxies to the proxied validator
+func (pv *proxiedValidatorEngine) HandleMsg(peer consensus.Peer, msgCode uint64, payload []byte) (bool, error) {
+	if msgCode != istanbul.ProxyHealthReportMsg {
+		return false, nil
+	}
+	if !pv.Running() {
+		return true, istanbul.ErrStoppedProxiedValidatorEngine
+	}
+
+	var report proxyHealthReport
+	if err := rlp.DecodeBytes(payload, &report); err != nil {
+		pv.logger.Error("Error in decoding received proxy health report", "err", err, "from", peer.Node().ID())
+		return true, err
+	}
+
+	select {
+	case pv.proxyHealthReports <- &proxyHealthReportInfo{peerID: peer.Node().ID(), report: &report, receivedAt: time.Now()}:
+	case <-pv.quit:
+		return true, istanbul.ErrStoppedProxiedValidatorEngine
+	}
+
+	return true, nil
+}
+
+// GetProxiesHealth will return the health of all of the proxies.
+func (pv *proxiedValidatorEngine) GetProxiesHealth() ([]*ProxyHealthInfo, error) {
+	if !pv.Running() {
+		return nil, istanbul.ErrStoppedProxiedValidatorEngine
+	}
+
+	var proxiesHealth []*ProxyHealthInfo
+
+	select {
+	case pv.proxiedValThreadOpCh <- func(ps *proxySet) {
+		proxiesHealth = make([]*ProxyHealthInfo, 0, len(ps.proxiesByID))
+		for _, proxy := range ps.proxiesByID {
+			proxiesHealth = append(proxiesHealth, NewProxyHealthInfo(proxy))
+		}
+	}:
+		<-pv.proxiedValThreadOpDoneCh
+
+	case <-pv.quit:
+		return nil, istanbul.ErrStoppedProxiedValidatorEngine
+
+	}
+
+	return proxiesHealth, nil
+}
+
 // run handles changes to proxies and validator assignments
 func (pv *proxiedValidatorEngine) threadRun() {
 	var (
@@ -393,6 +446,14 @@ func (pv *proxiedValidatorEngine) threadRun() {
 	schedulerTicker := time.NewTicker(schedulerPeriod)
 	defer schedulerTicker.Stop()
 
+	// Proxy health checks are disabled if the period is 0, in which case healthCheckTickerCh is never ready
+	var healthCheckTickerCh <-chan time.Time
+	if pv.config.ProxyHealthCheckPeriod > 0 {
+		healthCheckTicker := time.NewTicker(time.Duration(pv.config.ProxyHealthCheckPeriod) * time.Second)
+		defer healthCheckTicker.Stop()
+		healthCheckTickerCh = healthCheckTicker.C
+	}
+
 	pv.updateValidatorAssignments(ps)
 
 loop:
@@ -490,6 +551,17 @@ loop:
 		case fwdMsg := <-pv.sendFwdMsgsCh:
 			pv.sendForwardMsg(ps, fwdMsg.destAddresses, fwdMsg.ethMsgCode, fwdMsg.payload)
 
+		case <-healthCheckTickerCh:
+			// Fail the health checks that weren't answered within a period and probe the proxies again.
+			// Remote validators are reassigned away from the proxies that became unhealthy.
+			pv.sendProxyHealthChecks(ps)
+			pv.rebalanceProxies(ps)
+
+		case reportInfo := <-pv.proxyHealthReports:
+			// Got a health report from a proxy.  Remote validators are assigned back to it if it recovered.
+			pv.handleProxyHealthReport(ps, reportInfo)
+			pv.rebalanceProxies(ps)
+
 		case <-schedulerTicker.C:
 			logger.Trace("schedulerTicker ticked")
 
@@ -64,11 +64,19 @@ type BackendForProxyEngine interface {
 	// validator connection set and that the message's address field matches the message's signature's signer
 	VerifyValidatorConnectionSetSignature(data []byte, sig []byte) (common.Address, error)
 
+	// PeerCount returns the number of peers of this node
+	PeerCount() int
+
 	// GetProxy returns the proxy engine created for this Backend.  Note: This should be only used for the unit tests.
 	GetProxyEngine() ProxyEngine
 }
 
 type proxyEngine struct {
+	// Forward message counters, reported to the proxied validator in the health reports.
+	// Kept first for 64-bit alignment of the atomic operations.
+	forwardedMsgs  uint64 // atomic
+	failedForwards uint64 // atomic
+
 	config  *istanbul.Config
 	logger  log.Logger
 	backend BackendForProxyEngine
@@ -103,6 +111,8 @@ func (p *proxyEngine) HandleMsg(peer consensus.Peer, msgCode uint64, payload []b
 		return p.handleForwardMsg(peer, payload)
 	} else if msgCode == istanbul.ConsensusMsg {
 		return p.handleConsensusMsg(peer, payload)
+	} else if msgCode == istanbul.ProxyHealthCheckMsg {
+		return p.handleProxyHealthCheckMsg(peer, payload)
 	} else if msgCode == istanbul.EnodeCertificateMsg {
 		// See if the message is coming from the proxied validator
 		p.proxiedValidatorsMu.RLock()
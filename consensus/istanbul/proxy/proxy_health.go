@@ -0,0 +1,293 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/consensus"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/metrics"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
+	"github.com/celo-org/celo-blockchain/rlp"
+)
+
+// minProxyHealthyPeers is the minimum number of peers (other than its proxied validators) that a proxy
+// must have to pass a health check
+const minProxyHealthyPeers = 1
+
+var (
+	healthyProxiesGauge           = metrics.NewRegisteredGauge("consensus/istanbul/proxy/healthy", nil)
+	unhealthyProxiesGauge         = metrics.NewRegisteredGauge("consensus/istanbul/proxy/unhealthy", nil)
+	proxyHealthCheckFailuresMeter = metrics.NewRegisteredMeter("consensus/istanbul/proxy/healthcheck/failures", nil)
+	proxyHealthCheckLatencyTimer  = metrics.NewRegisteredTimer("consensus/istanbul/proxy/healthcheck/latency", nil)
+	proxyRebalancesMeter          = metrics.NewRegisteredMeter("consensus/istanbul/proxy/rebalances", nil)
+	forwardedMsgsMeter            = metrics.NewRegisteredMeter("consensus/istanbul/proxy/forward/sent", nil)
+	failedForwardsMeter           = metrics.NewRegisteredMeter("consensus/istanbul/proxy/forward/failed", nil)
+)
+
+// ==============================================
+//
+// define the health check messages.  They are not signed, since they are only accepted from
+// the directly peered proxied validator (for health checks) or proxies (for health reports).
+
+// proxyHealthCheck is sent by a proxied validator to probe one of its proxies
+type proxyHealthCheck struct {
+	Nonce uint64
+}
+
+// proxyHealthReport is the response of a proxy to a health check
+type proxyHealthReport struct {
+	Nonce          uint64
+	PeerCount      uint64 // Number of peers of the proxy, excluding its proxied validators
+	ForwardedMsgs  uint64 // Number of forward messages multicasted by the proxy since it started
+	FailedForwards uint64 // Number of forward messages that the proxy failed to multicast since it started
+}
+
+// ==============================================
+//
+// define the proxy health object, maintained by the proxied validator for each of its proxies
+
+// proxyHealth is the result of the health checks of a proxy.  Its zero value is a healthy proxy
+// that wasn't checked yet.
+type proxyHealth struct {
+	unhealthy           bool      // Set once maxFailures consecutive health checks failed
+	unassigned          bool      // Set if the proxy's remote validators were reassigned because it's unhealthy
+	consecutiveFailures uint64    // Number of consecutive failed health checks
+	nonce               uint64    // Nonce of the last health check sent to the proxy
+	pending             bool      // Whether the last health check is still unanswered
+	lastCheckTS         time.Time // Timestamp of the last health check sent to the proxy
+	lastReportTS        time.Time // Timestamp of the last health report received from the proxy
+	latency             time.Duration
+	lastReport          proxyHealthReport
+}
+
+// recordCheck records that a new health check is sent to the proxy, and returns its nonce.
+// If the previous health check is still unanswered, it is recorded as failed.
+func (h *proxyHealth) recordCheck(now time.Time, maxFailures uint64) uint64 {
+	if h.pending {
+		h.recordFailure(maxFailures)
+	}
+	h.nonce++
+	h.pending = true
+	h.lastCheckTS = now
+	return h.nonce
+}
+
+// recordFailure records a failed health check, and marks the proxy as unhealthy once
+// maxFailures consecutive checks failed
+func (h *proxyHealth) recordFailure(maxFailures uint64) {
+	proxyHealthCheckFailuresMeter.Mark(1)
+	h.consecutiveFailures++
+	if h.consecutiveFailures >= maxFailures {
+		h.unhealthy = true
+	}
+}
+
+// recordReport records the proxy's response to the last health check.  The check fails if the proxy
+// has too few peers, or if it failed to forward all the messages it received since the previous report.
+// Returns false if the report doesn't answer the last health check.
+func (h *proxyHealth) recordReport(report *proxyHealthReport, now time.Time, maxFailures uint64) bool {
+	if !h.pending || report.Nonce != h.nonce {
+		return false
+	}
+	h.pending = false
+	h.lastReportTS = now
+	h.latency = now.Sub(h.lastCheckTS)
+	proxyHealthCheckLatencyTimer.Update(h.latency)
+
+	// The counters are reset if the proxy restarted
+	forwarded, failed := report.ForwardedMsgs, report.FailedForwards
+	if forwarded >= h.lastReport.ForwardedMsgs && failed >= h.lastReport.FailedForwards {
+		forwarded -= h.lastReport.ForwardedMsgs
+		failed -= h.lastReport.FailedForwards
+	}
+	h.lastReport = *report
+
+	if report.PeerCount < minProxyHealthyPeers || (failed > 0 && forwarded == 0) {
+		h.recordFailure(maxFailures)
+	} else {
+		h.consecutiveFailures = 0
+		h.unhealthy = false
+	}
+	return true
+}
+
+// ProxyHealthInfo is used to provide the health of a proxy that can be given via an RPC
+type ProxyHealthInfo struct {
+	InternalNode        *enode.Node `json:"internalEnodeUrl"`
+	ExternalNode        *enode.Node `json:"externalEnodeUrl"`
+	IsPeered            bool        `json:"isPeered"`
+	IsHealthy           bool        `json:"isHealthy"`
+	IsAssigned          bool        `json:"isAssigned"` // False if the proxy's remote validators were reassigned because it's unhealthy
+	ConsecutiveFailures uint64      `json:"consecutiveFailures"`
+	Latency             int64       `json:"latencyMs"` // Round trip time of the last answered health check
+	PeerCount           uint64      `json:"peerCount"`
+	ForwardedMsgs       uint64      `json:"forwardedMessages"`
+	FailedForwards      uint64      `json:"failedForwards"`
+	LastCheckTS         int64       `json:"lastCheckTimestamp"`  // Unix time of the last health check (0 if never checked)
+	LastReportTS        int64       `json:"lastReportTimestamp"` // Unix time of the last health report (0 if never answered)
+}
+
+func NewProxyHealthInfo(p *Proxy) *ProxyHealthInfo {
+	unixTime := func(t time.Time) int64 {
+		if t.IsZero() {
+			return 0
+		}
+		return t.Unix()
+	}
+	return &ProxyHealthInfo{
+		InternalNode:        p.node,
+		ExternalNode:        p.ExternalNode(),
+		IsPeered:            p.IsPeered(),
+		IsHealthy:           !p.health.unhealthy,
+		IsAssigned:          !p.health.unassigned,
+		ConsecutiveFailures: p.health.consecutiveFailures,
+		Latency:             p.health.latency.Milliseconds(),
+		PeerCount:           p.health.lastReport.PeerCount,
+		ForwardedMsgs:       p.health.lastReport.ForwardedMsgs,
+		FailedForwards:      p.health.lastReport.FailedForwards,
+		LastCheckTS:         unixTime(p.health.lastCheckTS),
+		LastReportTS:        unixTime(p.health.lastReportTS),
+	}
+}
+
+// ==============================================
+//
+// define the proxied validator's health check functions
+
+type proxyHealthReportInfo struct {
+	peerID     enode.ID
+	report     *proxyHealthReport
+	receivedAt time.Time
+}
+
+// supportsHealthChecks returns whether the proxy peer negotiated a protocol version with the health
+// check messages.  Older proxies would drop the connection on a health check, so they are never
+// checked, and stay healthy.
+func supportsHealthChecks(peer consensus.Peer) bool {
+	return peer.Version() >= istanbul.Celo67
+}
+
+// sendProxyHealthChecks probes all the peered proxies supporting health checks, after failing the
+// health checks that weren't answered since the previous period
+func (pv *proxiedValidatorEngine) sendProxyHealthChecks(ps *proxySet) {
+	logger := pv.logger.New("func", "sendProxyHealthChecks")
+
+	now := time.Now()
+	for _, proxy := range ps.proxiesByID {
+		if proxy.peer == nil || !supportsHealthChecks(proxy.peer) {
+			continue
+		}
+		if proxy.health.pending {
+			logger.Debug("Proxy health check timed out", "proxy", proxy.String(), "nonce", proxy.health.nonce)
+		}
+		nonce := proxy.health.recordCheck(now, pv.config.ProxyHealthCheckMaxFailures)
+
+		payload, err := rlp.EncodeToBytes(&proxyHealthCheck{Nonce: nonce})
+		if err != nil {
+			logger.Error("Error encoding proxy health check", "err", err)
+			return
+		}
+		pv.backend.Unicast(proxy.peer, payload, istanbul.ProxyHealthCheckMsg)
+	}
+}
+
+// handleProxyHealthReport records the health report of a proxy
+func (pv *proxiedValidatorEngine) handleProxyHealthReport(ps *proxySet, reportInfo *proxyHealthReportInfo) {
+	logger := pv.logger.New("func", "handleProxyHealthReport")
+
+	proxy := ps.getProxy(reportInfo.peerID)
+	if proxy == nil || proxy.peer == nil {
+		logger.Debug("Ignoring health report from unknown or disconnected proxy", "peerID", reportInfo.peerID)
+		return
+	}
+	if !proxy.health.recordReport(reportInfo.report, reportInfo.receivedAt, pv.config.ProxyHealthCheckMaxFailures) {
+		logger.Debug("Ignoring stale proxy health report", "proxy", proxy.String(), "nonce", reportInfo.report.Nonce)
+		return
+	}
+	logger.Trace("Received proxy health report", "proxy", proxy.String(), "latency", proxy.health.latency, "peerCount", reportInfo.report.PeerCount, "unhealthy", proxy.health.unhealthy)
+}
+
+// rebalanceProxies reassigns the remote validators according to the proxies' health, and
+// updates the proxy health metrics
+func (pv *proxiedValidatorEngine) rebalanceProxies(ps *proxySet) {
+	if valsReassigned := ps.rebalanceUnhealthyProxies(); valsReassigned {
+		pv.logger.Info("Remote validator to proxy assignment has changed after proxy health checks.  Sending val enode share messages and updating announce version")
+		proxyRebalancesMeter.Mark(1)
+		pv.backend.UpdateAnnounceVersion()
+		pv.sendValEnodeShareMsgs(ps)
+	}
+
+	var healthy, unhealthy int64
+	for _, proxy := range ps.proxiesByID {
+		if proxy.peer == nil {
+			continue
+		}
+		if proxy.health.unhealthy {
+			unhealthy++
+		} else {
+			healthy++
+		}
+	}
+	healthyProxiesGauge.Update(healthy)
+	unhealthyProxiesGauge.Update(unhealthy)
+}
+
+// ==============================================
+//
+// define the proxy's health check functions
+
+func (p *proxyEngine) handleProxyHealthCheckMsg(peer consensus.Peer, payload []byte) (bool, error) {
+	logger := p.logger.New("func", "handleProxyHealthCheckMsg")
+
+	// Verify that it's coming from the proxied validator
+	p.proxiedValidatorsMu.RLock()
+	fromProxiedValidator := p.proxiedValidatorIDs[peer.Node().ID()]
+	numProxiedValidators := len(p.proxiedValidators)
+	p.proxiedValidatorsMu.RUnlock()
+	if !fromProxiedValidator {
+		logger.Warn("Got a proxy health check message from a peer that is not the proxy's proxied validator. Ignoring it", "from", peer.Node().ID())
+		return false, nil
+	}
+
+	var check proxyHealthCheck
+	if err := rlp.DecodeBytes(payload, &check); err != nil {
+		logger.Error("Error in decoding received proxy health check message", "err", err)
+		return true, err
+	}
+
+	peerCount := p.backend.PeerCount() - numProxiedValidators
+	if peerCount < 0 {
+		peerCount = 0
+	}
+	report := &proxyHealthReport{
+		Nonce:          check.Nonce,
+		PeerCount:      uint64(peerCount),
+		ForwardedMsgs:  atomic.LoadUint64(&p.forwardedMsgs),
+		FailedForwards: atomic.LoadUint64(&p.failedForwards),
+	}
+	reportBytes, err := rlp.EncodeToBytes(report)
+	if err != nil {
+		logger.Error("Error encoding proxy health report", "err", err)
+		return true, err
+	}
+	p.backend.Unicast(peer, reportBytes, istanbul.ProxyHealthReportMsg)
+
+	return true, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus"
+	"github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/p2p"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
+)
+
+func TestProxyHealth(t *testing.T) {
+	const maxFailures = 2
+	var h proxyHealth
+	now := time.Now()
+
+	// An unanswered health check fails once the next one is sent
+	h.recordCheck(now, maxFailures)
+	nonce := h.recordCheck(now, maxFailures)
+	if h.consecutiveFailures != 1 || h.unhealthy {
+		t.Fatalf("after a timeout: have %d failures (unhealthy %v), want 1 (healthy)", h.consecutiveFailures, h.unhealthy)
+	}
+
+	// Stale reports are ignored
+	if h.recordReport(&proxyHealthReport{Nonce: nonce - 1, PeerCount: 10}, now, maxFailures) {
+		t.Errorf("stale report should be ignored")
+	}
+
+	// A proxy without peers fails the check, and becomes unhealthy
+	if !h.recordReport(&proxyHealthReport{Nonce: nonce, PeerCount: 0}, now.Add(time.Second), maxFailures) {
+		t.Fatalf("report should be recorded")
+	}
+	if !h.unhealthy || h.latency != time.Second {
+		t.Fatalf("proxy without peers: have unhealthy %v (latency %v), want unhealthy (1s)", h.unhealthy, h.latency)
+	}
+	if h.recordReport(&proxyHealthReport{Nonce: nonce, PeerCount: 10}, now, maxFailures) {
+		t.Errorf("a health check can only be answered once")
+	}
+
+	// A successful check makes it healthy again
+	nonce = h.recordCheck(now, maxFailures)
+	h.recordReport(&proxyHealthReport{Nonce: nonce, PeerCount: 10, ForwardedMsgs: 5, FailedForwards: 1}, now, maxFailures)
+	if h.unhealthy || h.consecutiveFailures != 0 {
+		t.Fatalf("after a successful check: have unhealthy %v with %d failures, want healthy", h.unhealthy, h.consecutiveFailures)
+	}
+
+	// Failing to forward all of the messages since the previous report fails the check
+	nonce = h.recordCheck(now, maxFailures)
+	h.recordReport(&proxyHealthReport{Nonce: nonce, PeerCount: 10, ForwardedMsgs: 5, FailedForwards: 3}, now, maxFailures)
+	if h.consecutiveFailures != 1 {
+		t.Errorf("after failed forwards: have %d failures, want 1", h.consecutiveFailures)
+	}
+
+	// Counters of a restarted proxy are used as is
+	nonce = h.recordCheck(now, maxFailures)
+	h.recordReport(&proxyHealthReport{Nonce: nonce, PeerCount: 10, ForwardedMsgs: 1, FailedForwards: 0}, now, maxFailures)
+	if h.consecutiveFailures != 0 {
+		t.Errorf("after proxy restart: have %d failures, want 0", h.consecutiveFailures)
+	}
+}
+
+func TestRebalanceUnhealthyProxies(t *testing.T) {
+	ps := newProxySet(newConsistentHashingPolicy())
+
+	proxyIDs := make([]enode.ID, 3)
+	for i := range proxyIDs {
+		proxyConfig := createProxyConfig(int64(i))
+		proxyIDs[i] = proxyConfig.InternalNode.ID()
+		ps.addProxy(proxyConfig)
+		ps.setProxyPeer(proxyIDs[i], consensustest.NewMockPeer(proxyConfig.InternalNode, p2p.ProxyPurpose))
+	}
+
+	remoteVals := make([]common.Address, 20)
+	for i := range remoteVals {
+		remoteVals[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	ps.addRemoteValidators(remoteVals)
+
+	assignedVals := func(proxyID enode.ID) int {
+		return len(ps.getValidatorAssignments(nil, []enode.ID{proxyID}))
+	}
+	// The proxy keys are random, so start with a proxy which was assigned validators
+	for i, proxyID := range proxyIDs {
+		if assignedVals(proxyID) > 0 {
+			proxyIDs[0], proxyIDs[i] = proxyIDs[i], proxyIDs[0]
+			break
+		}
+	}
+
+	// Unhealthy proxies are unassigned
+	ps.getProxy(proxyIDs[0]).health.unhealthy = true
+	if !ps.rebalanceUnhealthyProxies() {
+		t.Fatalf("validators should be reassigned")
+	}
+	if n := assignedVals(proxyIDs[0]); n != 0 {
+		t.Errorf("unhealthy proxy has %d validators assigned, want 0", n)
+	}
+	if n := assignedVals(proxyIDs[1]) + assignedVals(proxyIDs[2]); n != len(remoteVals) {
+		t.Errorf("healthy proxies have %d validators assigned, want %d", n, len(remoteVals))
+	}
+	if ps.rebalanceUnhealthyProxies() {
+		t.Errorf("rebalancing again should not change the assignments")
+	}
+
+	// The last healthy proxies keep their validators
+	ps.getProxy(proxyIDs[1]).health.unhealthy = true
+	ps.getProxy(proxyIDs[2]).health.unhealthy = true
+	if ps.rebalanceUnhealthyProxies() {
+		t.Errorf("validators should not be reassigned when no proxy is healthy")
+	}
+	if n := assignedVals(proxyIDs[1]) + assignedVals(proxyIDs[2]); n != len(remoteVals) {
+		t.Errorf("unhealthy proxies have %d validators assigned, want %d", n, len(remoteVals))
+	}
+
+	// Recovered proxies are assigned again
+	ps.getProxy(proxyIDs[0]).health.unhealthy = false
+	ps.rebalanceUnhealthyProxies()
+	if n := assignedVals(proxyIDs[0]); n != len(remoteVals) {
+		t.Errorf("recovered proxy has %d validators assigned, want %d", n, len(remoteVals))
+	}
+	for _, proxyID := range proxyIDs[1:] {
+		if !ps.getProxy(proxyID).health.unassigned {
+			t.Errorf("unhealthy proxy %v should be unassigned once another proxy recovered", proxyID)
+		}
+	}
+
+	// Reconnected proxies are considered healthy
+	proxy := ps.getProxy(proxyIDs[1])
+	ps.removeProxyPeer(proxyIDs[1])
+	ps.setProxyPeer(proxyIDs[1], consensustest.NewMockPeer(proxy.node, p2p.ProxyPurpose))
+	if proxy.health.unhealthy || proxy.health.unassigned || assignedVals(proxyIDs[1]) == 0 {
+		t.Errorf("reconnected proxy should be healthy and assigned validators")
+	}
+}
+
+// versionedPeer is a mock peer which negotiated the given protocol version
+type versionedPeer struct {
+	*consensustest.MockPeer
+	version int
+}
+
+func (p *versionedPeer) Version() int { return p.version }
+
+// unicastRecorder records the peers unicasted to
+type unicastRecorder struct {
+	BackendForProxiedValidatorEngine
+	peers []consensus.Peer
+}
+
+func (b *unicastRecorder) Unicast(peer consensus.Peer, payload []byte, ethMsgCode uint64) {
+	b.peers = append(b.peers, peer)
+}
+
+func TestSendProxyHealthChecks(t *testing.T) {
+	ps := newProxySet(newConsistentHashingPolicy())
+
+	proxyIDs := make([]enode.ID, 2)
+	for i, version := range []int{istanbul.Celo66, istanbul.Celo67} {
+		proxyConfig := createProxyConfig(int64(i))
+		proxyIDs[i] = proxyConfig.InternalNode.ID()
+		ps.addProxy(proxyConfig)
+		ps.setProxyPeer(proxyIDs[i], &versionedPeer{consensustest.NewMockPeer(proxyConfig.InternalNode, p2p.ProxyPurpose), version})
+	}
+	backend := new(unicastRecorder)
+	pv := &proxiedValidatorEngine{config: istanbul.DefaultConfig, logger: log.New(), backend: backend}
+
+	// Proxies with an older protocol version are never checked, so they can't become unhealthy
+	for i := uint64(0); i <= istanbul.DefaultConfig.ProxyHealthCheckMaxFailures; i++ {
+		pv.sendProxyHealthChecks(ps)
+	}
+	if len(backend.peers) != int(istanbul.DefaultConfig.ProxyHealthCheckMaxFailures)+1 || backend.peers[0].Node().ID() != proxyIDs[1] {
+		t.Errorf("health checks sent to %d peers, want %d to the up to date proxy", len(backend.peers), istanbul.DefaultConfig.ProxyHealthCheckMaxFailures+1)
+	}
+	if old := ps.getProxy(proxyIDs[0]); old.health.unhealthy || !old.health.lastCheckTS.IsZero() {
+		t.Errorf("proxy with an older protocol version was checked")
+	}
+	if !ps.getProxy(proxyIDs[1]).health.unhealthy {
+		t.Errorf("proxy which never answered should be unhealthy")
+	}
+}
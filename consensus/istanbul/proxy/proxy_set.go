@@ -96,6 +96,8 @@ func (ps *proxySet) setProxyPeer(proxyID enode.ID, peer consensus.Peer) bool {
 	valsReassigned := false
 	if proxy != nil {
 		proxy.peer = peer
+		// A reconnected proxy is considered healthy until its health checks fail again
+		proxy.health = proxyHealth{}
 		logger.Trace("Assigning validators to proxy", "proxyID", proxyID)
 		valsReassigned = ps.valAssigner.assignProxy(proxy, ps.valAssignments)
 	}
@@ -195,6 +197,39 @@ func (ps *proxySet) unassignDisconnectedProxies(minAge time.Duration) bool {
 	return valsReassigned
 }
 
+// rebalanceUnhealthyProxies unassigns the peered proxies that are unhealthy, so that their remote
+// validators get reassigned to the healthy proxies, and assigns the proxies that recovered again.
+// Unhealthy proxies keep their validators if there is no healthy peered proxy to reassign them to.
+func (ps *proxySet) rebalanceUnhealthyProxies() bool {
+	logger := ps.logger.New("func", "rebalanceUnhealthyProxies")
+
+	hasHealthyProxy := false
+	for _, proxy := range ps.proxiesByID {
+		if proxy.peer != nil && !proxy.health.unhealthy {
+			hasHealthyProxy = true
+			break
+		}
+	}
+
+	valsReassigned := false
+	for _, proxy := range ps.proxiesByID {
+		if proxy.peer == nil {
+			continue
+		}
+		if proxy.health.unhealthy && !proxy.health.unassigned && hasHealthyProxy {
+			logger.Warn("Unassigning unhealthy proxy", "proxy", proxy.String(), "consecutiveFailures", proxy.health.consecutiveFailures)
+			proxy.health.unassigned = true
+			valsReassigned = ps.valAssigner.removeProxy(proxy, ps.valAssignments) || valsReassigned
+		} else if !proxy.health.unhealthy && proxy.health.unassigned {
+			logger.Info("Assigning recovered proxy", "proxy", proxy.String())
+			proxy.health.unassigned = false
+			valsReassigned = ps.valAssigner.assignProxy(proxy, ps.valAssignments) || valsReassigned
+		}
+	}
+
+	return valsReassigned
+}
+
 // getValidators returns all validators that are known by the proxy set
 func (ps *proxySet) getValidators() []common.Address {
 	return ps.valAssignments.getValidators()
@@ -126,6 +126,13 @@ type ProxiedValidatorEngine interface {
 
 	// NewEpoch will notify the proxied validator's thread that a new epoch started
 	NewEpoch() error
+
+	// HandleMsg is the `celo` subprotocol message handler for the messages that proxied validators
+	// receive from their proxies.
+	HandleMsg(peer consensus.Peer, msgCode uint64, payload []byte) (bool, error)
+
+	// GetProxiesHealth will retrieve the health of all of the proxies.
+	GetProxiesHealth() ([]*ProxyHealthInfo, error)
 }
 
 // ==============================================
@@ -137,6 +144,7 @@ type Proxy struct {
 	externalNode *enode.Node    // Enode for the external network interface
 	peer         consensus.Peer // Connected proxy peer.  Is nil if this node is not connected to the proxy
 	disconnectTS time.Time      // Timestamp when this proxy's peer last disconnected. Initially set to the timestamp of when the proxy was added
+	health       proxyHealth    // Result of the health checks of the connected proxy peer
 }
 
 func (p *Proxy) ID() enode.ID {
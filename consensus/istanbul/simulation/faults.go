@@ -0,0 +1,122 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// faultInjector holds the faults of the current step, and decides the fate of every message sent
+// between two validators.
+type faultInjector struct {
+	mu        sync.RWMutex
+	links     []LinkFault
+	groups    map[int]int // Partition group of each partitioned validator
+	byzantine map[int]string
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+func newFaultInjector(seed int64) *faultInjector {
+	return &faultInjector{
+		groups:    make(map[int]int),
+		byzantine: make(map[int]string),
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// apply replaces the current faults with the faults of the step
+func (f *faultInjector) apply(step *Step) {
+	groups := make(map[int]int)
+	for i, group := range step.Partition {
+		for _, index := range group {
+			groups[index] = i + 1
+		}
+	}
+	byzantine := make(map[int]string)
+	for _, fault := range step.Byzantine {
+		byzantine[fault.Validator] = fault.Mode
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.links = step.Links
+	f.groups = groups
+	f.byzantine = byzantine
+}
+
+// link returns whether a message sent by validator from to validator to must be dropped, and
+// otherwise how long it is delayed.
+func (f *faultInjector) link(from, to int) (bool, time.Duration) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Validators missing from the partition groups are in group 0
+	if f.groups[from] != f.groups[to] {
+		return true, 0
+	}
+
+	var delay time.Duration
+	for _, link := range f.links {
+		if !contains(link.From, from) || !contains(link.To, to) {
+			continue
+		}
+		if link.DropRate > 0 && f.float64() < link.DropRate {
+			return true, 0
+		}
+		delay += time.Duration(link.Delay) * time.Millisecond
+		if link.Jitter > 0 {
+			delay += time.Duration(f.int63n(int64(link.Jitter))) * time.Millisecond
+		}
+	}
+	return false, delay
+}
+
+// byzantineMode returns how a validator misbehaves, or the empty string if it's honest
+func (f *faultInjector) byzantineMode(validator int) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.byzantine[validator]
+}
+
+func (f *faultInjector) float64() float64 {
+	f.randMu.Lock()
+	defer f.randMu.Unlock()
+	return f.rand.Float64()
+}
+
+func (f *faultInjector) int63n(n int64) int64 {
+	f.randMu.Lock()
+	defer f.randMu.Unlock()
+	return f.rand.Int63n(n)
+}
+
+// contains returns whether the validator is in the list, or the list is empty
+func contains(validators []int, validator int) bool {
+	if len(validators) == 0 {
+		return true
+	}
+	for _, index := range validators {
+		if index == validator {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/types"
+)
+
+// BlockReport holds the statistics of a block committed during a scenario
+type BlockReport struct {
+	Number    uint64         `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	Proposer  common.Address `json:"proposer"`
+	Round     uint64         `json:"round"`     // Round at which the block was committed
+	BlockTime time.Duration  `json:"blockTime"` // Time between the first commits of the parent and of the block
+	Finality  time.Duration  `json:"finality"`  // Time between the first commit of the parent and a quorum of validators committing the block, zero if no quorum committed it
+	Commits   int            `json:"commits"`   // Number of validators which committed the block
+}
+
+// Report holds the statistics of a scenario
+type Report struct {
+	Scenario     string        `json:"scenario"`
+	Validators   int           `json:"validators"`
+	Completed    bool          `json:"completed"` // Whether the scenario produced all of its blocks in time
+	Duration     time.Duration `json:"duration"`
+	RoundChanges uint64        `json:"roundChanges"`
+	Conflicts    int           `json:"conflicts"` // Number of blocks committed by a validator which conflict with the blocks committed by other validators
+	AvgBlockTime time.Duration `json:"avgBlockTime"`
+	MaxBlockTime time.Duration `json:"maxBlockTime"`
+	AvgFinality  time.Duration `json:"avgFinality"`
+	MaxFinality  time.Duration `json:"maxFinality"`
+	Blocks       []BlockReport `json:"blocks"`
+}
+
+// Write prints the report as a table
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 1, 2, 2, ' ', 0)
+	fmt.Fprintf(tw, "SCENARIO\t%s\n", r.Scenario)
+	fmt.Fprintf(tw, "VALIDATORS\t%d\n", r.Validators)
+	fmt.Fprintf(tw, "COMPLETED\t%v\n", r.Completed)
+	fmt.Fprintf(tw, "DURATION\t%v\n", r.Duration)
+	fmt.Fprintf(tw, "BLOCKS\t%d\n", len(r.Blocks))
+	fmt.Fprintf(tw, "ROUND CHANGES\t%d\n", r.RoundChanges)
+	fmt.Fprintf(tw, "CONFLICTS\t%d\n", r.Conflicts)
+	fmt.Fprintf(tw, "BLOCK TIME\tavg %v, max %v\n", r.AvgBlockTime, r.MaxBlockTime)
+	fmt.Fprintf(tw, "FINALITY\tavg %v, max %v\n", r.AvgFinality, r.MaxFinality)
+	fmt.Fprintf(tw, "\nNUMBER\tROUND\tBLOCK TIME\tFINALITY\tCOMMITS\tPROPOSER\n")
+	for _, block := range r.Blocks {
+		fmt.Fprintf(tw, "%d\t%d\t%v\t%v\t%d\t%s\n", block.Number, block.Round, block.BlockTime, block.Finality, block.Commits, block.Proposer.Hex())
+	}
+	return tw.Flush()
+}
+
+// blockRecord holds the commits of a block by the validators
+type blockRecord struct {
+	hash     common.Hash
+	proposer common.Address
+	round    uint64
+	commits  []time.Time
+}
+
+// recorder records the blocks committed by the validators
+type recorder struct {
+	mu        sync.Mutex
+	start     time.Time
+	quorum    int
+	head      uint64 // Highest block committed by a quorum of validators
+	blocks    map[uint64]*blockRecord
+	conflicts int
+	newHead   chan struct{}
+}
+
+func newRecorder(validators int) *recorder {
+	return &recorder{
+		quorum:  (2*validators + 2) / 3,
+		blocks:  make(map[uint64]*blockRecord),
+		newHead: make(chan struct{}, 1),
+	}
+}
+
+// commit records that a validator committed the block
+func (r *recorder) commit(block *types.Block, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := r.blocks[block.NumberU64()]
+	if record == nil {
+		record = &blockRecord{hash: block.Hash(), proposer: block.Coinbase()}
+		if extra, err := types.ExtractIstanbulExtra(block.Header()); err == nil && extra.AggregatedSeal.Round != nil {
+			record.round = extra.AggregatedSeal.Round.Uint64()
+		}
+		r.blocks[block.NumberU64()] = record
+	} else if record.hash != block.Hash() {
+		r.conflicts++
+		return
+	}
+	record.commits = append(record.commits, at)
+
+	if len(record.commits) >= r.quorum && block.NumberU64() > r.head {
+		r.head = block.NumberU64()
+		select {
+		case r.newHead <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// highestBlock returns the highest block committed by a quorum of validators
+func (r *recorder) highestBlock() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.head
+}
+
+// report computes the statistics of the blocks committed by a quorum of validators since the start
+// of the scenario
+func (r *recorder) report(scenario *Scenario, completed bool) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Scenario:   scenario.Name,
+		Validators: scenario.Validators,
+		Completed:  completed,
+		Conflicts:  r.conflicts,
+		Duration:   time.Since(r.start),
+		Blocks:     make([]BlockReport, 0, len(r.blocks)),
+	}
+	var totalBlockTime, totalFinality time.Duration
+	var finalized int
+	parentCommit := r.start
+	for number := uint64(1); number <= r.head; number++ {
+		record := r.blocks[number]
+		if record == nil {
+			break
+		}
+		sort.Slice(record.commits, func(i, j int) bool { return record.commits[i].Before(record.commits[j]) })
+
+		block := BlockReport{
+			Number:    number,
+			Hash:      record.hash,
+			Proposer:  record.proposer,
+			Round:     record.round,
+			BlockTime: record.commits[0].Sub(parentCommit),
+			Commits:   len(record.commits),
+		}
+		if len(record.commits) >= r.quorum {
+			block.Finality = record.commits[r.quorum-1].Sub(parentCommit)
+			totalFinality += block.Finality
+			finalized++
+			if block.Finality > report.MaxFinality {
+				report.MaxFinality = block.Finality
+			}
+		}
+		totalBlockTime += block.BlockTime
+		if block.BlockTime > report.MaxBlockTime {
+			report.MaxBlockTime = block.BlockTime
+		}
+		report.RoundChanges += record.round
+		report.Blocks = append(report.Blocks, block)
+		parentCommit = record.commits[0]
+	}
+	if len(report.Blocks) > 0 {
+		report.AvgBlockTime = totalBlockTime / time.Duration(len(report.Blocks))
+	}
+	if finalized > 0 {
+		report.AvgFinality = totalFinality / time.Duration(finalized)
+	}
+	return report
+}
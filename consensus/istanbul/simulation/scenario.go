@@ -0,0 +1,183 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Byzantine behaviours of a validator
+const (
+	// ByzantineSilent validators never propose blocks, but still vote for the other proposals
+	ByzantineSilent = "silent"
+	// ByzantineInvalid validators propose blocks with an invalid state root
+	ByzantineInvalid = "invalid"
+)
+
+// Scenario describes a network of validators, and the faults injected while it produces blocks.
+type Scenario struct {
+	Name           string `json:"name"`
+	Validators     int    `json:"validators"`
+	Blocks         uint64 `json:"blocks"`         // The scenario ends once a quorum of validators committed this block
+	Epoch          uint64 `json:"epoch"`          // Epoch size, in blocks
+	BlockPeriod    uint64 `json:"blockPeriod"`    // Minimum time between blocks, in seconds
+	RequestTimeout uint64 `json:"requestTimeout"` // Timeout of the first round of a sequence, in milliseconds
+	Steps          []Step `json:"steps"`
+}
+
+// Step replaces the faults of the network. Steps are applied in order: a step is applied once a quorum
+// of validators committed the AtBlock block, and After milliseconds passed since the previous step.
+// Leading steps without conditions are applied before the validators start.
+type Step struct {
+	AtBlock   uint64           `json:"atBlock,omitempty"`
+	After     uint64           `json:"after,omitempty"`
+	Links     []LinkFault      `json:"links,omitempty"`
+	Partition [][]int          `json:"partition,omitempty"` // Validators missing from the groups form another group
+	Byzantine []ByzantineFault `json:"byzantine,omitempty"`
+}
+
+// LinkFault drops and delays the messages sent by validators From to validators To. All validators
+// match an empty list.
+type LinkFault struct {
+	From     []int   `json:"from,omitempty"`
+	To       []int   `json:"to,omitempty"`
+	DropRate float64 `json:"dropRate,omitempty"` // Probability of a message to be dropped
+	Delay    uint64  `json:"delay,omitempty"`    // Delay of the messages, in milliseconds
+	Jitter   uint64  `json:"jitter,omitempty"`   // Maximum random delay added to Delay, in milliseconds
+}
+
+// ByzantineFault makes a validator misbehave
+type ByzantineFault struct {
+	Validator int    `json:"validator"`
+	Mode      string `json:"mode"`
+}
+
+// Scenarios are the built-in scenarios
+var Scenarios = map[string]*Scenario{
+	"healthy": {
+		Name:       "healthy",
+		Validators: 10,
+		Blocks:     20,
+	},
+	"latency": {
+		Name:       "latency",
+		Validators: 20,
+		Blocks:     20,
+		Steps: []Step{
+			{Links: []LinkFault{{Delay: 100, Jitter: 200}}},
+		},
+	},
+	"lossy": {
+		Name:       "lossy",
+		Validators: 20,
+		Blocks:     20,
+		Steps: []Step{
+			{Links: []LinkFault{{DropRate: 0.1, Delay: 50}}},
+		},
+	},
+	"partition": {
+		Name:       "partition",
+		Validators: 10,
+		Blocks:     30,
+		Steps: []Step{
+			// No quorum on either side of the partition
+			{AtBlock: 5, Partition: [][]int{{0, 1, 2, 3, 4}}},
+			{After: 5000},
+			// The majority keeps producing blocks, and the minority catches up once healed
+			{AtBlock: 15, Partition: [][]int{{0, 1}}},
+			{AtBlock: 20},
+		},
+	},
+	"byzantine": {
+		Name:       "byzantine",
+		Validators: 10,
+		Blocks:     30,
+		Steps: []Step{
+			{Byzantine: []ByzantineFault{{Validator: 0, Mode: ByzantineSilent}, {Validator: 1, Mode: ByzantineInvalid}, {Validator: 2, Mode: ByzantineInvalid}}},
+		},
+	},
+}
+
+// LoadScenario reads a scenario from a JSON file
+func LoadScenario(path string) (*Scenario, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenario := new(Scenario)
+	if err := json.Unmarshal(blob, scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %v", path, err)
+	}
+	return scenario, nil
+}
+
+// withDefaults returns a copy of the scenario with the unset parameters set to their defaults
+func (s *Scenario) withDefaults() *Scenario {
+	scenario := *s
+	if scenario.Epoch == 0 {
+		scenario.Epoch = 1000
+	}
+	if scenario.RequestTimeout == 0 {
+		scenario.RequestTimeout = 1000
+	}
+	return &scenario
+}
+
+func (s *Scenario) validate() error {
+	if s.Validators < 1 {
+		return fmt.Errorf("scenario needs at least one validator, have %d", s.Validators)
+	}
+	if s.Blocks == 0 {
+		return fmt.Errorf("scenario needs to produce at least one block")
+	}
+	checkValidator := func(index int) error {
+		if index < 0 || index >= s.Validators {
+			return fmt.Errorf("unknown validator %d", index)
+		}
+		return nil
+	}
+	for _, step := range s.Steps {
+		for _, link := range step.Links {
+			if link.DropRate < 0 || link.DropRate > 1 {
+				return fmt.Errorf("invalid drop rate %v at block %d", link.DropRate, step.AtBlock)
+			}
+			for _, index := range append(append([]int{}, link.From...), link.To...) {
+				if err := checkValidator(index); err != nil {
+					return err
+				}
+			}
+		}
+		for _, group := range step.Partition {
+			for _, index := range group {
+				if err := checkValidator(index); err != nil {
+					return err
+				}
+			}
+		}
+		for _, byzantine := range step.Byzantine {
+			if err := checkValidator(byzantine.Validator); err != nil {
+				return err
+			}
+			if byzantine.Mode != ByzantineSilent && byzantine.Mode != ByzantineInvalid {
+				return fmt.Errorf("unknown byzantine mode %q", byzantine.Mode)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulation runs networks of istanbul validators on top of the p2p network simulator, to
+// measure how consensus behaves under scripted faults.
+//
+// Each validator runs a full istanbul backend on an in-memory chain. No core contracts are deployed in
+// the genesis block, so the backends fall back to the genesis validator set and to the default values
+// of the on-chain parameters.
+package simulation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/backend"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/crypto"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/node"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
+	"github.com/celo-org/celo-blockchain/p2p/simulations"
+	"github.com/celo-org/celo-blockchain/p2p/simulations/adapters"
+	"github.com/celo-org/celo-blockchain/params"
+)
+
+// serviceName is the name of the validator service in the simulation network
+const serviceName = "istanbul"
+
+// simulation holds the state shared by the validators of a scenario
+type simulation struct {
+	scenario  *Scenario
+	keys      []*ecdsa.PrivateKey
+	indexes   map[enode.ID]int
+	genesis   *core.Genesis
+	valEnodes map[common.Address]*istanbul.AddressEntry
+	faults    *faultInjector
+	recorder  *recorder
+}
+
+func newSimulation(scenario *Scenario) (*simulation, error) {
+	sim := &simulation{
+		scenario:  scenario,
+		keys:      make([]*ecdsa.PrivateKey, scenario.Validators),
+		indexes:   make(map[enode.ID]int),
+		valEnodes: make(map[common.Address]*istanbul.AddressEntry),
+		faults:    newFaultInjector(time.Now().UnixNano()),
+		recorder:  newRecorder(scenario.Validators),
+	}
+
+	validators := make([]istanbul.ValidatorData, scenario.Validators)
+	for i := range sim.keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		blsPrivateKey, err := blscrypto.ECDSAToBLS(key)
+		if err != nil {
+			return nil, err
+		}
+		blsPublicKey, err := blscrypto.PrivateToPublic(blsPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		sim.keys[i] = key
+		sim.indexes[enode.PubkeyToIDV4(&key.PublicKey)] = i
+		validators[i] = istanbul.ValidatorData{
+			Address:      crypto.PubkeyToAddress(key.PublicKey),
+			BLSPublicKey: blsPublicKey,
+		}
+	}
+
+	chainConfig := *params.IstanbulTestChainConfig
+	chainConfig.Istanbul = &params.IstanbulConfig{
+		Epoch:          scenario.Epoch,
+		LookbackWindow: 3,
+		BlockPeriod:    scenario.BlockPeriod,
+		RequestTimeout: scenario.RequestTimeout,
+	}
+	sim.genesis = &core.Genesis{
+		Config:    &chainConfig,
+		Timestamp: uint64(time.Now().Unix()),
+		Alloc:     core.GenesisAlloc{},
+	}
+	backend.AppendValidatorsToGenesisBlock(sim.genesis, validators)
+	return sim, nil
+}
+
+// validatorIndex returns the index of the validator running on the node
+func (sim *simulation) validatorIndex(id enode.ID) (int, bool) {
+	index, ok := sim.indexes[id]
+	return index, ok
+}
+
+// newService implements adapters.ServiceFunc
+func (sim *simulation) newService(ctx *adapters.ServiceContext) (node.Service, error) {
+	index, ok := sim.validatorIndex(ctx.Config.ID)
+	if !ok {
+		return nil, fmt.Errorf("node %v isn't a validator", ctx.Config.ID)
+	}
+	return newValidator(sim, index, sim.keys[index])
+}
+
+// Run runs the scenario on a fully connected network of validators, until a quorum of validators
+// committed the last block of the scenario or the context is done. The report of an interrupted scenario isn't
+// marked as completed.
+func Run(ctx context.Context, scenario *Scenario) (*Report, error) {
+	scenario = scenario.withDefaults()
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+	sim, err := newSimulation(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter := adapters.NewSimAdapter(adapters.Services{serviceName: sim.newService})
+	network := simulations.NewNetwork(adapter, &simulations.NetworkConfig{
+		ID:             scenario.Name,
+		DefaultService: serviceName,
+	})
+	defer network.Shutdown()
+
+	ids := make([]enode.ID, len(sim.keys))
+	for i, key := range sim.keys {
+		conf := &adapters.NodeConfig{
+			ID:         enode.PubkeyToIDV4(&key.PublicKey),
+			PrivateKey: key,
+			Name:       fmt.Sprintf("validator%02d", i),
+		}
+		if _, err := network.NewNodeWithConfig(conf); err != nil {
+			return nil, err
+		}
+		ids[i] = conf.ID
+		// The val enode table stores enode URLs, which can't hold the dummy records of simulated nodes
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		node := enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303)
+		sim.valEnodes[address] = &istanbul.AddressEntry{Address: address, PublicKey: &key.PublicKey, Node: node, Version: 1}
+	}
+
+	// Apply the leading steps without conditions before the validators start
+	steps := scenario.Steps
+	for len(steps) > 0 && steps[0].AtBlock == 0 && steps[0].After == 0 {
+		sim.faults.apply(&steps[0])
+		steps = steps[1:]
+	}
+
+	sim.recorder.start = time.Now()
+	if err := network.StartAll(); err != nil {
+		return nil, err
+	}
+	if err := network.ConnectNodesFull(ids); err != nil {
+		return nil, err
+	}
+	log.Info("Started istanbul simulation", "scenario", scenario.Name, "validators", scenario.Validators, "blocks", scenario.Blocks)
+
+	stepTimer := time.NewTimer(0)
+	defer stepTimer.Stop()
+	lastStep := time.Now()
+	for {
+		head := sim.recorder.highestBlock()
+		for len(steps) > 0 && head >= steps[0].AtBlock {
+			wait := time.Duration(steps[0].After)*time.Millisecond - time.Since(lastStep)
+			if wait > 0 {
+				stepTimer.Reset(wait)
+				break
+			}
+			log.Info("Applying simulation step", "scenario", scenario.Name, "number", head, "step", len(scenario.Steps)-len(steps))
+			sim.faults.apply(&steps[0])
+			steps = steps[1:]
+			lastStep = time.Now()
+		}
+		if head >= scenario.Blocks {
+			return sim.recorder.report(scenario, true), nil
+		}
+
+		select {
+		case <-sim.recorder.newHead:
+		case <-stepTimer.C:
+		case <-ctx.Done():
+			return sim.recorder.report(scenario, false), nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func runScenario(t *testing.T, scenario *Scenario) *Report {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report, err := Run(ctx, scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testing.Verbose() {
+		report.Write(os.Stdout)
+	}
+	if !report.Completed {
+		t.Fatalf("scenario %s didn't complete, committed %d blocks", scenario.Name, len(report.Blocks))
+	}
+	if report.Conflicts != 0 {
+		t.Fatalf("scenario %s committed %d conflicting blocks", scenario.Name, report.Conflicts)
+	}
+	return report
+}
+
+func TestHealthyNetwork(t *testing.T) {
+	report := runScenario(t, &Scenario{
+		Name:       "healthy",
+		Validators: 4,
+		Blocks:     5,
+		Steps: []Step{
+			{Links: []LinkFault{{Delay: 10, Jitter: 10}}},
+		},
+	})
+	if report.RoundChanges != 0 {
+		t.Errorf("round changes mismatch: have %d, want 0", report.RoundChanges)
+	}
+	for _, block := range report.Blocks {
+		if block.Finality == 0 {
+			t.Errorf("block %d wasn't committed by a quorum of validators", block.Number)
+		}
+	}
+}
+
+func TestPartitionedNetwork(t *testing.T) {
+	report := runScenario(t, &Scenario{
+		Name:           "partition",
+		Validators:     4,
+		Blocks:         6,
+		RequestTimeout: 500,
+		Steps: []Step{
+			// Neither side has a quorum
+			{AtBlock: 2, Partition: [][]int{{0, 1}}},
+			{After: 1500},
+		},
+	})
+	if report.MaxBlockTime < 1500*time.Millisecond {
+		t.Errorf("max block time mismatch: have %v, want at least 1.5s", report.MaxBlockTime)
+	}
+	if report.RoundChanges == 0 {
+		t.Errorf("expected round changes while partitioned")
+	}
+}
+
+func TestByzantineProposers(t *testing.T) {
+	report := runScenario(t, &Scenario{
+		Name:           "byzantine",
+		Validators:     4,
+		Blocks:         8,
+		RequestTimeout: 500,
+		Steps: []Step{
+			{Byzantine: []ByzantineFault{{Validator: 0, Mode: ByzantineInvalid}}},
+		},
+	})
+	for _, block := range report.Blocks {
+		if block.Round > 0 {
+			return
+		}
+	}
+	t.Errorf("expected blocks to be committed after a round change")
+}
+
+func TestScenarioValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario *Scenario
+	}{
+		{"no validators", &Scenario{Blocks: 1}},
+		{"no blocks", &Scenario{Validators: 4}},
+		{"unknown validator", &Scenario{Validators: 4, Blocks: 1, Steps: []Step{{Partition: [][]int{{4}}}}}},
+		{"invalid drop rate", &Scenario{Validators: 4, Blocks: 1, Steps: []Step{{Links: []LinkFault{{DropRate: 2}}}}}},
+		{"unknown byzantine mode", &Scenario{Validators: 4, Blocks: 1, Steps: []Step{{Byzantine: []ByzantineFault{{Mode: "loud"}}}}}},
+	}
+	for _, tt := range tests {
+		if _, err := Run(context.Background(), tt.scenario); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
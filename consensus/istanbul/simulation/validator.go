@@ -0,0 +1,466 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus"
+	"github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/backend"
+	"github.com/celo-org/celo-blockchain/contract_comm"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"github.com/celo-org/celo-blockchain/crypto"
+	"github.com/celo-org/celo-blockchain/ethdb"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/p2p"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
+	"github.com/celo-org/celo-blockchain/rpc"
+)
+
+// Message codes of the simulation protocol, besides the istanbul message codes
+const (
+	newHeadMsg   = 0x00 // Number of a new chain head
+	getBlocksMsg = 0x01 // Request for the blocks following a block number
+	blocksMsg    = 0x02 // Blocks sent in response to getBlocksMsg
+)
+
+const (
+	// Maximum number of blocks sent in a blocksMsg
+	maxBlocksPerMsg = 64
+	// Time after which blocks requested from a peer are requested again
+	blocksRequestTimeout = time.Second
+)
+
+// validator is a node service running an istanbul backend on an in-memory chain. It exchanges the
+// consensus messages and the committed blocks with the other validators through a protocol, which
+// sends the messages through the fault injector.
+type validator struct {
+	index   int
+	sim     *simulation
+	logger  log.Logger
+	db      ethdb.Database
+	chain   *core.BlockChain
+	backend *backend.Backend
+
+	peersMu       sync.RWMutex
+	peers         map[enode.ID]*peer
+	connected     chan struct{} // Closed once all the other validators are connected
+	connectedOnce sync.Once
+	validating    bool
+
+	syncMu           sync.Mutex
+	requestedBlock   uint64 // Number of the first block of the last request to a peer
+	requestedBlockAt time.Time
+
+	stopSeal chan struct{}
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newValidator(sim *simulation, index int, key *ecdsa.PrivateKey) (*validator, error) {
+	config := *istanbul.DefaultConfig
+	config.ReplicaStateDBPath = ""
+	config.ValidatorEnodeDBPath = ""
+	config.VersionCertificateDBPath = ""
+	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = ""
+	config.SlashingProtectionDBPath = ""
+	config.Validator = true
+	istanbul.ApplyParamsChainConfigToConfig(sim.genesis.Config, &config)
+	config.BlockPeriod = sim.scenario.BlockPeriod
+	config.RequestTimeout = sim.scenario.RequestTimeout
+	config.TimeoutBackoffFactor = sim.scenario.RequestTimeout / 3
+	config.MinResendRoundChangeTimeout = 2 * sim.scenario.RequestTimeout
+	config.MaxResendRoundChangeTimeout = 10 * sim.scenario.RequestTimeout
+
+	db := rawdb.NewMemoryDatabase()
+	b := backend.New(&config, db).(*backend.Backend)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	b.Authorize(address, address, &key.PublicKey, backend.DecryptFn(key), backend.SignFn(key), backend.SignBLSFn(key), backend.SignHashFn(key))
+
+	sim.genesis.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, sim.genesis.Config, b, vm.Config{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &validator{
+		index:     index,
+		sim:       sim,
+		logger:    log.New("validator", index),
+		db:        db,
+		chain:     chain,
+		backend:   b,
+		peers:     make(map[enode.ID]*peer),
+		connected: make(chan struct{}),
+		quit:      make(chan struct{}),
+	}, nil
+}
+
+// Protocols implements node.Service
+func (v *validator) Protocols() []p2p.Protocol {
+	version := istanbul.ProtocolVersions[0]
+	return []p2p.Protocol{{
+		Name:    "istanbulsim",
+		Version: version,
+		Length:  istanbul.ProtocolLengths[version],
+		Run:     v.runPeer,
+	}}
+}
+
+// APIs implements node.Service
+func (v *validator) APIs() []rpc.API {
+	return v.backend.APIs(v.chain)
+}
+
+// Start implements node.Service
+func (v *validator) Start(server *p2p.Server) error {
+	chain := v.chain
+	v.backend.SetChain(chain, chain.CurrentBlock, func(hash common.Hash) (*state.StateDB, error) {
+		return chain.StateAt(chain.GetHeaderByHash(hash).Root)
+	})
+	v.backend.SetBroadcaster(v)
+	// The simulation manages the connections between the validators
+	v.backend.SetP2PServer(&consensustest.MockP2PServer{Node: server.Self()})
+	if err := v.backend.SetBlockProcessors(chain.HasBadBlock,
+		func(block *types.Block, state *state.StateDB) (types.Receipts, []*types.Log, uint64, error) {
+			return chain.Processor().Process(block, state, *chain.GetVMConfig())
+		},
+		func(block *types.Block, state *state.StateDB, receipts types.Receipts, usedGas uint64) error {
+			return chain.Validator().ValidateState(block, state, receipts, usedGas)
+		}); err != nil {
+		return err
+	}
+	// The enodes of the validators are known in advance, instead of being announced
+	if err := v.backend.RewriteValEnodeTableEntries(v.sim.valEnodes); err != nil {
+		return err
+	}
+	contract_comm.SetInternalEVMHandler(chain)
+	if v.sim.scenario.Validators == 1 {
+		v.connectedOnce.Do(func() { close(v.connected) })
+	}
+
+	v.wg.Add(1)
+	go v.loop()
+	return nil
+}
+
+// Stop implements node.Service
+func (v *validator) Stop() error {
+	close(v.quit)
+	v.wg.Wait()
+	if v.validating {
+		if err := v.backend.StopValidating(); err != nil {
+			v.logger.Warn("Error stopping istanbul", "err", err)
+		}
+	}
+	v.chain.Stop()
+	v.backend.Close()
+	v.db.Close()
+	return nil
+}
+
+// loop starts istanbul once all the validators are connected, then records the committed blocks
+// and proposes a block on top of every new chain head.
+func (v *validator) loop() {
+	defer v.wg.Done()
+
+	// Starting before the connections are established would only cause round changes
+	select {
+	case <-v.connected:
+	case <-v.quit:
+		return
+	}
+	if err := v.backend.StartValidating(); err != nil {
+		v.logger.Error("Error starting istanbul", "err", err)
+		return
+	}
+	v.validating = true
+
+	chainCh := make(chan core.ChainEvent, 64)
+	chainSub := v.chain.SubscribeChainEvent(chainCh)
+	defer chainSub.Unsubscribe()
+	chainHeadCh := make(chan core.ChainHeadEvent, 10)
+	chainHeadSub := v.chain.SubscribeChainHeadEvent(chainHeadCh)
+	defer chainHeadSub.Unsubscribe()
+	results := make(chan *types.Block, 1)
+
+	v.propose(v.chain.CurrentBlock(), results)
+	for {
+		select {
+		case ev := <-chainCh:
+			v.sim.recorder.commit(ev.Block, time.Now())
+		case ev := <-chainHeadCh:
+			v.broadcast(newHeadMsg, ev.Block.NumberU64())
+			v.propose(ev.Block, results)
+		case block := <-results:
+			// This validator proposed the committed block
+			go v.insert(types.Blocks{block})
+		case <-v.quit:
+			if v.stopSeal != nil {
+				close(v.stopSeal)
+			}
+			return
+		}
+	}
+}
+
+// propose starts a new sequence, and proposes a block on top of the parent if this validator is
+// the proposer.
+func (v *validator) propose(parent *types.Block, results chan<- *types.Block) {
+	if v.stopSeal != nil {
+		close(v.stopSeal)
+		v.stopSeal = nil
+	}
+	if err := v.backend.NewWork(); err != nil {
+		v.logger.Warn("Error starting a new sequence", "err", err)
+		return
+	}
+
+	mode := v.sim.faults.byzantineMode(v.index)
+	if mode == ByzantineSilent {
+		return
+	}
+	block, err := v.buildBlock(parent)
+	if err != nil {
+		v.logger.Error("Error building block", "number", parent.NumberU64()+1, "err", err)
+		return
+	}
+	if mode == ByzantineInvalid {
+		header := block.Header()
+		header.Root = common.Hash{0x01}
+		block = block.WithSeal(header)
+	}
+
+	v.stopSeal = make(chan struct{})
+	if err := v.backend.Seal(v.chain, block, results, v.stopSeal); err != nil {
+		v.logger.Debug("Error sealing block", "number", block.NumberU64(), "err", err)
+	}
+}
+
+// buildBlock assembles an empty block on top of the parent
+func (v *validator) buildBlock(parent *types.Block) (*types.Block, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Coinbase:   v.backend.Address(),
+	}
+	if err := v.backend.Prepare(v.chain, header); err != nil {
+		return nil, err
+	}
+	state, err := v.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	block, err := v.backend.FinalizeAndAssemble(v.chain, header, state, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.backend.UpdateValSetDiff(v.chain, block.MutableHeader(), state); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// insert adds the blocks to the chain
+func (v *validator) insert(blocks types.Blocks) {
+	if _, err := v.chain.InsertChain(blocks); err != nil {
+		v.logger.Debug("Error inserting blocks", "number", blocks[0].NumberU64(), "count", len(blocks), "err", err)
+	}
+}
+
+// Enqueue implements consensus.Broadcaster.Enqueue. It's called with the blocks committed by
+// consensus, but proposed by other validators.
+func (v *validator) Enqueue(id string, block *types.Block) {
+	go v.insert(types.Blocks{block})
+}
+
+// FindPeers implements consensus.Broadcaster.FindPeers
+func (v *validator) FindPeers(targets map[enode.ID]bool, purpose p2p.PurposeFlag) map[enode.ID]consensus.Peer {
+	v.peersMu.RLock()
+	defer v.peersMu.RUnlock()
+	peers := make(map[enode.ID]consensus.Peer)
+	for id, p := range v.peers {
+		if (targets == nil || targets[id]) && p.PurposeIsSet(purpose) {
+			peers[id] = p
+		}
+	}
+	return peers
+}
+
+// broadcast sends the message to all connected validators
+func (v *validator) broadcast(msgcode uint64, data interface{}) {
+	v.peersMu.RLock()
+	defer v.peersMu.RUnlock()
+	for _, p := range v.peers {
+		if err := p.Send(msgcode, data); err != nil {
+			v.logger.Debug("Error sending message", "peer", p.to, "msgcode", msgcode, "err", err)
+		}
+	}
+}
+
+// runPeer handles the messages received from a validator
+func (v *validator) runPeer(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	to, ok := v.sim.validatorIndex(p2pPeer.ID())
+	if !ok {
+		return fmt.Errorf("unknown validator %v", p2pPeer.ID())
+	}
+	p := &peer{Peer: p2pPeer, rw: rw, from: v.index, to: to, faults: v.sim.faults}
+	v.peersMu.Lock()
+	v.peers[p.ID()] = p
+	if len(v.peers) == v.sim.scenario.Validators-1 {
+		v.connectedOnce.Do(func() { close(v.connected) })
+	}
+	v.peersMu.Unlock()
+	defer func() {
+		v.peersMu.Lock()
+		delete(v.peers, p.ID())
+		v.peersMu.Unlock()
+	}()
+
+	// Let the peer know the current head, so that it syncs if it's behind
+	if err := p.Send(newHeadMsg, v.chain.CurrentBlock().NumberU64()); err != nil {
+		return err
+	}
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if err := v.handleMsg(p, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (v *validator) handleMsg(p *peer, msg p2p.Msg) error {
+	defer msg.Discard()
+
+	switch msg.Code {
+	case newHeadMsg:
+		var number uint64
+		if err := msg.Decode(&number); err != nil {
+			return err
+		}
+		v.requestBlocks(p, number)
+
+	case getBlocksMsg:
+		var from uint64
+		if err := msg.Decode(&from); err != nil {
+			return err
+		}
+		var blocks types.Blocks
+		for number := from; number < from+maxBlocksPerMsg; number++ {
+			block := v.chain.GetBlockByNumber(number)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) > 0 {
+			return p.Send(blocksMsg, blocks)
+		}
+
+	case blocksMsg:
+		var blocks types.Blocks
+		if err := msg.Decode(&blocks); err != nil {
+			return err
+		}
+		if len(blocks) == 0 {
+			return nil
+		}
+		v.syncMu.Lock()
+		v.requestedBlockAt = time.Time{}
+		v.syncMu.Unlock()
+		v.insert(blocks)
+		v.requestBlocks(p, blocks[len(blocks)-1].NumberU64())
+
+	default:
+		addr := crypto.PubkeyToAddress(*p.Node().Pubkey())
+		if _, err := v.backend.HandleMsg(addr, msg, p); err != nil {
+			v.logger.Debug("Error handling istanbul message", "peer", p.to, "msgcode", msg.Code, "err", err)
+		}
+	}
+	return nil
+}
+
+// requestBlocks requests the blocks following the chain head from the peer, if the peer has the
+// given block and this validator doesn't.
+func (v *validator) requestBlocks(p *peer, number uint64) {
+	head := v.chain.CurrentBlock().NumberU64()
+	if number <= head {
+		return
+	}
+
+	v.syncMu.Lock()
+	defer v.syncMu.Unlock()
+	if v.requestedBlock == head+1 && time.Since(v.requestedBlockAt) < blocksRequestTimeout {
+		return
+	}
+	v.requestedBlock = head + 1
+	v.requestedBlockAt = time.Now()
+	if err := p.Send(getBlocksMsg, head+1); err != nil {
+		v.logger.Debug("Error requesting blocks", "peer", p.to, "err", err)
+	}
+}
+
+// peer is a connection to another validator. The messages it sends are dropped or delayed by the
+// fault injector.
+type peer struct {
+	*p2p.Peer
+	rw       p2p.MsgReadWriter
+	from, to int
+	faults   *faultInjector
+}
+
+// Send implements consensus.Peer.Send
+func (p *peer) Send(msgcode uint64, data interface{}) error {
+	drop, delay := p.faults.link(p.from, p.to)
+	if drop {
+		return nil
+	}
+	if delay > 0 {
+		time.AfterFunc(delay, func() { p2p.Send(p.rw, msgcode, data) })
+		return nil
+	}
+	return p2p.Send(p.rw, msgcode, data)
+}
+
+// Version implements consensus.Peer.Version
+func (p *peer) Version() int {
+	return int(istanbul.ProtocolVersions[0])
+}
+
+// ReadMsg implements consensus.Peer.ReadMsg
+func (p *peer) ReadMsg() (p2p.Msg, error) {
+	return p.rw.ReadMsg()
+}
+
+// PurposeIsSet implements consensus.Peer.PurposeIsSet. Validators are only connected to each other.
+func (p *peer) PurposeIsSet(purpose p2p.PurposeFlag) bool {
+	return purpose.IsSet(p2p.ValidatorPurpose)
+}
@@ -0,0 +1,112 @@
+package uptime
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/params"
+)
+
+// History replays the uptime accounting that the Monitor performs for an epoch from the
+// epoch's headers, exposing the signatures bitmap of every block so that callers can
+// build a per block signing history (which is not persisted by the Store).
+type History struct {
+	epoch            uint64
+	epochSize        uint64
+	lookbackWindow   uint64
+	monitoringWindow Window
+
+	uptime *Uptime
+}
+
+// NewHistory creates an empty History for the given epoch and validator set size
+func NewHistory(epoch uint64, epochSize uint64, lookbackWindow uint64, valSetSize int) (*History, error) {
+	monitoringWindow, err := MonitoringWindow(epoch, epochSize, lookbackWindow)
+	if err != nil {
+		return nil, err
+	}
+	return &History{
+		epoch:            epoch,
+		epochSize:        epochSize,
+		lookbackWindow:   lookbackWindow,
+		monitoringWindow: monitoringWindow,
+		uptime:           &Uptime{Entries: make([]UptimeEntry, valSetSize)},
+	}, nil
+}
+
+// MonitoringWindow returns the window of blocks of the epoch that count towards the uptime score
+func (h *History) MonitoringWindow() Window { return h.monitoringWindow }
+
+// LatestBlock returns the latest block processed by the history
+func (h *History) LatestBlock() uint64 { return h.uptime.LatestBlock }
+
+// ProcessHeader uses the header's parent aggregated seal bitmap to update the history, in the same
+// way Monitor.ProcessBlock does. Headers must be processed in order. It returns the bitmap of the
+// validators that signed the parent block, or nil if the header's parent seal is not accounted for
+// (i.e. the header is the first block of the epoch).
+func (h *History) ProcessHeader(header *types.Header) (*big.Int, error) {
+	number := header.Number.Uint64()
+	if istanbul.GetEpochNumber(number, h.epochSize) != h.epoch {
+		return nil, fmt.Errorf("block %d does not belong to epoch %d", number, h.epoch)
+	}
+	expected := h.uptime.LatestBlock + 1
+	if h.uptime.LatestBlock == 0 {
+		expected, _ = istanbul.GetEpochFirstBlockNumber(h.epoch, h.epochSize)
+	}
+	if number != expected {
+		return nil, fmt.Errorf("expected block %d, got block %d", expected, number)
+	}
+	if istanbul.IsFirstBlockOfEpoch(number, h.epochSize) {
+		h.uptime.LatestBlock = number
+		return nil, nil
+	}
+
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, errors.New("could not extract block header extra")
+	}
+	bitmap := extra.ParentAggregatedSeal.Bitmap
+	if bitmap == nil {
+		bitmap = new(big.Int)
+	}
+
+	h.uptime = updateUptime(h.uptime, number-1, bitmap, h.lookbackWindow, h.monitoringWindow)
+	h.uptime.LatestBlock = number
+	return bitmap, nil
+}
+
+// Uptime returns the accumulated uptime for the blocks processed so far, as the Monitor
+// would have stored it.
+func (h *History) Uptime() *Uptime { return h.uptime }
+
+// IsUp indicates if the `i`th validator signed a block within the lookback window ending at the
+// parent of the latest processed block.
+func (h *History) IsUp(i int) bool {
+	if h.uptime.LatestBlock == 0 {
+		return false
+	}
+	lastSigned := h.uptime.Entries[i].LastSignedBlock
+	return lastSigned > 0 && h.uptime.LatestBlock-1-lastSigned < h.lookbackWindow
+}
+
+// Scores returns the uptime score of each validator as ComputeValidatorsUptime would compute it
+// from the accumulated uptime. It is only final once the whole monitoring window was processed.
+func (h *History) Scores() []*big.Int {
+	scores := make([]*big.Int, len(h.uptime.Entries))
+	for i, entry := range h.uptime.Entries {
+		scores[i] = uptimeScore(entry.UpBlocks, h.monitoringWindow.Size())
+	}
+	return scores
+}
+
+// uptimeScore returns upBlocks / totalMonitoredBlocks as a fixidity value, capped at 1
+func uptimeScore(upBlocks, totalMonitoredBlocks uint64) *big.Int {
+	if upBlocks > totalMonitoredBlocks {
+		return new(big.Int).Set(params.Fixidity1)
+	}
+	numerator := big.NewInt(0).Mul(big.NewInt(int64(upBlocks)), params.Fixidity1)
+	return big.NewInt(0).Div(numerator, big.NewInt(int64(totalMonitoredBlocks)))
+}
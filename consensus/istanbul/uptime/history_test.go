@@ -0,0 +1,105 @@
+package uptime
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+)
+
+func headerWithParentBitmap(number uint64, bitmap *big.Int) *types.Header {
+	extra := &types.IstanbulExtra{
+		AddedValidators:           nil,
+		AddedValidatorsPublicKeys: nil,
+		RemovedValidators:         big.NewInt(0),
+		Seal:                      []byte{},
+		AggregatedSeal:            types.IstanbulAggregatedSeal{Bitmap: big.NewInt(0), Signature: []byte{}, Round: big.NewInt(0)},
+		ParentAggregatedSeal:      types.IstanbulAggregatedSeal{Bitmap: bitmap, Signature: []byte{}, Round: big.NewInt(0)},
+	}
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		panic(err)
+	}
+	return &types.Header{
+		Number: new(big.Int).SetUint64(number),
+		Extra:  append(make([]byte, types.IstanbulExtraVanity), payload...),
+	}
+}
+
+func TestHistoryMatchesUptime(t *testing.T) {
+	const epochSize, lookback = 10, 2
+	bitmaps := []*big.Int{
+		big.NewInt(7), // 111     // parent seal of block #2 (signatures for block #1)
+		big.NewInt(5), // 101
+		big.NewInt(3), // 011
+		big.NewInt(5), // 101
+		big.NewInt(5), // 101
+		big.NewInt(5), // 101     // parent seal of block #7 (signatures for block #6)
+	}
+
+	history, err := NewHistory(1, epochSize, lookback, 3)
+	if err != nil {
+		t.Fatalf("error mismatch: have %v, want nil", err)
+	}
+	if bitmap, err := history.ProcessHeader(headerWithParentBitmap(1, big.NewInt(7))); err != nil || bitmap != nil {
+		t.Fatalf("first block of epoch should not be accounted for: bitmap %v, err %v", bitmap, err)
+	}
+
+	var expected *Uptime
+	for i, bitmap := range bitmaps {
+		number := uint64(i + 2)
+		got, err := history.ProcessHeader(headerWithParentBitmap(number, bitmap))
+		if err != nil {
+			t.Fatalf("error mismatch for block %d: have %v, want nil", number, err)
+		}
+		if got.Cmp(bitmap) != 0 {
+			t.Errorf("bitmap mismatch for block %d: have %v, want %v", number, got, bitmap)
+		}
+		expected = updateUptime(expected, number-1, bitmap, lookback, history.MonitoringWindow())
+	}
+
+	if history.LatestBlock() != 7 {
+		t.Errorf("latest block mismatch: have %d, want 7", history.LatestBlock())
+	}
+	for i, entry := range history.Uptime().Entries {
+		if entry != expected.Entries[i] {
+			t.Errorf("entry %d mismatch: have %v, want %v", i, entry, expected.Entries[i])
+		}
+	}
+	// Validator 1 last signed block #3, which is outside the lookback window ending at block #6
+	for i, up := range []bool{true, false, true} {
+		if history.IsUp(i) != up {
+			t.Errorf("validator %d up mismatch: have %v, want %v", i, history.IsUp(i), up)
+		}
+	}
+
+	scores := history.Scores()
+	window := history.MonitoringWindow()
+	want := new(big.Int).Div(new(big.Int).Mul(big.NewInt(int64(expected.Entries[0].UpBlocks)), params.Fixidity1), big.NewInt(int64(window.Size())))
+	if scores[0].Cmp(want) != 0 {
+		t.Errorf("score mismatch: have %v, want %v", scores[0], want)
+	}
+}
+
+func TestHistoryRequiresContiguousHeaders(t *testing.T) {
+	history, err := NewHistory(2, 10, 2, 3)
+	if err != nil {
+		t.Fatalf("error mismatch: have %v, want nil", err)
+	}
+	// Headers must start at the first block of the epoch
+	if _, err := history.ProcessHeader(headerWithParentBitmap(12, big.NewInt(7))); err == nil {
+		t.Errorf("expected an error when skipping the first block of the epoch")
+	}
+	if _, err := history.ProcessHeader(headerWithParentBitmap(11, big.NewInt(7))); err != nil {
+		t.Fatalf("error mismatch: have %v, want nil", err)
+	}
+	if _, err := history.ProcessHeader(headerWithParentBitmap(13, big.NewInt(7))); err == nil {
+		t.Errorf("expected an error when skipping a block")
+	}
+	// Blocks from other epochs are rejected
+	if _, err := history.ProcessHeader(headerWithParentBitmap(21, big.NewInt(7))); err == nil {
+		t.Errorf("expected an error for a block of another epoch")
+	}
+}
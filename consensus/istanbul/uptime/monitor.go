@@ -10,7 +10,6 @@ import (
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/log"
-	"github.com/celo-org/celo-blockchain/params"
 )
 
 // Store provides a persistent storage for uptime entries
@@ -88,12 +87,8 @@ func (um *Monitor) ComputeValidatorsUptime(epoch uint64, valSetSize int) ([]*big
 
 		if entry.UpBlocks > totalMonitoredBlocks {
 			logger.Error("UpBlocks exceeds max possible", "upBlocks", entry.UpBlocks, "totalMonitoredBlocks", totalMonitoredBlocks, "valIdx", i)
-			uptimes = append(uptimes, params.Fixidity1)
-			continue
 		}
-
-		numerator := big.NewInt(0).Mul(big.NewInt(int64(entry.UpBlocks)), params.Fixidity1)
-		uptimes = append(uptimes, big.NewInt(0).Div(numerator, big.NewInt(int64(totalMonitoredBlocks))))
+		uptimes = append(uptimes, uptimeScore(entry.UpBlocks, totalMonitoredBlocks))
 	}
 
 	if len(uptimes) < valSetSize {
@@ -113,6 +113,11 @@ type ValidatorSet interface {
 	SetRandomness(seed common.Hash)
 	// Sets the randomness for use in the proposer policy
 	GetRandomness() common.Hash
+	// Sets the voting weight of each validator (in List order) for use in the proposer policy.
+	// This is injected into the ValidatorSet when we call `getOrderedValidators`
+	SetWeights(weights []*big.Int)
+	// Gets the voting weights for use in the proposer policy, or nil if they were not set
+	GetWeights() []*big.Int
 
 	// Return the validator size
 	Size() int
@@ -133,6 +133,8 @@ type defaultSet struct {
 	// This is set when we call `getOrderedValidators`
 	// TODO Rename to `EpochState` that has validators & randomness
 	randomness common.Hash
+	// Voting weight of each validator, set along with the randomness for the proposer policies that use it
+	weights []*big.Int
 }
 
 func newDefaultSet(validators []istanbul.ValidatorData) *defaultSet {
@@ -174,6 +176,22 @@ func (valSet *defaultSet) MinQuorumSize() int { return int(math.Ceil(float64(2*v
 func (valSet *defaultSet) SetRandomness(seed common.Hash) { valSet.randomness = seed }
 func (valSet *defaultSet) GetRandomness() common.Hash     { return valSet.randomness }
 
+func (valSet *defaultSet) SetWeights(weights []*big.Int) {
+	valSet.validatorMu.Lock()
+	defer valSet.validatorMu.Unlock()
+	if weights != nil && len(weights) != len(valSet.validators) {
+		log.Warn("Ignoring validator weights of the wrong size", "weights", len(weights), "validators", len(valSet.validators))
+		weights = nil
+	}
+	valSet.weights = weights
+}
+
+func (valSet *defaultSet) GetWeights() []*big.Int {
+	valSet.validatorMu.RLock()
+	defer valSet.validatorMu.RUnlock()
+	return valSet.weights
+}
+
 func (valSet *defaultSet) String() string {
 	var buf strings.Builder
 	if _, err := buf.WriteString("["); err != nil {
@@ -263,6 +281,7 @@ func (valSet *defaultSet) AddValidators(validators []istanbul.ValidatorData) boo
 	}
 
 	valSet.validators = append(valSet.validators, newValidators...)
+	valSet.weights = nil
 
 	return true
 }
@@ -289,6 +308,7 @@ func (valSet *defaultSet) RemoveValidators(removedValidators *big.Int) bool {
 	}
 
 	valSet.validators = tempList
+	valSet.weights = nil
 	return true
 }
 
@@ -301,6 +321,14 @@ func (valSet *defaultSet) Copy() istanbul.ValidatorSet {
 		newValSet.validators[i] = v.Copy()
 	}
 	newValSet.SetRandomness(valSet.randomness)
+	if valSet.weights != nil {
+		newValSet.weights = make([]*big.Int, len(valSet.weights))
+		for i, w := range valSet.weights {
+			if w != nil {
+				newValSet.weights[i] = new(big.Int).Set(w)
+			}
+		}
+	}
 	return newValSet
 }
 
@@ -17,11 +17,34 @@
 package validator
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math/big"
+	"sort"
+	"sync"
 
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator/random"
+	"github.com/celo-org/celo-blockchain/crypto"
+)
+
+// Names under which the built-in proposer selectors are registered
+const (
+	RoundRobinSelectorName         = "round-robin"
+	StickySelectorName             = "sticky"
+	ShuffledRoundRobinSelectorName = "shuffled-round-robin"
+	StakeWeightedSelectorName      = "stake-weighted"
+)
+
+var (
+	proposerSelectorsMu sync.RWMutex
+	proposerSelectors   = map[string]istanbul.ProposerSelector{
+		RoundRobinSelectorName:         RoundRobinProposer,
+		StickySelectorName:             StickyProposer,
+		ShuffledRoundRobinSelectorName: ShuffledRoundRobinProposer,
+		StakeWeightedSelectorName:      StakeWeightedProposer,
+	}
 )
 
 func proposerIndex(valSet istanbul.ValidatorSet, proposer common.Address) uint64 {
@@ -74,6 +97,106 @@ func StickyProposer(valSet istanbul.ValidatorSet, proposer common.Address, round
 	return valSet.List()[idx%uint64(valSet.Size())]
 }
 
+// StakeWeightedProposer selects the next proposer at random, with a probability proportional to the
+// validator's voting weight. The draw is seeded with the shared randomness, the last proposer and the
+// round, so that every node selects the same proposer. If no voting weights were set on the validator
+// set (or all of them are zero), every validator is equally likely to be selected.
+func StakeWeightedProposer(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
+	if valSet.Size() == 0 {
+		return nil
+	}
+	seed := valSet.GetRandomness()
+	var roundBytes [8]byte
+	binary.BigEndian.PutUint64(roundBytes[:], round)
+	draw := new(big.Int).SetBytes(crypto.Keccak256(seed[:], proposer[:], roundBytes[:]))
+
+	validators := valSet.List()
+	weights := valSet.GetWeights()
+	total := new(big.Int)
+	if len(weights) == len(validators) {
+		for _, weight := range weights {
+			if weight != nil && weight.Sign() > 0 {
+				total.Add(total, weight)
+			}
+		}
+	}
+	if total.Sign() == 0 {
+		return validators[draw.Mod(draw, big.NewInt(int64(len(validators)))).Uint64()]
+	}
+
+	draw.Mod(draw, total)
+	for i, weight := range weights {
+		if weight == nil || weight.Sign() <= 0 {
+			continue
+		}
+		if draw.Cmp(weight) < 0 {
+			return validators[i]
+		}
+		draw.Sub(draw, weight)
+	}
+	// Unreachable, as draw < total
+	return validators[len(validators)-1]
+}
+
+// RegisterProposerSelector makes a ProposerSelector available under the given name, so that it can be
+// chosen with the `proposerSelector` field of the genesis istanbul config. It must be called before the
+// istanbul engine is created, typically from an init function.
+func RegisterProposerSelector(name string, selector istanbul.ProposerSelector) error {
+	if name == "" || selector == nil {
+		return fmt.Errorf("invalid proposer selector registration: %q", name)
+	}
+	proposerSelectorsMu.Lock()
+	defer proposerSelectorsMu.Unlock()
+	if _, ok := proposerSelectors[name]; ok {
+		return fmt.Errorf("proposer selector already registered: %q", name)
+	}
+	proposerSelectors[name] = selector
+	return nil
+}
+
+// LookupProposerSelector returns the ProposerSelector registered under the given name
+func LookupProposerSelector(name string) (istanbul.ProposerSelector, error) {
+	proposerSelectorsMu.RLock()
+	defer proposerSelectorsMu.RUnlock()
+	selector, ok := proposerSelectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown proposer selector: %q", name)
+	}
+	return selector, nil
+}
+
+// ProposerSelectorNames returns the sorted names of the registered proposer selectors
+func ProposerSelectorNames() []string {
+	proposerSelectorsMu.RLock()
+	defer proposerSelectorsMu.RUnlock()
+	names := make([]string, 0, len(proposerSelectors))
+	for name := range proposerSelectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectorUsesWeights indicates if the proposer selector configured in `config` may use the voting
+// weights of the validators, i.e. if it is not one of the selectors that implement a ProposerPolicy.
+func SelectorUsesWeights(config *istanbul.Config) bool {
+	switch config.ProposerSelector {
+	case "", RoundRobinSelectorName, StickySelectorName, ShuffledRoundRobinSelectorName:
+		return false
+	default:
+		return true
+	}
+}
+
+// GetConfiguredProposerSelector returns the ProposerSelector registered under config.ProposerSelector,
+// or the one for config.ProposerPolicy if no selector name is configured.
+func GetConfiguredProposerSelector(config *istanbul.Config) (istanbul.ProposerSelector, error) {
+	if config.ProposerSelector == "" {
+		return GetProposerSelector(config.ProposerPolicy), nil
+	}
+	return LookupProposerSelector(config.ProposerSelector)
+}
+
 // GetProposerSelector returns the ProposerSelector for the given Policy
 func GetProposerSelector(pp istanbul.ProposerPolicy) istanbul.ProposerSelector {
 	switch pp {
@@ -18,6 +18,7 @@ package validator
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -268,3 +269,153 @@ func TestShuffledRoundRobinProposer(t *testing.T) {
 		}
 	})
 }
+
+func newTestWeightedSet(t *testing.T, seed common.Hash, weights []int64) istanbul.ValidatorSet {
+	var addrs []common.Address
+	for _, strAddr := range testAddresses {
+		addrs = append(addrs, common.HexToAddress(strAddr))
+	}
+	v, err := istanbul.CombineIstanbulExtraToValidatorData(addrs, make([]blscrypto.SerializedPublicKey, len(addrs)))
+	if err != nil {
+		t.Fatalf("CombineIstanbulExtraToValidatorData(...): %v", err)
+	}
+	valSet := newDefaultSet(v)
+	valSet.SetRandomness(seed)
+	if weights != nil {
+		bigWeights := make([]*big.Int, len(weights))
+		for i, w := range weights {
+			bigWeights[i] = big.NewInt(w)
+		}
+		valSet.SetWeights(bigWeights)
+	}
+	return valSet
+}
+
+func TestStakeWeightedProposer(t *testing.T) {
+	selector, err := LookupProposerSelector(StakeWeightedSelectorName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSeed := common.HexToHash("f36aa9716b892ec8")
+	weights := []int64{100, 0, 300, 50, 550}
+
+	// Validator sets built independently (as on different nodes) must agree on every proposer.
+	t.Run("determinism", func(t *testing.T) {
+		for _, seed := range []common.Hash{{}, testSeed} {
+			a := newTestWeightedSet(t, seed, weights)
+			b := newTestWeightedSet(t, seed, weights).Copy()
+			for _, last := range append([]istanbul.Validator{nil}, a.List()...) {
+				var lastProposer common.Address
+				if last != nil {
+					lastProposer = last.Address()
+				}
+				for round := uint64(0); round < 50; round++ {
+					if pa, pb := selector(a, lastProposer, round), selector(b, lastProposer, round); pa.Address() != pb.Address() {
+						t.Fatalf("proposer mismatch for (%v, %v, %d): %v != %v", seed, lastProposer, round, pa, pb)
+					}
+				}
+			}
+		}
+	})
+
+	// Explicit cases, which must not change between versions.
+	t.Run("cases", func(t *testing.T) {
+		valSet := newTestWeightedSet(t, testSeed, weights)
+		validators := valSet.List()
+		cases := []struct {
+			lastProposer common.Address
+			round        uint64
+			want         istanbul.Validator
+		}{
+			{common.Address{}, 0, validators[2]},
+			{common.Address{}, 1, validators[4]},
+			{validators[0].Address(), 0, validators[4]},
+			{validators[0].Address(), 1, validators[4]},
+			{validators[2].Address(), 0, validators[4]},
+			{validators[2].Address(), 1, validators[0]},
+		}
+		for i, c := range cases {
+			if proposer := selector(valSet, c.lastProposer, c.round); proposer.Address() != c.want.Address() {
+				t.Errorf("case %d: proposer mismatch: have %v, want %v", i, proposer, c.want)
+			}
+		}
+	})
+
+	// Proposers are selected in proportion to their weight, and never if their weight is zero.
+	t.Run("distribution", func(t *testing.T) {
+		valSet := newTestWeightedSet(t, testSeed, weights)
+		counts := make(map[common.Address]int)
+		const rounds = 10000
+		for round := uint64(0); round < rounds; round++ {
+			counts[selector(valSet, common.Address{}, round).Address()]++
+		}
+		for i, val := range valSet.List() {
+			expected := rounds * int(weights[i]) / 1000
+			if diff := counts[val.Address()] - expected; diff > rounds/50 || diff < -rounds/50 {
+				t.Errorf("validator %d selected %d times, expected about %d", i, counts[val.Address()], expected)
+			}
+		}
+		if counts[valSet.List()[1].Address()] != 0 {
+			t.Errorf("validator with zero weight was selected")
+		}
+	})
+
+	// Without weights, every validator can be selected.
+	t.Run("no weights", func(t *testing.T) {
+		valSet := newTestWeightedSet(t, testSeed, nil)
+		selected := make(map[common.Address]bool)
+		for round := uint64(0); round < 100; round++ {
+			selected[selector(valSet, common.Address{}, round).Address()] = true
+		}
+		if len(selected) != valSet.Size() {
+			t.Errorf("selected validators mismatch: have %d, want %d", len(selected), valSet.Size())
+		}
+	})
+}
+
+func TestProposerSelectorRegistry(t *testing.T) {
+	for _, name := range []string{RoundRobinSelectorName, StickySelectorName, ShuffledRoundRobinSelectorName, StakeWeightedSelectorName} {
+		if _, err := LookupProposerSelector(name); err != nil {
+			t.Errorf("built-in selector %q not registered: %v", name, err)
+		}
+	}
+	if _, err := LookupProposerSelector("test-last"); err == nil {
+		t.Fatalf("expected an error for an unknown selector")
+	}
+
+	last := func(valSet istanbul.ValidatorSet, proposer common.Address, round uint64) istanbul.Validator {
+		return valSet.List()[valSet.Size()-1]
+	}
+	if err := RegisterProposerSelector("test-last", last); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterProposerSelector("test-last", last); err == nil {
+		t.Errorf("expected an error when registering a selector twice")
+	}
+	if err := RegisterProposerSelector(StickySelectorName, last); err == nil {
+		t.Errorf("expected an error when overriding a built-in selector")
+	}
+
+	config := *istanbul.DefaultConfig
+	config.ProposerSelector = "test-last"
+	selector, err := GetConfiguredProposerSelector(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valSet := newTestWeightedSet(t, common.Hash{}, nil)
+	if proposer := selector(valSet, common.Address{}, 0); proposer.Address() != valSet.List()[4].Address() {
+		t.Errorf("proposer mismatch: have %v, want %v", proposer, valSet.List()[4])
+	}
+	if !SelectorUsesWeights(&config) {
+		t.Errorf("registered selectors should be given the voting weights")
+	}
+
+	config.ProposerSelector = ""
+	if SelectorUsesWeights(&config) {
+		t.Errorf("proposer policies should not use the voting weights")
+	}
+	config.ProposerSelector = "unknown"
+	if _, err := GetConfiguredProposerSelector(&config); err == nil {
+		t.Errorf("expected an error for an unknown configured selector")
+	}
+}
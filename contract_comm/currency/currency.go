@@ -139,11 +139,15 @@ func Convert(val *big.Int, currencyFrom *common.Address, currencyTo *common.Addr
 		}
 	}
 
+	return convertWithRates(val, exchangeRateFrom, exchangeRateTo), nil
+}
+
+func convertWithRates(val *big.Int, exchangeRateFrom *exchangeRate, exchangeRateTo *exchangeRate) *big.Int {
 	// Given value of val and rates n1/d1 and n2/d2 the function below does
 	// (val * d1 * n2) / (n1 * d2)
 	numerator := new(big.Int).Mul(val, new(big.Int).Mul(exchangeRateFrom.Denominator, exchangeRateTo.Numerator))
 	denominator := new(big.Int).Mul(exchangeRateFrom.Numerator, exchangeRateTo.Denominator)
-	return new(big.Int).Div(numerator, denominator), nil
+	return new(big.Int).Div(numerator, denominator)
 }
 
 func Cmp(val1 *big.Int, currency1 *common.Address, val2 *big.Int, currency2 *common.Address) int {
@@ -167,6 +171,10 @@ func Cmp(val1 *big.Int, currency1 *common.Address, val2 *big.Int, currency2 *com
 		return val1.Cmp(val2)
 	}
 
+	return cmpWithRates(val1, exchangeRate1, val2, exchangeRate2)
+}
+
+func cmpWithRates(val1 *big.Int, exchangeRate1 *exchangeRate, val2 *big.Int, exchangeRate2 *exchangeRate) int {
 	// Below code block is basically evaluating this comparison:
 	// val1 * exchangeRate1.Denominator/exchangeRate1.Numerator < val2 * exchangeRate2.Denominator/exchangeRate2.Numerator
 	// It will transform that comparison to this, to remove having to deal with fractional values.
@@ -177,6 +185,10 @@ func Cmp(val1 *big.Int, currency1 *common.Address, val2 *big.Int, currency2 *com
 }
 
 func getExchangeRate(currencyAddress *common.Address) (*exchangeRate, error) {
+	return getExchangeRateAt(currencyAddress, nil, nil)
+}
+
+func getExchangeRateAt(currencyAddress *common.Address, header *types.Header, state vm.StateDB) (*exchangeRate, error) {
 	var (
 		returnArray [2]*big.Int
 		leftoverGas uint64
@@ -185,7 +197,7 @@ func getExchangeRate(currencyAddress *common.Address) (*exchangeRate, error) {
 	if currencyAddress == nil {
 		return &exchangeRate{cgExchangeRateNum, cgExchangeRateDen}, nil
 	} else {
-		if leftoverGas, err := contract_comm.MakeStaticCall(params.SortedOraclesRegistryId, medianRateFuncABI, "medianRate", []interface{}{currencyAddress}, &returnArray, params.MaxGasForMedianRate, nil, nil); err != nil {
+		if leftoverGas, err := contract_comm.MakeStaticCall(params.SortedOraclesRegistryId, medianRateFuncABI, "medianRate", []interface{}{currencyAddress}, &returnArray, params.MaxGasForMedianRate, header, state); err != nil {
 			if err == errors.ErrSmartContractNotDeployed {
 				log.Warn("Registry address lookup failed", "err", err)
 				return &exchangeRate{big.NewInt(1), big.NewInt(1)}, err
@@ -224,7 +236,7 @@ func retrieveWhitelist(header *types.Header, state vm.StateDB) ([]common.Address
 
 	_, err := contract_comm.MakeStaticCall(params.FeeCurrencyWhitelistRegistryId, getWhitelistFuncABI, "getWhitelist", []interface{}{}, &returnList, params.MaxGasForGetWhiteList, header, state)
 	if err != nil {
-		if err == errors.ErrSmartContractNotDeployed {
+		if err == errors.ErrSmartContractNotDeployed || err == errors.ErrRegistryContractNotDeployed {
 			log.Warn("Registry address lookup failed", "err", err)
 		} else {
 			log.Error("getWhitelist invocation failed", "err", err)
@@ -261,3 +273,113 @@ func CurrencyWhitelist(header *types.Header, state vm.StateDB) ([]common.Address
 	}
 	return whitelist, err
 }
+
+// ------------------------------
+// RateSnapshot Functions
+//-------------------------------
+
+// RateSnapshot holds the fee currency whitelist and the exchange rates of the whitelisted
+// currencies as of a block, so that values in different currencies can be compared without
+// calling the contracts, and consistently within the block.
+type RateSnapshot struct {
+	whitelist []common.Address // nil if the whitelist couldn't be retrieved
+	rates     map[common.Address]*exchangeRate
+}
+
+// NewRateSnapshot retrieves the fee currency whitelist and the median rate of every
+// whitelisted currency at the given header and state. Nil header and state use the
+// current chain head.
+func NewRateSnapshot(header *types.Header, state vm.StateDB) *RateSnapshot {
+	snapshot := &RateSnapshot{rates: make(map[common.Address]*exchangeRate)}
+	whitelist, err := retrieveWhitelist(header, state)
+	if err != nil {
+		return snapshot
+	}
+	snapshot.whitelist = whitelist
+	for i := range whitelist {
+		rate, err := getExchangeRateAt(&whitelist[i], header, state)
+		if err != nil {
+			continue
+		}
+		snapshot.rates[whitelist[i]] = rate
+	}
+	return snapshot
+}
+
+// NewStaticRateSnapshot returns a snapshot of fixed rates, each rate being the amount of the
+// currency worth one CELO. The currencies of the rates form the whitelist.
+func NewStaticRateSnapshot(rates map[common.Address]*big.Rat) *RateSnapshot {
+	snapshot := &RateSnapshot{
+		whitelist: make([]common.Address, 0, len(rates)),
+		rates:     make(map[common.Address]*exchangeRate, len(rates)),
+	}
+	for address, rate := range rates {
+		snapshot.whitelist = append(snapshot.whitelist, address)
+		snapshot.rates[address] = &exchangeRate{new(big.Int).Set(rate.Num()), new(big.Int).Set(rate.Denom())}
+	}
+	return snapshot
+}
+
+// Whitelist returns the whitelisted fee currencies, or nil if the whitelist couldn't be
+// retrieved.
+func (s *RateSnapshot) Whitelist() []common.Address {
+	if s == nil {
+		return nil
+	}
+	return s.whitelist
+}
+
+// IsWhitelisted returns whether the currency is whitelisted. All currencies are considered
+// whitelisted if the whitelist couldn't be retrieved, like IsWhitelisted does.
+func (s *RateSnapshot) IsWhitelisted(currencyAddress common.Address) bool {
+	if s == nil || s.whitelist == nil {
+		return true
+	}
+	return containsCurrency(currencyAddress, s.whitelist)
+}
+
+// rate returns the exchange rate of the currency, where nil is CELO.
+func (s *RateSnapshot) rate(currencyAddress *common.Address) (*exchangeRate, bool) {
+	if currencyAddress == nil {
+		return &exchangeRate{cgExchangeRateNum, cgExchangeRateDen}, true
+	}
+	if s == nil {
+		return nil, false
+	}
+	rate, ok := s.rates[*currencyAddress]
+	return rate, ok
+}
+
+// HasRate returns whether the snapshot has an exchange rate for the currency, where nil is CELO.
+func (s *RateSnapshot) HasRate(currencyAddress *common.Address) bool {
+	_, ok := s.rate(currencyAddress)
+	return ok
+}
+
+// Cmp compares two values in possibly different currencies, like Cmp but with the rates of the
+// snapshot. Values in a currency without a rate are compared without conversion.
+func (s *RateSnapshot) Cmp(val1 *big.Int, currency1 *common.Address, val2 *big.Int, currency2 *common.Address) int {
+	if currency1 == currency2 || (currency1 != nil && currency2 != nil && *currency1 == *currency2) {
+		return val1.Cmp(val2)
+	}
+	exchangeRate1, ok1 := s.rate(currency1)
+	exchangeRate2, ok2 := s.rate(currency2)
+	if !ok1 || !ok2 {
+		return val1.Cmp(val2)
+	}
+	return cmpWithRates(val1, exchangeRate1, val2, exchangeRate2)
+}
+
+// Convert converts a value between currencies, like Convert but with the rates of the snapshot.
+// It returns ErrExchangeRateUnknown if either currency has no rate.
+func (s *RateSnapshot) Convert(val *big.Int, currencyFrom *common.Address, currencyTo *common.Address) (*big.Int, error) {
+	if currencyFrom == currencyTo || (currencyFrom != nil && currencyTo != nil && *currencyFrom == *currencyTo) {
+		return new(big.Int).Set(val), nil
+	}
+	exchangeRateFrom, ok1 := s.rate(currencyFrom)
+	exchangeRateTo, ok2 := s.rate(currencyTo)
+	if !ok1 || !ok2 {
+		return nil, errors.ErrExchangeRateUnknown
+	}
+	return convertWithRates(val, exchangeRateFrom, exchangeRateTo), nil
+}
@@ -213,6 +213,19 @@ func getTotalVotesForEligibleValidatorGroups(header *types.Header, state vm.Stat
 	return voteTotals, err
 }
 
+// GetGroupVoteTotals returns the total votes received by each of the eligible validator groups
+func GetGroupVoteTotals(header *types.Header, state vm.StateDB) (map[common.Address]*big.Int, error) {
+	voteTotals, err := getTotalVotesForEligibleValidatorGroups(header, state)
+	if err != nil {
+		return nil, err
+	}
+	groupVotes := make(map[common.Address]*big.Int, len(voteTotals))
+	for _, voteTotal := range voteTotals {
+		groupVotes[voteTotal.Group] = voteTotal.Value
+	}
+	return groupVotes, nil
+}
+
 func getGroupEpochRewards(header *types.Header, state vm.StateDB, group common.Address, maxRewards *big.Int, uptimes []*big.Int) (*big.Int, error) {
 	var groupEpochRewards *big.Int
 	_, err := contract_comm.MakeStaticCall(params.ElectionRegistryId, electionABI, "getGroupEpochRewards", []interface{}{group, maxRewards, uptimes}, &groupEpochRewards, params.MaxGasForGetGroupEpochRewards, header, state)
@@ -9,4 +9,6 @@ var (
 	ErrSmartContractNotDeployed      = errors.New("Contract not in Registry")
 	ErrRegistryContractNotDeployed   = errors.New("Registry not deployed")
 	ErrNoInternalEvmHandlerSingleton = errors.New("No internalEvmHandlerSingleton set for contract communication")
+	// ErrExchangeRateUnknown is returned when converting from or to a currency without an exchange rate
+	ErrExchangeRateUnknown = errors.New("No exchange rate for currency")
 )
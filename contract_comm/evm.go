@@ -19,6 +19,7 @@ package contract_comm
 import (
 	"math/big"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/celo-org/celo-blockchain/accounts/abi"
@@ -34,6 +35,7 @@ import (
 var (
 	emptyMessage                = types.NewMessage(common.HexToAddress("0x0"), nil, 0, common.Big0, 0, common.Big0, nil, nil, common.Big0, []byte{}, false)
 	internalEvmHandlerSingleton *InternalEVMHandler
+	internalEvmHandlerMu        sync.RWMutex
 )
 
 // An EVM handler to make calls to smart contracts from within geth
@@ -57,6 +59,16 @@ func MakeStaticCallWithAddress(scAddress common.Address, abi abi.ABI, funcName s
 	return makeCallFromSystem(scAddress, abi, funcName, args, returnObj, gas, nil, header, state, true)
 }
 
+// MakeCallFrom executes a call with raw input data on behalf of the sender, as a contract
+// calling another one would (e.g. the Governance contract executing a proposal)
+func MakeCallFrom(sender common.Address, scAddress common.Address, input []byte, gas uint64, value *big.Int, header *types.Header, state vm.StateDB) ([]byte, uint64, error) {
+	vmevm, err := createEVM(header, state)
+	if err != nil {
+		return nil, 0, err
+	}
+	return vmevm.Call(vm.AccountRef(sender), scAddress, input, gas, value)
+}
+
 func GetRegisteredAddress(registryId [32]byte, header *types.Header, state vm.StateDB) (*common.Address, error) {
 	vmevm, err := createEVM(header, state)
 	if err != nil {
@@ -70,17 +82,18 @@ func createEVM(header *types.Header, state vm.StateDB) (*vm.EVM, error) {
 	// there are times (e.g. retrieving the set of validators when an epoch ends) that we need
 	// to call the evm using the currently mined block.  In that case, the header and state params
 	// will be non nil.
-	if internalEvmHandlerSingleton == nil {
+	chain := InternalEVMChain()
+	if chain == nil {
 		return nil, errors.ErrNoInternalEvmHandlerSingleton
 	}
 
 	if header == nil {
-		header = internalEvmHandlerSingleton.chain.CurrentHeader()
+		header = chain.CurrentHeader()
 	}
 
 	if state == nil || reflect.ValueOf(state).IsNil() {
 		var err error
-		state, err = internalEvmHandlerSingleton.chain.State()
+		state, err = chain.State()
 		if err != nil {
 			log.Error("Error in retrieving the state from the blockchain", "err", err)
 			return nil, err
@@ -89,8 +102,8 @@ func createEVM(header *types.Header, state vm.StateDB) (*vm.EVM, error) {
 
 	// The EVM Context requires a msg, but the actual field values don't really matter for this case.
 	// Putting in zero values.
-	context := vm.NewEVMContext(emptyMessage, header, internalEvmHandlerSingleton.chain, nil)
-	evm := vm.NewEVM(context, state, internalEvmHandlerSingleton.chain.Config(), *internalEvmHandlerSingleton.chain.GetVMConfig())
+	context := vm.NewEVMContext(emptyMessage, header, chain, nil)
+	evm := vm.NewEVM(context, state, chain.Config(), *chain.GetVMConfig())
 
 	return evm, nil
 }
@@ -122,6 +135,9 @@ func makeCallFromSystem(scAddress common.Address, abi abi.ABI, funcName string,
 }
 
 func SetInternalEVMHandler(chain vm.ChainContext) {
+	internalEvmHandlerMu.Lock()
+	defer internalEvmHandlerMu.Unlock()
+
 	if internalEvmHandlerSingleton == nil {
 		log.Trace("Setting the InternalEVMHandler Singleton")
 		internalEvmHandler := InternalEVMHandler{
@@ -131,6 +147,30 @@ func SetInternalEVMHandler(chain vm.ChainContext) {
 	}
 }
 
+// UnsetInternalEVMHandler unsets the InternalEVMHandler if it was set with the chain, so
+// that the next chain set replaces it once the chain is stopped.
+func UnsetInternalEVMHandler(chain vm.ChainContext) {
+	internalEvmHandlerMu.Lock()
+	defer internalEvmHandlerMu.Unlock()
+
+	if internalEvmHandlerSingleton != nil && internalEvmHandlerSingleton.chain == chain {
+		log.Trace("Unsetting the InternalEVMHandler Singleton")
+		internalEvmHandlerSingleton = nil
+	}
+}
+
+// InternalEVMChain returns the chain of the InternalEVMHandler, or nil if it isn't set. The
+// handler is process-wide, so it's set by the first running chain of the process.
+func InternalEVMChain() vm.ChainContext {
+	internalEvmHandlerMu.RLock()
+	defer internalEvmHandlerMu.RUnlock()
+
+	if internalEvmHandlerSingleton == nil {
+		return nil
+	}
+	return internalEvmHandlerSingleton.chain
+}
+
 func makeCallWithContractId(registryId [32]byte, abi abi.ABI, funcName string, args []interface{}, returnObj interface{}, gas uint64, value *big.Int, header *types.Header, state vm.StateDB, static bool) (uint64, error) {
 	scAddress, err := GetRegisteredAddress(registryId, header, state)
 
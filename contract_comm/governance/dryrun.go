@@ -0,0 +1,321 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package governance previews the effect of Governance proposals, by executing
+// their transactions against the state of a block on behalf of the Governance
+// contract.
+package governance
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/celo-org/celo-blockchain/accounts/abi"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/common/math"
+	"github.com/celo-org/celo-blockchain/contract_comm"
+	"github.com/celo-org/celo-blockchain/contract_comm/blockchain_parameters"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"github.com/celo-org/celo-blockchain/crypto"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+	"github.com/celo-org/celo-blockchain/trie"
+)
+
+// registryNames are the names of the contracts whose storage changes are reported.
+// The names are taken from celo-monorepo/packages/protocol/lib/registry-utils.ts
+var registryNames = []string{
+	"Accounts",
+	"Attestations",
+	"BlockchainParameters",
+	"DoubleSigningSlasher",
+	"DowntimeSlasher",
+	"Election",
+	"EpochRewards",
+	"Escrow",
+	"Exchange",
+	"ExchangeEUR",
+	"FeeCurrencyWhitelist",
+	"Freezer",
+	"GasPriceMinimum",
+	"GoldToken",
+	"Governance",
+	"GovernanceSlasher",
+	"LockedGold",
+	"Random",
+	"Reserve",
+	"SortedOracles",
+	"StableToken",
+	"StableTokenEUR",
+	"TransferWhitelist",
+	"Validators",
+}
+
+// Transaction is a transaction of a proposal, executed by the Governance contract
+type Transaction struct {
+	Destination common.Address        `json:"destination"`
+	Value       *math.HexOrDecimal256 `json:"value"`
+	Data        hexutil.Bytes         `json:"data"`
+}
+
+// Proposal is the list of transactions of a Governance proposal
+type Proposal []Transaction
+
+// Result reports the effects of a proposal
+type Result struct {
+	Block      uint64         `json:"block"`
+	Governance common.Address `json:"governance"`
+	Success    bool           `json:"success"` // Whether all the transactions succeeded, as required by Governance
+	GasUsed    uint64         `json:"gasUsed"`
+	Steps      []*StepResult  `json:"steps"`
+
+	// Contracts listed in the registry before or after the proposal, whose storage changed
+	StorageDiffs []*StorageDiff `json:"storageDiffs"`
+
+	// Protocol parameters read by the node from the core contracts
+	Before     *Parameters        `json:"before"`
+	After      *Parameters        `json:"after"`
+	Parameters []*ParameterChange `json:"parameters"` // Parameters changed by the proposal
+}
+
+// StepResult reports the execution of a proposal transaction
+type StepResult struct {
+	Index       int            `json:"index"`
+	Destination common.Address `json:"destination"`
+	Contract    string         `json:"contract,omitempty"` // Registry name of the destination
+	Success     bool           `json:"success"`
+	Error       string         `json:"error,omitempty"`
+	GasUsed     uint64         `json:"gasUsed"`
+	ReturnData  hexutil.Bytes  `json:"returnData"`
+	Events      []*Event       `json:"events"`
+}
+
+// Event is a log emitted by a proposal transaction
+type Event struct {
+	Address  common.Address `json:"address"`
+	Contract string         `json:"contract,omitempty"` // Registry name of the emitter
+	Topics   []common.Hash  `json:"topics"`
+	Data     hexutil.Bytes  `json:"data"`
+}
+
+// StorageDiff reports the storage slots of a contract changed by a proposal
+type StorageDiff struct {
+	Address  common.Address `json:"address"`
+	Contract string         `json:"contract"`
+	Slots    []*SlotChange  `json:"slots"`
+}
+
+// SlotChange is a changed storage slot. If the slot preimage is unknown, the
+// key is the hash of the slot.
+type SlotChange struct {
+	Key    common.Hash `json:"key"`
+	Hashed bool        `json:"hashed,omitempty"`
+	Before common.Hash `json:"before"`
+	After  common.Hash `json:"after"`
+}
+
+// Parameters are the protocol parameters read from the core contracts by contract_comm.
+// Parameters which couldn't be read are left empty, and their errors reported.
+type Parameters struct {
+	MinimumClientVersion                  *params.VersionInfo         `json:"minimumClientVersion"`
+	BlockGasLimit                         uint64                      `json:"blockGasLimit"`
+	IntrinsicGasForAlternativeFeeCurrency uint64                      `json:"intrinsicGasForAlternativeFeeCurrency"`
+	GasPriceMinimum                       *big.Int                    `json:"gasPriceMinimum"`
+	FeeCurrencyWhitelist                  []common.Address            `json:"feeCurrencyWhitelist"`
+	FeeCurrencyGasPriceMinimums           map[common.Address]*big.Int `json:"feeCurrencyGasPriceMinimums"`
+	TransferWhitelist                     []common.Address            `json:"transferWhitelist"`
+	RegisteredValidators                  []common.Address            `json:"registeredValidators"`
+	ElectedValidators                     []common.Address            `json:"electedValidators"`
+	ValidatorEpochReward                  *big.Int                    `json:"validatorEpochReward"`
+	VoterRewards                          *big.Int                    `json:"voterRewards"`
+	CommunityReward                       *big.Int                    `json:"communityReward"`
+	CarbonOffsettingPartnerReward         *big.Int                    `json:"carbonOffsettingPartnerReward"`
+	CarbonOffsettingPartner               common.Address              `json:"carbonOffsettingPartner"`
+	ReserveLow                            bool                        `json:"reserveLow"`
+	Errors                                map[string]string           `json:"errors,omitempty"`
+}
+
+// ParameterChange is a protocol parameter changed by a proposal
+type ParameterChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DryRun executes the proposal transactions on top of the state of the header, on
+// behalf of the Governance contract, and reports their effects. Unlike Governance,
+// which reverts the whole proposal, the transactions following a failed one are
+// still executed. The statedb is modified, and the contract_comm internal EVM
+// handler must be set.
+func DryRun(proposal Proposal, header *types.Header, statedb *state.StateDB) (*Result, error) {
+	governance, err := contract_comm.GetRegisteredAddress(params.GovernanceRegistryId, header, statedb)
+	if err != nil {
+		return nil, fmt.Errorf("can't find the Governance contract: %w", err)
+	}
+	// If an error occurs, the default block gas limit will be returned and a log statement will be produced by contract_comm
+	gasLimit, _ := blockchain_parameters.GetBlockGasLimit(header, statedb)
+
+	before := statedb.Copy()
+	result := &Result{
+		Block:      header.Number.Uint64(),
+		Governance: *governance,
+		Success:    true,
+		Before:     readParameters(header, before.Copy()),
+	}
+	contracts := registryContracts(header, before.Copy())
+
+	for i, tx := range proposal {
+		step := &StepResult{
+			Index:       i,
+			Destination: tx.Destination,
+			Contract:    contracts[tx.Destination],
+			Events:      []*Event{},
+		}
+		// The transactions are executed within the Governance execute transaction
+		var gas uint64
+		if result.GasUsed < gasLimit {
+			gas = gasLimit - result.GasUsed
+		}
+		value := new(big.Int)
+		if tx.Value != nil {
+			value = (*big.Int)(tx.Value)
+		}
+		txHash := common.BigToHash(big.NewInt(int64(i)))
+		statedb.Prepare(txHash, header.Hash(), i)
+
+		ret, gasLeft, err := contract_comm.MakeCallFrom(*governance, tx.Destination, tx.Data, gas, value, header, statedb)
+		step.GasUsed = gas - gasLeft
+		step.ReturnData = ret
+		result.GasUsed += step.GasUsed
+		if err != nil {
+			step.Error = err.Error()
+			if reason, unpackErr := abi.UnpackRevert(ret); unpackErr == nil {
+				step.Error = fmt.Sprintf("%v: %s", err, reason)
+			}
+			result.Success = false
+		} else {
+			step.Success = true
+			for _, log := range statedb.GetLogs(txHash) {
+				step.Events = append(step.Events, &Event{
+					Address:  log.Address,
+					Contract: contracts[log.Address],
+					Topics:   log.Topics,
+					Data:     log.Data,
+				})
+			}
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	statedb.Finalise(true)
+
+	// The proposal can register new contracts, or replace existing ones
+	for address, name := range registryContracts(header, statedb.Copy()) {
+		contracts[address] = name
+	}
+	if result.StorageDiffs, err = storageDiffs(contracts, before, statedb); err != nil {
+		return nil, err
+	}
+
+	result.After = readParameters(header, statedb.Copy())
+	result.Parameters = parameterChanges(result.Before, result.After)
+	return result, nil
+}
+
+// registryContracts returns the names of the registered contracts by address
+func registryContracts(header *types.Header, statedb vm.StateDB) map[common.Address]string {
+	contracts := map[common.Address]string{
+		params.RegistrySmartContractAddress: "Registry",
+	}
+	for _, name := range registryNames {
+		var id [32]byte
+		copy(id[:], crypto.Keccak256([]byte(name)))
+		if address, err := contract_comm.GetRegisteredAddress(id, header, statedb); err == nil {
+			contracts[*address] = name
+		}
+	}
+	return contracts
+}
+
+// storageDiffs compares the storage of the contracts before and after the proposal
+func storageDiffs(contracts map[common.Address]string, before, after *state.StateDB) ([]*StorageDiff, error) {
+	var diffs []*StorageDiff
+	for address, name := range contracts {
+		slots, err := storageDiff(before.StorageTrie(address), after.StorageTrie(address))
+		if err != nil {
+			return nil, fmt.Errorf("can't compare %s storage: %w", name, err)
+		}
+		if len(slots) > 0 {
+			diffs = append(diffs, &StorageDiff{Address: address, Contract: name, Slots: slots})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Contract < diffs[j].Contract })
+	return diffs, nil
+}
+
+// storageDiff returns the slots which differ between two storage tries, any of which can be nil
+func storageDiff(before, after state.Trie) ([]*SlotChange, error) {
+	changes := make(map[common.Hash]*SlotChange)
+
+	// collect sets the values of the slots of b which differ from a
+	collect := func(a, b state.Trie, set func(change *SlotChange, value common.Hash)) error {
+		if b == nil {
+			return nil
+		}
+		// Hashing the tries lets the iterator skip their common subtries
+		b.Hash()
+		it := b.NodeIterator(nil)
+		if a != nil {
+			a.Hash()
+			it, _ = trie.NewDifferenceIterator(a.NodeIterator(nil), it)
+		}
+		leaves := trie.NewIterator(it)
+		for leaves.Next() {
+			hash := common.BytesToHash(leaves.Key)
+			change, ok := changes[hash]
+			if !ok {
+				change = &SlotChange{Key: hash, Hashed: true}
+				if key := b.GetKey(leaves.Key); key != nil {
+					change.Key, change.Hashed = common.BytesToHash(key), false
+				}
+				changes[hash] = change
+			}
+			_, value, _, err := rlp.Split(leaves.Value)
+			if err != nil {
+				return err
+			}
+			set(change, common.BytesToHash(value))
+		}
+		return leaves.Err
+	}
+	if err := collect(before, after, func(change *SlotChange, value common.Hash) { change.After = value }); err != nil {
+		return nil, err
+	}
+	if err := collect(after, before, func(change *SlotChange, value common.Hash) { change.Before = value }); err != nil {
+		return nil, err
+	}
+
+	slots := make([]*SlotChange, 0, len(changes))
+	for _, change := range changes {
+		slots = append(slots, change)
+	}
+	sort.Slice(slots, func(i, j int) bool { return bytes.Compare(slots[i].Key[:], slots[j].Key[:]) < 0 })
+	return slots, nil
+}
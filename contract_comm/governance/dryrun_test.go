@@ -0,0 +1,200 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package governance
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/contract_comm"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"github.com/celo-org/celo-blockchain/params"
+)
+
+func TestDryRun(t *testing.T) {
+	var (
+		governance = common.HexToAddress("0xd023")
+		reverter   = common.HexToAddress("0xbad")
+
+		// The registry returns the governance address for any contract
+		registryCode = append(append([]byte{0x73}, governance.Bytes()...), hexutil.MustDecode("0x60005260206000f3")...)
+		// The governance stores its caller in slot 0, and emits an event with topic 1
+		governanceCode = hexutil.MustDecode("0x33600055600160006000a100")
+		reverterCode   = hexutil.MustDecode("0x60006000fd")
+	)
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			params.RegistrySmartContractAddress: {Balance: common.Big0, Code: registryCode},
+			governance:                          {Balance: common.Big0, Code: governanceCode},
+			reverter:                            {Balance: common.Big0, Code: reverterCode},
+		},
+	}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, mockEngine.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	contract_comm.SetInternalEVMHandler(chain)
+
+	statedb, err := chain.StateAt(genesis.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := DryRun(Proposal{
+		{Destination: governance, Data: []byte{}},
+		{Destination: reverter, Data: []byte{}},
+	}, genesis.Header(), statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Governance != governance {
+		t.Errorf("governance: have %s, want %s", result.Governance.Hex(), governance.Hex())
+	}
+	if result.Success {
+		t.Errorf("proposal succeeded despite the reverted transaction")
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("steps: have %d, want 2", len(result.Steps))
+	}
+	if step := result.Steps[0]; !step.Success || len(step.Events) != 1 || step.Events[0].Topics[0] != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("first step: have %+v, want a success with an event", step)
+	}
+	if step := result.Steps[1]; step.Success || step.Error == "" || len(step.Events) != 0 {
+		t.Errorf("second step: have %+v, want a failure", step)
+	}
+
+	// The governance stored itself as the caller of its transaction
+	if len(result.StorageDiffs) != 1 || result.StorageDiffs[0].Address != governance {
+		t.Fatalf("storage diffs: have %+v, want the governance storage", result.StorageDiffs)
+	}
+	slots := result.StorageDiffs[0].Slots
+	want := SlotChange{Key: common.Hash{}, After: governance.Hash()}
+	if len(slots) != 1 || *slots[0] != want {
+		t.Errorf("slots: have %+v, want %+v", slots, want)
+	}
+}
+
+func TestDryRunParameters(t *testing.T) {
+	var (
+		parameters = common.HexToAddress("0xb10c")
+
+		// The registry returns the parameters address for any contract, including Governance
+		registryCode = append(append([]byte{0x73}, parameters.Bytes()...), hexutil.MustDecode("0x60005260206000f3")...)
+		// The parameters contract stores the argument of calls to 0x12345678 in slot 0, and returns
+		// slot 0 for any other call, such as blockGasLimit()
+		parametersCode = hexutil.MustDecode("0x60003560e01c631234567814601a5760005460005260206000f35b60043560005500")
+	)
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			params.RegistrySmartContractAddress: {Balance: common.Big0, Code: registryCode},
+			parameters: {
+				Balance: common.Big0,
+				Code:    parametersCode,
+				Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(20000000))},
+			},
+		},
+	}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, mockEngine.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	contract_comm.SetInternalEVMHandler(chain)
+
+	statedb, err := chain.StateAt(genesis.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(hexutil.MustDecode("0x12345678"), common.BigToHash(big.NewInt(30000000)).Bytes()...)
+	result, err := DryRun(Proposal{{Destination: parameters, Data: data}}, genesis.Header(), statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("proposal failed: %+v", result.Steps[0])
+	}
+
+	if result.Before.BlockGasLimit != 20000000 || result.After.BlockGasLimit != 30000000 {
+		t.Errorf("block gas limit: have %d before and %d after, want 20000000 and 30000000", result.Before.BlockGasLimit, result.After.BlockGasLimit)
+	}
+	var change *ParameterChange
+	for _, c := range result.Parameters {
+		if c.Name == "blockGasLimit" {
+			change = c
+		}
+	}
+	want := ParameterChange{Name: "blockGasLimit", Before: "20000000", After: "30000000"}
+	if change == nil || *change != want {
+		t.Errorf("block gas limit change: have %+v, want %+v", change, want)
+	}
+}
+
+func TestParameterChanges(t *testing.T) {
+	var (
+		cusd = common.HexToAddress("0xc05d")
+		ceur = common.HexToAddress("0xceee")
+	)
+	before := &Parameters{
+		BlockGasLimit:        20000000,
+		GasPriceMinimum:      big.NewInt(100),
+		FeeCurrencyWhitelist: []common.Address{cusd, ceur},
+		FeeCurrencyGasPriceMinimums: map[common.Address]*big.Int{
+			cusd: big.NewInt(200),
+			ceur: big.NewInt(300),
+		},
+	}
+	after := &Parameters{
+		BlockGasLimit:        20000000,
+		GasPriceMinimum:      big.NewInt(150),
+		FeeCurrencyWhitelist: []common.Address{cusd},
+		FeeCurrencyGasPriceMinimums: map[common.Address]*big.Int{
+			cusd: big.NewInt(200),
+		},
+	}
+
+	want := []ParameterChange{
+		{Name: "gasPriceMinimum", Before: "100", After: "150"},
+		{Name: "feeCurrencyWhitelist", Before: formatAddresses(before.FeeCurrencyWhitelist), After: formatAddresses(after.FeeCurrencyWhitelist)},
+		// The removed currency is reported without a value after the proposal
+		{Name: "gasPriceMinimum(" + ceur.Hex() + ")", Before: "300"},
+	}
+	changes := parameterChanges(before, after)
+	if len(changes) != len(want) {
+		t.Fatalf("changes: have %+v, want %+v", changes, want)
+	}
+	for i, change := range changes {
+		if *change != want[i] {
+			t.Errorf("change %d: have %+v, want %+v", i, change, want[i])
+		}
+	}
+
+	// Adding the currency back reports it without a value before the proposal
+	changes = parameterChanges(after, before)
+	if last := changes[len(changes)-1]; *last != (ParameterChange{Name: "gasPriceMinimum(" + ceur.Hex() + ")", After: "300"}) {
+		t.Errorf("added currency: have %+v", last)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package governance
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/contract_comm/blockchain_parameters"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
+	"github.com/celo-org/celo-blockchain/contract_comm/election"
+	"github.com/celo-org/celo-blockchain/contract_comm/epoch_rewards"
+	"github.com/celo-org/celo-blockchain/contract_comm/gasprice_minimum"
+	"github.com/celo-org/celo-blockchain/contract_comm/transfer_whitelist"
+	"github.com/celo-org/celo-blockchain/contract_comm/validators"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/core/vm"
+)
+
+// readParameters reads the protocol parameters at the header and state
+func readParameters(header *types.Header, state vm.StateDB) *Parameters {
+	p := &Parameters{
+		FeeCurrencyGasPriceMinimums: make(map[common.Address]*big.Int),
+		Errors:                      make(map[string]string),
+	}
+	fail := func(name string, err error) {
+		if err != nil {
+			p.Errors[name] = err.Error()
+		}
+	}
+
+	var err error
+	p.MinimumClientVersion, err = blockchain_parameters.GetMinimumVersion(header, state)
+	fail("minimumClientVersion", err)
+	p.BlockGasLimit, err = blockchain_parameters.GetBlockGasLimit(header, state)
+	fail("blockGasLimit", err)
+	p.IntrinsicGasForAlternativeFeeCurrency = blockchain_parameters.GetIntrinsicGasForAlternativeFeeCurrency(header, state)
+
+	p.GasPriceMinimum, err = gasprice_minimum.GetGasPriceMinimum(nil, header, state)
+	fail("gasPriceMinimum", err)
+	p.FeeCurrencyWhitelist, err = currency.CurrencyWhitelist(header, state)
+	fail("feeCurrencyWhitelist", err)
+	for _, address := range p.FeeCurrencyWhitelist {
+		address := address
+		p.FeeCurrencyGasPriceMinimums[address], err = gasprice_minimum.GetGasPriceMinimum(&address, header, state)
+		fail("feeCurrencyGasPriceMinimums", err)
+	}
+	p.TransferWhitelist, err = transfer_whitelist.GetWhitelist(header, state)
+	fail("transferWhitelist", err)
+
+	p.RegisteredValidators, err = validators.RetrieveRegisteredValidators(header, state)
+	fail("registeredValidators", err)
+	p.ElectedValidators, err = election.GetElectedValidators(header, state)
+	fail("electedValidators", err)
+
+	p.ValidatorEpochReward, p.VoterRewards, p.CommunityReward, p.CarbonOffsettingPartnerReward, err = epoch_rewards.CalculateTargetEpochRewards(header, state)
+	fail("epochRewards", err)
+	p.CarbonOffsettingPartner, err = epoch_rewards.GetCarbonOffsettingPartnerAddress(header, state)
+	fail("carbonOffsettingPartner", err)
+	p.ReserveLow, err = epoch_rewards.IsReserveLow(header, state)
+	fail("reserveLow", err)
+
+	return p
+}
+
+// values returns the parameters formatted by name, in a fixed order
+func (p *Parameters) values() [][2]string {
+	values := [][2]string{
+		{"minimumClientVersion", fmt.Sprint(p.MinimumClientVersion)},
+		{"blockGasLimit", fmt.Sprint(p.BlockGasLimit)},
+		{"intrinsicGasForAlternativeFeeCurrency", fmt.Sprint(p.IntrinsicGasForAlternativeFeeCurrency)},
+		{"gasPriceMinimum", fmt.Sprint(p.GasPriceMinimum)},
+		{"feeCurrencyWhitelist", formatAddresses(p.FeeCurrencyWhitelist)},
+	}
+	currencies := make([]common.Address, 0, len(p.FeeCurrencyGasPriceMinimums))
+	for address := range p.FeeCurrencyGasPriceMinimums {
+		currencies = append(currencies, address)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Hex() < currencies[j].Hex() })
+	for _, address := range currencies {
+		values = append(values, [2]string{"gasPriceMinimum(" + address.Hex() + ")", fmt.Sprint(p.FeeCurrencyGasPriceMinimums[address])})
+	}
+	return append(values,
+		[2]string{"transferWhitelist", formatAddresses(p.TransferWhitelist)},
+		[2]string{"registeredValidators", formatAddresses(p.RegisteredValidators)},
+		[2]string{"electedValidators", formatAddresses(p.ElectedValidators)},
+		[2]string{"validatorEpochReward", fmt.Sprint(p.ValidatorEpochReward)},
+		[2]string{"voterRewards", fmt.Sprint(p.VoterRewards)},
+		[2]string{"communityReward", fmt.Sprint(p.CommunityReward)},
+		[2]string{"carbonOffsettingPartnerReward", fmt.Sprint(p.CarbonOffsettingPartnerReward)},
+		[2]string{"carbonOffsettingPartner", p.CarbonOffsettingPartner.Hex()},
+		[2]string{"reserveLow", fmt.Sprint(p.ReserveLow)},
+	)
+}
+
+func formatAddresses(addresses []common.Address) string {
+	return fmt.Sprint(common.ConvertToStringSlice(addresses))
+}
+
+// parameterChanges returns the parameters which differ between before and after
+func parameterChanges(before, after *Parameters) []*ParameterChange {
+	previous := make(map[string]string)
+	for _, value := range before.values() {
+		previous[value[0]] = value[1]
+	}
+	changes := []*ParameterChange{}
+	for _, value := range after.values() {
+		if old, ok := previous[value[0]]; !ok || old != value[1] {
+			changes = append(changes, &ParameterChange{Name: value[0], Before: old, After: value[1]})
+		}
+		delete(previous, value[0])
+	}
+	// Fee currencies removed from the whitelist
+	for _, value := range before.values() {
+		if _, ok := previous[value[0]]; ok {
+			changes = append(changes, &ParameterChange{Name: value[0], Before: value[1]})
+		}
+	}
+	return changes
+}
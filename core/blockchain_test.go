@@ -28,8 +28,10 @@ import (
 	"time"
 
 	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/celo-org/celo-blockchain/consensus"
 	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/contract_comm"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
 	"github.com/celo-org/celo-blockchain/core/state"
 	"github.com/celo-org/celo-blockchain/core/types"
@@ -809,6 +811,210 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	assert(t, "light", light, height/2, 0, 0)
 }
 
+// Tests that the fees of sponsored transactions are paid by the fee payer, while
+// the sender only pays the value.
+func TestSponsoredTransactionFees(t *testing.T) {
+	var (
+		senderKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		feePayerKey, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		sender         = crypto.PubkeyToAddress(senderKey.PublicKey)
+		feePayer       = crypto.PubkeyToAddress(feePayerKey.PublicKey)
+		recipient      = common.HexToAddress("0xdeadbeef")
+		funds          = big.NewInt(1000000)
+		config         = *params.IstanbulTestChainConfig
+		db             = rawdb.NewMemoryDatabase()
+	)
+	config.DonutBlock = common.Big0
+	config.EspressoBlock = common.Big0
+	gspec := &Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			sender:   {Balance: funds},
+			feePayer: {Balance: funds},
+		},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.LatestSigner(&config)
+
+	tx := types.NewTransaction(0, recipient, big.NewInt(1000), params.TxGas, big.NewInt(2), nil, nil, nil, nil).WithFeePayer(config.ChainID, feePayer)
+	tx, _ = types.SignTx(tx, signer, senderKey)
+	tx, _ = types.SignFeePayer(tx, signer, feePayerKey)
+
+	chain, _ := GenerateChain(&config, genesis, mockEngine.NewFaker(), db, 1, func(i int, gen *BlockGen) {
+		gen.AddTx(tx)
+	})
+	blockchain, _ := NewBlockChain(db, nil, &config, mockEngine.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain[%d]: %v", i, err)
+	}
+	state, _ := blockchain.State()
+	if have, want := state.GetBalance(sender), new(big.Int).Sub(funds, big.NewInt(1000)); have.Cmp(want) != 0 {
+		t.Errorf("sender balance mismatch: have %v, want %v", have, want)
+	}
+	if have, want := state.GetBalance(feePayer), new(big.Int).Sub(funds, big.NewInt(2*int64(params.TxGas))); have.Cmp(want) != 0 {
+		t.Errorf("fee payer balance mismatch: have %v, want %v", have, want)
+	}
+	if have := state.GetNonce(sender); have != 1 {
+		t.Errorf("sender nonce mismatch: have %d, want 1", have)
+	}
+	if receipts := blockchain.GetReceiptsByHash(chain[0].Hash()); len(receipts) == 0 || receipts[0].Type != types.SponsoredTxType {
+		t.Errorf("sponsored transaction receipt type mismatch: have %v, want %d", receipts, types.SponsoredTxType)
+	}
+}
+
+// Tests that access list transactions are processed once the Espresso fork is
+// activated, warming the accesses of their access list as per EIP-2929.
+func TestAccessListTransactions(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xaaaa")
+		config   = *params.IstanbulTestChainConfig
+		db       = rawdb.NewMemoryDatabase()
+	)
+	config.DonutBlock = common.Big0
+	config.EspressoBlock = common.Big0
+	gspec := &Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			sender: {Balance: big.NewInt(1000000000)},
+			// The contract code loads the slots 1 and 0: PC PC SLOAD SLOAD
+			contract: {Balance: common.Big0, Code: []byte{byte(vm.PC), byte(vm.PC), byte(vm.SLOAD), byte(vm.SLOAD)}},
+		},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.LatestSigner(&config)
+
+	accessList := types.AccessList{{Address: contract, StorageKeys: []common.Hash{{0}}}}
+	accessListTx := types.NewTransaction(0, contract, common.Big0, 30000, big.NewInt(1), nil, nil, nil, nil).WithAccessList(config.ChainID, accessList)
+	accessListTx, _ = types.SignTx(accessListTx, signer, key)
+	legacyTx, _ := types.SignTx(types.NewTransaction(1, contract, common.Big0, 30000, big.NewInt(1), nil, nil, nil, nil), signer, key)
+
+	chain, _ := GenerateChain(&config, genesis, mockEngine.NewFaker(), db, 1, func(i int, gen *BlockGen) {
+		gen.AddTx(accessListTx)
+		gen.AddTx(legacyTx)
+	})
+	blockchain, _ := NewBlockChain(db, nil, &config, mockEngine.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain[%d]: %v", i, err)
+	}
+	receipts := blockchain.GetReceiptsByHash(chain[0].Hash())
+	if len(receipts) < 2 {
+		t.Fatalf("receipt count mismatch: have %d, want 2", len(receipts))
+	}
+	// The access list pays for the address and the slot 0, which is then warm
+	want := params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas +
+		2*vm.GasQuickStep + params.ColdSloadCostEIP2929 + params.WarmStorageReadCostEIP2929
+	if receipts[0].GasUsed != want {
+		t.Errorf("access list transaction gas used mismatch: have %d, want %d", receipts[0].GasUsed, want)
+	}
+	if receipts[0].Type != types.AccessListTxType {
+		t.Errorf("access list transaction receipt type mismatch: have %d, want %d", receipts[0].Type, types.AccessListTxType)
+	}
+	// Without access list both slots are cold
+	want = params.TxGas + 2*vm.GasQuickStep + 2*params.ColdSloadCostEIP2929
+	if receipts[1].GasUsed != want {
+		t.Errorf("legacy transaction gas used mismatch: have %d, want %d", receipts[1].GasUsed, want)
+	}
+	if receipts[1].Type != types.LegacyTxType {
+		t.Errorf("legacy transaction receipt type mismatch: have %d, want %d", receipts[1].Type, types.LegacyTxType)
+	}
+}
+
+// Tests that access list transactions are rejected before the Espresso fork.
+func TestAccessListTransactionsBeforeEspresso(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.IstanbulTestChainConfig
+		db     = rawdb.NewMemoryDatabase()
+	)
+	gspec := &Genesis{
+		Config: &config,
+		Alloc:  GenesisAlloc{sender: {Balance: big.NewInt(1000000000)}},
+	}
+	genesis := gspec.MustCommit(db)
+
+	tx := types.NewTransaction(0, common.HexToAddress("0xaaaa"), common.Big0, 30000, big.NewInt(1), nil, nil, nil, nil).WithAccessList(config.ChainID, nil)
+	tx, _ = types.SignTx(tx, types.LatestSignerForChainID(config.ChainID), key)
+
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(db), nil)
+	blockchain, _ := NewBlockChain(db, nil, &config, mockEngine.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+	header := &types.Header{ParentHash: genesis.Hash(), Number: common.Big1}
+	var gasUsed uint64
+	_, err := ApplyTransaction(&config, blockchain, nil, new(GasPool).AddGas(params.DefaultGasLimit), statedb, header, tx, &gasUsed, vm.Config{})
+	if err != types.ErrTxTypeNotSupported {
+		t.Errorf("error mismatch: have %v, want %v", err, types.ErrTxTypeNotSupported)
+	}
+}
+
+// Tests that transactions paying their fees in a non-CELO currency are processed once
+// the Espresso fork is activated, and that the fee currency is debited and credited with
+// the gas schedule before EIP-2929, which their gas budgets were measured with.
+func TestFeeCurrencyTransactionsAfterEspresso(t *testing.T) {
+	var (
+		sender    = common.HexToAddress("0x1000")
+		recipient = common.HexToAddress("0x2000")
+		token     = common.HexToAddress("0xfee")
+
+		// The registry returns the token for the fee currency whitelist, and no other contract
+		registryCode = hexutil.MustDecode("0x7f" + common.Bytes2Hex(params.FeeCurrencyWhitelistRegistryId[:]) +
+			"6004351473" + common.Bytes2Hex(token.Bytes()) + "0260005260206000f3")
+		// The token whitelists itself, has an unlimited balance for anyone, and stores the
+		// debited amount in slot 1, the gas left after storing it in slot 3, and the
+		// credited tip in slot 2
+		tokenCode = hexutil.MustDecode("0x60003560e01c8063d01f63f51461003357806370a082311461004757806358cf9672146100535780636a30b2531461005f57" +
+			"005b602060005260016020523060405260606000f35b60001960005260206000f35b6024356001555a600355005b60a43560025500")
+
+		config = *params.IstanbulTestChainConfig
+		db     = rawdb.NewMemoryDatabase()
+	)
+	config.DonutBlock = common.Big0
+	config.EspressoBlock = common.Big0
+	genesis := (&Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			params.RegistrySmartContractAddress: {Balance: common.Big0, Code: registryCode},
+			token:                               {Balance: common.Big0, Code: tokenCode},
+			sender:                              {Balance: common.Big1},
+		},
+	}).MustCommit(db)
+	blockchain, _ := NewBlockChain(db, nil, &config, mockEngine.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+	contract_comm.SetInternalEVMHandler(blockchain)
+
+	// transfer applies the transfer with the chain config, and returns the gas left to the debit
+	transfer := func(config *params.ChainConfig) uint64 {
+		statedb, _ := state.New(genesis.Root(), state.NewDatabase(db), nil)
+		header := &types.Header{ParentHash: genesis.Hash(), Number: common.Big1, Time: genesis.Time() + 5}
+		msg := types.NewMessage(sender, &recipient, 0, common.Big1, 100000, big.NewInt(3), &token, nil, common.Big0, nil, false)
+		evm := vm.NewEVM(vm.NewEVMContext(msg, header, blockchain, nil), statedb, config, vm.Config{})
+		result, err := ApplyMessage(evm, msg, new(GasPool).AddGas(params.DefaultGasLimit))
+		if err != nil {
+			t.Fatalf("failed to apply the transfer: %v", err)
+		}
+		if result.Failed() {
+			t.Fatalf("transfer failed: %v", result.Err)
+		}
+		if have, want := statedb.GetState(token, common.HexToHash("0x01")).Big(), big.NewInt(300000); have.Cmp(want) != 0 {
+			t.Errorf("debited amount mismatch: have %v, want %v", have, want)
+		}
+		if have, want := statedb.GetState(token, common.HexToHash("0x02")).Big(), new(big.Int).SetUint64(3*result.UsedGas); have.Cmp(want) != 0 {
+			t.Errorf("credited tip mismatch: have %v, want %v", have, want)
+		}
+		return statedb.GetState(token, common.HexToHash("0x03")).Big().Uint64()
+	}
+	beforeEspresso := config
+	beforeEspresso.EspressoBlock = nil
+	want := transfer(&beforeEspresso)
+	if have := transfer(&config); have != want {
+		t.Errorf("debit gas left mismatch: have %d, want %d", have, want)
+	}
+}
+
 // Tests that chain reorganisations handle transaction removals and reinsertions.
 func TestChainTxReorgs(t *testing.T) {
 	var (
@@ -16,7 +16,11 @@
 
 package core
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+)
 
 var (
 	// ErrKnownBlock is returned when a block to import is already known locally.
@@ -70,6 +74,14 @@ var (
 	// isn't one of the currencies whitelisted for that purpose.
 	ErrNonWhitelistedFeeCurrency = errors.New("non-whitelisted fee currency address")
 
+	// ErrSponsoredTxNotSupported is returned if a transaction paid by a fee payer
+	// is used before the Espresso fork.
+	ErrSponsoredTxNotSupported = errors.New("sponsored transactions not supported")
+
+	// ErrTxTypeNotSupported is returned if a typed transaction is used before the
+	// Espresso fork, or its type isn't supported.
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
+
 	// ErrGasUintOverflow is returned when calculating gas usage.
 	ErrGasUintOverflow = errors.New("gas uint64 overflow")
 
@@ -60,6 +60,8 @@ type StateTransition struct {
 	state           vm.StateDB
 	evm             *vm.EVM
 	gasPriceMinimum *big.Int
+
+	feeCurrencyGasUsed uint64 // Gas used by the calls to the fee currency contract
 }
 
 // ExecutionResult includes all output after executing given evm
@@ -68,6 +70,10 @@ type ExecutionResult struct {
 	UsedGas    uint64 // Total used gas but include the refunded gas
 	Err        error  // Any error encountered during the execution(listed in core/vm/errors.go)
 	ReturnData []byte // Returned data from evm(function result or data supplied with revert opcode)
+
+	// Gas used by the balanceOf, debit and credit calls to the fee currency contract,
+	// which is covered by the intrinsic gas instead of being charged separately
+	FeeCurrencyGasUsed uint64
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -97,8 +103,9 @@ func (result *ExecutionResult) Revert() []byte {
 	return common.CopyBytes(result.ReturnData)
 }
 
-// IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation bool, header *types.Header, state vm.StateDB, feeCurrency *common.Address, isEIP2028 bool) (uint64, error) {
+// IntrinsicGas computes the 'intrinsic gas' for a message with the given data
+// and access list.
+func IntrinsicGas(data []byte, accessList types.AccessList, contractCreation bool, header *types.Header, state vm.StateDB, feeCurrency *common.Address, isEIP2028 bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation {
@@ -134,6 +141,10 @@ func IntrinsicGas(data []byte, contractCreation bool, header *types.Header, stat
 		}
 		gas += z * params.TxDataZeroGas
 	}
+	if accessList != nil {
+		gas += uint64(len(accessList)) * params.TxAccessListAddressGas
+		gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	}
 
 	// This gas is used for charging user for one `debitFrom` transaction to deduct their balance in
 	// non-native currency and two `creditTo` transactions, one covers for the  miner fee in
@@ -204,7 +215,16 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
-// payFees deducts gas and gateway fees from sender balance and adds the purchased amount of gas to the state.
+// feePayer returns the account paying the fees of the message: its fee payer
+// if it is sponsored, the sender otherwise.
+func (st *StateTransition) feePayer() common.Address {
+	if st.msg.FeePayer() != nil {
+		return *st.msg.FeePayer()
+	}
+	return st.msg.From()
+}
+
+// payFees deducts gas and gateway fees from the fee payer balance and adds the purchased amount of gas to the state.
 func (st *StateTransition) payFees() error {
 	if st.msg.FeeCurrency() != nil && (!currency.IsWhitelisted(*st.msg.FeeCurrency(), st.evm.GetHeader(), st.evm.GetStateDB())) {
 		log.Trace("Fee currency not whitelisted", "fee currency address", st.msg.FeeCurrency())
@@ -218,7 +238,7 @@ func (st *StateTransition) payFees() error {
 		feeVal.Add(feeVal, st.msg.GatewayFee())
 	}
 
-	if !st.canPayFee(st.msg.From(), feeVal, st.msg.FeeCurrency()) {
+	if !st.canPayFee(st.feePayer(), feeVal, st.msg.FeeCurrency()) {
 		return ErrInsufficientFundsForFees
 	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
@@ -227,7 +247,7 @@ func (st *StateTransition) payFees() error {
 
 	st.initialGas = st.msg.Gas()
 	st.gas += st.msg.Gas()
-	err := st.debitFee(st.msg.From(), feeVal, st.msg.FeeCurrency())
+	err := st.debitFee(st.feePayer(), feeVal, st.msg.FeeCurrency())
 	return err
 }
 
@@ -238,6 +258,7 @@ func (st *StateTransition) canPayFee(accountOwner common.Address, fee *big.Int,
 
 	balanceOf, gasUsed, err := currency.GetBalanceOf(accountOwner, *feeCurrency, params.MaxGasToReadErc20Balance, st.evm.GetHeader(), st.evm.GetStateDB())
 	log.Debug("balanceOf called", "feeCurrency", *feeCurrency, "gasUsed", gasUsed)
+	st.feeCurrencyGasUsed += gasUsed
 
 	if err != nil {
 		return false
@@ -264,11 +285,11 @@ func (st *StateTransition) debitGas(address common.Address, amount *big.Int, fee
 		defer func() { evm.SetDebug(true) }()
 	}
 
-	rootCaller := vm.AccountRef(common.HexToAddress("0x0"))
 	// The caller was already charged for the cost of this operation via IntrinsicGas.
-	_, leftoverGas, err := evm.Call(rootCaller, *feeCurrency, transactionData, params.MaxGasForDebitGasFeesTransactions, big.NewInt(0))
+	_, leftoverGas, err := evm.SystemCall(*feeCurrency, transactionData, params.MaxGasForDebitGasFeesTransactions, big.NewInt(0))
 	gasUsed := params.MaxGasForDebitGasFeesTransactions - leftoverGas
 	log.Trace("debitGasFees called", "feeCurrency", *feeCurrency, "gasUsed", gasUsed)
+	st.feeCurrencyGasUsed += gasUsed
 	return err
 }
 
@@ -293,11 +314,11 @@ func (st *StateTransition) creditGasFees(
 		defer func() { evm.SetDebug(true) }()
 	}
 
-	rootCaller := vm.AccountRef(common.HexToAddress("0x0"))
 	// The caller was already charged for the cost of this operation via IntrinsicGas.
-	_, leftoverGas, err := evm.Call(rootCaller, *feeCurrency, transactionData, params.MaxGasForCreditGasFeesTransactions, big.NewInt(0))
+	_, leftoverGas, err := evm.SystemCall(*feeCurrency, transactionData, params.MaxGasForCreditGasFeesTransactions, big.NewInt(0))
 	gasUsed := params.MaxGasForCreditGasFeesTransactions - leftoverGas
 	log.Trace("creditGas called", "feeCurrency", *feeCurrency, "gasUsed", gasUsed)
+	st.feeCurrencyGasUsed += gasUsed
 	return err
 }
 
@@ -323,6 +344,14 @@ func (st *StateTransition) preCheck() error {
 		}
 	}
 
+	// Make sure fee payers and access lists are only used once the Espresso fork is activated.
+	if st.msg.FeePayer() != nil && !st.evm.ChainConfig().IsEspresso(st.evm.BlockNumber) {
+		return ErrSponsoredTxNotSupported
+	}
+	if st.msg.AccessList() != nil && !st.evm.ChainConfig().IsEspresso(st.evm.BlockNumber) {
+		return ErrTxTypeNotSupported
+	}
+
 	// Make sure this transaction's gas price is valid.
 	if st.gasPrice.Cmp(st.gasPriceMinimum) < 0 {
 		log.Error("Tx gas price is less than minimum", "minimum", st.gasPriceMinimum, "price", st.gasPrice)
@@ -351,13 +380,13 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	//
 	// 1. the nonce of the message caller is correct
 	// 2. the gas price meets the minimum gas price
-	// 3. caller has enough balance (in the right currency) to cover transaction fee
+	// 3. fee payer (the caller unless sponsored) has enough balance (in the right currency) to cover transaction fee
 	// 4. the amount of gas required is available in the block
 	// 5. the purchased gas is enough to cover intrinsic usage
 	// 6. there is no overflow when calculating intrinsic gas
 	// 7. caller has enough balance to cover asset transfer for **topmost** call
 
-	// Check clauses 1-2
+	// Check clauses 1-2, and that sponsored messages are allowed
 	if err := st.preCheck(); err != nil {
 		return nil, err
 	}
@@ -367,7 +396,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	contractCreation := msg.To() == nil
 
 	// Calculate intrinsic gas, check clauses 5-6
-	gas, err := IntrinsicGas(st.data, contractCreation, st.evm.GetHeader(), st.state, msg.FeeCurrency(), istanbul)
+	gas, err := IntrinsicGas(st.data, msg.AccessList(), contractCreation, st.evm.GetHeader(), st.state, msg.FeeCurrency(), istanbul)
 	if err != nil {
 		return nil, err
 	}
@@ -392,6 +421,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	if msg.Value().Sign() > 0 && !st.evm.CanTransfer(st.state, msg.From(), msg.Value()) {
 		return nil, ErrInsufficientFundsForTransfer
 	}
+
+	// Warm the sender, the destination, the precompiles and the accesses of the
+	// access list, after the fees were debited
+	if rules := st.evm.ChainConfig().Rules(st.evm.BlockNumber); rules.IsEspresso {
+		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
+	}
 	var (
 		ret   []byte
 		vmerr error // vm errors do not effect consensus and are therefore not assigned to err
@@ -412,9 +447,10 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}
 
 	return &ExecutionResult{
-		UsedGas:    st.gasUsed(),
-		Err:        vmerr,
-		ReturnData: ret,
+		UsedGas:            st.gasUsed(),
+		Err:                vmerr,
+		ReturnData:         ret,
+		FeeCurrencyGasUsed: st.feeCurrencyGasUsed,
 	}, nil
 }
 
@@ -430,7 +466,7 @@ func (st *StateTransition) distributeTxFees() error {
 	refund := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
 	gasUsed := new(big.Int).SetUint64(st.gasUsed())
 	totalTxFee := new(big.Int).Mul(gasUsed, st.gasPrice)
-	from := st.msg.From()
+	from := st.feePayer()
 
 	// Divide the transaction into a base (the minimum transaction fee) and tip (any extra).
 	baseTxFee := new(big.Int).Mul(gasUsed, st.gasPriceMinimum)
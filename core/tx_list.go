@@ -244,32 +244,83 @@ func (l *txList) Overlaps(tx *types.Transaction) bool {
 	return l.txs.Get(tx.Nonce()) != nil
 }
 
-// Add tries to insert a new transaction into the list, returning whether the
-// transaction was accepted, and if yes, any previous transaction it replaced.
+// Add tries to insert a new transaction into the list, returning any previous
+// transaction it replaced, or the reason why the transaction wasn't accepted.
+// Transactions paying fees in different currencies are compared with the
+// exchange rates of the snapshot.
 //
 // If the new transaction is accepted into the list, the lists' cost and gas
 // thresholds are also potentially updated.
-func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
+func (l *txList) Add(tx *types.Transaction, priceBump uint64, rates *currency.RateSnapshot) (*types.Transaction, error) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		// Have to ensure that the new gas price is higher than the old gas
-		// price as well as checking the percentage threshold to ensure that
-		// this is accurate for low (Wei-level) gas price replacements
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
-			return false, nil
+		if err := checkReplacement(old, tx, priceBump, rates); err != nil {
+			return nil, err
 		}
 	}
 	// Otherwise overwrite the old transaction with the current one
 	l.txs.Put(tx)
-	if cost := tx.Cost(); l.costcap.Cmp(cost) < 0 {
+	if cost := celoCost(tx); l.costcap.Cmp(cost) < 0 {
 		l.costcap = cost
 	}
 	if gas := tx.Gas(); l.gascap < gas {
 		l.gascap = gas
 	}
-	return true, old
+	return old, nil
+}
+
+// checkReplacement returns whether the transaction can replace the old one, which
+// requires its gas price to exceed the old one by priceBump percent.
+//
+// Gas prices in different fee currencies are normalized with the exchange rates,
+// and compared exactly. Gas prices in the same currency are compared as they are.
+func checkReplacement(old, tx *types.Transaction, priceBump uint64, rates *currency.RateSnapshot) error {
+	underpriced := &ReplaceUnderpricedError{
+		OldGasPrice:    old.GasPrice(),
+		OldFeeCurrency: old.FeeCurrency(),
+		GasPrice:       tx.GasPrice(),
+		FeeCurrency:    tx.FeeCurrency(),
+		PriceBump:      priceBump,
+	}
+	if sameFeeCurrency(old, tx) {
+		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+		// Have to ensure that the new gas price is higher than the old gas
+		// price as well as checking the percentage threshold to ensure that
+		// this is accurate for low (Wei-level) gas price replacements
+		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
+			return underpriced
+		}
+		return nil
+	}
+	if !rates.HasRate(old.FeeCurrency()) || !rates.HasRate(tx.FeeCurrency()) {
+		return ErrReplaceNoExchangeRate
+	}
+	scaledOld := new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump)))
+	scaledNew := new(big.Int).Mul(tx.GasPrice(), big.NewInt(100))
+	if rates.Cmp(tx.GasPrice(), tx.FeeCurrency(), old.GasPrice(), old.FeeCurrency()) <= 0 ||
+		rates.Cmp(scaledNew, tx.FeeCurrency(), scaledOld, old.FeeCurrency()) < 0 {
+		return underpriced
+	}
+	return nil
+}
+
+// sameFeeCurrency returns whether both transactions pay fees in the same currency.
+func sameFeeCurrency(tx1, tx2 *types.Transaction) bool {
+	if tx1.FeeCurrency() == nil || tx2.FeeCurrency() == nil {
+		return tx1.FeeCurrency() == nil && tx2.FeeCurrency() == nil
+	}
+	return *tx1.FeeCurrency() == *tx2.FeeCurrency()
+}
+
+// celoCost returns the CELO the transaction costs to its sender. Transactions
+// paying fees in another currency, or whose fees are paid by a fee payer, only
+// cost their value.
+func celoCost(tx *types.Transaction) *big.Int {
+	if tx.FeeCurrency() != nil || tx.Sponsored() {
+		return tx.Value()
+	}
+	return tx.Cost()
 }
 
 // Forward removes all transactions from the list with a nonce lower than the
@@ -301,15 +352,11 @@ func (l *txList) Filter(costLimit *big.Int, gasLimit uint64) (types.Transactions
 
 	// Filter out all the transactions above the account's funds
 	removed := l.txs.Filter(func(tx *types.Transaction) bool {
-		if tx.FeeCurrency() == nil {
-			log.Trace("Transaction Filter", "hash", tx.Hash(), "Fee currency", tx.FeeCurrency(), "Cost", tx.Cost(), "Cost Limit", costLimit, "Gas", tx.Gas(), "Gas Limit", gasLimit)
-			return tx.Cost().Cmp(costLimit) > 0 || tx.Gas() > gasLimit
-		} else {
-			// If the fees are being paid in the non-native currency, ensure that the `tx.Value` is less than costLimit
-			// as the fees will be deducted in the non-native currency.
-			log.Trace("Transaction Filter", "hash", tx.Hash(), "Fee currency", tx.FeeCurrency(), "Value", tx.Value(), "Cost Limit", costLimit, "Gas", tx.Gas(), "Gas Limit", gasLimit)
-			return tx.Value().Cmp(costLimit) > 0 || tx.Gas() > gasLimit
-		}
+		// If the fees are being paid in the non-native currency or by a fee payer, only ensure that the
+		// `tx.Value` is less than costLimit as the fees will not be deducted from the sender's balance.
+		cost := celoCost(tx)
+		log.Trace("Transaction Filter", "hash", tx.Hash(), "Fee currency", tx.FeeCurrency(), "Sponsored", tx.Sponsored(), "Cost", cost, "Cost Limit", costLimit, "Gas", tx.Gas(), "Gas Limit", gasLimit)
+		return cost.Cmp(costLimit) > 0 || tx.Gas() > gasLimit
 	})
 
 	// If the list was strict, filter anything above the lowest nonce
@@ -415,6 +462,7 @@ type txPricedList struct {
 	nonNilCurrencyHeaps map[common.Address]*priceHeap // Heap of prices of all the stored non-nil currency transactions
 	nilCurrencyHeap     *priceHeap                    // Heap of prices of all the stored nil currency transactions
 	stales              int                           // Number of stale price points to (re-heap trigger)
+	rates               *currency.RateSnapshot        // Exchange rates used to compare prices across currencies
 }
 
 // newTxPricedList creates a new price-sorted transaction heap.
@@ -426,6 +474,12 @@ func newTxPricedList(all *txLookup) *txPricedList {
 	}
 }
 
+// SetRates replaces the exchange rates used to compare prices in different currencies.
+// The heaps only hold transactions of a single currency, so they don't need a reheap.
+func (l *txPricedList) SetRates(rates *currency.RateSnapshot) {
+	l.rates = rates
+}
+
 // Gets the price heap for the given currency
 func (l *txPricedList) getPriceHeap(tx *types.Transaction) *priceHeap {
 	feeCurrency := tx.FeeCurrency()
@@ -491,7 +545,7 @@ func (l *txPricedList) Cap(cgThreshold *big.Int, local *accountSet) types.Transa
 			continue
 		}
 
-		if currency.Cmp(tx.GasPrice(), tx.FeeCurrency(), cgThreshold, nil) >= 0 {
+		if l.rates.Cmp(tx.GasPrice(), tx.FeeCurrency(), cgThreshold, nil) >= 0 {
 			save = append(save, tx)
 			break
 		}
@@ -533,7 +587,7 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 	}
 
 	cheapest := l.getMinPricedTx()
-	return currency.Cmp(cheapest.GasPrice(), cheapest.FeeCurrency(), tx.GasPrice(), tx.FeeCurrency()) >= 0
+	return l.rates.Cmp(cheapest.GasPrice(), cheapest.FeeCurrency(), tx.GasPrice(), tx.FeeCurrency()) >= 0
 }
 
 // Discard finds a number of most underpriced transactions, removes them from the
@@ -581,8 +635,9 @@ func (l *txPricedList) getHeapWithMinHead() (*priceHeap, *types.Transaction) {
 				cheapestTxn = []*types.Transaction(*cheapestHeap)[0]
 			} else {
 				txn := []*types.Transaction(*priceHeap)[0]
-				if currency.Cmp(txn.GasPrice(), txn.FeeCurrency(), cheapestTxn.GasPrice(), cheapestTxn.FeeCurrency()) < 0 {
+				if l.rates.Cmp(txn.GasPrice(), txn.FeeCurrency(), cheapestTxn.GasPrice(), cheapestTxn.FeeCurrency()) < 0 {
 					cheapestHeap = priceHeap
+					cheapestTxn = txn
 				}
 			}
 		}
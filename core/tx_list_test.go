@@ -17,9 +17,14 @@
 package core
 
 import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
 	"math/rand"
 	"testing"
 
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/crypto"
 )
@@ -37,7 +42,7 @@ func TestStrictTxListAdd(t *testing.T) {
 	// Insert the transactions in a random order
 	list := newTxList(true)
 	for _, v := range rand.Perm(len(txs)) {
-		list.Add(txs[v], DefaultTxPoolConfig.PriceBump)
+		list.Add(txs[v], DefaultTxPoolConfig.PriceBump, nil)
 	}
 	// Verify internal state
 	if len(list.txs.items) != len(txs) {
@@ -49,3 +54,140 @@ func TestStrictTxListAdd(t *testing.T) {
 		}
 	}
 }
+
+// Tests that transactions can replace transactions paying fees in another
+// currency, if their gas price normalized with the exchange rates exceeds the
+// replaced one by the price bump.
+func TestTxListCrossCurrencyReplacement(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	cUSD, cEUR := common.HexToAddress("0xcd"), common.HexToAddress("0xce")
+	rates := currency.NewStaticRateSnapshot(map[common.Address]*big.Rat{cUSD: big.NewRat(2, 1)})
+
+	tests := []struct {
+		old, tx *types.Transaction
+		err     error
+	}{
+		// 210 cUSD are worth 105 CELO, below the 10% bump
+		{currencyTransaction(0, big.NewInt(100), nil, key), currencyTransaction(0, big.NewInt(210), &cUSD, key), ErrReplaceUnderpriced},
+		{currencyTransaction(0, big.NewInt(100), nil, key), currencyTransaction(0, big.NewInt(220), &cUSD, key), nil},
+		// 220 cUSD are worth 110 CELO, which need to be bumped to 121
+		{currencyTransaction(0, big.NewInt(220), &cUSD, key), currencyTransaction(0, big.NewInt(120), nil, key), ErrReplaceUnderpriced},
+		{currencyTransaction(0, big.NewInt(220), &cUSD, key), currencyTransaction(0, big.NewInt(121), nil, key), nil},
+		// The normalized gas price needs to increase even without bump
+		{currencyTransaction(0, big.NewInt(1), nil, key), currencyTransaction(0, big.NewInt(2), &cUSD, key), ErrReplaceUnderpriced},
+		{currencyTransaction(0, big.NewInt(1), nil, key), currencyTransaction(0, big.NewInt(3), &cUSD, key), nil},
+		// Currencies without exchange rate can't be compared
+		{currencyTransaction(0, big.NewInt(100), nil, key), currencyTransaction(0, big.NewInt(1000), &cEUR, key), ErrReplaceNoExchangeRate},
+		{currencyTransaction(0, big.NewInt(100), &cEUR, key), currencyTransaction(0, big.NewInt(110), &cEUR, key), nil},
+	}
+	for i, tt := range tests {
+		list := newTxList(true)
+		list.Add(tt.old, DefaultTxPoolConfig.PriceBump, rates)
+		old, err := list.Add(tt.tx, DefaultTxPoolConfig.PriceBump, rates)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if err == nil && old != tt.old {
+			t.Errorf("test %d: replaced transaction mismatch: have %v, want %v", i, old, tt.old)
+		}
+		if err != nil && list.txs.Get(0) != tt.old {
+			t.Errorf("test %d: rejected transaction replaced the old one", i)
+		}
+	}
+}
+
+// Tests that transactions paying fees in another currency only count their
+// value towards the CELO cost cap of the list.
+func TestTxListFeeCurrencyCostCap(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	cUSD := common.HexToAddress("0xcd")
+
+	list := newTxList(true)
+	list.Add(currencyTransaction(0, big.NewInt(1000), &cUSD, key), DefaultTxPoolConfig.PriceBump, nil)
+	if list.costcap.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("cost cap mismatch: have %v, want 100", list.costcap)
+	}
+	if drops, _ := list.Filter(big.NewInt(100), 100000); len(drops) != 0 {
+		t.Errorf("dropped %d transactions covered by the CELO balance", len(drops))
+	}
+}
+
+func currencyTransaction(nonce uint64, gasprice *big.Int, feeCurrency *common.Address, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), 100000, gasprice, feeCurrency, nil, nil, nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that the priced list compares the prices of transactions in different
+// currencies with the exchange rates it was given.
+func TestPricedListCrossCurrency(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	cUSD := common.HexToAddress("0xcd")
+	rates := currency.NewStaticRateSnapshot(map[common.Address]*big.Rat{cUSD: big.NewRat(2, 1)})
+
+	all := newTxLookup()
+	priced := newTxPricedList(all)
+	priced.SetRates(rates)
+	for _, tx := range []*types.Transaction{
+		currencyTransaction(0, big.NewInt(10), nil, key),
+		currencyTransaction(1, big.NewInt(15), &cUSD, key), // Worth 7.5 CELO
+		currencyTransaction(2, big.NewInt(30), &cUSD, key), // Worth 15 CELO
+	} {
+		all.Add(tx)
+		priced.Put(tx)
+	}
+	locals := newAccountSet(types.HomesteadSigner{})
+	if !priced.Underpriced(currencyTransaction(3, big.NewInt(7), nil, key), locals) {
+		t.Errorf("transaction cheaper than the cheapest cUSD transaction not underpriced")
+	}
+	if priced.Underpriced(currencyTransaction(3, big.NewInt(8), nil, key), locals) {
+		t.Errorf("transaction more expensive than the cheapest cUSD transaction underpriced")
+	}
+	drop := priced.Discard(1, locals)
+	if len(drop) != 1 || drop[0].Nonce() != 1 {
+		t.Fatalf("discarded transactions mismatch: have %v, want the cheapest cUSD transaction", drop)
+	}
+}
+
+// Benchmarks the price ordering work of inserting transactions split between
+// CELO, cUSD and cEUR into a full pool, evicting the cheapest transactions.
+func BenchmarkPricedListInsert1000(b *testing.B)  { benchmarkPricedListInsert(b, 1000) }
+func BenchmarkPricedListInsert10000(b *testing.B) { benchmarkPricedListInsert(b, 10000) }
+
+func benchmarkPricedListInsert(b *testing.B, size int) {
+	cUSD, cEUR := common.HexToAddress("0xcd"), common.HexToAddress("0xce")
+	rates := currency.NewStaticRateSnapshot(map[common.Address]*big.Rat{
+		cUSD: big.NewRat(5, 2),
+		cEUR: big.NewRat(21, 10),
+	})
+	currencies := []*common.Address{nil, &cUSD, &cEUR}
+
+	keys := make([]*ecdsa.PrivateKey, 16)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	txs := make(types.Transactions, size)
+	for i := range txs {
+		txs[i] = currencyTransaction(uint64(i/len(keys)), big.NewInt(1+rand.Int63n(1000)), currencies[i%len(currencies)], keys[i%len(keys)])
+	}
+	locals := newAccountSet(types.HomesteadSigner{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		all := newTxLookup()
+		priced := newTxPricedList(all)
+		priced.SetRates(rates)
+		for _, tx := range txs {
+			// Once half of the transactions are in, make room like a full pool does
+			if all.Count() >= size/2 {
+				if priced.Underpriced(tx, locals) {
+					continue
+				}
+				for _, drop := range priced.Discard(1, locals) {
+					all.Remove(drop.Hash())
+				}
+			}
+			all.Add(tx)
+			priced.Put(tx)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -76,6 +77,11 @@ var (
 	// with a different one without the required price bump.
 	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
 
+	// ErrReplaceNoExchangeRate is returned if a transaction is attempted to be
+	// replaced with one paying fees in another currency, while the exchange rate
+	// of either currency is unknown.
+	ErrReplaceNoExchangeRate = errors.New("replacement transaction fee currency has no exchange rate")
+
 	// ErrGasLimit is returned if a transaction's requested gas limit exceeds the
 	// maximum allowance of the current block.
 	ErrGasLimit = errors.New("exceeds block gas limit")
@@ -92,8 +98,41 @@ var (
 	// ErrTransfersFrozen is returned if a transaction attempts to transfer between
 	// non-whitelisted addresses while transfers are frozen.
 	ErrTransfersFrozen = errors.New("transfers are currently frozen")
+
+	// ErrPrivateTxExpired is returned if a private transaction is added to the
+	// pool after its deadline block.
+	ErrPrivateTxExpired = errors.New("private transaction deadline passed")
 )
 
+// ReplaceUnderpricedError is returned if a transaction is attempted to be replaced
+// with one whose gas price, normalized to the fee currency of the replaced one,
+// doesn't exceed the replaced gas price by the price bump. It matches
+// ErrReplaceUnderpriced with errors.Is.
+type ReplaceUnderpricedError struct {
+	OldGasPrice    *big.Int
+	OldFeeCurrency *common.Address
+	GasPrice       *big.Int
+	FeeCurrency    *common.Address
+	PriceBump      uint64
+}
+
+func (e *ReplaceUnderpricedError) Error() string {
+	return fmt.Sprintf("%v: gas price %v%s must exceed %v%s by %d%%", ErrReplaceUnderpriced,
+		e.GasPrice, feeCurrencyString(e.FeeCurrency), e.OldGasPrice, feeCurrencyString(e.OldFeeCurrency), e.PriceBump)
+}
+
+// Is returns whether the target is ErrReplaceUnderpriced.
+func (e *ReplaceUnderpricedError) Is(target error) bool {
+	return target == ErrReplaceUnderpriced
+}
+
+func feeCurrencyString(feeCurrency *common.Address) string {
+	if feeCurrency == nil {
+		return " CELO"
+	}
+	return " " + feeCurrency.Hex()
+}
+
 var (
 	evictionInterval    = time.Minute     // Time interval to check for evictable transactions
 	statsReportInterval = 8 * time.Second // Time interval to report transaction pool stats
@@ -184,6 +223,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	PrivateLifetime uint64 // Maximum number of blocks private transactions are kept in the pool
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -201,6 +242,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	PrivateLifetime: 20,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -235,6 +278,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.PrivateLifetime < 1 {
+		log.Warn("Sanitizing invalid txpool private lifetime", "provided", conf.PrivateLifetime, "updated", DefaultTxPoolConfig.PrivateLifetime)
+		conf.PrivateLifetime = DefaultTxPoolConfig.PrivateLifetime
+	}
 	return conf
 }
 
@@ -256,10 +303,12 @@ type TxPool struct {
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	espresso bool // Fork indicator whether we are in the espresso stage.
 
-	currentState  *state.StateDB // Current state in the blockchain head
-	pendingNonces *txNoncer      // Pending state tracking virtual nonces
-	currentMaxGas uint64         // Current gas limit for transaction caps
+	currentState  *state.StateDB         // Current state in the blockchain head
+	pendingNonces *txNoncer              // Pending state tracking virtual nonces
+	currentMaxGas uint64                 // Current gas limit for transaction caps
+	currentRates  *currency.RateSnapshot // Fee currency whitelist and exchange rates in the blockchain head
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -269,6 +318,9 @@ type TxPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price.  One heap per fee currency.
+	private map[common.Hash]uint64       // Deadline blocks of the private transactions, kept out of gossip
+
+	rejections txRejections // Recently rejected transactions
 
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
@@ -295,11 +347,12 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:          config,
 		chainconfig:     chainconfig,
 		chain:           chain,
-		signer:          types.NewEIP155Signer(chainconfig.ChainID),
+		signer:          types.LatestSigner(chainconfig),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
 		all:             newTxLookup(),
+		private:         make(map[common.Hash]uint64),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
 		reqPromoteCh:    make(chan *accountSet),
@@ -349,6 +402,7 @@ func (pool *TxPool) loop() {
 
 	var (
 		prevPending, prevQueued, prevStales int
+		reportedCurrencies                  map[common.Address]struct{}
 		// Start the stats reporting and transaction eviction tickers
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
@@ -379,8 +433,16 @@ func (pool *TxPool) loop() {
 			pool.mu.RLock()
 			pending, queued := pool.stats()
 			stales := pool.priced.stales
+			var status map[common.Address]*TxPoolCurrencyStatus
+			if metrics.Enabled {
+				status = pool.celoStatus()
+			}
 			pool.mu.RUnlock()
 
+			if metrics.Enabled {
+				reportedCurrencies = reportCeloStatus(status, reportedCurrencies)
+			}
+
 			if pending != prevPending || queued != prevQueued || stales != prevStales {
 				log.Debug("Transaction pool status report", "executable", pending, "queued", queued, "stales", stales)
 				prevPending, prevQueued, prevStales = pending, queued, stales
@@ -496,6 +558,12 @@ func (pool *TxPool) Nonce(addr common.Address) uint64 {
 	return pool.pendingNonces.get(addr)
 }
 
+// PriceBump returns the minimum price bump percentage to replace a pooled
+// transaction.
+func (pool *TxPool) PriceBump() uint64 {
+	return pool.config.PriceBump
+}
+
 // Stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (pool *TxPool) Stats() (int, int) {
@@ -591,6 +659,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		log.Debug("max gas limit exceeded", "pool.currentMaxGas", pool.currentMaxGas, "tx.Gas()", tx.Gas())
 		return ErrGasLimit
 	}
+	// Fee payers are only accepted once sponsored transactions are activated
+	if tx.Sponsored() && !pool.espresso {
+		return ErrSponsoredTxNotSupported
+	}
+	// Typed transactions are only accepted once the Espresso fork is activated
+	if tx.Type() != types.LegacyTxType && !pool.espresso {
+		return ErrTxTypeNotSupported
+	}
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
@@ -598,25 +674,29 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	}
 
 	// Ensure the fee currency is native or whitelisted.
-	if tx.FeeCurrency() != nil && !currency.IsWhitelisted(*tx.FeeCurrency(), nil, nil) {
+	if tx.FeeCurrency() != nil && !pool.currentRates.IsWhitelisted(*tx.FeeCurrency()) {
 		return ErrNonWhitelistedFeeCurrency
 	}
 
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && currency.Cmp(pool.gasPrice, nil, tx.GasPrice(), tx.FeeCurrency()) > 0 {
+	if !local && pool.currentRates.Cmp(pool.gasPrice, nil, tx.GasPrice(), tx.FeeCurrency()) > 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
-	// Transactor should have enough funds to cover the costs
-	err = ValidateTransactorBalanceCoversTx(tx, from, pool.currentState)
+	// Transactor and fee payer should have enough funds to cover the costs
+	feePayer, err := types.FeePayer(pool.signer, tx)
+	if err != nil {
+		return ErrInvalidSender
+	}
+	err = ValidateTransactorBalanceCoversTx(tx, from, feePayer, pool.currentState)
 	if err != nil {
 		return err
 	}
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.chain.CurrentBlock().Header(), pool.currentState, tx.FeeCurrency(), pool.istanbul)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.chain.CurrentBlock().Header(), pool.currentState, tx.FeeCurrency(), pool.istanbul)
 	if err != nil {
 		log.Debug("validateTx gas less than intrinsic gas", "intrGas", intrGas, "err", err)
 		return err
@@ -704,10 +784,10 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
-		if !inserted {
+		old, err := list.Add(tx, pool.config.PriceBump, pool.currentRates)
+		if err != nil {
 			pendingDiscardMeter.Mark(1)
-			return false, ErrReplaceUnderpriced
+			return false, err
 		}
 		// New transaction is better, replace old one
 		if old != nil {
@@ -755,11 +835,11 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
-	if !inserted {
+	old, err := pool.queue[from].Add(tx, pool.config.PriceBump, pool.currentRates)
+	if err != nil {
 		// An older transaction was better, discard this
 		queuedDiscardMeter.Mark(1)
-		return false, ErrReplaceUnderpriced
+		return false, err
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
@@ -804,8 +884,8 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
-	if !inserted {
+	old, err := list.Add(tx, pool.config.PriceBump, pool.currentRates)
+	if err != nil {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
@@ -931,6 +1011,9 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 	for i, tx := range txs {
 		replaced, err := pool.add(tx, local)
 		errs[i] = err
+		if err != nil && err != ErrAlreadyKnown {
+			pool.RecordRejection(tx, txRejectReason(tx, err), err)
+		}
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
@@ -939,6 +1022,67 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 	return errs, dirty
 }
 
+// AddPrivate enqueues a single private transaction into the pool if it is valid.
+// Private transactions are not announced to the network, and are dropped if they
+// are not included by the deadline block. The deadline is capped to the private
+// lifetime of the pool, which is also used if the deadline is zero.
+//
+// Private transactions are subject to the pricing constraints of remote ones and
+// are not journaled, as they would be announced once loaded back.
+func (pool *TxPool) AddPrivate(tx *types.Transaction, deadline uint64) error {
+	head := pool.chain.CurrentBlock().NumberU64()
+	if max := head + pool.config.PrivateLifetime; deadline == 0 || deadline > max {
+		deadline = max
+	}
+	if deadline <= head {
+		return ErrPrivateTxExpired
+	}
+	// Mark the transaction private before adding it, so it's never announced
+	hash := tx.Hash()
+	pool.mu.Lock()
+	if pool.all.Get(hash) != nil {
+		pool.mu.Unlock()
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	pool.private[hash] = deadline
+	pool.mu.Unlock()
+
+	errs := pool.addTxs([]*types.Transaction{tx}, false, true)
+	if errs[0] != nil {
+		pool.mu.Lock()
+		delete(pool.private, hash)
+		pool.mu.Unlock()
+	}
+	return errs[0]
+}
+
+// PrivateDeadline returns the deadline block of a private transaction, and
+// whether the transaction with the given hash is private.
+func (pool *TxPool) PrivateDeadline(hash common.Hash) (uint64, bool) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	deadline, ok := pool.private[hash]
+	return deadline, ok
+}
+
+// dropPrivate removes the private transactions whose deadline passed. The ones
+// which left the pool (e.g. included in a block) are remembered until then too,
+// so they are still private if a reorg injects them back into the pool.
+func (pool *TxPool) dropPrivate(head uint64) {
+	for hash, deadline := range pool.private {
+		if deadline > head {
+			continue
+		}
+		if pool.all.Get(hash) != nil {
+			log.Debug("Dropping expired private transaction", "hash", hash, "deadline", deadline)
+			pool.removeTx(hash, true)
+		}
+		delete(pool.private, hash)
+	}
+}
+
 // Status returns the status (unknown/pending/queued) of a batch of transactions
 // identified by their hashes.
 func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
@@ -1263,15 +1407,21 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = CalcGasLimit(pool.chain.CurrentBlock(), statedb)
+	pool.currentRates = currency.NewRateSnapshot(newHead, statedb)
+	pool.priced.SetRates(pool.currentRates)
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	senderCacher.recover(pool.signer, reinject)
 	pool.addTxsLocked(reinject, false)
 
+	// Drop the private transactions which were not included by their deadline
+	pool.dropPrivate(newHead.Number.Uint64())
+
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.espresso = pool.chainconfig.IsEspresso(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1296,6 +1446,8 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
+		unsponsored, _ := pool.filterFeePayers(list)
+		drops = append(drops, unsponsored...)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
@@ -1489,6 +1641,9 @@ func (pool *TxPool) demoteUnexecutables() {
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
+		unsponsored, unsponsoredInvalids := pool.filterFeePayers(list)
+		drops = append(drops, unsponsored...)
+		invalids = append(invalids, unsponsoredInvalids...)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
@@ -1523,24 +1678,57 @@ func (pool *TxPool) demoteUnexecutables() {
 	}
 }
 
+// filterFeePayers removes all sponsored transactions from the list whose fee payer
+// can no longer cover their fees. Every removed transaction is returned, along with
+// the transactions invalidated by the removals (strict mode only). As for senders,
+// fee currency balances are only checked when transactions are added.
+func (pool *TxPool) filterFeePayers(list *txList) (types.Transactions, types.Transactions) {
+	var removed, invalids types.Transactions
+	for _, tx := range list.Flatten() {
+		if !tx.Sponsored() || tx.FeeCurrency() != nil {
+			continue
+		}
+		feePayer, err := types.FeePayer(pool.signer, tx)
+		if err == nil && pool.currentState.GetBalance(feePayer).Cmp(tx.Fee()) >= 0 {
+			continue
+		}
+		if ok, invalidated := list.Remove(tx); ok {
+			log.Trace("Transaction fee payer cannot cover the fees", "hash", tx.Hash(), "feePayer", feePayer, "fee", tx.Fee())
+			removed = append(removed, tx)
+			invalids = append(invalids, invalidated...)
+		}
+	}
+	return removed, invalids
+}
+
 // ValidateTransactorBalanceCoversTx validates transactor has enough funds to cover transaction cost: V + GP * GL.
-func ValidateTransactorBalanceCoversTx(tx *types.Transaction, from common.Address, currentState *state.StateDB) error {
-	if tx.FeeCurrency() == nil && currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
+// The fees (GP * GL + gateway fee) of sponsored transactions are covered by the fee payer instead,
+// which is the transactor for all other transactions.
+func ValidateTransactorBalanceCoversTx(tx *types.Transaction, from, feePayer common.Address, currentState *state.StateDB) error {
+	if tx.FeeCurrency() == nil && from == feePayer && currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		log.Debug("Insufficient funds",
 			"from", from, "Transaction cost", tx.Cost(), "to", tx.To(),
 			"gas", tx.Gas(), "gas price", tx.GasPrice(), "nonce", tx.Nonce(),
 			"value", tx.Value(), "fee currency", tx.FeeCurrency(), "balance", currentState.GetBalance(from))
 		return ErrInsufficientFunds
+	} else if tx.FeeCurrency() == nil && from != feePayer {
+		if currentState.GetBalance(feePayer).Cmp(tx.Fee()) < 0 {
+			log.Debug("validateTx insufficient fee payer funds", "feePayer", feePayer, "balance", currentState.GetBalance(feePayer))
+			return ErrInsufficientFunds
+		}
+		if currentState.GetBalance(from).Cmp(tx.Value()) < 0 {
+			log.Debug("validateTx insufficient funds", "balance", currentState.GetBalance(from).String())
+			return ErrInsufficientFunds
+		}
 	} else if tx.FeeCurrency() != nil {
-		feeCurrencyBalance, _, err := currency.GetBalanceOf(from, *tx.FeeCurrency(), params.MaxGasToReadErc20Balance, nil, nil)
+		feeCurrencyBalance, _, err := currency.GetBalanceOf(feePayer, *tx.FeeCurrency(), params.MaxGasToReadErc20Balance, nil, nil)
 
 		if err != nil {
 			log.Debug("validateTx error in getting fee currency balance", "feeCurrency", tx.FeeCurrency(), "error", err)
 			return err
 		}
 
-		gasFee := new(big.Int).Mul(tx.GasPrice(), big.NewInt(int64(tx.Gas())))
-		if feeCurrencyBalance.Cmp(new(big.Int).Add(gasFee, tx.GatewayFee())) < 0 {
+		if feeCurrencyBalance.Cmp(tx.Fee()) < 0 {
 			log.Debug("validateTx insufficient fee currency", "feeCurrency", tx.FeeCurrency(), "feeCurrencyBalance", feeCurrencyBalance)
 			return ErrInsufficientFunds
 		}
@@ -0,0 +1,229 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/metrics"
+)
+
+const (
+	// txRejectionsLifetime is how long rejected transactions are remembered.
+	txRejectionsLifetime = time.Hour
+
+	// txRejectionsLimit is the maximum number of rejected transactions remembered.
+	txRejectionsLimit = 4096
+)
+
+// TxRejectReason categorizes why the pool rejected a transaction.
+type TxRejectReason string
+
+const (
+	TxRejectNonWhitelistedCurrency  TxRejectReason = "nonWhitelistedCurrency"  // Fee currency isn't whitelisted
+	TxRejectInsufficientBalance     TxRejectReason = "insufficientBalance"     // CELO balance doesn't cover the cost
+	TxRejectInsufficientFeeCurrency TxRejectReason = "insufficientFeeCurrency" // Fee currency balance doesn't cover the fees, or CELO balance the value
+	TxRejectBelowGasPriceMinimum    TxRejectReason = "belowGasPriceMinimum"    // Gas price below the on-chain gas price minimum
+	TxRejectUnderpriced             TxRejectReason = "underpriced"             // Gas price below the pool's price limit, its cheapest transactions or the replacement bump
+	TxRejectGatewayFeeMismatch      TxRejectReason = "gatewayFeeMismatch"      // Gateway fee or recipient not accepted by the light server
+	TxRejectOther                   TxRejectReason = "other"
+)
+
+// txRejectReason categorizes the error the pool rejected the transaction with.
+func txRejectReason(tx *types.Transaction, err error) TxRejectReason {
+	if errors.Is(err, ErrReplaceUnderpriced) {
+		return TxRejectUnderpriced
+	}
+	switch err {
+	case ErrNonWhitelistedFeeCurrency:
+		return TxRejectNonWhitelistedCurrency
+	case ErrInsufficientFunds:
+		if tx.FeeCurrency() != nil {
+			return TxRejectInsufficientFeeCurrency
+		}
+		return TxRejectInsufficientBalance
+	case ErrGasPriceDoesNotExceedMinimum:
+		return TxRejectBelowGasPriceMinimum
+	case ErrUnderpriced:
+		return TxRejectUnderpriced
+	}
+	return TxRejectOther
+}
+
+// TxRejection describes a transaction rejected by the pool.
+type TxRejection struct {
+	Hash        common.Hash
+	From        common.Address
+	FeeCurrency *common.Address
+	GasPrice    *big.Int
+	GatewayFee  *big.Int
+	Reason      TxRejectReason
+	Err         error
+	Time        time.Time
+}
+
+// txRejections remembers the recently rejected transactions, oldest first.
+type txRejections struct {
+	mu   sync.Mutex
+	list []*TxRejection
+}
+
+// add remembers a rejected transaction, forgetting the expired ones.
+func (r *txRejections) add(rejection *TxRejection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.list = append(r.list, rejection)
+	expired := sort.Search(len(r.list), func(i int) bool {
+		return rejection.Time.Sub(r.list[i].Time) < txRejectionsLifetime
+	})
+	if overflow := len(r.list) - txRejectionsLimit; overflow > expired {
+		expired = overflow
+	}
+	if expired > 0 {
+		r.list = append(r.list[:0], r.list[expired:]...)
+	}
+}
+
+// since returns the transactions rejected from the given time on, oldest first.
+func (r *txRejections) since(from time.Time) []*TxRejection {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first := sort.Search(len(r.list), func(i int) bool { return !r.list[i].Time.Before(from) })
+	return append([]*TxRejection(nil), r.list[first:]...)
+}
+
+// TxPoolCurrencyStatus holds the statistics of the pooled transactions paying
+// fees in a currency. Gas prices are normalized to CELO, and are nil if no
+// transaction has a price convertible with the exchange rates of the head.
+type TxPoolCurrencyStatus struct {
+	Pending        int
+	Queued         int
+	Slots          int
+	MinGasPrice    *big.Int
+	MedianGasPrice *big.Int
+	MaxGasPrice    *big.Int
+}
+
+// RecordRejection remembers a transaction rejected on behalf of the pool, by
+// checks done before the transaction reaches it.
+func (pool *TxPool) RecordRejection(tx *types.Transaction, reason TxRejectReason, err error) {
+	from, _ := types.Sender(pool.signer, tx)
+	pool.rejections.add(&TxRejection{
+		Hash:        tx.Hash(),
+		From:        from,
+		FeeCurrency: tx.FeeCurrency(),
+		GasPrice:    tx.GasPrice(),
+		GatewayFee:  tx.GatewayFee(),
+		Reason:      reason,
+		Err:         err,
+		Time:        time.Now(),
+	})
+	metrics.GetOrRegisterMeter("txpool/rejected/"+string(reason), nil).Mark(1)
+}
+
+// Rejections returns the transactions rejected since the given time, oldest
+// first. Rejections are remembered for an hour at most.
+func (pool *TxPool) Rejections(since time.Time) []*TxRejection {
+	return pool.rejections.since(since)
+}
+
+// CeloStatus retrieves the statistics of the pooled transactions by fee
+// currency, where CELO is the zero address.
+func (pool *TxPool) CeloStatus() map[common.Address]*TxPoolCurrencyStatus {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.celoStatus()
+}
+
+// celoStatus retrieves the statistics of the pooled transactions by fee currency.
+// The pool lock must be held.
+func (pool *TxPool) celoStatus() map[common.Address]*TxPoolCurrencyStatus {
+	var (
+		status = make(map[common.Address]*TxPoolCurrencyStatus)
+		prices = make(map[common.Address][]*big.Int)
+	)
+	count := func(lists map[common.Address]*txList, pending bool) {
+		for _, list := range lists {
+			for _, tx := range list.txs.items {
+				var currencyAddress common.Address
+				if tx.FeeCurrency() != nil {
+					currencyAddress = *tx.FeeCurrency()
+				}
+				stats := status[currencyAddress]
+				if stats == nil {
+					stats = new(TxPoolCurrencyStatus)
+					status[currencyAddress] = stats
+				}
+				if pending {
+					stats.Pending++
+				} else {
+					stats.Queued++
+				}
+				stats.Slots += numSlots(tx)
+				if price, err := pool.currentRates.Convert(tx.GasPrice(), tx.FeeCurrency(), nil); err == nil {
+					prices[currencyAddress] = append(prices[currencyAddress], price)
+				}
+			}
+		}
+	}
+	count(pool.pending, true)
+	count(pool.queue, false)
+
+	for currencyAddress, stats := range status {
+		sorted := prices[currencyAddress]
+		if len(sorted) == 0 {
+			continue
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+		stats.MinGasPrice = sorted[0]
+		stats.MedianGasPrice = sorted[len(sorted)/2]
+		stats.MaxGasPrice = sorted[len(sorted)-1]
+	}
+	return status
+}
+
+// reportCeloStatus updates the per fee currency metrics, zeroing the ones of the
+// currencies reported previously but not pooled anymore. It returns the
+// currencies reported.
+func reportCeloStatus(status map[common.Address]*TxPoolCurrencyStatus, reported map[common.Address]struct{}) map[common.Address]struct{} {
+	update := func(currencyAddress common.Address, stats *TxPoolCurrencyStatus) {
+		prefix := "txpool/currency/" + currencyAddress.Hex() + "/"
+		metrics.GetOrRegisterGauge(prefix+"pending", nil).Update(int64(stats.Pending))
+		metrics.GetOrRegisterGauge(prefix+"queued", nil).Update(int64(stats.Queued))
+		metrics.GetOrRegisterGauge(prefix+"slots", nil).Update(int64(stats.Slots))
+	}
+	for currencyAddress := range reported {
+		if _, ok := status[currencyAddress]; !ok {
+			update(currencyAddress, new(TxPoolCurrencyStatus))
+		}
+	}
+	current := make(map[common.Address]struct{}, len(status))
+	for currencyAddress, stats := range status {
+		update(currencyAddress, stats)
+		current[currencyAddress] = struct{}{}
+	}
+	return current
+}
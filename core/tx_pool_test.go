@@ -18,6 +18,7 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -29,6 +30,7 @@ import (
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/consensus"
 	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
 	"github.com/celo-org/celo-blockchain/core/state"
 	"github.com/celo-org/celo-blockchain/core/types"
@@ -1713,7 +1715,7 @@ func TestTransactionReplacement(t *testing.T) {
 	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), key)); err != nil {
 		t.Fatalf("failed to add original cheap pending transaction: %v", err)
 	}
-	if err := pool.AddRemote(pricedTransaction(0, 100001, big.NewInt(1), key)); err != ErrReplaceUnderpriced {
+	if err := pool.AddRemote(pricedTransaction(0, 100001, big.NewInt(1), key)); !errors.Is(err, ErrReplaceUnderpriced) {
 		t.Fatalf("original cheap pending transaction replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
 	}
 	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(2), key)); err != nil {
@@ -1726,7 +1728,7 @@ func TestTransactionReplacement(t *testing.T) {
 	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(price), key)); err != nil {
 		t.Fatalf("failed to add original proper pending transaction: %v", err)
 	}
-	if err := pool.AddRemote(pricedTransaction(0, 100001, big.NewInt(threshold-1), key)); err != ErrReplaceUnderpriced {
+	if err := pool.AddRemote(pricedTransaction(0, 100001, big.NewInt(threshold-1), key)); !errors.Is(err, ErrReplaceUnderpriced) {
 		t.Fatalf("original proper pending transaction replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
 	}
 	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(threshold), key)); err != nil {
@@ -1740,7 +1742,7 @@ func TestTransactionReplacement(t *testing.T) {
 	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(1), key)); err != nil {
 		t.Fatalf("failed to add original cheap queued transaction: %v", err)
 	}
-	if err := pool.AddRemote(pricedTransaction(2, 100001, big.NewInt(1), key)); err != ErrReplaceUnderpriced {
+	if err := pool.AddRemote(pricedTransaction(2, 100001, big.NewInt(1), key)); !errors.Is(err, ErrReplaceUnderpriced) {
 		t.Fatalf("original cheap queued transaction replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
 	}
 	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(2), key)); err != nil {
@@ -1750,7 +1752,7 @@ func TestTransactionReplacement(t *testing.T) {
 	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(price), key)); err != nil {
 		t.Fatalf("failed to add original proper queued transaction: %v", err)
 	}
-	if err := pool.AddRemote(pricedTransaction(2, 100001, big.NewInt(threshold-1), key)); err != ErrReplaceUnderpriced {
+	if err := pool.AddRemote(pricedTransaction(2, 100001, big.NewInt(threshold-1), key)); !errors.Is(err, ErrReplaceUnderpriced) {
 		t.Fatalf("original proper queued transaction replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
 	}
 	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(threshold), key)); err != nil {
@@ -2028,3 +2030,287 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that the pool breaks its transactions down by fee currency, with gas
+// prices normalized to CELO, and remembers why it rejected transactions.
+func TestTransactionCeloStatus(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+	for _, tx := range []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(3), key),
+		pricedTransaction(1, 100000, big.NewInt(1), key),
+		pricedTransaction(2, 100000, big.NewInt(2), key),
+		pricedTransaction(4, 100000, big.NewInt(5), key),
+	} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Fee currency transactions need a token contract to be validated, queue one directly
+	cUSD := common.HexToAddress("0xcd")
+	currencyKey, _ := crypto.GenerateKey()
+	pool.mu.Lock()
+	pool.currentRates = currency.NewStaticRateSnapshot(map[common.Address]*big.Rat{cUSD: big.NewRat(2, 1)})
+	tx := currencyTransaction(0, big.NewInt(10), &cUSD, currencyKey)
+	if _, err := pool.enqueueTx(tx.Hash(), tx); err != nil {
+		t.Fatalf("failed to queue fee currency transaction: %v", err)
+	}
+	pool.mu.Unlock()
+
+	status := pool.CeloStatus()
+	if len(status) != 2 {
+		t.Fatalf("fee currency count mismatch: have %d, want 2", len(status))
+	}
+	celo := status[common.Address{}]
+	if celo.Pending != 3 || celo.Queued != 1 || celo.Slots != 4 {
+		t.Errorf("CELO counts mismatch: have %d pending, %d queued, %d slots, want 3, 1, 4", celo.Pending, celo.Queued, celo.Slots)
+	}
+	if celo.MinGasPrice.Uint64() != 1 || celo.MedianGasPrice.Uint64() != 3 || celo.MaxGasPrice.Uint64() != 5 {
+		t.Errorf("CELO gas prices mismatch: have %v/%v/%v, want 1/3/5", celo.MinGasPrice, celo.MedianGasPrice, celo.MaxGasPrice)
+	}
+	stable := status[cUSD]
+	if stable.Pending != 0 || stable.Queued != 1 {
+		t.Errorf("cUSD counts mismatch: have %d pending, %d queued, want 0, 1", stable.Pending, stable.Queued)
+	}
+	if stable.MedianGasPrice.Uint64() != 5 {
+		t.Errorf("cUSD gas price mismatch: have %v, want 5", stable.MedianGasPrice)
+	}
+
+	// Reject an underpriced transaction and one failing a gateway fee check
+	start := time.Now()
+	pool.gasPrice = big.NewInt(1000)
+	if err := pool.addRemoteSync(pricedTransaction(3, 100000, big.NewInt(1), key)); err != ErrUnderpriced {
+		t.Fatalf("expected %v, got %v", ErrUnderpriced, err)
+	}
+	pool.RecordRejection(lesTransaction(5, 100000, big.NewInt(1), key), TxRejectGatewayFeeMismatch, errors.New("gateway fee too low"))
+
+	rejections := pool.Rejections(start)
+	if len(rejections) != 2 {
+		t.Fatalf("rejection count mismatch: have %d, want 2", len(rejections))
+	}
+	if rejections[0].Reason != TxRejectUnderpriced || rejections[0].From != from {
+		t.Errorf("first rejection mismatch: have %s from %x, want %s from %x", rejections[0].Reason, rejections[0].From, TxRejectUnderpriced, from)
+	}
+	if rejections[1].Reason != TxRejectGatewayFeeMismatch {
+		t.Errorf("second rejection mismatch: have %s, want %s", rejections[1].Reason, TxRejectGatewayFeeMismatch)
+	}
+	if len(pool.Rejections(time.Now().Add(time.Minute))) != 0 {
+		t.Errorf("expected no rejections in the future")
+	}
+}
+
+// Tests that sponsored transactions are only accepted from the Espresso fork on,
+// and that their fees are covered by the fee payer instead of the sender.
+func TestTransactionSponsored(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	feePayerKey, _ := crypto.GenerateKey()
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+	sponsoredTransaction := func(signer types.Signer, value int64) *types.Transaction {
+		tx := types.NewTransaction(0, common.Address{}, big.NewInt(value), 100000, big.NewInt(1), nil, nil, nil, nil).WithFeePayer(params.TestChainConfig.ChainID, feePayer)
+		tx, _ = types.SignTx(tx, signer, key)
+		tx, _ = types.SignFeePayer(tx, signer, feePayerKey)
+		return tx
+	}
+	if err := pool.AddRemote(sponsoredTransaction(types.NewEspressoSigner(params.TestChainConfig.ChainID), 0)); err != ErrSponsoredTxNotSupported {
+		t.Fatalf("error mismatch before the fork: have %v, want %v", err, ErrSponsoredTxNotSupported)
+	}
+
+	config := *params.TestChainConfig
+	config.EspressoBlock = common.Big0
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	espressoPool := NewTxPool(testTxPoolConfig, &config, &testBlockChain{statedb, 10000000, new(event.Feed)})
+	defer espressoPool.Stop()
+
+	signer := types.LatestSigner(&config)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	// The fee payer needs to cover the fees, and the sender the value
+	if err := espressoPool.AddRemote(sponsoredTransaction(signer, 0)); err != ErrInsufficientFunds {
+		t.Fatalf("error mismatch without fee payer balance: have %v, want %v", err, ErrInsufficientFunds)
+	}
+	espressoPool.currentState.AddBalance(feePayer, big.NewInt(100000))
+	if err := espressoPool.AddRemote(sponsoredTransaction(signer, 100)); err != ErrInsufficientFunds {
+		t.Fatalf("error mismatch without sender balance: have %v, want %v", err, ErrInsufficientFunds)
+	}
+	espressoPool.currentState.AddBalance(from, big.NewInt(100))
+	if err := espressoPool.AddRemote(sponsoredTransaction(signer, 100)); err != nil {
+		t.Fatalf("failed to add sponsored transaction: %v", err)
+	}
+	// Transactions signed by another fee payer are rejected
+	forged, _ := types.SignFeePayer(sponsoredTransaction(signer, 0), signer, key)
+	if err := espressoPool.AddRemote(forged); err != ErrInvalidSender {
+		t.Fatalf("error mismatch for forged fee payer: have %v, want %v", err, ErrInvalidSender)
+	}
+}
+
+// Tests that sponsored transactions are kept on resets while the sender covers their
+// value and their fee payer the fees, and dropped once the fee payer can't anymore.
+func TestTransactionSponsoredReset(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.EspressoBlock = common.Big0
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	pool := NewTxPool(testTxPoolConfig, &config, &testBlockChain{statedb, 10000000, new(event.Feed)})
+	defer pool.Stop()
+
+	signer := types.LatestSigner(&config)
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	feePayerKey, _ := crypto.GenerateKey()
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+
+	pool.currentState.AddBalance(from, big.NewInt(100))
+	pool.currentState.AddBalance(feePayer, big.NewInt(100000))
+	for nonce, value := range []int64{0, 0, 100} {
+		tx := types.NewTransaction(uint64(nonce), common.Address{}, big.NewInt(value), 100000, big.NewInt(1), nil, nil, nil, nil).WithFeePayer(params.TestChainConfig.ChainID, feePayer)
+		tx, _ = types.SignTx(tx, signer, key)
+		tx, _ = types.SignFeePayer(tx, signer, feePayerKey)
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add sponsored transaction %d: %v", nonce, err)
+		}
+	}
+	<-pool.requestReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 3 || queued != 0 {
+		t.Fatalf("pending/queued mismatch with a funded fee payer: have %d/%d, want 3/0", pending, queued)
+	}
+	// Lower the sender's balance, only the transaction exceeding it in value is dropped
+	pool.currentState.SetBalance(from, big.NewInt(50))
+	<-pool.requestReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Fatalf("pending/queued mismatch after lowering the sender's balance: have %d/%d, want 2/0", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Drain the fee payer, the first transaction is dropped and the second demoted
+	pool.currentState.SetBalance(feePayer, big.NewInt(99999))
+	<-pool.requestReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 0 || queued != 1 {
+		t.Fatalf("pending/queued mismatch after draining the fee payer: have %d/%d, want 0/1", pending, queued)
+	}
+	<-pool.requestReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pending/queued mismatch after the second reset: have %d/%d, want 0/0", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that access list transactions are only accepted from the Espresso fork
+// on, and that their access list is accounted for in the intrinsic gas.
+func TestTransactionAccessList(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	accessList := types.AccessList{{Address: common.Address{1}, StorageKeys: []common.Hash{{0}}}}
+	accessListTransaction := func(config *params.ChainConfig, nonce, gas uint64) *types.Transaction {
+		tx := types.NewTransaction(nonce, common.Address{}, common.Big0, gas, big.NewInt(1), nil, nil, nil, nil).WithAccessList(config.ChainID, accessList)
+		tx, _ = types.SignTx(tx, types.NewEspressoSigner(config.ChainID), key)
+		return tx
+	}
+	if err := pool.AddRemote(accessListTransaction(params.TestChainConfig, 0, 100000)); err != ErrTxTypeNotSupported {
+		t.Fatalf("error mismatch before the fork: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+
+	config := *params.TestChainConfig
+	config.EspressoBlock = common.Big0
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	espressoPool := NewTxPool(testTxPoolConfig, &config, &testBlockChain{statedb, 10000000, new(event.Feed)})
+	defer espressoPool.Stop()
+
+	espressoPool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+	if err := espressoPool.AddRemote(accessListTransaction(&config, 0, params.TxGas)); err != ErrIntrinsicGas {
+		t.Fatalf("error mismatch without access list gas: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	gas := params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas
+	if err := espressoPool.AddRemote(accessListTransaction(&config, 0, gas)); err != nil {
+		t.Fatalf("failed to add access list transaction: %v", err)
+	}
+}
+
+// Tests that private transactions are tracked with their capped deadline, and
+// dropped from the pool once the deadline block is reached.
+func TestTransactionPrivate(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	lifetime := pool.config.PrivateLifetime
+	txs := []*types.Transaction{
+		transaction(0, 100000, key),
+		transaction(1, 100000, key),
+		transaction(2, 100000, key),
+	}
+	deadlines := []uint64{0, 3, lifetime + 10}
+	for i, tx := range txs {
+		if err := pool.AddPrivate(tx, deadlines[i]); err != nil {
+			t.Fatalf("failed to add private transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.AddPrivate(txs[0], 0); err != ErrAlreadyKnown {
+		t.Fatalf("error mismatch for a known transaction: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	public := transaction(3, 100000, key)
+	if err := pool.addRemoteSync(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	for i, want := range []uint64{lifetime, 3, lifetime} {
+		if deadline, private := pool.PrivateDeadline(txs[i].Hash()); !private || deadline != want {
+			t.Errorf("transaction %d: deadline mismatch: have %d (private %v), want %d", i, deadline, private, want)
+		}
+	}
+	if _, private := pool.PrivateDeadline(public.Hash()); private {
+		t.Errorf("public transaction marked private")
+	}
+	// Reach the deadline of the second transaction, which must be dropped
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(3)})
+	if pool.Has(txs[1].Hash()) {
+		t.Errorf("expired private transaction not dropped")
+	}
+	if _, private := pool.PrivateDeadline(txs[1].Hash()); private {
+		t.Errorf("expired private transaction still tracked")
+	}
+	if !pool.Has(txs[0].Hash()) || !pool.Has(txs[2].Hash()) || !pool.Has(public.Hash()) {
+		t.Errorf("unexpired transactions dropped")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+
+	// A private transaction leaving the pool, as if included in a block, must still be
+	// private when a reorg injects it back
+	pool.mu.Lock()
+	pool.removeTx(txs[2].Hash(), true)
+	pool.mu.Unlock()
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(4)})
+	if err := pool.addRemoteSync(txs[2]); err != nil {
+		t.Fatalf("failed to inject back private transaction: %v", err)
+	}
+	if deadline, private := pool.PrivateDeadline(txs[2].Hash()); !private || deadline != lifetime {
+		t.Errorf("injected back transaction: deadline mismatch: have %d (private %v), want %d", deadline, private, lifetime)
+	}
+	// Past the deadline, transactions which left the pool are forgotten
+	pool.mu.Lock()
+	pool.removeTx(txs[2].Hash(), true)
+	pool.mu.Unlock()
+	<-pool.requestReset(nil, &types.Header{Number: new(big.Int).SetUint64(lifetime)})
+	if _, private := pool.PrivateDeadline(txs[2].Hash()); private {
+		t.Errorf("expired private transaction still tracked after leaving the pool")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+)
+
+// Transaction types of the EIP-2718 envelope. Celo specific types are taken from
+// the top of the range, clear of the Ethereum ones.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01
+	SponsoredTxType  = 0x7f
+)
+
+// AccessList is an EIP-2930 access list.
+type AccessList []AccessTuple
+
+// AccessTuple is the element type of an access list.
+type AccessTuple struct {
+	Address     common.Address `json:"address"     gencodec:"required"`
+	StorageKeys []common.Hash  `json:"storageKeys" gencodec:"required"`
+}
+
+// StorageKeys returns the total number of storage keys in the access list.
+func (al AccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return sum
+}
+
+// accessListTxdata is the RLP encoding of access list transactions, following
+// their type byte. Besides the Celo fee fields, it holds the chain id the
+// transaction is valid on and the list of addresses and storage keys it plans
+// to access. V is the parity of the signature, either 0 or 1.
+type accessListTxdata struct {
+	ChainID             *big.Int
+	AccountNonce        uint64
+	Price               *big.Int
+	GasLimit            uint64
+	FeeCurrency         *common.Address `rlp:"nil"`
+	GatewayFeeRecipient *common.Address `rlp:"nil"`
+	GatewayFee          *big.Int        `rlp:"nil"`
+	Recipient           *common.Address `rlp:"nil"`
+	Amount              *big.Int
+	Payload             []byte
+	AccessList          AccessList
+
+	// Signature values
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+// accessListTx converts the transaction data to its access list RLP encoding.
+func (d *txdata) accessListTx() *accessListTxdata {
+	return &accessListTxdata{
+		ChainID:             d.ChainID,
+		AccountNonce:        d.AccountNonce,
+		Price:               d.Price,
+		GasLimit:            d.GasLimit,
+		FeeCurrency:         d.FeeCurrency,
+		GatewayFeeRecipient: d.GatewayFeeRecipient,
+		GatewayFee:          d.GatewayFee,
+		Recipient:           d.Recipient,
+		Amount:              d.Amount,
+		Payload:             d.Payload,
+		AccessList:          d.AccessList,
+		V:                   d.V,
+		R:                   d.R,
+		S:                   d.S,
+	}
+}
+
+// txdata converts the access list RLP encoding to the transaction data.
+func (d *accessListTxdata) txdata() txdata {
+	return txdata{
+		Type:                AccessListTxType,
+		ChainID:             d.ChainID,
+		AccountNonce:        d.AccountNonce,
+		Price:               d.Price,
+		GasLimit:            d.GasLimit,
+		FeeCurrency:         d.FeeCurrency,
+		GatewayFeeRecipient: d.GatewayFeeRecipient,
+		GatewayFee:          d.GatewayFee,
+		Recipient:           d.Recipient,
+		Amount:              d.Amount,
+		Payload:             d.Payload,
+		AccessList:          d.AccessList,
+		V:                   d.V,
+		R:                   d.R,
+		S:                   d.S,
+	}
+}
@@ -16,6 +16,9 @@ var _ = (*txdataMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (t txdata) MarshalJSON() ([]byte, error) {
 	type txdata struct {
+		Type                hexutil.Uint64  `json:"type"                 rlp:"-"`
+		ChainID             *hexutil.Big    `json:"chainId,omitempty"    rlp:"-"`
+		AccessList          AccessList      `json:"accessList,omitempty" rlp:"-"`
 		AccountNonce        hexutil.Uint64  `json:"nonce"    gencodec:"required"`
 		Price               *hexutil.Big    `json:"gasPrice" gencodec:"required"`
 		GasLimit            hexutil.Uint64  `json:"gas"      gencodec:"required"`
@@ -28,9 +31,16 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 		V                   *hexutil.Big    `json:"v" gencodec:"required"`
 		R                   *hexutil.Big    `json:"r" gencodec:"required"`
 		S                   *hexutil.Big    `json:"s" gencodec:"required"`
+		FeePayer            *common.Address `json:"feePayer,omitempty" rlp:"-"`
+		FeePayerV           *hexutil.Big    `json:"feePayerV,omitempty" rlp:"-"`
+		FeePayerR           *hexutil.Big    `json:"feePayerR,omitempty" rlp:"-"`
+		FeePayerS           *hexutil.Big    `json:"feePayerS,omitempty" rlp:"-"`
 		Hash                *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var enc txdata
+	enc.Type = hexutil.Uint64(t.Type)
+	enc.ChainID = (*hexutil.Big)(t.ChainID)
+	enc.AccessList = t.AccessList
 	enc.AccountNonce = hexutil.Uint64(t.AccountNonce)
 	enc.Price = (*hexutil.Big)(t.Price)
 	enc.GasLimit = hexutil.Uint64(t.GasLimit)
@@ -43,6 +53,10 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 	enc.V = (*hexutil.Big)(t.V)
 	enc.R = (*hexutil.Big)(t.R)
 	enc.S = (*hexutil.Big)(t.S)
+	enc.FeePayer = t.FeePayer
+	enc.FeePayerV = (*hexutil.Big)(t.FeePayerV)
+	enc.FeePayerR = (*hexutil.Big)(t.FeePayerR)
+	enc.FeePayerS = (*hexutil.Big)(t.FeePayerS)
 	enc.Hash = t.Hash
 	return json.Marshal(&enc)
 }
@@ -50,6 +64,9 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (t *txdata) UnmarshalJSON(input []byte) error {
 	type txdata struct {
+		Type                *hexutil.Uint64 `json:"type"                 rlp:"-"`
+		ChainID             *hexutil.Big    `json:"chainId,omitempty"    rlp:"-"`
+		AccessList          *AccessList     `json:"accessList,omitempty" rlp:"-"`
 		AccountNonce        *hexutil.Uint64 `json:"nonce"    gencodec:"required"`
 		Price               *hexutil.Big    `json:"gasPrice" gencodec:"required"`
 		GasLimit            *hexutil.Uint64 `json:"gas"      gencodec:"required"`
@@ -62,12 +79,25 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 		V                   *hexutil.Big    `json:"v" gencodec:"required"`
 		R                   *hexutil.Big    `json:"r" gencodec:"required"`
 		S                   *hexutil.Big    `json:"s" gencodec:"required"`
+		FeePayer            *common.Address `json:"feePayer,omitempty" rlp:"-"`
+		FeePayerV           *hexutil.Big    `json:"feePayerV,omitempty" rlp:"-"`
+		FeePayerR           *hexutil.Big    `json:"feePayerR,omitempty" rlp:"-"`
+		FeePayerS           *hexutil.Big    `json:"feePayerS,omitempty" rlp:"-"`
 		Hash                *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var dec txdata
 	if err := json.Unmarshal(input, &dec); err != nil {
 		return err
 	}
+	if dec.Type != nil {
+		t.Type = uint8(*dec.Type)
+	}
+	if dec.ChainID != nil {
+		t.ChainID = (*big.Int)(dec.ChainID)
+	}
+	if dec.AccessList != nil {
+		t.AccessList = *dec.AccessList
+	}
 	if dec.AccountNonce == nil {
 		return errors.New("missing required field 'nonce' for txdata")
 	}
@@ -112,6 +142,18 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 's' for txdata")
 	}
 	t.S = (*big.Int)(dec.S)
+	if dec.FeePayer != nil {
+		t.FeePayer = dec.FeePayer
+	}
+	if dec.FeePayerV != nil {
+		t.FeePayerV = (*big.Int)(dec.FeePayerV)
+	}
+	if dec.FeePayerR != nil {
+		t.FeePayerR = (*big.Int)(dec.FeePayerR)
+	}
+	if dec.FeePayerS != nil {
+		t.FeePayerS = (*big.Int)(dec.FeePayerS)
+	}
 	if dec.Hash != nil {
 		t.Hash = dec.Hash
 	}
@@ -242,3 +242,36 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+func TestTypedReceiptEncoding(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{{1}}, Data: []byte{0x01}},
+		},
+	}
+	bin, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("binary encode error: %v", err)
+	}
+	if bin[0] != AccessListTxType {
+		t.Errorf("binary encoding type mismatch: have %d, want %d", bin[0], AccessListTxType)
+	}
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	decoded := new(Receipt)
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if decoded.Type != receipt.Type || decoded.Status != receipt.Status || decoded.CumulativeGasUsed != receipt.CumulativeGasUsed || len(decoded.Logs) != 1 {
+		t.Errorf("decoded receipt mismatch: have %+v, want %+v", decoded, receipt)
+	}
+	// The consensus encoding used by the receipt trie is the binary one
+	if got := (Receipts{receipt}).GetRlp(0); !bytes.Equal(got, bin) {
+		t.Errorf("receipt trie encoding mismatch: have %x, want %x", got, bin)
+	}
+}
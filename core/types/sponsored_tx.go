@@ -0,0 +1,103 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+)
+
+// sponsoredTxdata is the RLP encoding of sponsored transactions, following their
+// type byte. Their fees are paid by a fee payer instead of the sender: the sender
+// signs over the fee payer address, and the fee payer over the transaction signed
+// by the sender. Like access list transactions, they hold the chain id they are
+// valid on and an access list, and both V values are the parity of the signatures.
+type sponsoredTxdata struct {
+	ChainID             *big.Int
+	AccountNonce        uint64
+	Price               *big.Int
+	GasLimit            uint64
+	FeeCurrency         *common.Address `rlp:"nil"`
+	GatewayFeeRecipient *common.Address `rlp:"nil"`
+	GatewayFee          *big.Int        `rlp:"nil"`
+	Recipient           *common.Address `rlp:"nil"`
+	Amount              *big.Int
+	Payload             []byte
+	AccessList          AccessList
+	FeePayer            common.Address
+
+	// Signature values of the sender
+	V *big.Int
+	R *big.Int
+	S *big.Int
+
+	// Signature values of the fee payer
+	FeePayerV *big.Int
+	FeePayerR *big.Int
+	FeePayerS *big.Int
+}
+
+// sponsoredTx converts the transaction data to its sponsored RLP encoding.
+func (d *txdata) sponsoredTx() *sponsoredTxdata {
+	return &sponsoredTxdata{
+		ChainID:             d.ChainID,
+		AccountNonce:        d.AccountNonce,
+		Price:               d.Price,
+		GasLimit:            d.GasLimit,
+		FeeCurrency:         d.FeeCurrency,
+		GatewayFeeRecipient: d.GatewayFeeRecipient,
+		GatewayFee:          d.GatewayFee,
+		Recipient:           d.Recipient,
+		Amount:              d.Amount,
+		Payload:             d.Payload,
+		AccessList:          d.AccessList,
+		FeePayer:            *d.FeePayer,
+		V:                   d.V,
+		R:                   d.R,
+		S:                   d.S,
+		FeePayerV:           d.FeePayerV,
+		FeePayerR:           d.FeePayerR,
+		FeePayerS:           d.FeePayerS,
+	}
+}
+
+// txdata converts the sponsored RLP encoding to the transaction data.
+func (d *sponsoredTxdata) txdata() txdata {
+	feePayer := d.FeePayer
+	return txdata{
+		Type:                SponsoredTxType,
+		ChainID:             d.ChainID,
+		AccountNonce:        d.AccountNonce,
+		Price:               d.Price,
+		GasLimit:            d.GasLimit,
+		FeeCurrency:         d.FeeCurrency,
+		GatewayFeeRecipient: d.GatewayFeeRecipient,
+		GatewayFee:          d.GatewayFee,
+		Recipient:           d.Recipient,
+		Amount:              d.Amount,
+		Payload:             d.Payload,
+		AccessList:          d.AccessList,
+		V:                   d.V,
+		R:                   d.R,
+		S:                   d.S,
+		FeePayer:            &feePayer,
+		FeePayerV:           d.FeePayerV,
+		FeePayerR:           d.FeePayerR,
+		FeePayerS:           d.FeePayerS,
+	}
+}
@@ -17,6 +17,7 @@
 package types
 
 import (
+	"bytes"
 	"container/heap"
 	"errors"
 	"io"
@@ -32,18 +33,27 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+	ErrInvalidSig         = errors.New("invalid transaction v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
 )
 
 type Transaction struct {
 	data txdata
 	// caches
-	hash atomic.Value
-	size atomic.Value
-	from atomic.Value
+	hash     atomic.Value
+	size     atomic.Value
+	from     atomic.Value
+	feePayer atomic.Value
 }
 
 type txdata struct {
+	// Type of the EIP-2718 envelope, and chain id and access list of typed
+	// transactions. Typed transactions are RLP encoded after their type byte.
+	Type       uint8      `json:"type"                 rlp:"-"`
+	ChainID    *big.Int   `json:"chainId,omitempty"    rlp:"-"`
+	AccessList AccessList `json:"accessList,omitempty" rlp:"-"`
+
 	AccountNonce        uint64          `json:"nonce"    gencodec:"required"`
 	Price               *big.Int        `json:"gasPrice" gencodec:"required"`
 	GasLimit            uint64          `json:"gas"      gencodec:"required"`
@@ -59,11 +69,21 @@ type txdata struct {
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
 
+	// Fee payer of sponsored transactions and its signature values, nil if the
+	// sender pays the fees. Sponsored transactions are typed transactions, RLP
+	// encoded as sponsoredTxdata.
+	FeePayer  *common.Address `json:"feePayer,omitempty" rlp:"-"`
+	FeePayerV *big.Int        `json:"feePayerV,omitempty" rlp:"-"`
+	FeePayerR *big.Int        `json:"feePayerR,omitempty" rlp:"-"`
+	FeePayerS *big.Int        `json:"feePayerS,omitempty" rlp:"-"`
+
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
 
 type txdataMarshaling struct {
+	Type                hexutil.Uint64
+	ChainID             *hexutil.Big
 	AccountNonce        hexutil.Uint64
 	Price               *hexutil.Big
 	GasLimit            hexutil.Uint64
@@ -118,11 +138,18 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.data.Type != LegacyTxType {
+		return new(big.Int).Set(tx.data.ChainID)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 // Protected returns whether the transaction is protected from replay protection.
+// Typed transactions are always protected.
 func (tx *Transaction) Protected() bool {
+	if tx.data.Type != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
@@ -135,20 +162,117 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
-// EncodeRLP implements rlp.Encoder
+// EncodeRLP implements rlp.Encoder. Typed transactions are encoded as an RLP
+// string holding the type byte followed by the RLP encoding of the transaction.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
+	if tx.data.Type != LegacyTxType {
+		buf := new(bytes.Buffer)
+		if err := tx.encodeTyped(buf); err != nil {
+			return err
+		}
+		return rlp.Encode(w, buf.Bytes())
+	}
 	return rlp.Encode(w, &tx.data)
 }
 
+// typedTx converts the transaction data to the RLP encoding of its type.
+func (d *txdata) typedTx() (interface{}, error) {
+	switch d.Type {
+	case AccessListTxType:
+		return d.accessListTx(), nil
+	case SponsoredTxType:
+		return d.sponsoredTx(), nil
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+}
+
+// encodeTyped writes the canonical encoding of a typed transaction to w.
+func (tx *Transaction) encodeTyped(w *bytes.Buffer) error {
+	inner, err := tx.data.typedTx()
+	if err != nil {
+		return err
+	}
+	w.WriteByte(tx.data.Type)
+	return rlp.Encode(w, inner)
+}
+
+// MarshalBinary returns the canonical encoding of the transaction: the RLP
+// encoding of legacy transactions, and the type byte followed by the RLP
+// encoding of typed transactions.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.data.Type == LegacyTxType {
+		return rlp.EncodeToBytes(tx)
+	}
+	var buf bytes.Buffer
+	err := tx.encodeTyped(&buf)
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary decodes the canonical encoding of transactions.
+// It supports legacy RLP transactions and EIP-2718 typed transactions.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		// It's a legacy transaction
+		return rlp.DecodeBytes(b, tx)
+	}
+	data, err := decodeTyped(b)
+	if err != nil {
+		return err
+	}
+	*tx = Transaction{data: data}
+	tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+	return nil
+}
+
+// decodeTyped decodes the type byte and the RLP encoding of a typed transaction.
+func decodeTyped(b []byte) (txdata, error) {
+	if len(b) == 0 {
+		return txdata{}, errEmptyTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+		var dec accessListTxdata
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return txdata{}, err
+		}
+		return dec.txdata(), nil
+	case SponsoredTxType:
+		var dec sponsoredTxdata
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return txdata{}, err
+		}
+		return dec.txdata(), nil
+	default:
+		return txdata{}, ErrTxTypeNotSupported
+	}
+}
+
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.String {
+		// Typed transactions are wrapped in an RLP string
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		data, err := decodeTyped(b)
+		if err != nil {
+			return err
+		}
+		tx.data = data
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		return nil
 	}
-
-	return err
+	if err := s.Decode(&tx.data); err != nil {
+		return err
+	}
+	tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+	return nil
 }
 
 // MarshalJSON encodes the web3 RPC transaction format.
@@ -166,10 +290,42 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		return err
 	}
 
+	switch dec.Type {
+	case LegacyTxType:
+		if dec.AccessList != nil {
+			return errors.New("access list not supported by legacy transactions")
+		}
+		if dec.FeePayer != nil {
+			return ErrSponsoredTxNotSupported
+		}
+	case AccessListTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' in access list transaction")
+		}
+		if dec.FeePayer != nil {
+			return ErrSponsoredTxNotSupported
+		}
+	case SponsoredTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' in sponsored transaction")
+		}
+		if dec.FeePayer == nil {
+			return errors.New("missing required field 'feePayer' in sponsored transaction")
+		}
+	default:
+		return ErrTxTypeNotSupported
+	}
+
 	withSignature := dec.V.Sign() != 0 || dec.R.Sign() != 0 || dec.S.Sign() != 0
 	if withSignature {
 		var V byte
-		if isProtectedV(dec.V) {
+		if dec.Type != LegacyTxType {
+			// Typed transactions are signed with the parity of the signature
+			if dec.V.BitLen() > 1 {
+				return ErrInvalidSig
+			}
+			V = byte(dec.V.Uint64())
+		} else if isProtectedV(dec.V) {
 			chainID := deriveChainId(dec.V).Uint64()
 			V = byte(dec.V.Uint64() - 35 - 2*chainID)
 		} else {
@@ -179,6 +335,21 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 			return ErrInvalidSig
 		}
 	}
+	if dec.FeePayer != nil {
+		if dec.FeePayerV == nil || dec.FeePayerR == nil || dec.FeePayerS == nil {
+			return errors.New("missing fee payer signature values for sponsored transaction")
+		}
+		if dec.FeePayerV.Sign() != 0 || dec.FeePayerR.Sign() != 0 || dec.FeePayerS.Sign() != 0 {
+			// Fee payers sign with the parity of the signature too
+			if dec.FeePayerV.BitLen() > 1 {
+				return ErrInvalidSig
+			}
+			V := byte(dec.FeePayerV.Uint64())
+			if !crypto.ValidateSignatureValues(V, dec.FeePayerR, dec.FeePayerS, false) {
+				return ErrInvalidSig
+			}
+		}
+	}
 
 	*tx = Transaction{data: dec}
 	return nil
@@ -194,6 +365,28 @@ func (tx *Transaction) Value() *big.Int                      { return new(big.In
 func (tx *Transaction) Nonce() uint64                        { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool                     { return true }
 
+// Type returns the EIP-2718 type of the transaction, LegacyTxType for untyped
+// transactions.
+func (tx *Transaction) Type() uint8 { return tx.data.Type }
+
+// AccessList returns the access list of the transaction, nil for legacy
+// transactions.
+func (tx *Transaction) AccessList() AccessList { return tx.data.AccessList }
+
+// Sponsored returns whether the fees of the transaction are paid by a fee payer
+// instead of the sender.
+func (tx *Transaction) Sponsored() bool { return tx.data.FeePayer != nil }
+
+// FeePayer returns the fee payer address the sender signed over.
+// It returns nil if the transaction isn't sponsored.
+func (tx *Transaction) FeePayer() *common.Address {
+	if tx.data.FeePayer == nil {
+		return nil
+	}
+	feePayer := *tx.data.FeePayer
+	return &feePayer
+}
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -204,13 +397,19 @@ func (tx *Transaction) To() *common.Address {
 	return &to
 }
 
-// Hash hashes the RLP encoding of tx.
+// Hash hashes the canonical encoding of tx.
 // It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.data.Type == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		inner, _ := tx.data.typedTx()
+		v = prefixedRlpHash(tx.data.Type, inner)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -222,7 +421,7 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -243,11 +442,17 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 		to:                  tx.data.Recipient,
 		amount:              tx.data.Amount,
 		data:                tx.data.Payload,
+		accessList:          tx.data.AccessList,
 		checkNonce:          true,
 	}
 
 	var err error
 	msg.from, err = Sender(s, tx)
+	if err != nil || !tx.Sponsored() {
+		return msg, err
+	}
+	feePayer, err := FeePayer(s, tx)
+	msg.feePayer = &feePayer
 	return msg, err
 }
 
@@ -263,6 +468,56 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	return cpy, nil
 }
 
+// WithAccessList returns a new unsigned access list transaction, valid on the
+// given chain, with the given access list. Sponsored transactions stay sponsored.
+func (tx *Transaction) WithAccessList(chainID *big.Int, accessList AccessList) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	if cpy.data.Type != SponsoredTxType {
+		cpy.data.Type = AccessListTxType
+	}
+	cpy.data.ChainID = new(big.Int).Set(chainID)
+	cpy.data.AccessList = accessList
+	cpy.data.V, cpy.data.R, cpy.data.S = new(big.Int), new(big.Int), new(big.Int)
+	if cpy.data.FeePayer != nil {
+		cpy.data.FeePayerV, cpy.data.FeePayerR, cpy.data.FeePayerS = new(big.Int), new(big.Int), new(big.Int)
+	}
+	return cpy
+}
+
+// WithFeePayer returns a new unsigned sponsored transaction, valid on the given
+// chain, whose fees are paid by the given fee payer. The access list of access
+// list transactions is kept. The sender signs the returned transaction first,
+// followed by the fee payer.
+func (tx *Transaction) WithFeePayer(chainID *big.Int, feePayer common.Address) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.Type = SponsoredTxType
+	cpy.data.ChainID = new(big.Int).Set(chainID)
+	cpy.data.FeePayer = &feePayer
+	cpy.data.V, cpy.data.R, cpy.data.S = new(big.Int), new(big.Int), new(big.Int)
+	cpy.data.FeePayerV, cpy.data.FeePayerR, cpy.data.FeePayerS = new(big.Int), new(big.Int), new(big.Int)
+	return cpy
+}
+
+// WithFeePayerSignature returns a new sponsored transaction with the given
+// signature of the fee payer. This signature needs to be in the [R || S || V]
+// format where V is 0 or 1.
+func (tx *Transaction) WithFeePayerSignature(signer Signer, sig []byte) (*Transaction, error) {
+	if !tx.Sponsored() {
+		return nil, ErrNotSponsored
+	}
+	fs, ok := signer.(FeePayerSigner)
+	if !ok {
+		return nil, ErrSponsoredTxNotSupported
+	}
+	r, s, v, err := fs.FeePayerSignatureValues(tx, sig)
+	if err != nil {
+		return nil, err
+	}
+	cpy := &Transaction{data: tx.data}
+	cpy.data.FeePayerR, cpy.data.FeePayerS, cpy.data.FeePayerV = r, s, v
+	return cpy, nil
+}
+
 // Cost returns amount + gasprice * gaslimit + gatewayfee.
 func (tx *Transaction) Cost() *big.Int {
 	total := new(big.Int).Mul(tx.data.Price, new(big.Int).SetUint64(tx.data.GasLimit))
@@ -271,12 +526,26 @@ func (tx *Transaction) Cost() *big.Int {
 	return total
 }
 
+// Fee returns gasprice * gaslimit + gatewayfee, paid in the fee currency.
+func (tx *Transaction) Fee() *big.Int {
+	total := new(big.Int).Mul(tx.data.Price, new(big.Int).SetUint64(tx.data.GasLimit))
+	total.Add(total, tx.data.GatewayFee)
+	return total
+}
+
 // RawSignatureValues returns the V, R, S signature values of the transaction.
 // The return values should not be modified by the caller.
 func (tx *Transaction) RawSignatureValues() (v, r, s *big.Int) {
 	return tx.data.V, tx.data.R, tx.data.S
 }
 
+// RawFeePayerSignatureValues returns the V, R, S signature values of the fee
+// payer of a sponsored transaction, nil otherwise.
+// The return values should not be modified by the caller.
+func (tx *Transaction) RawFeePayerSignatureValues() (v, r, s *big.Int) {
+	return tx.data.FeePayerV, tx.data.FeePayerR, tx.data.FeePayerS
+}
+
 // Transactions is a Transaction slice type for basic sorting.
 type Transactions []*Transaction
 
@@ -286,9 +555,10 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s.
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp.
+// GetRlp implements Rlpable and returns the canonical encoding of the i'th
+// element of s.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -430,7 +700,9 @@ type Message struct {
 	feeCurrency         *common.Address
 	gatewayFeeRecipient *common.Address
 	gatewayFee          *big.Int
+	feePayer            *common.Address
 	data                []byte
+	accessList          AccessList
 	checkNonce          bool
 }
 
@@ -456,8 +728,23 @@ func (m Message) GasPrice() *big.Int                   { return m.gasPrice }
 func (m Message) FeeCurrency() *common.Address         { return m.feeCurrency }
 func (m Message) GatewayFeeRecipient() *common.Address { return m.gatewayFeeRecipient }
 func (m Message) GatewayFee() *big.Int                 { return m.gatewayFee }
+func (m Message) FeePayer() *common.Address            { return m.feePayer }
 func (m Message) Value() *big.Int                      { return m.amount }
 func (m Message) Gas() uint64                          { return m.gasLimit }
 func (m Message) Nonce() uint64                        { return m.nonce }
 func (m Message) Data() []byte                         { return m.data }
+func (m Message) AccessList() AccessList               { return m.accessList }
 func (m Message) CheckNonce() bool                     { return m.checkNonce }
+
+// WithFeePayer returns a copy of the message whose fees are paid by the given
+// fee payer instead of the sender.
+func (m Message) WithFeePayer(feePayer common.Address) Message {
+	m.feePayer = &feePayer
+	return m
+}
+
+// WithAccessList returns a copy of the message with the given access list.
+func (m Message) WithAccessList(accessList AccessList) Message {
+	m.accessList = accessList
+	return m
+}
@@ -28,7 +28,10 @@ import (
 )
 
 var (
-	ErrInvalidChainId = errors.New("invalid chain id for signer")
+	ErrInvalidChainId          = errors.New("invalid chain id for signer")
+	ErrSponsoredTxNotSupported = errors.New("sponsored transactions not supported")
+	ErrNotSponsored            = errors.New("transaction not sponsored")
+	ErrInvalidFeePayer         = errors.New("fee payer signature doesn't match the fee payer")
 )
 
 // sigCache is used to cache the derived sender and contains
@@ -42,6 +45,8 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsEspresso(blockNumber):
+		signer = NewEspressoSigner(config.ChainID)
 	case config.IsEIP155(blockNumber):
 		signer = NewEIP155Signer(config.ChainID)
 	case config.IsHomestead(blockNumber):
@@ -52,6 +57,23 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	return signer
 }
 
+// LatestSigner returns the 'most permissive' Signer available for the given chain
+// configuration. Use this in transaction-handling code where the current block
+// number is unknown, such as the transaction pool.
+func LatestSigner(config *params.ChainConfig) Signer {
+	if config.ChainID != nil && config.EspressoBlock != nil {
+		return NewEspressoSigner(config.ChainID)
+	}
+	return NewEIP155Signer(config.ChainID)
+}
+
+// LatestSignerForChainID returns the 'most permissive' Signer available for the
+// given chain ID, accepting sponsored transactions. Use this in wallets and other
+// code where the chain configuration is unknown.
+func LatestSignerForChainID(chainID *big.Int) Signer {
+	return NewEspressoSigner(chainID)
+}
+
 // SignTx signs the transaction using the given signer and private key
 func SignTx(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
 	h := s.Hash(tx)
@@ -88,6 +110,51 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// SignFeePayer signs the sponsored transaction as its fee payer using the given
+// signer and private key. The sender needs to sign the transaction first.
+func SignFeePayer(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	fs, ok := s.(FeePayerSigner)
+	if !ok {
+		return nil, ErrSponsoredTxNotSupported
+	}
+	if !tx.Sponsored() {
+		return nil, ErrNotSponsored
+	}
+	h := fs.FeePayerHash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithFeePayerSignature(s, sig)
+}
+
+// FeePayer returns the address paying the fees of the transaction: the fee payer
+// of sponsored transactions, whose signature is verified, and the sender of all
+// other transactions.
+//
+// FeePayer may cache the address like Sender does.
+func FeePayer(signer Signer, tx *Transaction) (common.Address, error) {
+	if !tx.Sponsored() {
+		return Sender(signer, tx)
+	}
+	if sc := tx.feePayer.Load(); sc != nil {
+		sigCache := sc.(sigCache)
+		if sigCache.signer.Equal(signer) {
+			return sigCache.from, nil
+		}
+	}
+	fs, ok := signer.(FeePayerSigner)
+	if !ok {
+		return common.Address{}, ErrSponsoredTxNotSupported
+	}
+	addr, err := fs.FeePayer(tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	tx.feePayer.Store(sigCache{signer: signer, from: addr})
+	return addr, nil
+}
+
 // Signer encapsulates transaction signature handling. Note that this interface is not a
 // stable API and may change at any time to accommodate new protocol rules.
 type Signer interface {
@@ -102,6 +169,168 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+// FeePayerSigner is a Signer handling sponsored transactions, whose fees are paid
+// by a fee payer signing over the transaction signed by the sender.
+type FeePayerSigner interface {
+	Signer
+	// FeePayer returns the fee payer address of a sponsored transaction, once
+	// verified against its signature.
+	FeePayer(tx *Transaction) (common.Address, error)
+	// FeePayerSignatureValues returns the raw R, S, V values corresponding to
+	// the given signature of the fee payer.
+	FeePayerSignatureValues(tx *Transaction, sig []byte) (r, s, v *big.Int, err error)
+	// FeePayerHash returns the hash to be signed by the fee payer.
+	FeePayerHash(tx *Transaction) common.Hash
+}
+
+// EspressoSigner implements FeePayerSigner using the EIP155 rules, accepting
+// EIP-2930 access list transactions and sponsored transactions, both typed, from
+// the Espresso fork on.
+type EspressoSigner struct{ EIP155Signer }
+
+func NewEspressoSigner(chainId *big.Int) EspressoSigner {
+	return EspressoSigner{NewEIP155Signer(chainId)}
+}
+
+func (s EspressoSigner) Equal(s2 Signer) bool {
+	espresso, ok := s2.(EspressoSigner)
+	return ok && espresso.chainId.Cmp(s.chainId) == 0
+}
+
+func (s EspressoSigner) Sender(tx *Transaction) (common.Address, error) {
+	switch tx.Type() {
+	case LegacyTxType:
+		return s.EIP155Signer.Sender(tx)
+	case AccessListTxType, SponsoredTxType:
+		if tx.data.ChainID.Cmp(s.chainId) != 0 {
+			return common.Address{}, ErrInvalidChainId
+		}
+		// Typed transactions are signed with the parity of the signature
+		V := new(big.Int).Add(tx.data.V, big27)
+		addr, _, err := recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+		return addr, err
+	default:
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+}
+
+// SignatureValues returns signature values. This signature
+// needs to be in the [R || S || V] format where V is 0 or 1.
+func (s EspressoSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() == LegacyTxType {
+		return s.EIP155Signer.SignatureValues(tx, sig)
+	}
+	if tx.Type() != AccessListTxType && tx.Type() != SponsoredTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	V = big.NewInt(int64(sig[64]))
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender. The hash of typed transactions
+// commits to their type, and the one of sponsored transactions to the fee payer.
+// It does not uniquely identify the transaction.
+func (s EspressoSigner) Hash(tx *Transaction) common.Hash {
+	switch tx.Type() {
+	case AccessListTxType:
+		return prefixedRlpHash(tx.Type(), []interface{}{
+			s.chainId,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.FeeCurrency,
+			tx.data.GatewayFeeRecipient,
+			tx.data.GatewayFee,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.data.AccessList,
+		})
+	case SponsoredTxType:
+		return prefixedRlpHash(tx.Type(), []interface{}{
+			s.chainId,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.FeeCurrency,
+			tx.data.GatewayFeeRecipient,
+			tx.data.GatewayFee,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.data.AccessList,
+			*tx.data.FeePayer,
+		})
+	default:
+		return s.EIP155Signer.Hash(tx)
+	}
+}
+
+func (s EspressoSigner) FeePayer(tx *Transaction) (common.Address, error) {
+	if !tx.Sponsored() {
+		return common.Address{}, ErrNotSponsored
+	}
+	if tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	// Fee payers sign with the parity of the signature too
+	V := new(big.Int).Add(tx.data.FeePayerV, big27)
+	addr, _, err := recoverPlain(s.FeePayerHash(tx), tx.data.FeePayerR, tx.data.FeePayerS, V, true)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if addr != *tx.data.FeePayer {
+		return common.Address{}, ErrInvalidFeePayer
+	}
+	return addr, nil
+}
+
+// FeePayerSignatureValues returns signature values. This signature
+// needs to be in the [R || S || V] format where V is 0 or 1.
+func (s EspressoSigner) FeePayerSignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if !tx.Sponsored() {
+		return nil, nil, nil, ErrNotSponsored
+	}
+	if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	V = big.NewInt(int64(sig[64]))
+	return R, S, V, nil
+}
+
+// FeePayerHash returns the hash to be signed by the fee payer, which commits to
+// the signature of the sender.
+func (s EspressoSigner) FeePayerHash(tx *Transaction) common.Hash {
+	return prefixedRlpHash(tx.Type(), []interface{}{
+		s.chainId,
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.FeeCurrency,
+		tx.data.GatewayFeeRecipient,
+		tx.data.GatewayFee,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.AccessList,
+		*tx.data.FeePayer,
+		tx.data.V,
+		tx.data.R,
+		tx.data.S,
+	})
+}
+
 // EIP155Transaction implements Signer using the EIP155 rules.
 type EIP155Signer struct {
 	chainId, chainIdMul *big.Int
@@ -122,9 +351,18 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 	return ok && eip155.chainId.Cmp(s.chainId) == 0
 }
 
-var big8 = big.NewInt(8)
+var (
+	big8  = big.NewInt(8)
+	big27 = big.NewInt(27)
+)
 
 func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Sponsored() {
+		return common.Address{}, ErrSponsoredTxNotSupported
+	}
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	if !tx.Protected() {
 		return HomesteadSigner{}.Sender(tx)
 	}
@@ -140,6 +378,9 @@ func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
 // SignatureValues returns signature values. This signature
 // needs to be in the [R || S || V] format where V is 0 or 1.
 func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
 	R, S, V, err = HomesteadSigner{}.SignatureValues(tx, sig)
 	if err != nil {
 		return nil, nil, nil, err
@@ -184,6 +425,12 @@ func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v
 }
 
 func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Sponsored() {
+		return common.Address{}, ErrSponsoredTxNotSupported
+	}
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	addr, _, err := recoverPlain(hs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, true)
 	return addr, err
 }
@@ -233,6 +480,12 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Sponsored() {
+		return common.Address{}, ErrSponsoredTxNotSupported
+	}
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	addr, _, err := recoverPlain(fs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, false)
 	return addr, err
 }
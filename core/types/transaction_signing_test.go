@@ -131,3 +131,54 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+func TestEspressoSponsoredSigning(t *testing.T) {
+	senderKey, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey)
+	feePayerKey, _ := crypto.GenerateKey()
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+
+	signer := NewEspressoSigner(big.NewInt(18))
+	tx, err := SignTx(NewTransaction(0, common.Address{1}, new(big.Int), 21000, big.NewInt(1), nil, nil, nil, nil).WithFeePayer(big.NewInt(18), feePayer), signer, senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err = SignFeePayer(tx, signer, feePayerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != sender {
+		t.Errorf("sender mismatch: have %x (%v), want %x", from, err, sender)
+	}
+	if payer, err := FeePayer(signer, tx); err != nil || payer != feePayer {
+		t.Errorf("fee payer mismatch: have %x (%v), want %x", payer, err, feePayer)
+	}
+	// Signers before Espresso don't accept sponsored transactions
+	if _, err := Sender(NewEIP155Signer(big.NewInt(18)), tx); err != ErrSponsoredTxNotSupported {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrSponsoredTxNotSupported)
+	}
+	// Only the fee payer the sender signed over can pay the fees
+	forged, err := SignFeePayer(tx, signer, senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FeePayer(signer, forged); err != ErrInvalidFeePayer {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrInvalidFeePayer)
+	}
+	swapped := &Transaction{data: tx.data}
+	swapped.data.FeePayer = &sender
+	if from, _ := Sender(signer, swapped); from == sender {
+		t.Errorf("sender signature valid for another fee payer")
+	}
+	// Transactions paying their own fees are signed as before
+	legacy, err := SignTx(NewTransaction(0, common.Address{1}, new(big.Int), 21000, big.NewInt(1), nil, nil, nil, nil), signer, senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := Sender(NewEIP155Signer(big.NewInt(18)), legacy); err != nil || from != sender {
+		t.Errorf("sender mismatch: have %x (%v), want %x", from, err, sender)
+	}
+	if payer, err := FeePayer(signer, legacy); err != nil || payer != sender {
+		t.Errorf("fee payer mismatch: have %x (%v), want %x", payer, err, sender)
+	}
+}
@@ -308,3 +308,140 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestSponsoredTransactionEncoding(t *testing.T) {
+	senderKey, sender := defaultTestKey()
+	feePayerKey, _ := crypto.GenerateKey()
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+	accessList := AccessList{{Address: common.Address{1}, StorageKeys: []common.Hash{{0}, {1}}}}
+
+	// Sponsored transactions can carry an access list too
+	signer := NewEspressoSigner(common.Big1)
+	unsigned := NewTransaction(1, common.Address{1}, common.Big1, 30000, common.Big2, nil, nil, nil, []byte("abcdef")).WithAccessList(common.Big1, accessList).WithFeePayer(common.Big1, feePayer)
+	tx, err := SignTx(unsigned, signer, senderKey)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx, err = SignFeePayer(tx, signer, feePayerKey); err != nil {
+		t.Fatalf("could not sign transaction as fee payer: %v", err)
+	}
+	if tx.Type() != SponsoredTxType || len(tx.AccessList()) != 1 {
+		t.Fatalf("transaction type or access list mismatch: have %d with %v, want %d with %v", tx.Type(), tx.AccessList(), SponsoredTxType, accessList)
+	}
+
+	// Check the binary round trip, which is also the hashed encoding
+	bin, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("binary encode error: %v", err)
+	}
+	if bin[0] != SponsoredTxType {
+		t.Errorf("binary encoding type mismatch: have %d, want %d", bin[0], SponsoredTxType)
+	}
+	if hash := crypto.Keccak256Hash(bin); hash != tx.Hash() {
+		t.Errorf("transaction hash mismatch: have %x, want %x", tx.Hash(), hash)
+	}
+
+	// Check the RLP round trip, which wraps the binary encoding in a string
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	decoded, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if decoded.Hash() != tx.Hash() || decoded.Size() != tx.Size() || decoded.Type() != SponsoredTxType {
+		t.Errorf("decoded transaction mismatch: have %v, want %v", decoded, tx)
+	}
+	if from, err := Sender(signer, decoded); err != nil || from != sender {
+		t.Errorf("decoded sender mismatch: have %x (%v), want %x", from, err, sender)
+	}
+	if payer, err := FeePayer(signer, decoded); err != nil || payer != feePayer {
+		t.Errorf("decoded fee payer mismatch: have %x (%v), want %x", payer, err, feePayer)
+	}
+
+	// Check the JSON round trip
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	var parsed *Transaction
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+}
+
+func TestAccessListTransactionEncoding(t *testing.T) {
+	key, addr := defaultTestKey()
+	accessList := AccessList{{Address: common.Address{1}, StorageKeys: []common.Hash{{0}, {1}}}}
+
+	signer := NewEspressoSigner(common.Big1)
+	tx, err := SignTx(NewTransaction(1, common.Address{1}, common.Big1, 30000, common.Big2, nil, nil, nil, []byte("abcdef")).WithAccessList(common.Big1, accessList), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx.Type() != AccessListTxType {
+		t.Fatalf("transaction type mismatch: have %d, want %d", tx.Type(), AccessListTxType)
+	}
+
+	// Check the binary round trip, which is also the hashed encoding
+	bin, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("binary encode error: %v", err)
+	}
+	if bin[0] != AccessListTxType {
+		t.Errorf("binary encoding type mismatch: have %d, want %d", bin[0], AccessListTxType)
+	}
+	if hash := crypto.Keccak256Hash(bin); hash != tx.Hash() {
+		t.Errorf("transaction hash mismatch: have %x, want %x", tx.Hash(), hash)
+	}
+	decoded := new(Transaction)
+	if err := decoded.UnmarshalBinary(bin); err != nil {
+		t.Fatalf("binary decode error: %v", err)
+	}
+	if decoded.Hash() != tx.Hash() || decoded.Type() != tx.Type() {
+		t.Errorf("decoded transaction mismatch: have %v, want %v", decoded, tx)
+	}
+	if from, err := Sender(signer, decoded); err != nil || from != addr {
+		t.Errorf("decoded sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+
+	// Check the RLP round trip, which wraps the binary encoding in a string
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if decoded, err = decodeTx(enc); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if decoded.Hash() != tx.Hash() || decoded.Size() != tx.Size() {
+		t.Errorf("decoded transaction mismatch: have %v, want %v", decoded, tx)
+	}
+	if len(decoded.AccessList()) != 1 || decoded.AccessList().StorageKeys() != 2 {
+		t.Errorf("decoded access list mismatch: have %v, want %v", decoded.AccessList(), accessList)
+	}
+
+	// Legacy signers and other chains must reject the transaction
+	if _, err := Sender(NewEIP155Signer(common.Big1), tx); err != ErrTxTypeNotSupported {
+		t.Errorf("EIP155 sender error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	if _, err := Sender(NewEspressoSigner(common.Big2), tx); err != ErrInvalidChainId {
+		t.Errorf("sender error mismatch on other chain: have %v, want %v", err, ErrInvalidChainId)
+	}
+
+	// Check the JSON round trip
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	var parsed *Transaction
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+}
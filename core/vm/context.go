@@ -41,11 +41,17 @@ type Message interface {
 	FeeCurrency() *common.Address
 	GatewayFeeRecipient() *common.Address
 	GatewayFee() *big.Int
+	// FeePayer specifies the account paying the gas and gateway fees instead of the sender.
+	// nil corresponds to the sender paying its own fees.
+	FeePayer() *common.Address
 	Value() *big.Int
 
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	// AccessList specifies the EIP-2930 access list of the message, nil for legacy
+	// transactions.
+	AccessList() types.AccessList
 }
 
 // ChainContext supports retrieving chain data and consensus parameters
@@ -199,6 +199,32 @@ var PrecompiledContractsDonut = map[common.Address]PrecompiledContract{
 	cip26Address:             &getValidatorBLS{},
 }
 
+// activePrecompiledContracts returns the precompiled contracts enabled with the
+// given rules.
+func activePrecompiledContracts(rules params.Rules) map[common.Address]PrecompiledContract {
+	switch {
+	case rules.IsDonut:
+		return PrecompiledContractsDonut
+	case rules.IsIstanbul:
+		return PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		return PrecompiledContractsByzantium
+	default:
+		return PrecompiledContractsHomestead
+	}
+}
+
+// ActivePrecompiles returns the addresses of the precompiled contracts enabled
+// with the given rules.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	precompiles := activePrecompiledContracts(rules)
+	addrs := make([]common.Address, 0, len(precompiles))
+	for addr := range precompiles {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract, evm *EVM) (ret []byte, err error) {
 	log.Trace("Running precompiled contract", "codeaddr", contract.CodeAddr, "input", input, "caller", contract.CallerAddress, "gas", contract.Gas)
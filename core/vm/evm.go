@@ -63,16 +63,7 @@ type (
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
 	if contract.CodeAddr != nil {
-		precompiles := PrecompiledContractsHomestead
-		if evm.chainRules.IsByzantium {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if evm.chainRules.IsIstanbul {
-			precompiles = PrecompiledContractsIstanbul
-		}
-		if evm.chainRules.IsDonut {
-			precompiles = PrecompiledContractsDonut
-		}
+		precompiles := activePrecompiledContracts(evm.chainRules)
 		if p := precompiles[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract, evm)
 		}
@@ -161,6 +152,9 @@ type EVM struct {
 	callGasTemp uint64
 
 	DontMeterGas bool
+
+	// systemCall is set while a call from the system is running, see SystemCall
+	systemCall bool
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -253,16 +247,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		precompiles := PrecompiledContractsHomestead
-		if evm.chainRules.IsByzantium {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if evm.chainRules.IsIstanbul {
-			precompiles = PrecompiledContractsIstanbul
-		}
-		if evm.chainRules.IsDonut {
-			precompiles = PrecompiledContractsDonut
-		}
+		precompiles := activePrecompiledContracts(evm.chainRules)
 		if precompiles[addr] == nil && evm.chainRules.IsEIP158 && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
@@ -444,6 +429,11 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	nonce := evm.StateDB.GetNonce(caller.Address())
 	evm.StateDB.SetNonce(caller.Address(), nonce+1)
+	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
+	// the access-list change should not be rolled back
+	if evm.chainRules.IsEspresso {
+		evm.StateDB.AddAddressToAccessList(address)
+	}
 
 	// Ensure there's no existing contract already at the designated address
 	contractHash := evm.StateDB.GetCodeHash(address)
@@ -532,13 +522,13 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
-func getTobinTax(evm *EVM, sender common.Address) (numerator *big.Int, denominator *big.Int, reserveAddress *common.Address, err error) {
+func getTobinTax(evm *EVM) (numerator *big.Int, denominator *big.Int, reserveAddress *common.Address, err error) {
 	reserveAddress, err = GetRegisteredAddressWithEvm(params.ReserveRegistryId, evm)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	ret, _, err := evm.Call(AccountRef(sender), *reserveAddress, params.TobinTaxFunctionSelector, params.MaxGasForGetOrComputeTobinTax, big.NewInt(0))
+	ret, _, err := evm.SystemCall(*reserveAddress, params.TobinTaxFunctionSelector, params.MaxGasForGetOrComputeTobinTax, big.NewInt(0))
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -570,7 +560,7 @@ func (evm *EVM) TobinTransfer(db StateDB, sender, recipient common.Address, gas
 	}
 
 	if amount.Cmp(big.NewInt(0)) != 0 {
-		numerator, denominator, reserveAddress, err := getTobinTax(evm, sender)
+		numerator, denominator, reserveAddress, err := getTobinTax(evm)
 		if err == nil {
 			tobinTax := new(big.Int).Div(new(big.Int).Mul(numerator, amount), denominator)
 			evm.Context.Transfer(db, sender, recipient, new(big.Int).Sub(amount, tobinTax))
@@ -587,9 +577,30 @@ func (evm *EVM) TobinTransfer(db StateDB, sender, recipient common.Address, gas
 	return gas, nil
 }
 
+// SystemCall executes the contract at addr with the given input on behalf of the system.
+// The gas budgets of system calls (e.g. the fee currency debit and credit) were measured
+// before Espresso, and they don't have an access list, so they keep the gas schedule
+// without the EIP-2929 access costs.
+func (evm *EVM) SystemCall(addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
+	if !evm.systemCall {
+		evm.systemCall = true
+		defer func() { evm.systemCall = false }()
+	}
+	return evm.Call(systemCaller, addr, input, gas, value)
+}
+
+// StaticSystemCall is the read only version of SystemCall.
+func (evm *EVM) StaticSystemCall(addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	if !evm.systemCall {
+		evm.systemCall = true
+		defer func() { evm.systemCall = false }()
+	}
+	return evm.StaticCall(systemCaller, addr, input, gas)
+}
+
 func (evm *EVM) StaticCallFromSystem(contractAddress common.Address, abi abipkg.ABI, funcName string, args []interface{}, returnObj interface{}, gas uint64) (uint64, error) {
 	staticCall := func(transactionData []byte) ([]byte, uint64, error) {
-		return evm.StaticCall(systemCaller, contractAddress, transactionData, gas)
+		return evm.StaticSystemCall(contractAddress, transactionData, gas)
 	}
 
 	return evm.handleABICall(abi, funcName, args, returnObj, staticCall)
@@ -597,7 +608,7 @@ func (evm *EVM) StaticCallFromSystem(contractAddress common.Address, abi abipkg.
 
 func (evm *EVM) CallFromSystem(contractAddress common.Address, abi abipkg.ABI, funcName string, args []interface{}, returnObj interface{}, gas uint64, value *big.Int) (uint64, error) {
 	call := func(transactionData []byte) ([]byte, uint64, error) {
-		return evm.Call(systemCaller, contractAddress, transactionData, gas, value)
+		return evm.SystemCall(contractAddress, transactionData, gas, value)
 	}
 	return evm.handleABICall(abi, funcName, args, returnObj, call)
 }
@@ -60,6 +60,18 @@ type StateDB interface {
 	RevertToSnapshot(int)
 	Snapshot() int
 
+	// PrepareAccessList, AddressInAccessList, SlotInAccessList, AddAddressToAccessList
+	// and AddSlotToAccessList handle the EIP-2929 access list of the transaction.
+	PrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList)
+	AddressInAccessList(addr common.Address) bool
+	SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool)
+	// AddAddressToAccessList adds the given address to the access list. This operation is safe to perform
+	// even if the feature/fork is not active yet
+	AddAddressToAccessList(addr common.Address)
+	// AddSlotToAccessList adds the given (address,slot) to the access list. This operation is safe to perform
+	// even if the feature/fork is not active yet
+	AddSlotToAccessList(addr common.Address, slot common.Hash)
+
 	AddLog(*types.Log)
 	AddPreimage(common.Hash, []byte)
 
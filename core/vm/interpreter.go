@@ -90,6 +90,8 @@ type EVMInterpreter struct {
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
+
+	systemJumpTable *JumpTable // Instruction table of the system calls, see EVM.SystemCall
 }
 
 // NewEVMInterpreter returns a new instance of the Interpreter.
@@ -100,6 +102,8 @@ func NewEVMInterpreter(evm *EVM, cfg *Config) *EVMInterpreter {
 	if !cfg.JumpTable[STOP].valid {
 		var jt JumpTable
 		switch {
+		case evm.chainRules.IsEspresso:
+			jt = espressoInstructionSet
 		case evm.chainRules.IsIstanbul:
 			jt = istanbulInstructionSet
 		case evm.chainRules.IsConstantinople:
@@ -124,10 +128,16 @@ func NewEVMInterpreter(evm *EVM, cfg *Config) *EVMInterpreter {
 		}
 		cfg.JumpTable = jt
 	}
+	// System calls keep the gas schedule before Espresso
+	systemJumpTable := (*JumpTable)(&cfg.JumpTable)
+	if evm.chainRules.IsEspresso {
+		systemJumpTable = &istanbulInstructionSet
+	}
 
 	return &EVMInterpreter{
-		evm: evm,
-		cfg: cfg,
+		evm:             evm,
+		cfg:             cfg,
+		systemJumpTable: systemJumpTable,
 	}
 }
 
@@ -188,6 +198,11 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	)
 	contract.Input = input
 
+	jumpTable := (*JumpTable)(&in.cfg.JumpTable)
+	if in.evm.systemCall {
+		jumpTable = in.systemJumpTable
+	}
+
 	// Reclaim the stack as an int pool when the execution stops
 	defer func() { in.intPool.put(stack.data...) }()
 
@@ -220,7 +235,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
-		operation := in.cfg.JumpTable[op]
+		operation := jumpTable[op]
 		if !operation.valid {
 			return nil, &ErrInvalidOpCode{opcode: op}
 		}
@@ -20,10 +20,11 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/celo-org/celo-blockchain/accounts"
 	"github.com/celo-org/celo-blockchain/common"
-	gpm "github.com/celo-org/celo-blockchain/contract_comm/gasprice_minimum"
+	"github.com/celo-org/celo-blockchain/consensus"
 	"github.com/celo-org/celo-blockchain/core"
 	"github.com/celo-org/celo-blockchain/core/bloombits"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
@@ -31,6 +32,7 @@ import (
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/core/vm"
 	"github.com/celo-org/celo-blockchain/eth/downloader"
+	"github.com/celo-org/celo-blockchain/eth/gasprice"
 	"github.com/celo-org/celo-blockchain/ethdb"
 	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/params"
@@ -41,6 +43,7 @@ import (
 type EthAPIBackend struct {
 	extRPCEnabled bool
 	eth           *Ethereum
+	gpo           *gasprice.Oracle
 }
 
 // ChainConfig returns the active chain configuration.
@@ -48,6 +51,11 @@ func (b *EthAPIBackend) ChainConfig() *params.ChainConfig {
 	return b.eth.blockchain.Config()
 }
 
+// Engine returns the consensus engine of the chain.
+func (b *EthAPIBackend) Engine() consensus.Engine {
+	return b.eth.engine
+}
+
 func (b *EthAPIBackend) CurrentBlock() *types.Block {
 	return b.eth.blockchain.CurrentBlock()
 }
@@ -223,6 +231,16 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
+// SendPrivateTx adds a private transaction to the pool and relays it to the
+// trusted private transaction peers.
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, deadline uint64) error {
+	if err := b.eth.txPool.AddPrivate(signedTx, deadline); err != nil {
+		return err
+	}
+	b.eth.protocolManager.RelayPrivateTransaction(signedTx)
+	return nil
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending, err := b.eth.txPool.Pending()
 	if err != nil {
@@ -252,6 +270,18 @@ func (b *EthAPIBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats()
 }
 
+func (b *EthAPIBackend) TxPoolCeloStatus() map[common.Address]*core.TxPoolCurrencyStatus {
+	return b.eth.TxPool().CeloStatus()
+}
+
+func (b *EthAPIBackend) TxPoolRejections(since time.Time) []*core.TxRejection {
+	return b.eth.TxPool().Rejections(since)
+}
+
+func (b *EthAPIBackend) TxPoolPriceBump() uint64 {
+	return b.eth.TxPool().PriceBump()
+}
+
 func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	return b.eth.TxPool().Content()
 }
@@ -269,11 +299,15 @@ func (b *EthAPIBackend) ProtocolVersion() int {
 }
 
 func (b *EthAPIBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
-	return gpm.GetGasPriceSuggestion(nil, nil, nil)
+	return b.gpo.SuggestPrice(ctx, nil)
+}
+
+func (b *EthAPIBackend) SuggestPriceInCurrency(ctx context.Context, currencyAddress *common.Address) (*big.Int, error) {
+	return b.gpo.SuggestPrice(ctx, currencyAddress)
 }
 
-func (b *EthAPIBackend) SuggestPriceInCurrency(ctx context.Context, currencyAddress *common.Address, header *types.Header, state *state.StateDB) (*big.Int, error) {
-	return gpm.GetGasPriceSuggestion(currencyAddress, header, state)
+func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64, currencyAddress *common.Address) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles, currencyAddress)
 }
 
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
@@ -42,6 +42,7 @@ import (
 	"github.com/celo-org/celo-blockchain/core/vm"
 	"github.com/celo-org/celo-blockchain/eth/downloader"
 	"github.com/celo-org/celo-blockchain/eth/filters"
+	"github.com/celo-org/celo-blockchain/eth/gasprice"
 	"github.com/celo-org/celo-blockchain/ethdb"
 	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/internal/ethapi"
@@ -233,6 +234,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(chainConfig, checkpoint, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit, config.Whitelist, ctx.Server, ctx.ProxyServer); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.privateTxPeers = make(map[enode.ID]struct{})
+	for _, node := range config.PrivateTxPeers {
+		eth.protocolManager.privateTxPeers[node.ID()] = struct{}{}
+	}
 
 	// If the engine is istanbul, then inject the blockchain
 	if istanbul, isIstanbul := eth.engine.(*istanbulBackend.Backend); isIstanbul {
@@ -247,7 +252,8 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock, chainDb)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
-	eth.APIBackend = &EthAPIBackend{ctx.ExtRPCEnabled(), eth}
+	eth.APIBackend = &EthAPIBackend{ctx.ExtRPCEnabled(), eth, nil}
+	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, config.GPO)
 
 	eth.dialCandiates, err = eth.setupDiscovery(&ctx.Config.P2P)
 	if err != nil {
@@ -617,6 +623,11 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
+	// Keep connected to the peers private transactions are relayed to
+	for _, node := range s.config.PrivateTxPeers {
+		srvr.AddTrustedPeer(node, p2p.ExplicitTrustedPurpose)
+		srvr.AddPeer(node, p2p.ExplicitStaticPurpose)
+	}
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
@@ -644,6 +655,7 @@ func (s *Ethereum) Stop() error {
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.blockchain.Stop()
+	contract_comm.UnsetInternalEVMHandler(s.blockchain)
 	s.engine.Close()
 	s.chainDb.Close()
 	s.eventMux.Stop()
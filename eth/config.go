@@ -24,7 +24,9 @@ import (
 	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/core"
 	"github.com/celo-org/celo-blockchain/eth/downloader"
+	"github.com/celo-org/celo-blockchain/eth/gasprice"
 	"github.com/celo-org/celo-blockchain/miner"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
 	"github.com/celo-org/celo-blockchain/params"
 )
 
@@ -41,14 +43,16 @@ var DefaultConfig = Config{
 	TrieTimeout:        60 * time.Minute,
 	SnapshotCache:      256,
 	Miner: miner.Config{
-		GasFloor: 8000000,
-		GasCeil:  8000000,
-		GasPrice: big.NewInt(1),
-		Recommit: 3 * time.Second,
+		GasFloor:   8000000,
+		GasCeil:    8000000,
+		GasPrice:   big.NewInt(1),
+		Recommit:   3 * time.Second,
+		TxOrdering: miner.TxOrderingPrice,
 	},
 	GatewayFee: big.NewInt(0),
 
 	TxPool: core.DefaultTxPoolConfig,
+	GPO:    gasprice.DefaultConfig,
 
 	Istanbul: *istanbul.DefaultConfig,
 }
@@ -74,6 +78,10 @@ type Config struct {
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Trusted peers the private transactions are relayed to and accepted
+	// from, typically validators or their proxies
+	PrivateTxPeers []*enode.Node `toml:",omitempty"`
+
 	// Light client options
 	LightServ    int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightIngress int `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -109,6 +117,9 @@ type Config struct {
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
+	// Gas price oracle options
+	GPO gasprice.Config
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
@@ -18,6 +18,7 @@ package fetcher
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"sort"
@@ -281,20 +282,20 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			// Track the transaction hash if the price is too low for us.
 			// Avoid re-request this transaction when we receive another
 			// announcement.
-			if err == core.ErrUnderpriced || err == core.ErrReplaceUnderpriced {
+			if err == core.ErrUnderpriced || errors.Is(err, core.ErrReplaceUnderpriced) {
 				for f.underpriced.Cardinality() >= maxTxUnderpricedSetSize {
 					f.underpriced.Pop()
 				}
 				f.underpriced.Add(txs[i].Hash())
 			}
 			// Track a few interesting failure types
-			switch err {
-			case nil: // Noop, but need to handle to not count these
+			switch {
+			case err == nil: // Noop, but need to handle to not count these
 
-			case core.ErrAlreadyKnown:
+			case err == core.ErrAlreadyKnown:
 				duplicate++
 
-			case core.ErrUnderpriced, core.ErrReplaceUnderpriced:
+			case err == core.ErrUnderpriced, errors.Is(err, core.ErrReplaceUnderpriced):
 				underpriced++
 
 			default:
@@ -0,0 +1,238 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
+	gpm "github.com/celo-org/celo-blockchain/contract_comm/gasprice_minimum"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/rpc"
+)
+
+var (
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+)
+
+// txFee holds the gas used and the gas price of a transaction
+type txFee struct {
+	gasUsed     uint64
+	gasPrice    *big.Int
+	feeCurrency *common.Address
+}
+
+// blockFees holds the fee data of a block, as needed by the oracle
+type blockFees struct {
+	number          uint64
+	gasUsed         uint64
+	gasUsedRatio    float64
+	gasPriceMinimum *big.Int               // Gas price minimum of the block, in CELO
+	rates           *currency.RateSnapshot // Exchange rates the block was processed with
+	txs             []txFee
+}
+
+// blockFees returns the fee data of the block, from the cache if possible.
+//
+// The gas price minimum, the exchange rates and the gas limit a block was
+// processed with are read from the state of its parent.
+func (o *Oracle) blockFees(ctx context.Context, number uint64) (*blockFees, error) {
+	block, err := o.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+	if block == nil || err != nil {
+		if err == nil {
+			err = fmt.Errorf("block %d not found", number)
+		}
+		return nil, err
+	}
+	if fees, ok := o.cache.Get(block.Hash()); ok {
+		return fees.(*blockFees), nil
+	}
+
+	receipts, err := o.backend.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(block.Transactions()) {
+		return nil, fmt.Errorf("receipts mismatch for block %d: have %d, want %d", number, len(receipts), len(block.Transactions()))
+	}
+	parentNumber := number
+	if parentNumber > 0 {
+		parentNumber--
+	}
+	parentState, parentHeader, err := o.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(parentNumber))
+	if parentState == nil || err != nil {
+		if err == nil {
+			err = fmt.Errorf("state of block %d not found", parentNumber)
+		}
+		return nil, err
+	}
+	minimum, err := gpm.GetGasPriceMinimum(nil, block.Header(), parentState)
+	if err != nil {
+		return nil, err
+	}
+
+	fees := &blockFees{
+		number:          number,
+		gasUsed:         block.GasUsed(),
+		gasPriceMinimum: minimum,
+		rates:           currency.NewRateSnapshot(block.Header(), parentState),
+		txs:             make([]txFee, len(receipts)),
+	}
+	if gasLimit := core.CalcGasLimit(types.NewBlockWithHeader(parentHeader), parentState); gasLimit > 0 {
+		fees.gasUsedRatio = float64(fees.gasUsed) / float64(gasLimit)
+	}
+	for i, tx := range block.Transactions() {
+		fees.txs[i] = txFee{gasUsed: receipts[i].GasUsed, gasPrice: tx.GasPrice(), feeCurrency: tx.FeeCurrency()}
+	}
+	o.cache.Add(block.Hash(), fees)
+	return fees, nil
+}
+
+// rewards returns the gas prices above the gas price minimum which were paid
+// at the given gas used weighted percentiles of the block, in the fee currency.
+func (f *blockFees) rewards(percentiles []float64, feeCurrency *common.Address) ([]*big.Int, error) {
+	minimum, err := f.rates.Convert(f.gasPriceMinimum, nil, feeCurrency)
+	if err != nil {
+		return nil, err
+	}
+	type reward struct {
+		gasUsed uint64
+		reward  *big.Int
+	}
+	var (
+		sorted  = make([]reward, 0, len(f.txs))
+		gasUsed uint64
+	)
+	for _, tx := range f.txs {
+		price, err := f.rates.Convert(tx.gasPrice, tx.feeCurrency, feeCurrency)
+		if err != nil {
+			continue
+		}
+		price.Sub(price, minimum)
+		if price.Sign() < 0 {
+			price.SetUint64(0)
+		}
+		sorted = append(sorted, reward{tx.gasUsed, price})
+		gasUsed += tx.gasUsed
+	}
+	rewards := make([]*big.Int, len(percentiles))
+	if len(sorted) == 0 {
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+		}
+		return rewards, nil
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].reward.Cmp(sorted[j].reward) < 0 })
+
+	var txIndex int
+	sumGasUsed := sorted[0].gasUsed
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(gasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+		rewards[i] = sorted[txIndex].reward
+	}
+	return rewards, nil
+}
+
+// FeeHistory returns the fee market history of up to the given number of
+// blocks ending with lastBlock, in the fee currency where nil is CELO.
+//
+// For every block it returns the gas price minimum and the gas used ratio, and
+// the gas prices above the gas price minimum paid at the given gas used weighted
+// percentiles if any. The gas price minimums include the one of the block
+// following lastBlock. The number of the oldest block is returned along with
+// the history, which is capped at the configured maximum number of blocks.
+func (o *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64, feeCurrency *common.Address) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	if blocks < 1 {
+		return common.Big0, nil, nil, nil, nil
+	}
+	if blocks > o.maxHeaderHistory {
+		blocks = o.maxHeaderHistory
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return common.Big0, nil, nil, nil, fmt.Errorf("%w: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return common.Big0, nil, nil, nil, fmt.Errorf("%w: #%d:%f > #%d:%f", errInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+
+	head, err := o.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil || err != nil {
+		return common.Big0, nil, nil, nil, err
+	}
+	headNumber := head.Number.Uint64()
+	last := headNumber
+	if lastBlock >= 0 {
+		if uint64(lastBlock) > headNumber {
+			return common.Big0, nil, nil, nil, fmt.Errorf("%w: requested %d, head %d", errRequestBeyondHead, lastBlock, headNumber)
+		}
+		last = uint64(lastBlock)
+	}
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	var (
+		reward          [][]*big.Int
+		gasPriceMinimum = make([]*big.Int, blocks+1)
+		gasUsedRatio    = make([]float64, blocks)
+	)
+	if len(rewardPercentiles) != 0 {
+		reward = make([][]*big.Int, blocks)
+	}
+	for i := 0; i < blocks; i++ {
+		fees, err := o.blockFees(ctx, oldest+uint64(i))
+		if err != nil {
+			return common.Big0, nil, nil, nil, err
+		}
+		if gasPriceMinimum[i], err = fees.rates.Convert(fees.gasPriceMinimum, nil, feeCurrency); err != nil {
+			return common.Big0, nil, nil, nil, err
+		}
+		gasUsedRatio[i] = fees.gasUsedRatio
+		if reward != nil {
+			if reward[i], err = fees.rewards(rewardPercentiles, feeCurrency); err != nil {
+				return common.Big0, nil, nil, nil, err
+			}
+		}
+	}
+
+	// The gas price minimum of the next block is held by the contract after the last block
+	lastState, lastHeader, err := o.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(last))
+	if lastState == nil || err != nil {
+		if err == nil {
+			err = fmt.Errorf("state of block %d not found", last)
+		}
+		return common.Big0, nil, nil, nil, err
+	}
+	if gasPriceMinimum[blocks], err = gpm.GetGasPriceMinimum(feeCurrency, lastHeader, lastState); err != nil {
+		return common.Big0, nil, nil, nil, err
+	}
+	return new(big.Int).SetUint64(oldest), reward, gasPriceMinimum, gasUsedRatio, nil
+}
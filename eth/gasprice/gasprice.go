@@ -0,0 +1,236 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gasprice recommends gas prices in every fee currency, from the gas
+// prices paid in recent blocks.
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/celo-org/celo-blockchain/common"
+	gpm "github.com/celo-org/celo-blockchain/contract_comm/gasprice_minimum"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/log"
+	"github.com/celo-org/celo-blockchain/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// Number of the cheapest transactions sampled in a congested block
+	sampleNumber = 3
+	// Gas used ratio from which a block is considered congested. The gas price
+	// minimum was enough to be included in the blocks below it.
+	congestedRatio = 0.8
+	// Number of blocks whose fee data is cached
+	blockCacheSize = 2048
+)
+
+// Config are the settings of the gas price oracle.
+type Config struct {
+	Blocks           int // Number of recent blocks sampled to suggest a gas price
+	Percentile       int // Percentile of the sampled gas prices which is suggested
+	MaxHeaderHistory int // Maximum number of blocks of a fee history request
+}
+
+// DefaultConfig contains the default settings of the oracle of full nodes.
+var DefaultConfig = Config{
+	Blocks:           20,
+	Percentile:       60,
+	MaxHeaderHistory: 1024,
+}
+
+// DefaultLightConfig contains the default settings of the oracle of light
+// clients, which fetch every sampled block from the network.
+var DefaultLightConfig = Config{
+	Blocks:           2,
+	Percentile:       60,
+	MaxHeaderHistory: 128,
+}
+
+// OracleBackend includes all necessary background APIs for the oracle.
+type OracleBackend interface {
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error)
+}
+
+// Oracle recommends gas prices in every fee currency, based on the content of
+// recent blocks. Suitable for both light and full clients.
+type Oracle struct {
+	backend          OracleBackend
+	blocks           int
+	percentile       int
+	maxHeaderHistory int
+
+	cache *lru.Cache // Fee data of the recently sampled blocks, by hash
+
+	cacheLock  sync.RWMutex
+	fetchLock  sync.Mutex
+	lastHead   common.Hash
+	lastPrices map[common.Address]*big.Int // Prices suggested for the last head, by fee currency
+}
+
+// NewOracle returns a new gas price oracle which can recommend suitable gas
+// prices for newly created transactions.
+func NewOracle(backend OracleBackend, params Config) *Oracle {
+	blocks := params.Blocks
+	if blocks < 1 {
+		blocks = 1
+		log.Warn("Sanitizing invalid gasprice oracle sample blocks", "provided", params.Blocks, "updated", blocks)
+	}
+	percentile := params.Percentile
+	if percentile < 0 {
+		percentile = 0
+		log.Warn("Sanitizing invalid gasprice oracle sample percentile", "provided", params.Percentile, "updated", percentile)
+	}
+	if percentile > 100 {
+		percentile = 100
+		log.Warn("Sanitizing invalid gasprice oracle sample percentile", "provided", params.Percentile, "updated", percentile)
+	}
+	maxHeaderHistory := params.MaxHeaderHistory
+	if maxHeaderHistory < 1 {
+		maxHeaderHistory = 1
+		log.Warn("Sanitizing invalid gasprice oracle max header history", "provided", params.MaxHeaderHistory, "updated", maxHeaderHistory)
+	}
+	cache, _ := lru.New(blockCacheSize)
+	return &Oracle{
+		backend:          backend,
+		blocks:           blocks,
+		percentile:       percentile,
+		maxHeaderHistory: maxHeaderHistory,
+		cache:            cache,
+		lastPrices:       make(map[common.Address]*big.Int),
+	}
+}
+
+// currencyKey returns the key of the fee currency in the caches, where CELO is
+// the zero address.
+func currencyKey(feeCurrency *common.Address) common.Address {
+	if feeCurrency == nil {
+		return common.Address{}
+	}
+	return *feeCurrency
+}
+
+// SuggestPrice returns a gas price in the fee currency which gets a transaction
+// included in the next blocks with a high probability, nil being CELO.
+//
+// Uncongested blocks show that their gas price minimum was enough to be
+// included, and are sampled as such. Congested blocks are sampled through the
+// cheapest gas prices they included. The suggestion is the configured
+// percentile of the samples, but never below the suggestion of the gas price
+// minimum contract (a multiple of the gas price minimum of the next block).
+func (o *Oracle) SuggestPrice(ctx context.Context, feeCurrency *common.Address) (*big.Int, error) {
+	head, err := o.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	headHash, key := head.Hash(), currencyKey(feeCurrency)
+
+	o.cacheLock.RLock()
+	if headHash == o.lastHead && o.lastPrices[key] != nil {
+		price := o.lastPrices[key]
+		o.cacheLock.RUnlock()
+		return new(big.Int).Set(price), nil
+	}
+	o.cacheLock.RUnlock()
+
+	o.fetchLock.Lock()
+	defer o.fetchLock.Unlock()
+
+	// Try checking the cache again, maybe the last fetch fetched what we need
+	o.cacheLock.RLock()
+	if headHash == o.lastHead && o.lastPrices[key] != nil {
+		price := o.lastPrices[key]
+		o.cacheLock.RUnlock()
+		return new(big.Int).Set(price), nil
+	}
+	o.cacheLock.RUnlock()
+
+	var samples []*big.Int
+	number := head.Number.Uint64()
+	for i := 0; i < o.blocks && i <= int(number); i++ {
+		fees, err := o.blockFees(ctx, number-uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		blockSamples, err := fees.samples(feeCurrency)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, blockSamples...)
+	}
+
+	state, _, err := o.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return nil, err
+	}
+	price, err := gpm.GetGasPriceSuggestion(feeCurrency, head, state)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) > 0 {
+		sort.Sort(bigIntArray(samples))
+		if sample := samples[(len(samples)-1)*o.percentile/100]; sample.Cmp(price) > 0 {
+			price = sample
+		}
+	}
+
+	o.cacheLock.Lock()
+	if o.lastHead != headHash {
+		o.lastHead = headHash
+		o.lastPrices = make(map[common.Address]*big.Int)
+	}
+	o.lastPrices[key] = price
+	o.cacheLock.Unlock()
+	return new(big.Int).Set(price), nil
+}
+
+// samples returns the gas prices sampled from the block, in the fee currency
+func (f *blockFees) samples(feeCurrency *common.Address) ([]*big.Int, error) {
+	minimum, err := f.rates.Convert(f.gasPriceMinimum, nil, feeCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if f.gasUsedRatio < congestedRatio {
+		return []*big.Int{minimum}, nil
+	}
+	prices := make([]*big.Int, 0, len(f.txs))
+	for _, tx := range f.txs {
+		price, err := f.rates.Convert(tx.gasPrice, tx.feeCurrency, feeCurrency)
+		if err != nil {
+			continue
+		}
+		prices = append(prices, price)
+	}
+	sort.Sort(bigIntArray(prices))
+	if len(prices) > sampleNumber {
+		prices = prices[:sampleNumber]
+	}
+	return prices, nil
+}
+
+type bigIntArray []*big.Int
+
+func (s bigIntArray) Len() int           { return len(s) }
+func (s bigIntArray) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigIntArray) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,196 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rpc"
+)
+
+// testBackend serves a chain of blocks without core contracts, where the gas
+// price minimum is zero and the block gas limit is the default one.
+type testBackend struct {
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+}
+
+func (b *testBackend) number(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return uint64(len(b.blocks) - 1)
+	}
+	return uint64(number)
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if n := b.number(number); n < uint64(len(b.blocks)) {
+		return b.blocks[n], nil
+	}
+	return nil, errors.New("unknown block")
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header, err := b.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	return statedb, header, nil
+}
+
+// newTestBackend creates a chain where the blocks from the given number on are
+// full, and include transactions paying 1 to 10 times the given base price.
+func newTestBackend(blocks int, congestedFrom int, basePrice int64) *testBackend {
+	backend := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	for i := 0; i < blocks; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		var (
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+		)
+		if i > 0 && i >= congestedFrom {
+			for j := int64(1); j <= 10; j++ {
+				price := big.NewInt(basePrice * j)
+				txs = append(txs, types.NewTransaction(uint64(j), common.Address{}, common.Big0, params.DefaultGasLimit/10, price, nil, nil, nil, nil))
+				receipts = append(receipts, &types.Receipt{GasUsed: params.DefaultGasLimit / 10})
+			}
+			header.GasUsed = params.DefaultGasLimit
+		}
+		block := types.NewBlock(header, txs, receipts, nil)
+		backend.blocks = append(backend.blocks, block)
+		backend.receipts[block.Hash()] = receipts
+	}
+	return backend
+}
+
+func TestSuggestPrice(t *testing.T) {
+	tests := []struct {
+		congestedFrom int
+		want          int64
+	}{
+		{congestedFrom: 32, want: 0},   // Uncongested blocks only sample the zero minimum
+		{congestedFrom: 30, want: 0},   // Mostly uncongested blocks
+		{congestedFrom: 28, want: 1e9}, // Some congested blocks
+		{congestedFrom: 16, want: 2e9}, // Congested blocks only sample their 3 cheapest prices
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(newTestBackend(32, tt.congestedFrom, 1e9), Config{Blocks: 16, Percentile: 60, MaxHeaderHistory: 32})
+		price, err := oracle.SuggestPrice(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to suggest a price: %v", i, err)
+		}
+		if price.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("test %d: price mismatch: have %v, want %v", i, price, tt.want)
+		}
+	}
+}
+
+func TestSuggestPriceUnknownCurrency(t *testing.T) {
+	oracle := NewOracle(newTestBackend(8, 0, 1e9), DefaultConfig)
+	currency := common.HexToAddress("0xd8763cba276a3738e6de85b4b3bf5fded6d6ca73")
+	if _, err := oracle.SuggestPrice(context.Background(), &currency); err == nil {
+		t.Fatalf("expected an error for a currency without exchange rate")
+	}
+}
+
+func TestFeeHistory(t *testing.T) {
+	tests := []struct {
+		maxHeader   int
+		count       int
+		last        rpc.BlockNumber
+		percentiles []float64
+		expFirst    uint64
+		expCount    int
+		expErr      error
+	}{
+		{maxHeader: 1000, count: 10, last: 30, expFirst: 21, expCount: 10},
+		{maxHeader: 1000, count: 10, last: 30, percentiles: []float64{0, 10}, expFirst: 21, expCount: 10},
+		{maxHeader: 1000, count: 10, last: rpc.LatestBlockNumber, expFirst: 22, expCount: 10},
+		{maxHeader: 1000, count: 10, last: rpc.PendingBlockNumber, expFirst: 22, expCount: 10},
+		{maxHeader: 1000, count: 40, last: 30, expFirst: 0, expCount: 31},
+		{maxHeader: 5, count: 10, last: 30, expFirst: 26, expCount: 5},
+		{maxHeader: 1000, count: 0, last: 30, expFirst: 0, expCount: 0},
+		{maxHeader: 1000, count: 10, last: 40, expErr: errRequestBeyondHead},
+		{maxHeader: 1000, count: 10, last: 30, percentiles: []float64{10, 0}, expErr: errInvalidPercentile},
+		{maxHeader: 1000, count: 10, last: 30, percentiles: []float64{101}, expErr: errInvalidPercentile},
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(newTestBackend(32, 16, 1e9), Config{Blocks: 20, Percentile: 60, MaxHeaderHistory: tt.maxHeader})
+		first, reward, minimum, ratio, err := oracle.FeeHistory(context.Background(), tt.count, tt.last, tt.percentiles, nil)
+		if tt.expErr != nil {
+			if !errors.Is(err, tt.expErr) {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.expErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: failed to get the fee history: %v", i, err)
+		}
+		if first.Uint64() != tt.expFirst {
+			t.Errorf("test %d: first block mismatch: have %d, want %d", i, first, tt.expFirst)
+		}
+		if len(ratio) != tt.expCount {
+			t.Errorf("test %d: gas used ratio count mismatch: have %d, want %d", i, len(ratio), tt.expCount)
+		}
+		if tt.expCount > 0 && len(minimum) != tt.expCount+1 {
+			t.Errorf("test %d: gas price minimum count mismatch: have %d, want %d", i, len(minimum), tt.expCount+1)
+		}
+		if len(tt.percentiles) == 0 && reward != nil {
+			t.Errorf("test %d: expected no rewards", i)
+		}
+		if len(tt.percentiles) > 0 && len(reward) != tt.expCount {
+			t.Errorf("test %d: reward count mismatch: have %d, want %d", i, len(reward), tt.expCount)
+		}
+	}
+}
+
+func TestFeeHistoryRewards(t *testing.T) {
+	oracle := NewOracle(newTestBackend(4, 0, 1e9), DefaultConfig)
+	_, reward, _, ratio, err := oracle.FeeHistory(context.Background(), 1, 3, []float64{0, 25, 50, 100}, nil)
+	if err != nil {
+		t.Fatalf("failed to get the fee history: %v", err)
+	}
+	if ratio[0] != 1 {
+		t.Errorf("gas used ratio mismatch: have %f, want 1", ratio[0])
+	}
+	// Every transaction uses a tenth of the gas, and pays a multiple of the base price
+	for i, want := range []int64{1e9, 3e9, 5e9, 10e9} {
+		if reward[0][i].Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("reward %d mismatch: have %v, want %v", i, reward[0][i], want)
+		}
+	}
+}
@@ -102,6 +102,8 @@ type ProtocolManager struct {
 	server      *p2p.Server
 	proxyServer *p2p.Server
 
+	privateTxPeers map[enode.ID]struct{} // Trusted peers the private transactions are relayed to and accepted from
+
 	// Test fields or hooks
 	broadcastTxAnnouncesOnly bool // Testing field, disable transaction propagation
 }
@@ -846,11 +848,14 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			} else if err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
-			// Retrieve the requested transaction, skipping if unknown to us
+			// Retrieve the requested transaction, skipping if unknown to us or private
 			tx := pm.txpool.Get(hash)
 			if tx == nil {
 				continue
 			}
+			if _, private := pm.txpool.PrivateDeadline(hash); private {
+				continue
+			}
 			// If known, encode and queue for response packet
 			if encoded, err := rlp.EncodeToBytes(tx); err != nil {
 				log.Error("Failed to encode transaction", "err", err)
@@ -881,6 +886,35 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txFetcher.Enqueue(p.id, txs, msg.Code == PooledTransactionsMsg)
 
+	case msg.Code == PrivateTransactionsMsg && p.version >= istanbul.Celo68:
+		// Private transactions arrived, make sure we have a valid and fresh chain to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		// Otherwise any peer could keep transactions out of the public pool until their deadline
+		if !pm.isPrivateTxSource(p) {
+			p.Log().Debug("Ignoring private transactions from untrusted peer")
+			break
+		}
+		var txs []*privateTxData
+		if err := msg.Decode(&txs); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, data := range txs {
+			if data == nil || data.Tx == nil {
+				return errResp(ErrDecode, "private transaction %d is nil", i)
+			}
+			p.MarkTransaction(data.Tx.Hash())
+		}
+		for _, data := range txs {
+			if err := pm.txpool.AddPrivate(data.Tx, data.Deadline); err != nil {
+				p.Log().Debug("Rejected private transaction", "hash", data.Tx.Hash(), "err", err)
+				continue
+			}
+			// Proxies hand the private transactions over to their proxied validators
+			pm.relayPrivateTransaction(data.Tx, pm.isProxiedPeer)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -931,6 +965,9 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 		txset = make(map[*peer][]common.Hash)
 		annos = make(map[*peer][]common.Hash)
 	)
+	// Private transactions are only ever relayed to trusted peers
+	txs = pm.publicTransactions(txs)
+
 	// Broadcast transactions to a batch of peers not knowing about it
 	if propagate {
 		for _, tx := range txs {
@@ -964,6 +1001,65 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 	}
 }
 
+// publicTransactions filters the private transactions out of a batch.
+func (pm *ProtocolManager) publicTransactions(txs types.Transactions) types.Transactions {
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if _, private := pm.txpool.PrivateDeadline(tx.Hash()); !private {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+// RelayPrivateTransaction sends a private transaction of the pool to the
+// connected trusted private transaction peers and, on a proxy, to the proxied
+// validators. It returns the number of peers the transaction was sent to.
+func (pm *ProtocolManager) RelayPrivateTransaction(tx *types.Transaction) int {
+	return pm.relayPrivateTransaction(tx, func(p *peer) bool {
+		_, trusted := pm.privateTxPeers[p.ID()]
+		return trusted || pm.isProxiedPeer(p)
+	})
+}
+
+// relayPrivateTransaction sends a private transaction of the pool, along with
+// its deadline, to the selected peers not knowing about it yet.
+func (pm *ProtocolManager) relayPrivateTransaction(tx *types.Transaction, selected func(p *peer) bool) int {
+	hash := tx.Hash()
+	deadline, private := pm.txpool.PrivateDeadline(hash)
+	if !private {
+		return 0
+	}
+	sent := 0
+	for _, p := range pm.peers.PeersWithoutTx(hash) {
+		if p.version < istanbul.Celo68 || !selected(p) {
+			continue
+		}
+		if err := p.SendPrivateTransactions([]*privateTxData{{Tx: tx, Deadline: deadline}}); err != nil {
+			p.Log().Debug("Failed to relay private transaction", "hash", hash, "err", err)
+			continue
+		}
+		sent++
+	}
+	log.Trace("Relayed private transaction", "hash", hash, "deadline", deadline, "recipients", sent)
+	return sent
+}
+
+// isPrivateTxSource returns whether private transactions are accepted from the
+// peer, which must be a trusted private transaction peer, a trusted peer, a
+// proxy of this validator or a validator proxied by this node.
+func (pm *ProtocolManager) isPrivateTxSource(p *peer) bool {
+	if _, trusted := pm.privateTxPeers[p.ID()]; trusted {
+		return true
+	}
+	return p.Peer.HasPurpose(p2p.ProxyPurpose) || pm.isProxiedPeer(p) || p.Peer.Info().Network.Trusted
+}
+
+// isProxiedPeer returns whether the peer is a validator proxied by this node.
+func (pm *ProtocolManager) isProxiedPeer(p *peer) bool {
+	return pm.proxyServer != nil && p.Peer.Server == pm.proxyServer
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	defer pm.wg.Done()
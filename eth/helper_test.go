@@ -91,9 +91,10 @@ func newTestProtocolManagerMust(t *testing.T, mode downloader.SyncMode, blocks i
 
 // testTxPool is a fake, helper transaction pool for testing purposes
 type testTxPool struct {
-	txFeed event.Feed
-	pool   map[common.Hash]*types.Transaction // Hash map of collected transactions
-	added  chan<- []*types.Transaction        // Notification channel for new transactions
+	txFeed  event.Feed
+	pool    map[common.Hash]*types.Transaction // Hash map of collected transactions
+	private map[common.Hash]uint64             // Deadlines of the private transactions
+	added   chan<- []*types.Transaction        // Notification channel for new transactions
 
 	lock sync.RWMutex // Protects the transaction pool
 }
@@ -132,6 +133,36 @@ func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// AddPrivate appends a private transaction to the pool, and notifies any
+// listeners if the addition channel is non nil
+func (p *testTxPool) AddPrivate(tx *types.Transaction, deadline uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.private == nil {
+		p.private = make(map[common.Hash]uint64)
+	}
+	p.pool[tx.Hash()] = tx
+	p.private[tx.Hash()] = deadline
+
+	txs := []*types.Transaction{tx}
+	if p.added != nil {
+		p.added <- txs
+	}
+	p.txFeed.Send(core.NewTxsEvent{Txs: txs})
+	return nil
+}
+
+// PrivateDeadline returns the deadline of a private transaction, and whether
+// the transaction is private
+func (p *testTxPool) PrivateDeadline(hash common.Hash) (uint64, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	deadline, ok := p.private[hash]
+	return deadline, ok
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
 	p.lock.RLock()
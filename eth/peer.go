@@ -431,6 +431,19 @@ func (p *peer) SendPooledTransactionsRLP(hashes []common.Hash, txs []rlp.RawValu
 	return p2p.Send(p.rw, PooledTransactionsMsg, txs)
 }
 
+// SendPrivateTransactions sends private transactions to the peer and includes
+// the hashes in its transaction hash set for future reference.
+func (p *peer) SendPrivateTransactions(txs []*privateTxData) error {
+	// Mark all the transactions as known, but ensure we don't overflow our limits
+	for p.knownTxs.Cardinality() > max(0, maxKnownTxs-len(txs)) {
+		p.knownTxs.Pop()
+	}
+	for _, data := range txs {
+		p.knownTxs.Add(data.Tx.Hash())
+	}
+	return p2p.Send(p.rw, PrivateTransactionsMsg, txs)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -53,6 +53,10 @@ const (
 	NewPooledTransactionHashesMsg = 0x08
 	GetPooledTransactionsMsg      = 0x09
 	PooledTransactionsMsg         = 0x0a
+
+	// New protocol message codes introduced in celo68, following the istanbul
+	// message codes.
+	PrivateTransactionsMsg = 0x1b
 )
 
 type errCode int
@@ -98,6 +102,14 @@ type txPool interface {
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// AddPrivate should add the given transaction to the pool as private,
+	// keeping it out of gossip until the deadline block.
+	AddPrivate(tx *types.Transaction, deadline uint64) error
+
+	// PrivateDeadline should return the deadline block of a private
+	// transaction, and whether the transaction is private.
+	PrivateDeadline(hash common.Hash) (uint64, bool)
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -190,6 +202,13 @@ func (request *newBlockData) sanityCheck() error {
 	return nil
 }
 
+// privateTxData is the network packet of a private transaction, relayed only to
+// trusted peers and never gossiped.
+type privateTxData struct {
+	Tx       *types.Transaction
+	Deadline uint64 // Block after which the transaction is dropped
+}
+
 type blockBodyWithBlockHash struct {
 	BlockHash common.Hash
 	BlockBody *types.Body
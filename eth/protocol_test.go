@@ -26,6 +26,7 @@ import (
 
 	"github.com/celo-org/celo-blockchain/common"
 	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/core"
 	"github.com/celo-org/celo-blockchain/core/forkid"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
@@ -367,6 +368,157 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// This test checks that received private transactions are added to the local
+// pool along with their deadline.
+func TestRecvPrivateTransactions(t *testing.T) {
+	txAdded := make(chan []*types.Transaction)
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, txAdded)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	p, _ := newTestPeer("peer", istanbul.Celo68, pm, true)
+	defer pm.Stop()
+	defer p.close()
+	pm.privateTxPeers = map[enode.ID]struct{}{p.peer.ID(): {}}
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, PrivateTransactionsMsg, []*privateTxData{{Tx: tx, Deadline: 5}}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case added := <-txAdded:
+		if len(added) != 1 {
+			t.Fatalf("wrong number of added transactions: got %d, want 1", len(added))
+		} else if added[0].Hash() != tx.Hash() {
+			t.Fatalf("added wrong tx hash: got %v, want %v", added[0].Hash(), tx.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no private transaction added within 2 seconds")
+	}
+	if deadline, private := pm.txpool.PrivateDeadline(tx.Hash()); !private || deadline != 5 {
+		t.Errorf("private deadline mismatch: have %d (private %v), want 5", deadline, private)
+	}
+}
+
+// This test checks that private transactions are only relayed to the trusted
+// private transaction peers, and are never broadcast nor served to others.
+func TestPrivateTransactionRelay(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	trusted, _ := newTestPeer("trusted", istanbul.Celo68, pm, true)
+	defer trusted.close()
+	other, _ := newTestPeer("other", istanbul.Celo68, pm, true)
+	defer other.close()
+	pm.privateTxPeers = map[enode.ID]struct{}{trusted.peer.ID(): {}}
+
+	private := newTestTransaction(testAccount, 0, 0)
+	if err := pm.txpool.AddPrivate(private, 10); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	sent := make(chan int, 1)
+	go func() { sent <- pm.RelayPrivateTransaction(private) }()
+
+	msg, err := trusted.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if msg.Code != PrivateTransactionsMsg {
+		t.Fatalf("got code %d, want PrivateTransactionsMsg", msg.Code)
+	}
+	var relayed []*privateTxData
+	if err := msg.Decode(&relayed); err != nil {
+		t.Fatalf("failed to decode private transactions: %v", err)
+	}
+	if len(relayed) != 1 || relayed[0].Tx.Hash() != private.Hash() || relayed[0].Deadline != 10 {
+		t.Fatalf("relayed private transactions mismatch: %v", relayed)
+	}
+	if n := <-sent; n != 1 {
+		t.Fatalf("private transaction relayed to %d peers, want 1", n)
+	}
+	// Broadcast a public transaction, which must be the next one all peers get
+	public := newTestTransaction(testAccount, 1, 0)
+	pm.txpool.AddRemotes([]*types.Transaction{public})
+
+	for _, p := range []*testPeer{trusted, other} {
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("%v: read error: %v", p.Peer, err)
+		}
+		var hashes []common.Hash
+		switch msg.Code {
+		case TransactionMsg:
+			var txs []*types.Transaction
+			if err := msg.Decode(&txs); err != nil {
+				t.Fatalf("%v: %v", p.Peer, err)
+			}
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash())
+			}
+		case NewPooledTransactionHashesMsg:
+			if err := msg.Decode(&hashes); err != nil {
+				t.Fatalf("%v: %v", p.Peer, err)
+			}
+		default:
+			t.Fatalf("%v: got code %d, want a transaction broadcast", p.Peer, msg.Code)
+		}
+		if len(hashes) != 1 || hashes[0] != public.Hash() {
+			t.Fatalf("%v: broadcast transactions mismatch: have %x, want %x", p.Peer, hashes, public.Hash())
+		}
+	}
+	// Request both transactions, only the public one must be served
+	if err := p2p.Send(other.app, GetPooledTransactionsMsg, []common.Hash{private.Hash(), public.Hash()}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	msg, err = other.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if msg.Code != PooledTransactionsMsg {
+		t.Fatalf("got code %d, want PooledTransactionsMsg", msg.Code)
+	}
+	var served []*types.Transaction
+	if err := msg.Decode(&served); err != nil {
+		t.Fatalf("failed to decode pooled transactions: %v", err)
+	}
+	if len(served) != 1 || served[0].Hash() != public.Hash() {
+		t.Fatalf("served transactions mismatch: have %d transactions, want the public one", len(served))
+	}
+}
+
+// Tests that private transactions are only accepted from trusted peers.
+func TestPrivateTransactionSources(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+	atomic.StoreUint32(&pm.acceptTxs, 1)
+
+	trusted, _ := newTestPeer("trusted", istanbul.Celo68, pm, true)
+	defer trusted.close()
+	other, _ := newTestPeer("other", istanbul.Celo68, pm, true)
+	defer other.close()
+	pm.privateTxPeers = map[enode.ID]struct{}{trusted.peer.ID(): {}}
+
+	// send delivers the private transaction, and returns once the peer handled it
+	send := func(p *testPeer, tx *types.Transaction) {
+		for _, txs := range [][]*privateTxData{{{Tx: tx, Deadline: 10}}, {}} {
+			if err := p2p.Send(p.app, PrivateTransactionsMsg, txs); err != nil {
+				t.Fatalf("%v: send error: %v", p.Peer, err)
+			}
+		}
+	}
+	rejected := newTestTransaction(testAccount, 0, 0)
+	send(other, rejected)
+	if pm.txpool.Get(rejected.Hash()) != nil {
+		t.Errorf("private transaction accepted from an untrusted peer")
+	}
+	accepted := newTestTransaction(testAccount, 1, 0)
+	send(trusted, accepted)
+	if pm.txpool.Get(accepted.Hash()) == nil {
+		t.Errorf("private transaction rejected from a trusted peer")
+	}
+	if _, private := pm.txpool.PrivateDeadline(accepted.Hash()); !private {
+		t.Errorf("transaction from a trusted peer not private")
+	}
+}
+
 func TestTransactionPropagation(t *testing.T)  { testSyncTransaction(t, true) }
 func TestTransactionAnnouncement(t *testing.T) { testSyncTransaction(t, false) }
 
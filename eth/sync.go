@@ -58,6 +58,7 @@ func (pm *ProtocolManager) syncTransactions(p *peer) {
 	for _, batch := range pending {
 		txs = append(txs, batch...)
 	}
+	txs = pm.publicTransactions(txs)
 	if len(txs) == 0 {
 		return
 	}
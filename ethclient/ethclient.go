@@ -28,7 +28,6 @@ import (
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/celo-org/celo-blockchain/core/types"
-	"github.com/celo-org/celo-blockchain/rlp"
 	"github.com/celo-org/celo-blockchain/rpc"
 )
 
@@ -549,6 +548,39 @@ func (ec *Client) SuggestGasPriceInCurrency(ctx context.Context, feeCurrency *co
 	return (*big.Int)(&hex), nil
 }
 
+type feeHistoryResultMarshaling struct {
+	OldestBlock     *hexutil.Big     `json:"oldestBlock"`
+	Reward          [][]*hexutil.Big `json:"reward,omitempty"`
+	GasPriceMinimum []*hexutil.Big   `json:"gasPriceMinimum,omitempty"`
+	GasUsedRatio    []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory retrieves the fee market history of up to blockCount blocks ending with
+// lastBlock, in the fee currency where nil is CELO. A nil lastBlock is the latest block.
+func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64, feeCurrency *common.Address) (*ethereum.FeeHistory, error) {
+	var res feeHistoryResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles, feeCurrency); err != nil {
+		return nil, err
+	}
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	gasPriceMinimum := make([]*big.Int, len(res.GasPriceMinimum))
+	for i, b := range res.GasPriceMinimum {
+		gasPriceMinimum[i] = (*big.Int)(b)
+	}
+	return &ethereum.FeeHistory{
+		OldestBlock:     (*big.Int)(res.OldestBlock),
+		Reward:          reward,
+		GasPriceMinimum: gasPriceMinimum,
+		GasUsedRatio:    res.GasUsedRatio,
+	}, nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the backend blockchain. There is no guarantee that this is
 // the true gas limit requirement as other transactions may be added or removed by miners,
@@ -567,7 +599,7 @@ func (ec *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -601,5 +633,11 @@ func toCallArg(msg ethereum.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.FeePayer != nil {
+		arg["feePayer"] = msg.FeePayer
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
@@ -22,17 +22,20 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	ethereum "github.com/celo-org/celo-blockchain"
 	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
 	mockEngine "github.com/celo-org/celo-blockchain/consensus/consensustest"
 	"github.com/celo-org/celo-blockchain/core"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/crypto"
 	"github.com/celo-org/celo-blockchain/eth"
+	"github.com/celo-org/celo-blockchain/internal/ethapi"
 	"github.com/celo-org/celo-blockchain/node"
 	"github.com/celo-org/celo-blockchain/params"
 )
@@ -169,6 +172,12 @@ var (
 )
 
 func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
+	return newTestBackendWithConfig(t, &eth.Config{})
+}
+
+// newTestBackendWithConfig starts a node running the test chain, with the genesis
+// set in the given Ethereum service configuration.
+func newTestBackendWithConfig(t *testing.T, config *eth.Config) (*node.Node, []*types.Block) {
 	// Generate test chain.
 	genesis, blocks := generateTestChain()
 
@@ -176,7 +185,7 @@ func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	var ethservice *eth.Ethereum
 	n, err := node.New(&node.Config{})
 	n.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		config := &eth.Config{Genesis: genesis}
+		config.Genesis = genesis
 		ethservice, err = eth.New(ctx, config)
 		return ethservice, err
 	})
@@ -336,3 +345,137 @@ func TestChainID(t *testing.T) {
 		t.Fatalf("ChainID returned wrong number: %+v", id)
 	}
 }
+
+func TestFeeHistory(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+	ec := NewClient(client)
+
+	history, err := ec.FeeHistory(context.Background(), 1, nil, []float64{25, 75}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.OldestBlock.Uint64() != 1 {
+		t.Fatalf("FeeHistory returned wrong oldest block: %v", history.OldestBlock)
+	}
+	if len(history.GasUsedRatio) != 1 || len(history.GasPriceMinimum) != 2 || len(history.Reward) != 1 || len(history.Reward[0]) != 2 {
+		t.Fatalf("FeeHistory returned wrong lengths: %+v", history)
+	}
+}
+
+func TestCallBundle(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	var (
+		recipient = common.HexToAddress("0x1234")
+		signer    = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+	)
+	tx, _ := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(1000), params.TxGas, big.NewInt(1), nil, nil, nil, nil), signer, testKey)
+	raw, _ := tx.MarshalBinary()
+
+	// Transfer to the recipient, send part of it back with an unsigned call, and
+	// replay the first transaction, which fails the nonce check
+	bundle := []map[string]interface{}{
+		{"raw": hexutil.Bytes(raw)},
+		{"from": recipient, "to": testAddr, "value": (*hexutil.Big)(big.NewInt(400))},
+		{"raw": hexutil.Bytes(raw)},
+	}
+	var result ethapi.RPCBundleResult
+	if err := client.CallContext(context.Background(), &result, "eth_callBundle", bundle, "latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Results) != 3 {
+		t.Fatalf("result count mismatch: have %d, want 3", len(result.Results))
+	}
+	if first := result.Results[0]; first.Error != "" || first.TxHash == nil || *first.TxHash != tx.Hash() || uint64(first.GasUsed) != params.TxGas {
+		t.Errorf("signed transaction result mismatch: %+v", first)
+	}
+	if second := result.Results[1]; second.Error != "" || second.From != recipient || uint64(second.GasUsed) != params.TxGas {
+		t.Errorf("unsigned call result mismatch: %+v", second)
+	}
+	if third := result.Results[2]; third.Error == "" || third.GasUsed != 0 {
+		t.Errorf("replayed transaction should fail: %+v", third)
+	}
+	if uint64(result.GasUsed) != 2*params.TxGas {
+		t.Errorf("bundle gas used mismatch: have %d, want %d", result.GasUsed, 2*params.TxGas)
+	}
+	if len(result.Fees) != 1 || result.Fees[0].FeeCurrency != nil || result.Fees[0].Fees.ToInt().Uint64() != params.TxGas {
+		t.Errorf("bundle fees mismatch: %+v", result.Fees)
+	}
+	// The state diff holds the balance of the recipient and the nonce of the sender
+	if diff := result.StateDiff[recipient]; diff == nil || diff.Balance == nil || diff.Balance.From != "0x0" || diff.Balance.To != "0x258" {
+		t.Errorf("recipient diff mismatch: %+v", diff)
+	}
+	if diff := result.StateDiff[testAddr]; diff == nil || diff.Nonce == nil || diff.Nonce.From != "0x0" || diff.Nonce.To != "0x1" {
+		t.Errorf("sender diff mismatch: %+v", diff)
+	}
+}
+
+func TestCallBundleGasCap(t *testing.T) {
+	backend, _ := newTestBackendWithConfig(t, &eth.Config{RPCGasCap: new(big.Int).SetUint64(3 * params.TxGas)})
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	// Each transfer fits the gas cap, but the bundle only fits two of them
+	transfer := map[string]interface{}{"from": testAddr, "to": common.HexToAddress("0x1234"), "gas": hexutil.Uint64(params.TxGas + params.TxGas/2)}
+	var result ethapi.RPCBundleResult
+	if err := client.CallContext(context.Background(), &result, "eth_callBundle", []interface{}{transfer, transfer}, "latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uint64(result.GasUsed) != 2*params.TxGas {
+		t.Errorf("bundle gas used mismatch: have %d, want %d", result.GasUsed, 2*params.TxGas)
+	}
+	err := client.CallContext(context.Background(), &result, "eth_callBundle", []interface{}{transfer, transfer, transfer}, "latest")
+	if err == nil || !strings.Contains(err.Error(), "bundle gas cap") {
+		t.Errorf("error mismatch: have %v, want bundle gas cap exhausted", err)
+	}
+}
+
+func TestEstimateGasDetailed(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	recipient := common.HexToAddress("0x1234")
+	args := map[string]interface{}{
+		"from":                testAddr,
+		"to":                  recipient,
+		"gasPrice":            (*hexutil.Big)(big.NewInt(2)),
+		"gatewayFeeRecipient": recipient,
+		"gatewayFee":          (*hexutil.Big)(big.NewInt(5)),
+		"data":                hexutil.Bytes{0x01, 0x00},
+	}
+	var estimate ethapi.RPCGasEstimate
+	if err := client.CallContext(context.Background(), &estimate, "eth_estimateGasDetailed", args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A CELO transfer only pays the base and data intrinsic gas
+	wantGas := params.TxGas + params.TxDataNonZeroGasEIP2028 + params.TxDataZeroGas
+	if uint64(estimate.Gas) != wantGas || uint64(estimate.IntrinsicGas) != wantGas {
+		t.Errorf("gas mismatch: have %d (intrinsic %d), want %d", estimate.Gas, estimate.IntrinsicGas, wantGas)
+	}
+	if estimate.ExecutionGas != 0 || estimate.FeeCurrencyGas != 0 || estimate.FeeCurrencyGasUsed != 0 || estimate.FeeCurrency != nil {
+		t.Errorf("unexpected execution or fee currency gas: %+v", estimate)
+	}
+	if want := 2*wantGas + 5; estimate.Fee.ToInt().Uint64() != want {
+		t.Errorf("fee mismatch: have %v, want %d", estimate.Fee, want)
+	}
+	// A gateway fee recipient without a gateway fee defaults to a zero fee
+	delete(args, "gatewayFee")
+	if err := client.CallContext(context.Background(), &estimate, "eth_estimateGasDetailed", args); err != nil {
+		t.Fatalf("unexpected error without gateway fee: %v", err)
+	}
+	if estimate.GatewayFee.ToInt().Sign() != 0 {
+		t.Errorf("gateway fee mismatch: have %v, want 0", estimate.GatewayFee)
+	}
+	if want := 2 * wantGas; estimate.Fee.ToInt().Uint64() != want {
+		t.Errorf("fee mismatch without gateway fee: have %v, want %d", estimate.Fee, want)
+	}
+}
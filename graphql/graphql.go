@@ -20,18 +20,19 @@ package graphql
 import (
 	"context"
 	"errors"
+	"math/big"
 	"time"
 
 	ethereum "github.com/celo-org/celo-blockchain"
 	"github.com/celo-org/celo-blockchain/common"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
 	"github.com/celo-org/celo-blockchain/core/rawdb"
 	"github.com/celo-org/celo-blockchain/core/state"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/core/vm"
 	"github.com/celo-org/celo-blockchain/eth/filters"
 	"github.com/celo-org/celo-blockchain/internal/ethapi"
-	"github.com/celo-org/celo-blockchain/rlp"
 	"github.com/celo-org/celo-blockchain/rpc"
 )
 
@@ -215,7 +216,7 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	}
 	var signer types.Signer = types.HomesteadSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -314,6 +315,30 @@ func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
 	return &ret, nil
 }
 
+func (t *Transaction) FeeCurrency(ctx context.Context) (*common.Address, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return tx.FeeCurrency(), nil
+}
+
+func (t *Transaction) GatewayFeeRecipient(ctx context.Context) (*common.Address, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return tx.GatewayFeeRecipient(), nil
+}
+
+func (t *Transaction) GatewayFee(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.GatewayFee() == nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tx.GatewayFee()), nil
+}
+
 func (t *Transaction) R(ctx context.Context) (hexutil.Big, error) {
 	tx, err := t.resolve(ctx)
 	if err != nil || tx == nil {
@@ -747,6 +772,176 @@ func (b *Block) EstimateGas(ctx context.Context, args struct {
 	return gas, err
 }
 
+// Randomness represents the randomness revealed by the proposer of a block.
+type Randomness struct {
+	randomness *types.Randomness
+}
+
+func (r *Randomness) Revealed() common.Hash {
+	return r.randomness.Revealed
+}
+
+func (r *Randomness) Committed() common.Hash {
+	return r.randomness.Committed
+}
+
+// EpochSnarkData represents the aggregated signature over the validator set of
+// the next epoch.
+type EpochSnarkData struct {
+	data *types.EpochSnarkData
+}
+
+func (e *EpochSnarkData) Bitmap() hexutil.Big {
+	return hexutil.Big(*e.data.Bitmap)
+}
+
+func (e *EpochSnarkData) Signature() hexutil.Bytes {
+	return hexutil.Bytes(e.data.Signature)
+}
+
+// AggregatedSeal represents an aggregated BLS signature over a block.
+type AggregatedSeal struct {
+	seal types.IstanbulAggregatedSeal
+}
+
+func (s *AggregatedSeal) Bitmap() hexutil.Big {
+	if s.seal.Bitmap == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*s.seal.Bitmap)
+}
+
+func (s *AggregatedSeal) Signature() hexutil.Bytes {
+	return hexutil.Bytes(s.seal.Signature)
+}
+
+func (s *AggregatedSeal) Round() hexutil.Big {
+	if s.seal.Round == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*s.seal.Round)
+}
+
+// IstanbulExtra represents the Istanbul consensus data of a block.
+type IstanbulExtra struct {
+	extra *types.IstanbulExtra
+}
+
+func (e *IstanbulExtra) AddedValidators() []common.Address {
+	return e.extra.AddedValidators
+}
+
+func (e *IstanbulExtra) AddedValidatorsPublicKeys() []hexutil.Bytes {
+	keys := make([]hexutil.Bytes, len(e.extra.AddedValidatorsPublicKeys))
+	for i, key := range e.extra.AddedValidatorsPublicKeys {
+		keys[i] = hexutil.Bytes(key[:])
+	}
+	return keys
+}
+
+func (e *IstanbulExtra) RemovedValidators() hexutil.Big {
+	if e.extra.RemovedValidators == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*e.extra.RemovedValidators)
+}
+
+func (e *IstanbulExtra) Seal() hexutil.Bytes {
+	return hexutil.Bytes(e.extra.Seal)
+}
+
+func (e *IstanbulExtra) AggregatedSeal() *AggregatedSeal {
+	return &AggregatedSeal{seal: e.extra.AggregatedSeal}
+}
+
+func (e *IstanbulExtra) ParentAggregatedSeal() *AggregatedSeal {
+	return &AggregatedSeal{seal: e.extra.ParentAggregatedSeal}
+}
+
+// Validator represents a member of the validator set.
+type Validator struct {
+	validator istanbul.Validator
+}
+
+func (v *Validator) Address() common.Address {
+	return v.validator.Address()
+}
+
+func (v *Validator) BlsPublicKey() hexutil.Bytes {
+	key := v.validator.BLSPublicKey()
+	return hexutil.Bytes(key[:])
+}
+
+func (b *Block) Randomness(ctx context.Context) (*Randomness, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	randomness := block.Randomness()
+	if randomness == nil {
+		randomness = &types.Randomness{}
+	}
+	return &Randomness{randomness: randomness}, nil
+}
+
+func (b *Block) EpochSnarkData(ctx context.Context) (*EpochSnarkData, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	data := block.EpochSnarkData()
+	if data == nil || data.IsEmpty() {
+		return nil, nil
+	}
+	return &EpochSnarkData{data: data}, nil
+}
+
+func (b *Block) IstanbulExtra(ctx context.Context) (*IstanbulExtra, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	return &IstanbulExtra{extra: extra}, nil
+}
+
+func (b *Block) EpochNumber(ctx context.Context) (hexutil.Uint64, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(istanbul.GetEpochNumber(header.Number.Uint64(), b.backend.Engine().EpochSize())), nil
+}
+
+func (b *Block) IsEpochBlock(ctx context.Context) (bool, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return false, err
+	}
+	return istanbul.IsLastBlockOfEpoch(header.Number.Uint64(), b.backend.Engine().EpochSize()), nil
+}
+
+func (b *Block) Validators(ctx context.Context) ([]*Validator, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The validator set signing a block is the one elected as of its parent
+	number, hash := header.Number, header.Hash()
+	if number.Sign() > 0 {
+		number, hash = new(big.Int).Sub(number, common.Big1), header.ParentHash
+	}
+	validators := b.backend.Engine().GetValidators(number, hash)
+	ret := make([]*Validator, len(validators))
+	for i, validator := range validators {
+		ret[i] = &Validator{validator: validator}
+	}
+	return ret, nil
+}
+
 type Pending struct {
 	backend ethapi.Backend
 }
@@ -852,6 +1047,21 @@ func (r *Resolver) Block(ctx context.Context, args struct {
 	return block, nil
 }
 
+func (r *Resolver) Validators(ctx context.Context, args struct {
+	Number *hexutil.Uint64
+	Hash   *common.Hash
+}) (*[]*Validator, error) {
+	block, err := r.Block(ctx, args)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	validators, err := block.Validators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &validators, nil
+}
+
 func (r *Resolver) Blocks(ctx context.Context, args struct {
 	From hexutil.Uint64
 	To   *hexutil.Uint64
@@ -899,7 +1109,7 @@ func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Has
 
 func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(args.Data, tx); err != nil {
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
 		return common.Hash{}, err
 	}
 	hash, err := ethapi.SubmitTransaction(ctx, r.backend, tx)
@@ -17,7 +17,27 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
 	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/consensus"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul/validator"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/types"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/ethdb"
+	"github.com/celo-org/celo-blockchain/internal/ethapi"
+	"github.com/celo-org/celo-blockchain/rlp"
+	"github.com/celo-org/celo-blockchain/rpc"
+	"github.com/graph-gophers/graphql-go"
 )
 
 func TestBuildSchema(t *testing.T) {
@@ -26,3 +46,268 @@ func TestBuildSchema(t *testing.T) {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
 }
+
+// testBackend serves the blocks of a memory database to the resolvers. The
+// methods that aren't used by the tested resolvers panic.
+type testBackend struct {
+	ethapi.Backend
+	db     ethdb.Database
+	engine *testEngine
+}
+
+func (b *testBackend) ChainDb() ethdb.Database                           { return b.db }
+func (b *testBackend) Engine() consensus.Engine                          { return b.engine }
+func (b *testBackend) GetPoolTransaction(common.Hash) *types.Transaction { return nil }
+
+func (b *testBackend) hashOf(blockNrOrHash rpc.BlockNumberOrHash) common.Hash {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return hash
+	}
+	number, _ := blockNrOrHash.Number()
+	if number == rpc.LatestBlockNumber {
+		return rawdb.ReadHeadBlockHash(b.db)
+	}
+	return rawdb.ReadCanonicalHash(b.db, uint64(number))
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadHeader(b.db, hash, *number), nil
+}
+
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	return b.HeaderByHash(ctx, b.hashOf(blockNrOrHash))
+}
+
+func (b *testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	hash := b.hashOf(blockNrOrHash)
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadBlock(b.db, hash, *number), nil
+}
+
+// testEngine returns the validator set elected as of each block
+type testEngine struct {
+	consensus.Engine
+	epochSize  uint64
+	validators map[common.Hash][]istanbul.Validator
+}
+
+func (e *testEngine) EpochSize() uint64 { return e.epochSize }
+
+func (e *testEngine) GetValidators(blockNumber *big.Int, headerHash common.Hash) []istanbul.Validator {
+	return e.validators[headerHash]
+}
+
+var (
+	testValidator    = common.HexToAddress("0x1111")
+	testValidatorKey = blscrypto.SerializedPublicKey{0x11}
+	testFeeCurrency  = common.HexToAddress("0x2222")
+	testGateway      = common.HexToAddress("0x3333")
+)
+
+// newTestResolver writes a chain with an epoch size of 2 in a memory database:
+// block 1 pays fees in a fee currency, and block 2 ends the first epoch.
+func newTestResolver(t *testing.T) (*graphql.Schema, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	engine := &testEngine{epochSize: 2, validators: make(map[common.Hash][]istanbul.Validator)}
+
+	extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{
+		AddedValidators:           []common.Address{testValidator},
+		AddedValidatorsPublicKeys: []blscrypto.SerializedPublicKey{testValidatorKey},
+		RemovedValidators:         big.NewInt(2),
+		Seal:                      []byte{0x01},
+		AggregatedSeal:            types.IstanbulAggregatedSeal{Bitmap: big.NewInt(3), Signature: []byte{0x02}, Round: big.NewInt(1)},
+		ParentAggregatedSeal:      types.IstanbulAggregatedSeal{Bitmap: big.NewInt(1), Signature: []byte{0x03}, Round: big.NewInt(0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra = append(make([]byte, types.IstanbulExtraVanity), extra...)
+
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), Extra: extra}, nil, nil, nil)
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), &testFeeCurrency, &testGateway, big.NewInt(100), nil),
+		types.NewTransaction(1, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil, nil, nil, nil),
+	}
+	block1 := types.NewBlock(&types.Header{Number: big.NewInt(1), ParentHash: genesis.Hash(), Extra: extra}, txs, nil, nil)
+	block2 := types.NewBlock(&types.Header{Number: big.NewInt(2), ParentHash: block1.Hash(), Extra: extra}, nil, nil, &types.Randomness{
+		Revealed:  common.HexToHash("0x04"),
+		Committed: common.HexToHash("0x05"),
+	}).WithEpochSnarkData(&types.EpochSnarkData{Bitmap: big.NewInt(7), Signature: []byte{0x06}})
+
+	blocks := []*types.Block{genesis, block1, block2}
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteTxLookupEntries(db, block)
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		engine.validators[block.Hash()] = []istanbul.Validator{validator.New(common.BigToAddress(big.NewInt(int64(i+1))), testValidatorKey)}
+	}
+	return graphql.MustParseSchema(schema, &Resolver{&testBackend{db: db, engine: engine}}), blocks
+}
+
+// execQuery runs a query and decodes its result
+func execQuery(t *testing.T, s *graphql.Schema, query string, result interface{}) {
+	res := s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) > 0 {
+		t.Fatalf("query %q failed: %v", query, res.Errors)
+	}
+	if err := json.Unmarshal(res.Data, result); err != nil {
+		t.Fatalf("can't decode result of query %q: %v", query, err)
+	}
+}
+
+func TestFeeCurrencyTransaction(t *testing.T) {
+	s, blocks := newTestResolver(t)
+	txs := blocks[1].Transactions()
+
+	type transaction struct {
+		FeeCurrency         *common.Address
+		GatewayFeeRecipient *common.Address
+		GatewayFee          hexutil.Big
+	}
+	tests := []struct {
+		tx                  *types.Transaction
+		feeCurrency         *common.Address
+		gatewayFeeRecipient *common.Address
+		gatewayFee          int64
+	}{
+		{txs[0], &testFeeCurrency, &testGateway, 100},
+		// Transactions paying fees in CELO have no fee currency nor gateway fee
+		{txs[1], nil, nil, 0},
+	}
+	for i, tt := range tests {
+		var res struct{ Transaction transaction }
+		execQuery(t, s, fmt.Sprintf(`{ transaction(hash: "%s") { feeCurrency gatewayFeeRecipient gatewayFee } }`, tt.tx.Hash().Hex()), &res)
+		have := res.Transaction
+		if !reflect.DeepEqual(have.FeeCurrency, tt.feeCurrency) || !reflect.DeepEqual(have.GatewayFeeRecipient, tt.gatewayFeeRecipient) || have.GatewayFee.ToInt().Int64() != tt.gatewayFee {
+			t.Errorf("test %d: transaction mismatch: have %v, %v, %v, want %v, %v, %d", i, have.FeeCurrency, have.GatewayFeeRecipient, have.GatewayFee.ToInt(), tt.feeCurrency, tt.gatewayFeeRecipient, tt.gatewayFee)
+		}
+	}
+}
+
+func TestEpochBlock(t *testing.T) {
+	s, _ := newTestResolver(t)
+
+	type aggregatedSeal struct {
+		Bitmap    hexutil.Big
+		Signature hexutil.Bytes
+		Round     hexutil.Big
+	}
+	type block struct {
+		Randomness struct {
+			Revealed  common.Hash
+			Committed common.Hash
+		}
+		EpochSnarkData *struct {
+			Bitmap    hexutil.Big
+			Signature hexutil.Bytes
+		}
+		IstanbulExtra struct {
+			AddedValidators           []common.Address
+			AddedValidatorsPublicKeys []hexutil.Bytes
+			RemovedValidators         hexutil.Big
+			Seal                      hexutil.Bytes
+			AggregatedSeal            aggregatedSeal
+			ParentAggregatedSeal      aggregatedSeal
+		}
+		EpochNumber  hexutil.Uint64
+		IsEpochBlock bool
+	}
+	query := `{ block(number: %d) {
+		randomness { revealed committed }
+		epochSnarkData { bitmap signature }
+		istanbulExtra {
+			addedValidators addedValidatorsPublicKeys removedValidators seal
+			aggregatedSeal { bitmap signature round }
+			parentAggregatedSeal { bitmap signature round }
+		}
+		epochNumber isEpochBlock
+	} }`
+
+	var res struct{ Block block }
+	execQuery(t, s, fmt.Sprintf(query, 2), &res)
+	if res.Block.Randomness.Revealed != common.HexToHash("0x04") || res.Block.Randomness.Committed != common.HexToHash("0x05") {
+		t.Errorf("randomness mismatch: have %+v", res.Block.Randomness)
+	}
+	if data := res.Block.EpochSnarkData; data == nil || data.Bitmap.ToInt().Int64() != 7 || !bytes.Equal(data.Signature, []byte{0x06}) {
+		t.Errorf("epoch snark data mismatch: have %+v", data)
+	}
+	extra := res.Block.IstanbulExtra
+	if !reflect.DeepEqual(extra.AddedValidators, []common.Address{testValidator}) {
+		t.Errorf("added validators mismatch: have %v", extra.AddedValidators)
+	}
+	if len(extra.AddedValidatorsPublicKeys) != 1 || !bytes.Equal(extra.AddedValidatorsPublicKeys[0], testValidatorKey[:]) {
+		t.Errorf("added validators public keys mismatch: have %v", extra.AddedValidatorsPublicKeys)
+	}
+	if extra.RemovedValidators.ToInt().Int64() != 2 || !bytes.Equal(extra.Seal, []byte{0x01}) {
+		t.Errorf("removed validators or seal mismatch: have %v, %v", extra.RemovedValidators.ToInt(), extra.Seal)
+	}
+	if seal := extra.AggregatedSeal; seal.Bitmap.ToInt().Int64() != 3 || !bytes.Equal(seal.Signature, []byte{0x02}) || seal.Round.ToInt().Int64() != 1 {
+		t.Errorf("aggregated seal mismatch: have %+v", seal)
+	}
+	if seal := extra.ParentAggregatedSeal; seal.Bitmap.ToInt().Int64() != 1 || !bytes.Equal(seal.Signature, []byte{0x03}) || seal.Round.ToInt().Int64() != 0 {
+		t.Errorf("parent aggregated seal mismatch: have %+v", seal)
+	}
+	if res.Block.EpochNumber != 1 || !res.Block.IsEpochBlock {
+		t.Errorf("epoch mismatch: have epoch %d, epoch block %v, want epoch 1, epoch block true", res.Block.EpochNumber, res.Block.IsEpochBlock)
+	}
+
+	// Only the last block of an epoch has epoch snark data
+	res = struct{ Block block }{}
+	execQuery(t, s, fmt.Sprintf(query, 1), &res)
+	if res.Block.EpochSnarkData != nil {
+		t.Errorf("unexpected epoch snark data: %+v", res.Block.EpochSnarkData)
+	}
+	if res.Block.Randomness.Revealed != (common.Hash{}) || res.Block.Randomness.Committed != (common.Hash{}) {
+		t.Errorf("unexpected randomness: %+v", res.Block.Randomness)
+	}
+	if res.Block.EpochNumber != 1 || res.Block.IsEpochBlock {
+		t.Errorf("epoch mismatch: have epoch %d, epoch block %v, want epoch 1, epoch block false", res.Block.EpochNumber, res.Block.IsEpochBlock)
+	}
+}
+
+func TestValidators(t *testing.T) {
+	s, _ := newTestResolver(t)
+
+	type validator struct {
+		Address      common.Address
+		BlsPublicKey hexutil.Bytes
+	}
+	tests := []struct {
+		query string
+		want  common.Address // the validator set signing a block is the one of its parent
+	}{
+		{`{ validators { address blsPublicKey } }`, common.BigToAddress(big.NewInt(2))},
+		{`{ validators(number: 1) { address blsPublicKey } }`, common.BigToAddress(big.NewInt(1))},
+		{`{ validators(number: 0) { address blsPublicKey } }`, common.BigToAddress(big.NewInt(1))},
+		{`{ block(number: 2) { validators { address blsPublicKey } } }`, common.BigToAddress(big.NewInt(2))},
+	}
+	for _, tt := range tests {
+		var res struct {
+			Validators []validator
+			Block      struct{ Validators []validator }
+		}
+		execQuery(t, s, tt.query, &res)
+		validators := res.Validators
+		if validators == nil {
+			validators = res.Block.Validators
+		}
+		want := []validator{{tt.want, testValidatorKey[:]}}
+		if !reflect.DeepEqual(validators, want) {
+			t.Errorf("query %q: validators mismatch: have %+v, want %+v", tt.query, validators, want)
+		}
+	}
+
+	// Unknown blocks have no validator set
+	res := s.Exec(context.Background(), `{ validators(number: 3) { address } }`, "", nil)
+	if len(res.Errors) > 0 || string(res.Data) != `{"validators":null}` {
+		t.Errorf("unknown block: have %s, errors %v", res.Data, res.Errors)
+	}
+}
@@ -115,6 +115,15 @@ const schema string = `
         # Logs is a list of log entries emitted by this transaction. If the
         # transaction has not yet been mined, this field will be null.
         logs: [Log!]
+        # FeeCurrency is the address of the token contract the fees are paid in.
+        # This is null for transactions paying fees in CELO.
+        feeCurrency: Address
+        # GatewayFeeRecipient is the address the gateway fee is paid to. This is
+        # null if the transaction doesn't pay a gateway fee.
+        gatewayFeeRecipient: Address
+        # GatewayFee is the fee paid to the gateway fee recipient, in the fee
+        # currency.
+        gatewayFee: BigInt!
         r: BigInt!
         s: BigInt!
         v: BigInt!
@@ -140,6 +149,60 @@ const schema string = `
         topics: [[Bytes32!]!]
     }
 
+    # Randomness is the randomness revealed by the proposer of a block.
+    type Randomness {
+        # Revealed is the randomness revealed by the proposer of this block.
+        revealed: Bytes32!
+        # Committed is the commitment of the proposer to the randomness it will
+        # reveal when it next proposes a block.
+        committed: Bytes32!
+    }
+
+    # EpochSnarkData is the aggregated signature of the validators over the
+    # validator set of the next epoch, as verified by light clients.
+    type EpochSnarkData {
+        # Bitmap has an active bit for each validator that signed the data.
+        bitmap: BigInt!
+        # Signature is the aggregated BLS signature of the validators.
+        signature: Bytes!
+    }
+
+    # AggregatedSeal is an aggregated BLS signature of the validators over a block.
+    type AggregatedSeal {
+        # Bitmap has an active bit for each validator that signed the block.
+        bitmap: BigInt!
+        # Signature is the aggregated BLS signature of the validators.
+        signature: Bytes!
+        # Round is the consensus round in which the signature was created.
+        round: BigInt!
+    }
+
+    # IstanbulExtra is the Istanbul consensus data in the extra data of a block.
+    type IstanbulExtra {
+        # AddedValidators are the validators added to the validator set in the block.
+        addedValidators: [Address!]!
+        # AddedValidatorsPublicKeys are the BLS public keys of the added validators.
+        addedValidatorsPublicKeys: [Bytes!]!
+        # RemovedValidators has an active bit for each validator removed from the
+        # validator set in the block.
+        removedValidators: BigInt!
+        # Seal is the ECDSA signature of the proposer over the block.
+        seal: Bytes!
+        # AggregatedSeal is the signature of the validators that committed the block.
+        aggregatedSeal: AggregatedSeal!
+        # ParentAggregatedSeal is the signature of the validators that committed
+        # the parent block, as seen by the proposer.
+        parentAggregatedSeal: AggregatedSeal!
+    }
+
+    # Validator is a member of the validator set.
+    type Validator {
+        # Address is the address of the validator.
+        address: Address!
+        # BlsPublicKey is the compressed BLS public key of the validator.
+        blsPublicKey: Bytes!
+    }
+
     # Block is an Ethereum block.
     type Block {
         # Number is the number of this block, starting at 0 for the genesis block.
@@ -185,6 +248,21 @@ const schema string = `
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
+        # Randomness is the randomness revealed by the proposer of this block.
+        randomness: Randomness!
+        # EpochSnarkData is the aggregated signature of the validators over the
+        # validator set of the next epoch. This will be null if the block is not
+        # the last block of an epoch.
+        epochSnarkData: EpochSnarkData
+        # IstanbulExtra is the Istanbul consensus data in the extra data of this block.
+        istanbulExtra: IstanbulExtra!
+        # EpochNumber is the number of the epoch this block belongs to.
+        epochNumber: Long!
+        # IsEpochBlock is true if this block is the last block of its epoch.
+        isEpochBlock: Boolean!
+        # Validators is the validator set that signed this block. The bitmap of
+        # the aggregated seal of this block is indexed by this list.
+        validators: [Validator!]!
     }
 
     # CallData represents the data associated with a local contract call.
@@ -290,6 +368,10 @@ const schema string = `
         protocolVersion: Int!
         # Syncing returns information on the current synchronisation state.
         syncing: SyncState
+        # Validators returns the validator set that signed a block, specified by
+        # number or by hash. If neither is supplied, the validator set of the
+        # most recent known block is returned.
+        validators(number: Long, hash: Bytes32): [Validator!]
     }
 
     type Mutation {
@@ -119,9 +119,12 @@ type CallMsg struct {
 	FeeCurrency         *common.Address // 0 for the native currency
 	GatewayFeeRecipient *common.Address // 0 for no gateway fee
 	GatewayFee          *big.Int        // 0 for no gateway fee
+	FeePayer            *common.Address // nil if the sender pays the fees
 	GasPrice            *big.Int        // wei <-> gas exchange ratio
 	Value               *big.Int        // amount of wei sent along with the call
 	Data                []byte          // input data, usually an ABI-encoded contract method invocation
+
+	AccessList types.AccessList // EIP-2930 access list.
 }
 
 // A ContractCaller provides contract calls, essentially transactions that are executed by
@@ -181,6 +184,15 @@ type GasPricer interface {
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 }
 
+// FeeHistory provides recent fee market data that consumers can use to determine
+// a reasonable gas price, in a given fee currency.
+type FeeHistory struct {
+	OldestBlock     *big.Int     // block corresponding to first response value
+	Reward          [][]*big.Int // gas prices above the gas price minimum at the requested percentiles
+	GasPriceMinimum []*big.Int   // gas price minimum of every block, including the next one
+	GasUsedRatio    []float64    // gasUsed/gasLimit of every block
+}
+
 // A PendingStateReader provides access to the pending state, which is the result of all
 // known executable transactions which have not yet been included in the blockchain. It is
 // commonly used to display the result of ’unconfirmed’ actions (e.g. wallet value
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,7 +34,9 @@ import (
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/celo-org/celo-blockchain/common/math"
 	"github.com/celo-org/celo-blockchain/contract_comm/blockchain_parameters"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
 	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/state"
 	"github.com/celo-org/celo-blockchain/core/types"
 	"github.com/celo-org/celo-blockchain/core/vm"
 	"github.com/celo-org/celo-blockchain/crypto"
@@ -59,17 +62,45 @@ func NewPublicEthereumAPI(b Backend) *PublicEthereumAPI {
 
 // GasPrice returns a suggestion for a gas price.
 func (s *PublicEthereumAPI) GasPrice(ctx context.Context, feeCurrency *common.Address) (*hexutil.Big, error) {
-	if feeCurrency == nil {
-		price, err := s.b.SuggestPrice(ctx)
-		return (*hexutil.Big)(price), err
-	}
+	price, err := s.b.SuggestPriceInCurrency(ctx, feeCurrency)
+	return (*hexutil.Big)(price), err
+}
+
+type feeHistoryResult struct {
+	OldestBlock     *hexutil.Big     `json:"oldestBlock"`
+	Reward          [][]*hexutil.Big `json:"reward,omitempty"`
+	GasPriceMinimum []*hexutil.Big   `json:"gasPriceMinimum,omitempty"`
+	GasUsedRatio    []float64        `json:"gasUsedRatio"`
+}
 
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+// FeeHistory returns the gas price minimums, the gas used ratios and the gas prices
+// above the gas price minimums paid at the given percentiles of a range of blocks,
+// in the fee currency where nil is CELO.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64, feeCurrency *common.Address) (*feeHistoryResult, error) {
+	oldest, reward, gasPriceMinimum, gasUsedRatio, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles, feeCurrency)
 	if err != nil {
 		return nil, err
 	}
-	price, err := s.b.SuggestPriceInCurrency(ctx, feeCurrency, header, state)
-	return (*hexutil.Big)(price), err
+	results := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: gasUsedRatio,
+	}
+	if reward != nil {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, w := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				results.Reward[i][j] = (*hexutil.Big)(v)
+			}
+		}
+	}
+	if gasPriceMinimum != nil {
+		results.GasPriceMinimum = make([]*hexutil.Big, len(gasPriceMinimum))
+		for i, v := range gasPriceMinimum {
+			results.GasPriceMinimum[i] = (*hexutil.Big)(v)
+		}
+	}
+	return results, nil
 }
 
 // ProtocolVersion returns the current Ethereum protocol version this node supports
@@ -158,10 +189,17 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 
 	// Define a formatter to flatten a transaction into a string
 	var format = func(tx *types.Transaction) string {
+		var fees string
+		if tx.FeeCurrency() != nil {
+			fees += fmt.Sprintf(" in %s", tx.FeeCurrency().Hex())
+		}
+		if tx.GatewayFeeRecipient() != nil {
+			fees += fmt.Sprintf(" + %v gateway fee to %s", tx.GatewayFee(), tx.GatewayFeeRecipient().Hex())
+		}
 		if to := tx.To(); to != nil {
-			return fmt.Sprintf("%s: %v wei + %v gas × %v wei", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
+			return fmt.Sprintf("%s: %v wei + %v gas × %v wei%s", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice(), fees)
 		}
-		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
+		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei%s", tx.Value(), tx.Gas(), tx.GasPrice(), fees)
 	}
 	// Flatten the pending transactions
 	for account, txs := range pending {
@@ -182,6 +220,90 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// defaultRejectionsWindow is the period of the rejected transactions returned by
+// CeloStatus if none is given.
+const defaultRejectionsWindow = 10 * time.Minute
+
+// RPCFeeCurrencyStatus holds the statistics of the pooled transactions paying fees
+// in a currency. Gas prices are normalized to CELO.
+type RPCFeeCurrencyStatus struct {
+	FeeCurrency    *common.Address `json:"feeCurrency"` // nil for CELO
+	Pending        hexutil.Uint    `json:"pending"`
+	Queued         hexutil.Uint    `json:"queued"`
+	Slots          hexutil.Uint    `json:"slots"`
+	MinGasPrice    *hexutil.Big    `json:"minGasPrice"`
+	MedianGasPrice *hexutil.Big    `json:"medianGasPrice"`
+	MaxGasPrice    *hexutil.Big    `json:"maxGasPrice"`
+}
+
+// RPCTxRejection describes a transaction rejected by the pool.
+type RPCTxRejection struct {
+	Hash        common.Hash         `json:"hash"`
+	From        common.Address      `json:"from"`
+	FeeCurrency *common.Address     `json:"feeCurrency"`
+	GasPrice    *hexutil.Big        `json:"gasPrice"`
+	GatewayFee  *hexutil.Big        `json:"gatewayFee"`
+	Reason      core.TxRejectReason `json:"reason"`
+	Error       string              `json:"error"`
+	Time        hexutil.Uint64      `json:"time"`
+}
+
+// RPCCeloStatus holds the fee currency breakdown of the transaction pool.
+type RPCCeloStatus struct {
+	Currencies []*RPCFeeCurrencyStatus `json:"currencies"`
+	Rejected   []*RPCTxRejection       `json:"rejected"`
+}
+
+// CeloStatus returns the number of pending and queued transactions, the slots they
+// take and their gas prices by fee currency, and the transactions rejected in the
+// last given minutes with the reason why, ten minutes by default.
+func (s *PublicTxPoolAPI) CeloStatus(minutes *hexutil.Uint64) *RPCCeloStatus {
+	window := defaultRejectionsWindow
+	if minutes != nil {
+		window = time.Duration(*minutes) * time.Minute
+	}
+	result := &RPCCeloStatus{
+		Currencies: []*RPCFeeCurrencyStatus{},
+		Rejected:   []*RPCTxRejection{},
+	}
+	for currencyAddress, stats := range s.b.TxPoolCeloStatus() {
+		status := &RPCFeeCurrencyStatus{
+			Pending:        hexutil.Uint(stats.Pending),
+			Queued:         hexutil.Uint(stats.Queued),
+			Slots:          hexutil.Uint(stats.Slots),
+			MinGasPrice:    (*hexutil.Big)(stats.MinGasPrice),
+			MedianGasPrice: (*hexutil.Big)(stats.MedianGasPrice),
+			MaxGasPrice:    (*hexutil.Big)(stats.MaxGasPrice),
+		}
+		if currencyAddress != (common.Address{}) {
+			feeCurrency := currencyAddress
+			status.FeeCurrency = &feeCurrency
+		}
+		result.Currencies = append(result.Currencies, status)
+	}
+	// CELO first, then the other currencies by address
+	sort.Slice(result.Currencies, func(i, j int) bool {
+		a, b := result.Currencies[i].FeeCurrency, result.Currencies[j].FeeCurrency
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return bytes.Compare(a.Bytes(), b.Bytes()) < 0
+	})
+	for _, rejection := range s.b.TxPoolRejections(time.Now().Add(-window)) {
+		result.Rejected = append(result.Rejected, &RPCTxRejection{
+			Hash:        rejection.Hash,
+			From:        rejection.From,
+			FeeCurrency: rejection.FeeCurrency,
+			GasPrice:    (*hexutil.Big)(rejection.GasPrice),
+			GatewayFee:  (*hexutil.Big)(rejection.GatewayFee),
+			Reason:      rejection.Reason,
+			Error:       rejection.Err.Error(),
+			Time:        hexutil.Uint64(rejection.Time.Unix()),
+		})
+	}
+	return result
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -401,7 +523,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 		log.Warn("Failed transaction sign attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -729,15 +851,26 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
-	From                *common.Address `json:"from"`
-	To                  *common.Address `json:"to"`
-	Gas                 *hexutil.Uint64 `json:"gas"`
-	GasPrice            *hexutil.Big    `json:"gasPrice"`
-	FeeCurrency         *common.Address `json:"feeCurrency"`
-	GatewayFeeRecipient *common.Address `json:"gatewayFeeRecipient"`
-	GatewayFee          hexutil.Big     `json:"gatewayFee"`
-	Value               *hexutil.Big    `json:"value"`
-	Data                *hexutil.Bytes  `json:"data"`
+	From                *common.Address   `json:"from"`
+	To                  *common.Address   `json:"to"`
+	Gas                 *hexutil.Uint64   `json:"gas"`
+	GasPrice            *hexutil.Big      `json:"gasPrice"`
+	FeeCurrency         *common.Address   `json:"feeCurrency"`
+	GatewayFeeRecipient *common.Address   `json:"gatewayFeeRecipient"`
+	GatewayFee          hexutil.Big       `json:"gatewayFee"`
+	FeePayer            *common.Address   `json:"feePayer"`
+	Value               *hexutil.Big      `json:"value"`
+	Data                *hexutil.Bytes    `json:"data"`
+	AccessList          *types.AccessList `json:"accessList"`
+}
+
+// gatewayFee returns the gateway fee paid by the call, which is zero if no gateway
+// fee recipient is given, and defaults to zero if the recipient is given without a fee.
+func (args *CallArgs) gatewayFee() *big.Int {
+	if args.GatewayFeeRecipient == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(args.GatewayFee.ToInt())
 }
 
 // ToMessage converts CallArgs to the Message type used by the core evm
@@ -773,6 +906,12 @@ func (args *CallArgs) ToMessage(globalGasCap *big.Int) types.Message {
 	}
 
 	msg := types.NewMessage(addr, args.To, 0, value, gas, gasPrice, args.FeeCurrency, args.GatewayFeeRecipient, args.GatewayFee.ToInt(), data, false)
+	if args.FeePayer != nil {
+		msg = msg.WithFeePayer(*args.FeePayer)
+	}
+	if args.AccessList != nil {
+		msg = msg.WithAccessList(*args.AccessList)
+	}
 	return msg
 }
 
@@ -790,41 +929,49 @@ type account struct {
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
 }
 
-func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) (*core.ExecutionResult, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
-
-	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
-	if state == nil || err != nil {
-		return nil, err
-	}
-	// Override the fields of specified contracts before execution.
+// applyOverrides overrides the fields of the specified accounts in the state.
+func applyOverrides(statedb *state.StateDB, overrides map[common.Address]account) error {
 	for addr, account := range overrides {
 		// Override account nonce.
 		if account.Nonce != nil {
-			state.SetNonce(addr, uint64(*account.Nonce))
+			statedb.SetNonce(addr, uint64(*account.Nonce))
 		}
 		// Override account(contract) code.
 		if account.Code != nil {
-			state.SetCode(addr, *account.Code)
+			statedb.SetCode(addr, *account.Code)
 		}
 		// Override account balance.
 		if account.Balance != nil {
-			state.SetBalance(addr, (*big.Int)(*account.Balance))
+			statedb.SetBalance(addr, (*big.Int)(*account.Balance))
 		}
 		if account.State != nil && account.StateDiff != nil {
-			return nil, fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
 		}
 		// Replace entire state if caller requires.
 		if account.State != nil {
-			state.SetStorage(addr, *account.State)
+			statedb.SetStorage(addr, *account.State)
 		}
 		// Apply state diff into specified accounts.
 		if account.StateDiff != nil {
 			for key, value := range *account.StateDiff {
-				state.SetState(addr, key, value)
+				statedb.SetState(addr, key, value)
 			}
 		}
 	}
+	return nil
+}
+
+func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) (*core.ExecutionResult, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Override the fields of specified contracts before execution.
+	if err := applyOverrides(state, overrides); err != nil {
+		return nil, err
+	}
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
@@ -881,6 +1028,280 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNrOr
 	return result.Return(), nil
 }
 
+// BundleTxArgs represents a transaction of a bundle: either a signed raw
+// transaction, or the arguments of an unsigned call.
+type BundleTxArgs struct {
+	CallArgs
+	Raw *hexutil.Bytes `json:"raw"`
+}
+
+// RPCBundleTxResult holds the outcome of a transaction of a bundle. The fee is
+// the gas used times the gas price, in the fee currency of the transaction.
+type RPCBundleTxResult struct {
+	TxHash              *common.Hash    `json:"txHash,omitempty"` // Only set for signed transactions
+	From                common.Address  `json:"from"`
+	To                  *common.Address `json:"to"`
+	FeePayer            common.Address  `json:"feePayer"`
+	GasUsed             hexutil.Uint64  `json:"gasUsed"`
+	ReturnData          hexutil.Bytes   `json:"returnData"`
+	Logs                []*types.Log    `json:"logs"`
+	FeeCurrency         *common.Address `json:"feeCurrency"`
+	Fee                 *hexutil.Big    `json:"fee"`
+	GatewayFeeRecipient *common.Address `json:"gatewayFeeRecipient"`
+	GatewayFee          *hexutil.Big    `json:"gatewayFee"`
+	Error               string          `json:"error,omitempty"`
+	RevertReason        string          `json:"revertReason,omitempty"`
+}
+
+// RPCBundleFees holds the fees charged by the transactions of a bundle paying
+// in a fee currency.
+type RPCBundleFees struct {
+	FeeCurrency *common.Address `json:"feeCurrency"` // nil for CELO
+	Fees        *hexutil.Big    `json:"fees"`
+	GatewayFees *hexutil.Big    `json:"gatewayFees"`
+}
+
+// RPCValueDiff holds a state value before and after the execution of a bundle.
+type RPCValueDiff struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// RPCAccountDiff holds the changes of an account made by a bundle.
+type RPCAccountDiff struct {
+	Balance *RPCValueDiff                 `json:"balance,omitempty"`
+	Nonce   *RPCValueDiff                 `json:"nonce,omitempty"`
+	Code    *RPCValueDiff                 `json:"code,omitempty"`
+	Storage map[common.Hash]*RPCValueDiff `json:"storage,omitempty"`
+}
+
+// RPCBundleResult holds the outcome of the execution of a bundle.
+type RPCBundleResult struct {
+	Results   []*RPCBundleTxResult               `json:"results"`
+	GasUsed   hexutil.Uint64                     `json:"gasUsed"`
+	Fees      []*RPCBundleFees                   `json:"fees"`
+	StateDiff map[common.Address]*RPCAccountDiff `json:"stateDiff"`
+}
+
+// DoCallBundle executes a sequence of transactions on top of the state of the
+// given block, each one seeing the changes of the previous ones. Transactions
+// failing the consensus checks, e.g. because of a wrong nonce, don't change the
+// state and are reported with their error. The whole bundle can use up to the
+// global gas cap, and fails once a transaction exceeds the gas left.
+func DoCallBundle(ctx context.Context, b Backend, txs []BundleTxArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, timeout time.Duration, globalGasCap *big.Int) (*RPCBundleResult, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM bundle finished", "runtime", time.Since(start)) }(time.Now())
+
+	if len(txs) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(statedb, overrides); err != nil {
+		return nil, err
+	}
+	pre := statedb.Copy()
+
+	// Setup context so it may be cancelled when the bundle has completed
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	gasCap := uint64(math.MaxUint64)
+	if globalGasCap != nil && globalGasCap.Sign() > 0 {
+		gasCap = globalGasCap.Uint64()
+	}
+	var (
+		signer  = types.MakeSigner(b.ChainConfig(), header.Number)
+		gp      = new(core.GasPool).AddGas(gasCap)
+		gasUsed uint64
+		fees    = make(map[common.Address]*RPCBundleFees)
+		result  = &RPCBundleResult{Results: make([]*RPCBundleTxResult, 0, len(txs))}
+	)
+	for i, args := range txs {
+		// Convert the bundle entry into a message, signed ones checking their nonce
+		var (
+			msg    types.Message
+			txHash common.Hash
+			signed = args.Raw != nil
+		)
+		if signed {
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(*args.Raw); err != nil {
+				return nil, fmt.Errorf("transaction %d: %v", i, err)
+			}
+			if msg, err = tx.AsMessage(signer); err != nil {
+				return nil, fmt.Errorf("transaction %d: %v", i, err)
+			}
+			txHash = tx.Hash()
+		} else {
+			// Unsigned calls default to the gas left to the bundle
+			if gp.Gas() == 0 || (args.Gas != nil && uint64(*args.Gas) > gp.Gas()) {
+				return nil, fmt.Errorf("transaction %d: %w, bundle gas cap %d exhausted", i, core.ErrGasLimitReached, gasCap)
+			}
+			msg = args.ToMessage(new(big.Int).SetUint64(gp.Gas()))
+			// Unsigned calls have no hash, so their logs are keyed by their index
+			txHash = common.BigToHash(big.NewInt(int64(i)))
+		}
+		txResult := &RPCBundleTxResult{
+			From:                msg.From(),
+			To:                  msg.To(),
+			FeePayer:            msg.From(),
+			Logs:                []*types.Log{},
+			FeeCurrency:         msg.FeeCurrency(),
+			Fee:                 new(hexutil.Big),
+			GatewayFeeRecipient: msg.GatewayFeeRecipient(),
+			GatewayFee:          new(hexutil.Big),
+		}
+		if signed {
+			txResult.TxHash = &txHash
+		}
+		if msg.FeePayer() != nil {
+			txResult.FeePayer = *msg.FeePayer()
+		}
+		result.Results = append(result.Results, txResult)
+
+		// Apply the message, reverting its changes if it fails the consensus checks
+		evm, vmError, err := b.GetEVM(ctx, msg, header, statedb)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+		statedb.Prepare(txHash, header.Hash(), i)
+		snapshot := statedb.Snapshot()
+
+		var res *core.ExecutionResult
+		if signed {
+			res, err = core.ApplyMessage(evm, msg, gp)
+		} else {
+			res, err = core.ApplyMessageWithoutGasPriceMinimum(evm, msg, gp)
+		}
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		if errors.Is(err, core.ErrGasLimitReached) {
+			return nil, fmt.Errorf("transaction %d: %w, bundle gas cap %d exhausted", i, err, gasCap)
+		}
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			txResult.Error = err.Error()
+			continue
+		}
+		statedb.Finalise(true)
+
+		txResult.GasUsed = hexutil.Uint64(res.UsedGas)
+		txResult.ReturnData = res.Return()
+		if logs := statedb.GetLogs(txHash); logs != nil {
+			txResult.Logs = logs
+		}
+		if res.Err != nil {
+			txResult.Error = res.Err.Error()
+			if len(res.Revert()) > 0 {
+				if reason, err := abi.UnpackRevert(res.Revert()); err == nil {
+					txResult.RevertReason = reason
+				} else {
+					txResult.RevertReason = hexutil.Encode(res.Revert())
+				}
+			}
+		}
+		gasUsed += res.UsedGas
+
+		// Account the fees in the fee currency of the transaction
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(res.UsedGas), msg.GasPrice())
+		txResult.Fee = (*hexutil.Big)(fee)
+		gatewayFee := new(big.Int)
+		if msg.GatewayFeeRecipient() != nil {
+			gatewayFee.Set(msg.GatewayFee())
+		}
+		txResult.GatewayFee = (*hexutil.Big)(gatewayFee)
+
+		var currencyAddress common.Address
+		if msg.FeeCurrency() != nil {
+			currencyAddress = *msg.FeeCurrency()
+		}
+		total, ok := fees[currencyAddress]
+		if !ok {
+			total = &RPCBundleFees{FeeCurrency: msg.FeeCurrency(), Fees: new(hexutil.Big), GatewayFees: new(hexutil.Big)}
+			fees[currencyAddress] = total
+		}
+		total.Fees.ToInt().Add(total.Fees.ToInt(), fee)
+		total.GatewayFees.ToInt().Add(total.GatewayFees.ToInt(), gatewayFee)
+	}
+	result.GasUsed = hexutil.Uint64(gasUsed)
+
+	// CELO first, then the other currencies by address
+	result.Fees = make([]*RPCBundleFees, 0, len(fees))
+	for _, total := range fees {
+		result.Fees = append(result.Fees, total)
+	}
+	sort.Slice(result.Fees, func(i, j int) bool {
+		a, b := result.Fees[i].FeeCurrency, result.Fees[j].FeeCurrency
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return bytes.Compare(a.Bytes(), b.Bytes()) < 0
+	})
+	result.StateDiff = bundleStateDiff(pre, statedb)
+	return result, nil
+}
+
+// bundleStateDiff returns the changes of the accounts modified by a bundle.
+func bundleStateDiff(pre, post *state.StateDB) map[common.Address]*RPCAccountDiff {
+	diff := make(map[common.Address]*RPCAccountDiff)
+	for addr, keys := range post.ModifiedAccounts() {
+		var (
+			account = new(RPCAccountDiff)
+			changed bool
+		)
+		if from, to := pre.GetBalance(addr), post.GetBalance(addr); from.Cmp(to) != 0 {
+			account.Balance, changed = &RPCValueDiff{From: (*hexutil.Big)(from), To: (*hexutil.Big)(to)}, true
+		}
+		if from, to := pre.GetNonce(addr), post.GetNonce(addr); from != to {
+			account.Nonce, changed = &RPCValueDiff{From: hexutil.Uint64(from), To: hexutil.Uint64(to)}, true
+		}
+		if pre.GetCodeHash(addr) != post.GetCodeHash(addr) {
+			account.Code, changed = &RPCValueDiff{From: hexutil.Bytes(pre.GetCode(addr)), To: hexutil.Bytes(post.GetCode(addr))}, true
+		}
+		for _, key := range keys {
+			if from, to := pre.GetState(addr, key), post.GetState(addr, key); from != to {
+				if account.Storage == nil {
+					account.Storage = make(map[common.Hash]*RPCValueDiff)
+				}
+				account.Storage[key], changed = &RPCValueDiff{From: from, To: to}, true
+			}
+		}
+		if changed {
+			diff[addr] = account
+		}
+	}
+	return diff
+}
+
+// CallBundle executes a sequence of signed or unsigned transactions on top of the
+// state of the given block, with optional state overrides, and returns the result,
+// gas used and logs of each transaction, the fees charged by fee currency and the
+// resulting state changes.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, txs []BundleTxArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *map[common.Address]account) (*RPCBundleResult, error) {
+	var accounts map[common.Address]account
+	if overrides != nil {
+		accounts = *overrides
+	}
+	return DoCallBundle(ctx, s.b, txs, blockNrOrHash, accounts, 50*time.Second, s.b.RPCGasCap())
+}
+
 type estimateGasError struct {
 	error  string // Concrete error type if it's failed to estimate gas usage
 	vmerr  error  // Additional field, it's non-nil if the given transaction is invalid
@@ -925,17 +1346,38 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", gasCap)
 		hi = gasCap.Uint64()
 	}
-	cap = hi
 
 	// Use zero address if sender unspecified.
 	if args.From == nil {
 		args.From = new(common.Address)
 	}
-	// Set gas price to nil (which will lead to it being zero), because the binary search
-	// assumes that if the transaction fails with gas limit A, and B < A, then it would
-	// also fail with gas limit B, which may not be the case if the gas price is non-zero,
-	// depending on the account's balance.
-	args.GasPrice = nil
+	if args.FeeCurrency == nil {
+		// Set gas price to nil (which will lead to it being zero), because the binary search
+		// assumes that if the transaction fails with gas limit A, and B < A, then it would
+		// also fail with gas limit B, which may not be the case if the gas price is non-zero,
+		// depending on the account's balance.
+		args.GasPrice = nil
+	} else {
+		// The fee currency contract is only debited and credited for non-zero fees, so
+		// keep the gas price to model the whole fee currency path, and instead cap the
+		// gas limit to what the fee payer can afford in the fee currency.
+		gasPrice, err := estimationGasPrice(ctx, b, args)
+		if err != nil {
+			return 0, err
+		}
+		args.GasPrice = (*hexutil.Big)(gasPrice)
+		if gasPrice.Sign() > 0 {
+			allowance, err := feeCurrencyAllowance(ctx, b, args, blockNrOrHash)
+			if err != nil {
+				return 0, err
+			}
+			if hi > allowance {
+				log.Warn("Gas estimation capped by limited funds", "original", hi, "allowance", allowance, "feeCurrency", args.FeeCurrency)
+				hi = allowance
+			}
+		}
+	}
+	cap = hi
 	// Create a helper to check if a gas allowance results in an executable transaction
 	executable := func(gas uint64) (bool, *core.ExecutionResult, error) {
 		args.Gas = (*hexutil.Uint64)(&gas)
@@ -996,6 +1438,46 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 	return hexutil.Uint64(hi), nil
 }
 
+// estimationGasPrice returns the gas price of the call, or the suggested gas price
+// in its fee currency if none is given.
+func estimationGasPrice(ctx context.Context, b Backend, args CallArgs) (*big.Int, error) {
+	if args.GasPrice != nil {
+		return args.GasPrice.ToInt(), nil
+	}
+	return b.SuggestPriceInCurrency(ctx, args.FeeCurrency)
+}
+
+// feeCurrencyAllowance returns the highest gas limit the fee payer of the call can
+// afford at its gas price in its fee currency, after paying the gateway fee. It fails
+// with ErrInsufficientFundsForFees if the fee payer's balance in the fee currency
+// can't cover the gateway fee and a single unit of gas, so the estimation of a call
+// whose fee payer holds none of its fee currency fails instead of returning zero.
+func feeCurrencyAllowance(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return 0, err
+	}
+	feePayer := *args.From
+	if args.FeePayer != nil {
+		feePayer = *args.FeePayer
+	}
+	balance, _, err := currency.GetBalanceOf(feePayer, *args.FeeCurrency, params.MaxGasToReadErc20Balance, header, statedb)
+	if err != nil {
+		return 0, err
+	}
+	// The balance needs to exceed the fees, gateway fee included
+	available := new(big.Int).Sub(balance, common.Big1)
+	available.Sub(available, args.gatewayFee())
+	if available.Sign() < 0 {
+		return 0, core.ErrInsufficientFundsForFees
+	}
+	allowance := available.Div(available, args.GasPrice.ToInt())
+	if !allowance.IsUint64() {
+		return math.MaxUint64, nil
+	}
+	return allowance.Uint64(), nil
+}
+
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
@@ -1003,6 +1485,88 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	return DoEstimateGas(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
 }
 
+// RPCGasEstimate is the breakdown of the gas estimate of a transaction, with the
+// fee it pays at the given or suggested gas price in its fee currency.
+type RPCGasEstimate struct {
+	Gas                hexutil.Uint64  `json:"gas"`
+	IntrinsicGas       hexutil.Uint64  `json:"intrinsicGas"`       // Base, data and access list gas
+	FeeCurrencyGas     hexutil.Uint64  `json:"feeCurrencyGas"`     // Intrinsic gas charged for a non-CELO fee currency
+	ExecutionGas       hexutil.Uint64  `json:"executionGas"`       // Gas of the EVM execution
+	FeeCurrencyGasUsed hexutil.Uint64  `json:"feeCurrencyGasUsed"` // Gas used by the balanceOf, debit and credit calls to the fee currency
+	FeeCurrency        *common.Address `json:"feeCurrency"`        // nil for CELO
+	GasPrice           *hexutil.Big    `json:"gasPrice"`
+	GatewayFee         *hexutil.Big    `json:"gatewayFee"`
+	Fee                *hexutil.Big    `json:"fee"` // Gas times gas price, plus the gateway fee
+}
+
+// DoEstimateGasDetailed estimates the gas of a transaction, and breaks it down
+// into intrinsic, fee currency and execution gas.
+func DoEstimateGasDetailed(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap *big.Int) (*RPCGasEstimate, error) {
+	gas, err := DoEstimateGas(ctx, b, args, blockNrOrHash, gasCap)
+	if err != nil {
+		return nil, err
+	}
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	var (
+		data       []byte
+		accessList types.AccessList
+		isEIP2028  = b.ChainConfig().IsIstanbul(header.Number)
+	)
+	if args.Data != nil {
+		data = *args.Data
+	}
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	intrinsicGas, err := core.IntrinsicGas(data, accessList, args.To == nil, header, statedb, nil, isEIP2028)
+	if err != nil {
+		return nil, err
+	}
+	totalIntrinsicGas, err := core.IntrinsicGas(data, accessList, args.To == nil, header, statedb, args.FeeCurrency, isEIP2028)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := estimationGasPrice(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &RPCGasEstimate{
+		Gas:            gas,
+		IntrinsicGas:   hexutil.Uint64(intrinsicGas),
+		FeeCurrencyGas: hexutil.Uint64(totalIntrinsicGas - intrinsicGas),
+		ExecutionGas:   gas - hexutil.Uint64(totalIntrinsicGas),
+		FeeCurrency:    args.FeeCurrency,
+		GasPrice:       (*hexutil.Big)(gasPrice),
+		GatewayFee:     (*hexutil.Big)(args.gatewayFee()),
+	}
+	// Replay the call with the estimate to measure the fee currency calls
+	if args.FeeCurrency != nil {
+		if args.From == nil {
+			args.From = new(common.Address)
+		}
+		args.Gas, args.GasPrice = &gas, estimate.GasPrice
+		result, err := DoCall(ctx, b, args, blockNrOrHash, nil, vm.Config{}, 0, gasCap)
+		if err != nil {
+			return nil, err
+		}
+		estimate.FeeCurrencyGasUsed = hexutil.Uint64(result.FeeCurrencyGasUsed)
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(uint64(gas)), gasPrice)
+	estimate.Fee = (*hexutil.Big)(fee.Add(fee, estimate.GatewayFee.ToInt()))
+	return estimate, nil
+}
+
+// EstimateGasDetailed returns an estimate of the amount of gas needed to execute
+// the given transaction against the current pending block, broken down into
+// intrinsic, fee currency and execution gas, and the fee it pays.
+func (s *PublicBlockChainAPI) EstimateGasDetailed(ctx context.Context, args CallArgs) (*RPCGasEstimate, error) {
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	return DoEstimateGasDetailed(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -1150,23 +1714,30 @@ func (s *PublicBlockChainAPI) rpcMarshalBlock(b *types.Block, inclTx bool, fullT
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	BlockHash           *common.Hash    `json:"blockHash"`
-	BlockNumber         *hexutil.Big    `json:"blockNumber"`
-	From                common.Address  `json:"from"`
-	Gas                 hexutil.Uint64  `json:"gas"`
-	GasPrice            *hexutil.Big    `json:"gasPrice"`
-	FeeCurrency         *common.Address `json:"feeCurrency"`
-	GatewayFeeRecipient *common.Address `json:"gatewayFeeRecipient"`
-	GatewayFee          *hexutil.Big    `json:"gatewayFee"`
-	Hash                common.Hash     `json:"hash"`
-	Input               hexutil.Bytes   `json:"input"`
-	Nonce               hexutil.Uint64  `json:"nonce"`
-	To                  *common.Address `json:"to"`
-	TransactionIndex    *hexutil.Uint64 `json:"transactionIndex"`
-	Value               *hexutil.Big    `json:"value"`
-	V                   *hexutil.Big    `json:"v"`
-	R                   *hexutil.Big    `json:"r"`
-	S                   *hexutil.Big    `json:"s"`
+	BlockHash           *common.Hash      `json:"blockHash"`
+	BlockNumber         *hexutil.Big      `json:"blockNumber"`
+	From                common.Address    `json:"from"`
+	Gas                 hexutil.Uint64    `json:"gas"`
+	GasPrice            *hexutil.Big      `json:"gasPrice"`
+	FeeCurrency         *common.Address   `json:"feeCurrency"`
+	GatewayFeeRecipient *common.Address   `json:"gatewayFeeRecipient"`
+	GatewayFee          *hexutil.Big      `json:"gatewayFee"`
+	Hash                common.Hash       `json:"hash"`
+	Input               hexutil.Bytes     `json:"input"`
+	Nonce               hexutil.Uint64    `json:"nonce"`
+	To                  *common.Address   `json:"to"`
+	TransactionIndex    *hexutil.Uint64   `json:"transactionIndex"`
+	Value               *hexutil.Big      `json:"value"`
+	V                   *hexutil.Big      `json:"v"`
+	R                   *hexutil.Big      `json:"r"`
+	S                   *hexutil.Big      `json:"s"`
+	FeePayer            *common.Address   `json:"feePayer,omitempty"`
+	FeePayerV           *hexutil.Big      `json:"feePayerV,omitempty"`
+	FeePayerR           *hexutil.Big      `json:"feePayerR,omitempty"`
+	FeePayerS           *hexutil.Big      `json:"feePayerS,omitempty"`
+	Type                hexutil.Uint64    `json:"type"`
+	ChainID             *hexutil.Big      `json:"chainId,omitempty"`
+	Accesses            *types.AccessList `json:"accessList,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1174,7 +1745,7 @@ type RPCTransaction struct {
 func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 	v, r, s := tx.RawSignatureValues()
@@ -1194,6 +1765,17 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		V:                   (*hexutil.Big)(v),
 		R:                   (*hexutil.Big)(r),
 		S:                   (*hexutil.Big)(s),
+		Type:                hexutil.Uint64(tx.Type()),
+	}
+	if tx.Type() != types.LegacyTxType {
+		al := tx.AccessList()
+		result.Accesses = &al
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+	}
+	if tx.Sponsored() {
+		v, r, s := tx.RawFeePayerSignatureValues()
+		result.FeePayer = tx.FeePayer()
+		result.FeePayerV, result.FeePayerR, result.FeePayerS = (*hexutil.Big)(v), (*hexutil.Big)(r), (*hexutil.Big)(s)
 	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = &blockHash
@@ -1223,7 +1805,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -1349,8 +1931,8 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 			return nil, nil
 		}
 	}
-	// Serialize to RLP and return
-	return rlp.EncodeToBytes(tx)
+	// Serialize to binary and return
+	return tx.MarshalBinary()
 }
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
@@ -1370,7 +1952,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -1386,6 +1968,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
+		"type":              hexutil.Uint(tx.Type()),
 	}
 
 	// Assign receipt status or post state.
@@ -1417,6 +2000,31 @@ func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transacti
 	return wallet.SignTx(account, tx, s.b.ChainConfig().ChainID)
 }
 
+// signFeePayer is a helper function that signs a sponsored transaction, already
+// signed by its sender, with the private key of its fee payer.
+func (s *PublicTransactionPoolAPI) signFeePayer(tx *types.Transaction) (*types.Transaction, error) {
+	if !tx.Sponsored() {
+		return nil, types.ErrNotSponsored
+	}
+	signer, ok := types.LatestSigner(s.b.ChainConfig()).(types.FeePayerSigner)
+	if !ok {
+		return nil, types.ErrSponsoredTxNotSupported
+	}
+	// Look up the wallet containing the fee payer
+	account := accounts.Account{Address: *tx.FeePayer()}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	hash := signer.FeePayerHash(tx)
+	sig, err := wallet.SignHash(account, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithFeePayerSignature(signer, sig)
+}
+
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
 type SendTxArgs struct {
 	From                common.Address  `json:"from"`
@@ -1426,12 +2034,17 @@ type SendTxArgs struct {
 	FeeCurrency         *common.Address `json:"feeCurrency"`
 	GatewayFeeRecipient *common.Address `json:"gatewayFeeRecipient"`
 	GatewayFee          *hexutil.Big    `json:"gatewayFee"`
+	FeePayer            *common.Address `json:"feePayer"` // Account paying the fees instead of the sender
 	Value               *hexutil.Big    `json:"value"`
 	Nonce               *hexutil.Uint64 `json:"nonce"`
 	// We accept "data" and "input" for backwards-compatibility reasons. "input" is the
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// For access list transactions, whose chain id defaults to the one of the node
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1457,11 +2070,7 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	// which will always be in Gold. This allows the default price to be set for the proper currency.
 	// TODO(asa): Remove this once this is handled in the Provider.
 	if args.GasPrice == nil || args.GasPrice.ToInt().Cmp(big.NewInt(0)) == 0 {
-		state, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
-		if err != nil {
-			return err
-		}
-		price, err := b.SuggestPriceInCurrency(ctx, args.FeeCurrency, header, state)
+		price, err := b.SuggestPriceInCurrency(ctx, args.FeeCurrency)
 		if err != nil {
 			return err
 		}
@@ -1509,11 +2118,13 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 			input = args.Data
 		}
 		callArgs := CallArgs{
-			From:     &args.From, // From shouldn't be nil
-			To:       args.To,
-			GasPrice: args.GasPrice,
-			Value:    args.Value,
-			Data:     input,
+			From:       &args.From, // From shouldn't be nil
+			To:         args.To,
+			GasPrice:   args.GasPrice,
+			FeePayer:   args.FeePayer,
+			Value:      args.Value,
+			Data:       input,
+			AccessList: args.AccessList,
 		}
 		pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, b.RPCGasCap())
@@ -1526,6 +2137,9 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.GatewayFeeRecipient != nil && args.GatewayFee == nil {
 		args.GatewayFee = (*hexutil.Big)(b.GatewayFee())
 	}
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(b.ChainConfig().ChainID)
+	}
 	return nil
 }
 
@@ -1536,10 +2150,19 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Data != nil {
 		input = *args.Data
 	}
+	var tx *types.Transaction
 	if args.To == nil {
-		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.FeeCurrency, args.GatewayFeeRecipient, (*big.Int)(args.GatewayFee), input)
+		tx = types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.FeeCurrency, args.GatewayFeeRecipient, (*big.Int)(args.GatewayFee), input)
+	} else {
+		tx = types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.FeeCurrency, args.GatewayFeeRecipient, (*big.Int)(args.GatewayFee), input)
+	}
+	if args.AccessList != nil {
+		tx = tx.WithAccessList((*big.Int)(args.ChainID), *args.AccessList)
 	}
-	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), args.FeeCurrency, args.GatewayFeeRecipient, (*big.Int)(args.GatewayFee), input)
+	if args.FeePayer != nil {
+		tx = tx.WithFeePayer((*big.Int)(args.ChainID), *args.FeePayer)
+	}
+	return tx
 }
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
@@ -1590,6 +2213,12 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return common.Hash{}, err
 	}
+	// Sponsored transactions need the fee payer to be an account of the node too
+	if signed.Sponsored() {
+		if signed, err = s.signFeePayer(signed); err != nil {
+			return common.Hash{}, err
+		}
+	}
 	return SubmitTransaction(ctx, s.b, signed)
 }
 
@@ -1602,7 +2231,7 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Sen
 	}
 	// Assemble the transaction and obtain rlp
 	tx := args.toTransaction()
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1613,12 +2242,33 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Sen
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateRawTransaction will add the signed transaction to the transaction pool
+// as private. It is not announced to the network, but only relayed to the trusted
+// private transaction peers, and it's dropped if it's not included by the max block
+// number. The private transaction lifetime of the pool is used if no max block
+// number is given.
+func (s *PublicTransactionPoolAPI) SendPrivateRawTransaction(ctx context.Context, encodedTx hexutil.Bytes, maxBlockNumber *hexutil.Uint64) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
+		return common.Hash{}, err
+	}
+	var deadline uint64
+	if maxBlockNumber != nil {
+		deadline = uint64(*maxBlockNumber)
+	}
+	if err := s.b.SendPrivateTx(ctx, tx, deadline); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To())
+	return tx.Hash(), nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -1670,7 +2320,27 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, tx}, nil
+}
+
+// SignFeePayerTransaction signs the given sponsored transaction, signed by its
+// sender, with the fee payer account. The node needs to have the private key of
+// the fee payer. The transaction is returned in RLP-form, not broadcast to other
+// nodes.
+func (s *PublicTransactionPoolAPI) SignFeePayerTransaction(ctx context.Context, input hexutil.Bytes) (*SignTransactionResult, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, err
+	}
+	tx, err := s.signFeePayer(tx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1694,7 +2364,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	for _, tx := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if tx.Protected() {
-			signer = types.NewEIP155Signer(tx.ChainId())
+			signer = types.LatestSignerForChainID(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
@@ -1722,7 +2392,7 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	for _, p := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if p.Protected() {
-			signer = types.NewEIP155Signer(p.ChainId())
+			signer = types.LatestSignerForChainID(p.ChainId())
 		}
 		wantSigHash := signer.Hash(matchTx)
 
@@ -1748,6 +2418,101 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	return common.Hash{}, fmt.Errorf("transaction %#x not found", matchTx.Hash())
 }
 
+// ReplaceTxArgs represents the fee changes of a transaction replacement.
+type ReplaceTxArgs struct {
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	// FeeCurrency is the zero address for CELO, and defaults to the fee currency
+	// of the replaced transaction.
+	FeeCurrency *common.Address `json:"feeCurrency"`
+}
+
+// ReplaceTransaction re-signs a pooled transaction of a local account with a new
+// gas price or fee currency, and submits it to replace the pooled one.
+//
+// The gas limit is raised by the intrinsic gas of alternative fee currencies when
+// switching from CELO, unless given. The gas price defaults to the suggested gas
+// price in the fee currency, and at least the price bump of the pool over the
+// replaced gas price if the fee currency doesn't change. The replacement of a
+// sponsored transaction is paid by the same fee payer, which must be an account
+// of the node too.
+func (s *PublicTransactionPoolAPI) ReplaceTransaction(ctx context.Context, hash common.Hash, args ReplaceTxArgs) (common.Hash, error) {
+	tx := s.b.GetPoolTransaction(hash)
+	if tx == nil {
+		return common.Hash{}, fmt.Errorf("transaction %#x not pooled", hash)
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	feeCurrency := tx.FeeCurrency()
+	if args.FeeCurrency != nil {
+		feeCurrency = args.FeeCurrency
+		if *feeCurrency == (common.Address{}) {
+			feeCurrency = nil
+		}
+	}
+	gas := tx.Gas()
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	} else if tx.FeeCurrency() == nil && feeCurrency != nil {
+		state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err == nil {
+			gas += blockchain_parameters.GetIntrinsicGasForAlternativeFeeCurrency(header, state)
+		} else {
+			log.Warn("Cannot read intrinsic gas for alternative fee currency", "err", err)
+			gas += params.IntrinsicGasForAlternativeFeeCurrency
+		}
+	}
+	var gasPrice *big.Int
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	} else {
+		if gasPrice, err = s.b.SuggestPriceInCurrency(ctx, feeCurrency); err != nil {
+			return common.Hash{}, err
+		}
+		if tx.FeeCurrency() == feeCurrency || (feeCurrency != nil && tx.FeeCurrency() != nil && *feeCurrency == *tx.FeeCurrency()) {
+			bumped := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(100+s.b.TxPoolPriceBump()))
+			bumped.Div(bumped, big.NewInt(100))
+			if bumped.Cmp(tx.GasPrice()) <= 0 {
+				bumped.Add(tx.GasPrice(), common.Big1)
+			}
+			if bumped.Cmp(gasPrice) > 0 {
+				gasPrice = bumped
+			}
+		}
+	}
+
+	var replacement *types.Transaction
+	if tx.To() == nil {
+		replacement = types.NewContractCreation(tx.Nonce(), tx.Value(), gas, gasPrice, feeCurrency, tx.GatewayFeeRecipient(), tx.GatewayFee(), tx.Data())
+	} else {
+		replacement = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), gas, gasPrice, feeCurrency, tx.GatewayFeeRecipient(), tx.GatewayFee(), tx.Data())
+	}
+	if tx.Type() != types.LegacyTxType {
+		replacement = replacement.WithAccessList(tx.ChainId(), tx.AccessList())
+	}
+	if tx.Sponsored() {
+		replacement = replacement.WithFeePayer(tx.ChainId(), *tx.FeePayer())
+	}
+	signed, err := s.sign(from, replacement)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// The fee payer signs over the new fees too, so it needs to be an account of the node
+	if signed.Sponsored() {
+		if signed, err = s.signFeePayer(signed); err != nil {
+			return common.Hash{}, fmt.Errorf("cannot sign the replacement by fee payer %s: %w", tx.FeePayer().Hex(), err)
+		}
+	}
+	return SubmitTransaction(ctx, s.b, signed)
+}
+
 // PublicDebugAPI is the collection of Ethereum APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
@@ -1760,6 +2525,16 @@ func NewPublicDebugAPI(b Backend) *PublicDebugAPI {
 	return &PublicDebugAPI{b: b}
 }
 
+// SimulateTransactions executes a sequence of signed or unsigned transactions on
+// top of the state of the given block, as eth_callBundle does.
+func (api *PublicDebugAPI) SimulateTransactions(ctx context.Context, txs []BundleTxArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *map[common.Address]account) (*RPCBundleResult, error) {
+	var accounts map[common.Address]account
+	if overrides != nil {
+		accounts = *overrides
+	}
+	return DoCallBundle(ctx, api.b, txs, blockNrOrHash, accounts, 50*time.Second, api.b.RPCGasCap())
+}
+
 // GetBlockRlp retrieves the RLP encoded for of a single block.
 func (api *PublicDebugAPI) GetBlockRlp(ctx context.Context, number uint64) (string, error) {
 	block, _ := api.b.BlockByNumber(ctx, rpc.BlockNumber(number))
@@ -167,6 +167,12 @@ web3._extend({
 			call: 'debug_getBlockRlp',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'simulateTransactions',
+			call: 'debug_simulateTransactions',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'setHead',
 			call: 'debug_setHead',
@@ -405,12 +411,22 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
 		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'eth_replaceTransaction',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'signTransaction',
 			call: 'eth_signTransaction',
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'signFeePayerTransaction',
+			call: 'eth_signFeePayerTransaction',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'submitTransaction',
 			call: 'eth_submitTransaction',
@@ -448,6 +464,23 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'sendPrivateRawTransaction',
+			call: 'eth_sendPrivateRawTransaction',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'estimateGasDetailed',
+			call: 'eth_estimateGasDetailed',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -462,6 +495,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'validator',
 			call: 'eth_validator',
@@ -670,7 +709,14 @@ web3._extend({
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'celoStatus',
+			call: 'txpool_celoStatus',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -767,6 +813,23 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'getEquivocationEvidence',
+			call: 'istanbul_getEquivocationEvidence',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorUptime',
+			call: 'istanbul_getValidatorUptime',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getEpochProof',
+			call: 'istanbul_getEpochProof',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'addProxy',
 			call: 'istanbul_addProxy',
@@ -815,6 +878,10 @@ web3._extend({
 			name: 'proxies',
 			getter: 'istanbul_getProxiesInfo',
 		}),
+		new web3._extend.Property({
+			name: 'proxiesHealth',
+			getter: 'istanbul_getProxiesHealth',
+		}),
 		new web3._extend.Property({
 			name: 'proxiedValidators',
 			getter: 'istanbul_getProxiedValidators',
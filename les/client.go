@@ -36,6 +36,7 @@ import (
 	"github.com/celo-org/celo-blockchain/eth"
 	"github.com/celo-org/celo-blockchain/eth/downloader"
 	"github.com/celo-org/celo-blockchain/eth/filters"
+	"github.com/celo-org/celo-blockchain/eth/gasprice"
 	"github.com/celo-org/celo-blockchain/event"
 	"github.com/celo-org/celo-blockchain/internal/ethapi"
 	"github.com/celo-org/celo-blockchain/les/checkpointoracle"
@@ -184,7 +185,8 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 
-	leth.ApiBackend = &LesApiBackend{ctx.ExtRPCEnabled(), leth}
+	leth.ApiBackend = &LesApiBackend{ctx.ExtRPCEnabled(), leth, nil}
+	leth.ApiBackend.gpo = gasprice.NewOracle(leth.ApiBackend, config.GPO)
 
 	leth.chainreader = &LightChainReader{
 		config:     leth.chainConfig,
@@ -342,6 +344,7 @@ func (s *LightEthereum) Stop() error {
 		s.chtIndexer.Close()
 	}
 	s.blockchain.Stop()
+	contract_comm.UnsetInternalEVMHandler(s.blockchain)
 	s.handler.stop()
 	s.txPool.Stop()
 	s.engine.Close()
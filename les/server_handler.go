@@ -780,6 +780,7 @@ func (h *serverHandler) handleMsg(p *clientPeer, wg *sync.WaitGroup) error {
 						// Only include transactions that have a valid gateway fee recipient & fee
 						if err := h.verifyGatewayFee(tx.GatewayFeeRecipient(), tx.GatewayFee()); err != nil {
 							p.Log().Trace("Rejected transaction from light peer for invalid gateway fee", "hash", hash.String(), "err", err)
+							h.txpool.RecordRejection(tx, core.TxRejectGatewayFeeMismatch, err)
 							stats[i].Error = err.Error()
 							continue
 						}
@@ -72,6 +72,7 @@ type TxPool struct {
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	espresso bool // Fork indicator whether we are in the espresso stage.
 }
 
 // TxRelayBackend provides an interface to the mechanism that forwards transacions
@@ -94,7 +95,7 @@ type TxRelayBackend interface {
 func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBackend) *TxPool {
 	pool := &TxPool{
 		config:      config,
-		signer:      types.NewEIP155Signer(config.ChainID),
+		signer:      types.LatestSigner(config),
 		nonce:       make(map[common.Address]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
@@ -325,6 +326,7 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	// Update fork indicator by next pending block number
 	next := new(big.Int).Add(head.Number, big.NewInt(1))
 	pool.istanbul = pool.config.IsIstanbul(next)
+	pool.espresso = pool.config.IsEspresso(next)
 }
 
 // Stop stops the light transaction pool
@@ -360,11 +362,23 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		err  error
 	)
 
+	// Fee payers are only accepted once sponsored transactions are activated
+	if tx.Sponsored() && !pool.espresso {
+		return core.ErrSponsoredTxNotSupported
+	}
+	// Typed transactions are only accepted once the Espresso fork is activated
+	if tx.Type() != types.LegacyTxType && !pool.espresso {
+		return core.ErrTxTypeNotSupported
+	}
 	// Validate the transaction sender and it's sig. Throw
 	// if the from fields is invalid.
 	if from, err = types.Sender(pool.signer, tx); err != nil {
 		return core.ErrInvalidSender
 	}
+	feePayer, err := types.FeePayer(pool.signer, tx)
+	if err != nil {
+		return core.ErrInvalidSender
+	}
 	// Last but not least check for nonce errors
 	currentState := pool.currentState(ctx)
 	if n := currentState.GetNonce(from); n > tx.Nonce() {
@@ -379,14 +393,14 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	}
 
 	// Transactor should have enough funds to cover the costs
-	err = core.ValidateTransactorBalanceCoversTx(tx, from, currentState)
+	err = core.ValidateTransactorBalanceCoversTx(tx, from, feePayer, currentState)
 	if err != nil {
 		return err
 	}
 
 	// Should supply enough intrinsic gas
 	header := pool.chain.GetHeaderByHash(pool.head)
-	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, header, currentState, tx.FeeCurrency(), pool.istanbul)
+	gas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, header, currentState, tx.FeeCurrency(), pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -57,6 +57,8 @@ type Config struct {
 	Recommit            time.Duration  // The time interval for miner to re-create mining work.
 	Noverify            bool           // Disable remote mining solution verification(only useful in ethash).
 	VerificationService string         // Celo verification service URL
+	TxOrdering          string         `toml:",omitempty"` // Transaction ordering strategy of mined blocks ("price" or "sendercap")
+	SenderGasCap        uint64         `toml:",omitempty"` // Maximum gas of the transactions of a sender in a block, for the "sendercap" ordering
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -206,6 +208,11 @@ func (miner *Miner) SetRecommitInterval(interval time.Duration) {
 	miner.worker.setRecommitInterval(interval)
 }
 
+// SetTxOrdering sets the strategy ordering the transactions of the mined blocks.
+func (miner *Miner) SetTxOrdering(ordering TxOrderingStrategy) {
+	miner.worker.setTxOrdering(ordering)
+}
+
 // Pending returns the currently pending block and associated state.
 func (miner *Miner) Pending() (*types.Block, *state.StateDB) {
 	return miner.worker.pending()
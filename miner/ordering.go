@@ -0,0 +1,163 @@
+// Copyright 2021 The Celo Authors
+// This file is part of the celo library.
+//
+// The celo library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The celo library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the celo library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/contract_comm/currency"
+	"github.com/celo-org/celo-blockchain/core/types"
+)
+
+// Names of the transaction ordering strategies selectable in the miner config.
+const (
+	TxOrderingPrice     = "price"     // Locals first, then remotes, each by gas price and nonce
+	TxOrderingSenderCap = "sendercap" // Price ordering, capping the gas of each sender in a block
+)
+
+// TxSet is a set of transactions which the worker commits in the order they
+// are peeked, until the set is exhausted or the block is full.
+// types.TransactionsByPriceAndNonce implements it.
+type TxSet interface {
+	// Peek returns the next transaction to commit, or nil if the set is exhausted.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one of the same sender.
+	Shift()
+
+	// Pop removes the current transaction and all the later ones of its sender.
+	Pop()
+}
+
+// OrderingContext holds the information about the block being built that is
+// available to transaction ordering strategies.
+type OrderingContext struct {
+	Signer   types.Signer           // Signer of the block's transactions
+	Header   *types.Header          // Header of the block being built
+	GasLimit uint64                 // Gas limit of the block being built
+	Rates    *currency.RateSnapshot // Exchange rates of the fee currencies at the parent block
+	Locals   []common.Address       // Accounts whose transactions are local to the node
+}
+
+// TxOrderingStrategy decides in which order the pending transactions are
+// committed to a block. The worker commits the returned sets one after the
+// other, so transactions of earlier sets take precedence for block space.
+type TxOrderingStrategy interface {
+	// Order splits the pending transactions, grouped by sender and sorted by
+	// nonce, into the sets to commit to the block. The pending map is owned by
+	// the strategy.
+	Order(ctx *OrderingContext, pending map[common.Address]types.Transactions) []TxSet
+}
+
+// NewTxOrderingStrategy creates the transaction ordering strategy selected in
+// the miner config.
+func NewTxOrderingStrategy(config *Config) (TxOrderingStrategy, error) {
+	switch config.TxOrdering {
+	case "", TxOrderingPrice:
+		return PriceOrdering{}, nil
+	case TxOrderingSenderCap:
+		if config.SenderGasCap == 0 {
+			return nil, fmt.Errorf("%q transaction ordering requires a sender gas cap", TxOrderingSenderCap)
+		}
+		return &SenderCapOrdering{Inner: PriceOrdering{}, Cap: config.SenderGasCap}, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q", config.TxOrdering)
+	}
+}
+
+// PriceOrdering is the default transaction ordering strategy. It commits the
+// transactions of local accounts first and then the remote ones, each sorted by
+// gas price and nonce. Gas prices in different fee currencies are compared
+// with the exchange rates of the block.
+type PriceOrdering struct{}
+
+// Order implements TxOrderingStrategy.
+func (PriceOrdering) Order(ctx *OrderingContext, pending map[common.Address]types.Transactions) []TxSet {
+	txCmp := func(tx1 *types.Transaction, tx2 *types.Transaction) int {
+		return ctx.Rates.Cmp(tx1.GasPrice(), tx1.FeeCurrency(), tx2.GasPrice(), tx2.FeeCurrency())
+	}
+	// Split the pending transactions into locals and remotes
+	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
+	for _, account := range ctx.Locals {
+		if txs := remoteTxs[account]; len(txs) > 0 {
+			delete(remoteTxs, account)
+			localTxs[account] = txs
+		}
+	}
+	var sets []TxSet
+	if len(localTxs) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(ctx.Signer, localTxs, txCmp))
+	}
+	if len(remoteTxs) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(ctx.Signer, remoteTxs, txCmp))
+	}
+	return sets
+}
+
+// SenderCapOrdering orders the transactions with another strategy, but stops
+// including the transactions of a sender once their gas limits would exceed
+// the cap. It keeps a single sender from filling the block.
+type SenderCapOrdering struct {
+	Inner TxOrderingStrategy // Strategy ordering the transactions
+	Cap   uint64             // Maximum gas of the transactions of a sender in a block
+}
+
+// Order implements TxOrderingStrategy.
+func (o *SenderCapOrdering) Order(ctx *OrderingContext, pending map[common.Address]types.Transactions) []TxSet {
+	inner := o.Inner.Order(ctx, pending)
+	used := make(map[common.Address]uint64)
+
+	sets := make([]TxSet, len(inner))
+	for i, set := range inner {
+		sets[i] = &senderCapSet{TxSet: set, signer: ctx.Signer, cap: o.Cap, used: used}
+	}
+	return sets
+}
+
+// senderCapSet wraps a transaction set, skipping the senders that reached the
+// gas cap. The gas used is shared among the sets of a block.
+type senderCapSet struct {
+	TxSet
+	signer types.Signer
+	cap    uint64
+	used   map[common.Address]uint64
+}
+
+// Peek implements TxSet, popping the senders over the cap.
+func (s *senderCapSet) Peek() *types.Transaction {
+	for {
+		tx := s.TxSet.Peek()
+		if tx == nil {
+			return nil
+		}
+		from, _ := types.Sender(s.signer, tx)
+		if s.used[from]+tx.Gas() <= s.cap {
+			return tx
+		}
+		s.TxSet.Pop()
+	}
+}
+
+// Shift implements TxSet, accounting the gas of the shifted transaction.
+func (s *senderCapSet) Shift() {
+	if tx := s.TxSet.Peek(); tx != nil {
+		from, _ := types.Sender(s.signer, tx)
+		s.used[from] += tx.Gas()
+	}
+	s.TxSet.Shift()
+}
@@ -97,7 +97,8 @@ type environment struct {
 	header     *types.Header
 	txs        []*types.Transaction
 	receipts   []*types.Receipt
-	randomness *types.Randomness // The types.Randomness of the last block by mined by this worker.
+	randomness *types.Randomness      // The types.Randomness of the last block by mined by this worker.
+	rates      *currency.RateSnapshot // Exchange rates used to order the transactions of the block
 }
 
 // task contains all information for consensus engine sealing and result submitting.
@@ -160,10 +161,11 @@ type worker struct {
 	current     *environment       // An environment for current running cycle.
 	unconfirmed *unconfirmedBlocks // A set of locally mined blocks pending canonicalness confirmations.
 
-	mu             sync.RWMutex // The lock used to protect the validator, txFeeRecipient and extra fields
+	mu             sync.RWMutex // The lock used to protect the validator, txFeeRecipient, extra and ordering fields
 	validator      common.Address
 	txFeeRecipient common.Address
 	extra          []byte
+	ordering       TxOrderingStrategy // Strategy ordering the transactions of the blocks
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
@@ -218,6 +220,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
 	worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
 
+	// Fall back to the default transaction ordering if the configured one is invalid.
+	ordering, err := NewTxOrderingStrategy(config)
+	if err != nil {
+		log.Error("Invalid transaction ordering, using the default one", "err", err)
+		ordering = PriceOrdering{}
+	}
+	worker.ordering = ordering
+
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
 	if recommit < minRecommitInterval {
@@ -258,6 +268,13 @@ func (w *worker) setExtra(extra []byte) {
 	w.extra = extra
 }
 
+// setTxOrdering sets the strategy ordering the transactions of the blocks.
+func (w *worker) setTxOrdering(ordering TxOrderingStrategy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ordering = ordering
+}
+
 // setRecommitInterval updates the interval for miner sealing work recommitting.
 func (w *worker) setRecommitInterval(interval time.Duration) {
 	w.resubmitIntervalCh <- interval
@@ -321,8 +338,16 @@ func (w *worker) close() {
 	close(w.exitCh)
 }
 
-func (w *worker) txCmp(tx1 *types.Transaction, tx2 *types.Transaction) int {
-	return currency.Cmp(tx1.GasPrice(), tx1.FeeCurrency(), tx2.GasPrice(), tx2.FeeCurrency())
+// orderingContext returns the information about the current block passed to
+// the transaction ordering strategy.
+func (w *worker) orderingContext() *OrderingContext {
+	return &OrderingContext{
+		Signer:   w.current.signer,
+		Header:   w.current.header,
+		GasLimit: w.current.gasLimit,
+		Rates:    w.current.rates,
+		Locals:   w.eth.TxPool().Locals(),
+	}
 }
 
 // newWorkLoop is a standalone goroutine to submit new mining work upon received events.
@@ -474,6 +499,7 @@ func (w *worker) mainLoop() {
 					txFeeRecipient = w.validator
 					log.Warn("TxFeeRecipient and Validator flags set before split etherbase fork is active. Defaulting to the given validator address for the coinbase.")
 				}
+				ordering := w.ordering
 				w.mu.RUnlock()
 
 				txs := make(map[common.Address]types.Transactions)
@@ -482,9 +508,10 @@ func (w *worker) mainLoop() {
 					txs[acc] = append(txs[acc], tx)
 				}
 
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs, w.txCmp)
 				tcount := w.current.tcount
-				w.commitTransactions(txset, txFeeRecipient, nil)
+				for _, txset := range ordering.Order(w.orderingContext(), txs) {
+					w.commitTransactions(txset, txFeeRecipient, nil)
+				}
 				// Only update the snapshot if any new transactons were added
 				// to the pending block
 				if tcount != w.current.tcount {
@@ -632,11 +659,12 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 	}
 
 	env := &environment{
-		signer:    types.NewEIP155Signer(w.chainConfig.ChainID),
+		signer:    types.MakeSigner(w.chainConfig, header.Number),
 		state:     state,
 		ancestors: mapset.NewSet(),
 		header:    header,
 		gasLimit:  core.CalcGasLimit(parent, state),
+		rates:     currency.NewRateSnapshot(header, state),
 	}
 
 	// when 08 is processed ancestors contain 07 (quick block)
@@ -680,7 +708,7 @@ func (w *worker) commitTransaction(tx *types.Transaction, txFeeRecipient common.
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, txFeeRecipient common.Address, interrupt *int32) bool {
+func (w *worker) commitTransactions(txs TxSet, txFeeRecipient common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
 		return true
@@ -947,22 +975,8 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		istanbulEmptyBlockCommit()
 		return
 	}
-	// Split the pending transactions into locals and remotes
-	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
-	for _, account := range w.eth.TxPool().Locals() {
-		if txs := remoteTxs[account]; len(txs) > 0 {
-			delete(remoteTxs, account)
-			localTxs[account] = txs
-		}
-	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs, w.txCmp)
-		if w.commitTransactions(txs, txFeeRecipient, interrupt) {
-			return
-		}
-	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs, w.txCmp)
+	// Commit the transaction sets in the order of the configured strategy
+	for _, txs := range w.ordering.Order(w.orderingContext(), pending) {
 		if w.commitTransactions(txs, txFeeRecipient, interrupt) {
 			return
 		}
@@ -19,6 +19,7 @@ package miner
 import (
 	"math/big"
 	"math/rand"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -248,6 +249,8 @@ func getAuthorizedIstanbulEngine() consensus.Istanbul {
 	config := istanbul.DefaultConfig
 	config.ReplicaStateDBPath = ""
 	config.RoundStateDBPath = ""
+	config.EquivocationDBPath = ""
+	config.SlashingProtectionDBPath = ""
 	config.ValidatorEnodeDBPath = ""
 	config.VersionCertificateDBPath = ""
 
@@ -517,3 +520,87 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+// emptyOrdering is a transaction ordering strategy that excludes all transactions.
+type emptyOrdering struct{}
+
+func (emptyOrdering) Order(ctx *OrderingContext, pending map[common.Address]types.Transactions) []TxSet {
+	return nil
+}
+
+// testTxOrdering checks the number of transactions included by the worker in
+// the full block, built after the empty one, with the given strategy.
+func testTxOrdering(t *testing.T, ordering TxOrderingStrategy, txs []*types.Transaction, wantTxs int) {
+	engine := mockEngine.NewFaker()
+	w, b := newTestWorker(t, params.IstanbulTestChainConfig, engine, rawdb.NewMemoryDatabase(), 0, false)
+	defer w.close()
+
+	w.setTxOrdering(ordering)
+	b.txPool.AddLocals(txs)
+
+	var (
+		taskIndex int
+		taskCh    = make(chan struct{}, 2)
+	)
+	w.newTaskHook = func(task *task) {
+		if task.block.NumberU64() == 1 && taskIndex < 2 {
+			// The first task is the empty block, the second one the full block
+			if taskIndex == 1 && len(task.receipts) != wantTxs {
+				t.Errorf("receipt number mismatch: have %d, want %d", len(task.receipts), wantTxs)
+			}
+			taskIndex += 1
+			taskCh <- struct{}{}
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	for i := 0; i < 2; i += 1 {
+		select {
+		case <-taskCh:
+		case <-time.NewTimer(3 * time.Second).C:
+			t.Fatal("new task timeout")
+		}
+	}
+}
+
+func TestTxOrderingStrategy(t *testing.T) {
+	var txs []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, testUserAddress, big.NewInt(1000), params.TxGas, nil, nil, nil, nil, nil), types.HomesteadSigner{}, testBankKey)
+		txs = append(txs, tx)
+	}
+	t.Run("price", func(t *testing.T) {
+		testTxOrdering(t, PriceOrdering{}, txs, 3)
+	})
+	t.Run("sendercap", func(t *testing.T) {
+		testTxOrdering(t, &SenderCapOrdering{Inner: PriceOrdering{}, Cap: 2 * params.TxGas}, txs, 2)
+	})
+	t.Run("custom", func(t *testing.T) {
+		testTxOrdering(t, emptyOrdering{}, txs, 0)
+	})
+}
+
+func TestNewTxOrderingStrategy(t *testing.T) {
+	tests := []struct {
+		config  Config
+		want    TxOrderingStrategy
+		wantErr bool
+	}{
+		{Config{}, PriceOrdering{}, false},
+		{Config{TxOrdering: TxOrderingPrice}, PriceOrdering{}, false},
+		{Config{TxOrdering: TxOrderingSenderCap, SenderGasCap: 100000}, &SenderCapOrdering{Inner: PriceOrdering{}, Cap: 100000}, false},
+		{Config{TxOrdering: TxOrderingSenderCap}, nil, true},
+		{Config{TxOrdering: "random"}, nil, true},
+	}
+	for i, tt := range tests {
+		ordering, err := NewTxOrderingStrategy(&tt.config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(ordering, tt.want) {
+			t.Errorf("test %d: ordering mismatch: have %#v, want %#v", i, ordering, tt.want)
+		}
+	}
+}
@@ -247,6 +247,9 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		ethConf.Istanbul.VersionCertificateDBPath = ""
 		// Use an in memory DB for roundState table
 		ethConf.Istanbul.RoundStateDBPath = ""
+		// Use an in memory DB for equivocation evidence
+		ethConf.Istanbul.EquivocationDBPath = ""
+		ethConf.Istanbul.SlashingProtectionDBPath = ""
 		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			nodeResponse.les, err = les.New(ctx, &ethConf)
 			return nodeResponse.les, err
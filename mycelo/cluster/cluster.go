@@ -10,8 +10,8 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-// Cluster represent a set of nodes (validators)
-// that are managed together
+// Cluster represent a set of nodes (validators, their proxies,
+// full nodes and light clients) that are managed together
 type Cluster struct {
 	env      *env.Environment
 	gethPath string
@@ -33,11 +33,14 @@ func New(env *env.Environment, gethPath string) *Cluster {
 func (cl *Cluster) Init() error {
 	var err error
 
-	nodes := cl.ensureNodes()
+	nodes, err := cl.ensureNodes()
+	if err != nil {
+		return err
+	}
 	enodeUrls := make([]string, len(nodes))
-	console.Info("Initializing validator nodes")
+	console.Info("Initializing nodes")
 	for i, node := range nodes {
-		console.Infof("validator-%d> geth init", i)
+		console.Infof("%s> geth init", node.Name)
 		if err := node.Init(cl.env.GenesisPath()); err != nil {
 			return err
 		}
@@ -48,11 +51,26 @@ func (cl *Cluster) Init() error {
 		}
 	}
 
-	// Connect each validator to each other
+	// Connect each public node (validators without proxies, proxies and full
+	// nodes) to each other, and the light clients to the light servers.
+	// Proxied validators connect to their proxies through the proxy flags.
 	for i, node := range nodes {
-		var urls []string
-		urls = append(urls, enodeUrls[:i]...)
-		urls = append(urls, enodeUrls[i+1:]...)
+		urls := make([]string, 0)
+		for j, other := range nodes {
+			if i == j {
+				continue
+			}
+			switch node.Kind {
+			case LightClientNode, UltralightClientNode:
+				if other.Kind == LightServerNode {
+					urls = append(urls, enodeUrls[j])
+				}
+			default:
+				if node.IsPublic() && other.IsPublic() {
+					urls = append(urls, enodeUrls[j])
+				}
+			}
+		}
 		err = node.SetStaticNodes(urls...)
 		if err != nil {
 			return err
@@ -62,45 +80,94 @@ func (cl *Cluster) Init() error {
 	return nil
 }
 
-func (cl *Cluster) ensureNodes() []*Node {
-
-	if cl.nodes == nil {
-		validators := cl.env.ValidatorAccounts()
-		cl.nodes = make([]*Node, len(validators))
-		for i, validator := range validators {
-			nodeConfig := &NodeConfig{
-				GethPath: cl.gethPath,
-				Number:   i,
-				Account:  validator,
-				Datadir:  cl.env.ValidatorDatadir(i),
-				ChainID:  cl.env.Config.ChainID,
-			}
-			cl.nodes[i] = NewNode(nodeConfig)
+func (cl *Cluster) ensureNodes() ([]*Node, error) {
+	if cl.nodes != nil {
+		return cl.nodes, nil
+	}
+
+	cfg := &cl.env.Config
+	if (cfg.LightClients > 0 || cfg.UltralightClients > 0) && cfg.LightServers == 0 {
+		return nil, fmt.Errorf("light clients require at least one light server")
+	}
+
+	var nodes []*Node
+	addNode := func(name string, kind NodeKind, account env.Account) *Node {
+		node := NewNode(&NodeConfig{
+			GethPath: cl.gethPath,
+			Number:   len(nodes),
+			Name:     name,
+			Kind:     kind,
+			Account:  account,
+			Datadir:  cl.env.NodeDatadir(name),
+			ChainID:  cfg.ChainID,
+		})
+		nodes = append(nodes, node)
+		return node
+	}
+	// Validators go first, so they keep the same ports in every topology
+	validators := cl.env.ValidatorAccounts()
+	validatorNodes := make([]*Node, len(validators))
+	for i, validator := range validators {
+		validatorNodes[i] = addNode(fmt.Sprintf("validator-%02d", i), ValidatorNode, validator)
+	}
+	for i, validator := range validatorNodes {
+		for j := 0; j < cfg.ProxiesPerValidator; j++ {
+			proxy := addNode(fmt.Sprintf("proxy-%02d-%02d", i, j), ProxyNode, env.Account{})
+			proxy.ProxiedValidator = validator.Account.Address
+			validator.Proxies = append(validator.Proxies, proxy)
+		}
+	}
+	// Full nodes and light servers share the tx node accounts
+	txNodes := cfg.FullNodes + cfg.LightServers
+	for i := 0; i < txNodes; i++ {
+		account, err := cl.env.Account(env.TxNode, i)
+		if err != nil {
+			return nil, err
+		}
+		if i < cfg.FullNodes {
+			addNode(fmt.Sprintf("fullnode-%02d", i), FullNode, *account)
+		} else {
+			addNode(fmt.Sprintf("lightserver-%02d", i-cfg.FullNodes), LightServerNode, *account)
 		}
 	}
-	return cl.nodes
+	for i := 0; i < cfg.LightClients; i++ {
+		addNode(fmt.Sprintf("light-%02d", i), LightClientNode, env.Account{})
+	}
+	for i := 0; i < cfg.UltralightClients; i++ {
+		addNode(fmt.Sprintf("ultralight-%02d", i), UltralightClientNode, env.Account{})
+	}
+
+	cl.nodes = nodes
+	return cl.nodes, nil
 }
 
 // PrintNodeInfo prints debug information about nodes
 func (cl *Cluster) PrintNodeInfo() error {
-	for i, node := range cl.ensureNodes() {
+	nodes, err := cl.ensureNodes()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
 		endoreURL, err := node.EnodeURL()
 		if err != nil {
 			return err
 		}
-		fmt.Printf("validator-%d: %s\n", i, endoreURL)
+		fmt.Printf("%s: %s\n", node.Name, endoreURL)
 	}
 	return nil
 }
 
 // Run will run all the cluster nodes
 func (cl *Cluster) Run(ctx context.Context) error {
+	nodes, err := cl.ensureNodes()
+	if err != nil {
+		return err
+	}
 	group, ctx := errgroup.WithContext(ctx)
 	log.Printf("Starting cluster")
-	for i, node := range cl.ensureNodes() {
+	for _, node := range nodes {
 		node := node
-		i := i
-		log.Printf("Starting validator%02d...", i)
+		log.Printf("Starting %s...", node.Name)
 		group.Go(func() error { return node.Run(ctx) })
 	}
 	return group.Wait()
@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	. "github.com/onsi/gomega"
+)
+
+func TestClusterTopology(t *testing.T) {
+	RegisterTestingT(t)
+
+	workdir, err := ioutil.TempDir("", "mycelo-topology-")
+	Ω(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(workdir)
+
+	e, err := env.New(workdir, &env.Config{
+		ChainID:             big.NewInt(1500),
+		Mnemonic:            env.MustNewMnemonic(),
+		InitialValidators:   2,
+		ValidatorsPerGroup:  1,
+		ProxiesPerValidator: 2,
+		FullNodes:           1,
+		LightServers:        1,
+		LightClients:        1,
+		UltralightClients:   1,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	nodes, err := New(e, "geth").ensureNodes()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var names []string
+	for i, node := range nodes {
+		Ω(node.Number).Should(Equal(i))
+		names = append(names, node.Name)
+	}
+	Ω(names).Should(Equal([]string{
+		"validator-00", "validator-01",
+		"proxy-00-00", "proxy-00-01", "proxy-01-00", "proxy-01-01",
+		"fullnode-00", "lightserver-00", "light-00", "ultralight-00",
+	}))
+
+	validator, proxy := nodes[0], nodes[2]
+	Ω(validator.Datadir).Should(Equal(e.ValidatorDatadir(0)))
+	Ω(validator.Proxies).Should(Equal([]*Node{nodes[2], nodes[3]}))
+	Ω(validator.IsPublic()).Should(BeFalse())
+	Ω(proxy.ProxiedValidator).Should(Equal(validator.Account.Address))
+	Ω(proxy.IsPublic()).Should(BeTrue())
+	Ω(nodes[6].Account.Address).ShouldNot(Equal(nodes[7].Account.Address))
+	Ω(nodes[8].IsPublic()).Should(BeFalse())
+
+	// The proxied validator connects to both endpoints of its proxies
+	for _, node := range validator.Proxies {
+		Ω(os.MkdirAll(path.Dir(node.keyFile()), os.ModePerm)).Should(Succeed())
+		Ω(node.generateNodeKey()).Should(Succeed())
+	}
+	args, err := validator.kindArgs()
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(args).Should(ContainElement("--proxy.proxied"))
+	pairs := strings.Split(args[len(args)-2], ",")
+	Ω(pairs).Should(HaveLen(2))
+	internal, err := proxy.ProxyEnodeURL()
+	Ω(err).ShouldNot(HaveOccurred())
+	external, err := proxy.EnodeURL()
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(pairs[0]).Should(Equal(internal + ";" + external))
+
+	args, err = proxy.kindArgs()
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(args).Should(ContainElement(validator.Account.Address.Hex()))
+
+	args, err = nodes[9].kindArgs()
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(args).Should(Equal([]string{"--syncmode", "lightest"}))
+
+	// Light clients can't run without light servers
+	e.Config.LightServers = 0
+	_, err = New(e, "geth").ensureNodes()
+	Ω(err).Should(HaveOccurred())
+}
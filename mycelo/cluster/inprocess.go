@@ -0,0 +1,293 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/accounts/keystore"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/consensus/istanbul"
+	istanbulBackend "github.com/celo-org/celo-blockchain/consensus/istanbul/backend"
+	"github.com/celo-org/celo-blockchain/contract_comm"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"github.com/celo-org/celo-blockchain/eth"
+	"github.com/celo-org/celo-blockchain/eth/downloader"
+	"github.com/celo-org/celo-blockchain/ethclient"
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	"github.com/celo-org/celo-blockchain/mycelo/internal/utils"
+	"github.com/celo-org/celo-blockchain/node"
+	"github.com/celo-org/celo-blockchain/p2p"
+	"github.com/celo-org/celo-blockchain/p2p/enode"
+)
+
+// peersTimeout is how long the validators have to connect to each other
+const peersTimeout = 10 * time.Second
+
+// InProcessConfig represents the configuration of an in-process cluster
+type InProcessConfig struct {
+	Genesis *core.Genesis // Genesis block of the chain (default: the environment's genesis.json)
+	IPC     bool          // Serve the validators RPC on the environment's IPC paths
+}
+
+// InProcessCluster represents a set of validators running within the current
+// process, without an external geth binary. Their chain is kept in memory, so
+// the cluster starts from the genesis block every time. The other nodes of the
+// environment's topology are not run, and the validators connect directly.
+//
+// The core contracts are called (e.g. to read the validators' BLS keys or the
+// gas price minimum) through a process-wide handler, bound to the chain of the
+// first node started in the process. All the validators use the chain of the
+// first one for those calls, which is only sound because they share the same
+// genesis. As a consequence, a process can only run a single cluster: once it
+// stops, another one can't be started.
+type InProcessCluster struct {
+	env *env.Environment
+	cfg InProcessConfig
+
+	nodes []*InProcessNode
+}
+
+// InProcessNode represents a validator of an in-process cluster
+type InProcessNode struct {
+	Number  int
+	Account env.Account
+	Node    *node.Node
+	Eth     *eth.Ethereum
+}
+
+// NewInProcess creates a new in-process cluster instance
+func NewInProcess(env *env.Environment, cfg *InProcessConfig) *InProcessCluster {
+	return &InProcessCluster{
+		env: env,
+		cfg: *cfg,
+	}
+}
+
+// Start creates and starts all the cluster validators, connects each
+// validator to each other and starts mining on them
+func (cl *InProcessCluster) Start() error {
+	genesis := cl.cfg.Genesis
+	if genesis == nil {
+		genesis = new(core.Genesis)
+		if err := utils.ReadJson(genesis, cl.env.GenesisPath()); err != nil {
+			return fmt.Errorf("can't read genesis: %w", err)
+		}
+	}
+
+	for i, validator := range cl.env.ValidatorAccounts() {
+		n, err := cl.startNode(i, validator, genesis)
+		if err != nil {
+			cl.Stop()
+			return fmt.Errorf("can't start validator-%d: %w", i, err)
+		}
+		cl.nodes = append(cl.nodes, n)
+	}
+	if err := cl.checkContractCallsChain(); err != nil {
+		cl.Stop()
+		return err
+	}
+
+	// The enodes of the validators are known in advance, instead of being announced
+	valEnodes := make(map[common.Address]*istanbul.AddressEntry)
+	for _, n := range cl.nodes {
+		// The val enode table stores enode URLs, which can't hold the full node records
+		self := n.Node.Server().Self()
+		self = enode.NewV4(self.Pubkey(), self.IP(), self.TCP(), self.UDP())
+		valEnodes[n.Account.Address] = &istanbul.AddressEntry{Address: n.Account.Address, PublicKey: self.Pubkey(), Node: self, Version: 1}
+	}
+	for _, n := range cl.nodes {
+		if err := n.Eth.Engine().(*istanbulBackend.Backend).RewriteValEnodeTableEntries(valEnodes); err != nil {
+			cl.Stop()
+			return err
+		}
+	}
+
+	// Connect each validator to each other
+	for i, n := range cl.nodes {
+		for _, peer := range cl.nodes[i+1:] {
+			n.Node.Server().AddPeer(peer.Node.Server().Self(), p2p.ExplicitStaticPurpose)
+		}
+	}
+	// Starting before the connections are established would only cause round changes
+	if err := cl.waitForPeers(); err != nil {
+		cl.Stop()
+		return err
+	}
+
+	for i, n := range cl.nodes {
+		if err := n.Eth.StartMining(1); err != nil {
+			cl.Stop()
+			return fmt.Errorf("can't start mining on validator-%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (cl *InProcessCluster) startNode(number int, validator env.Account, genesis *core.Genesis) (*InProcessNode, error) {
+	nodeConfig := &node.Config{
+		Name:              "celo",
+		UseLightweightKDF: true,
+		P2P: p2p.Config{
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			MaxPeers:    25,
+			NetworkId:   cl.env.Config.ChainID.Uint64(),
+		},
+	}
+	if cl.cfg.IPC {
+		ipcPath := cl.env.ValidatorIPC(number)
+		if err := os.MkdirAll(path.Dir(ipcPath), os.ModePerm); err != nil {
+			return nil, err
+		}
+		nodeConfig.IPCPath = ipcPath
+	}
+	stack, err := node.New(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ethConfig := eth.DefaultConfig
+	ethConfig.Genesis = genesis
+	ethConfig.NetworkId = cl.env.Config.ChainID.Uint64()
+	ethConfig.SyncMode = downloader.FullSync
+	ethConfig.Miner.Validator = validator.Address
+	ethConfig.TxFeeRecipient = validator.Address
+	ethConfig.BLSbase = validator.Address
+	ethConfig.Istanbul.Validator = true
+
+	// Without a datadir the istanbul databases are kept in memory
+	ethConfig.Istanbul.ReplicaStateDBPath = stack.ResolvePath(ethConfig.Istanbul.ReplicaStateDBPath)
+	ethConfig.Istanbul.ValidatorEnodeDBPath = stack.ResolvePath(ethConfig.Istanbul.ValidatorEnodeDBPath)
+	ethConfig.Istanbul.VersionCertificateDBPath = stack.ResolvePath(ethConfig.Istanbul.VersionCertificateDBPath)
+	ethConfig.Istanbul.RoundStateDBPath = stack.ResolvePath(ethConfig.Istanbul.RoundStateDBPath)
+	ethConfig.Istanbul.EquivocationDBPath = stack.ResolvePath(ethConfig.Istanbul.EquivocationDBPath)
+	ethConfig.Istanbul.SlashingProtectionDBPath = stack.ResolvePath(ethConfig.Istanbul.SlashingProtectionDBPath)
+
+	n := &InProcessNode{
+		Number:  number,
+		Account: validator,
+		Node:    stack,
+	}
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		n.Eth, err = eth.New(ctx, &ethConfig)
+		return n.Eth, err
+	})
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+
+	// Add the validator account, which signs the blocks and consensus messages
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, err := ks.ImportECDSA(validator.PrivateKey, "")
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		stack.Close()
+		return nil, err
+	}
+
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// checkContractCallsChain checks that the core contracts are called through the chain of a
+// cluster validator, and that all the validators run the same chain
+func (cl *InProcessCluster) checkContractCallsChain() error {
+	genesis := cl.nodes[0].Eth.BlockChain().Genesis().Hash()
+	shared := false
+	for _, n := range cl.nodes {
+		if hash := n.Eth.BlockChain().Genesis().Hash(); hash != genesis {
+			return fmt.Errorf("validator-%d has genesis %s, validator-0 has %s", n.Number, hash.Hex(), genesis.Hex())
+		}
+		shared = shared || contract_comm.InternalEVMChain() == vm.ChainContext(n.Eth.BlockChain())
+	}
+	if !shared {
+		return errors.New("core contracts are called through the chain of another running node, only one in-process cluster can run at a time per process")
+	}
+	return nil
+}
+
+// waitForPeers waits until each validator is connected to each other
+func (cl *InProcessCluster) waitForPeers() error {
+	timeout := time.After(peersTimeout)
+	for _, n := range cl.nodes {
+		for n.Node.Server().PeerCount() < len(cl.nodes)-1 {
+			select {
+			case <-timeout:
+				return fmt.Errorf("validator-%d connected to %d peers", n.Number, n.Node.Server().PeerCount())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	return nil
+}
+
+// Stop stops all the cluster validators
+func (cl *InProcessCluster) Stop() error {
+	var firstErr error
+	for _, n := range cl.nodes {
+		if err := n.Node.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	cl.nodes = nil
+	return firstErr
+}
+
+// Run will run all the cluster validators until the context is done
+func (cl *InProcessCluster) Run(ctx context.Context) error {
+	if err := cl.Start(); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return cl.Stop()
+}
+
+// Nodes returns the running validators
+func (cl *InProcessCluster) Nodes() []*InProcessNode { return cl.nodes }
+
+// Client returns a client connected to the validator-[idx] RPC
+func (cl *InProcessCluster) Client(idx int) (*ethclient.Client, error) {
+	if idx < 0 || idx >= len(cl.nodes) {
+		return nil, fmt.Errorf("validator-%d is not running", idx)
+	}
+	rpcClient, err := cl.nodes[idx].Node.Attach()
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// WaitForBlock waits until all the validators have imported the block number
+func (cl *InProcessCluster) WaitForBlock(ctx context.Context, number uint64) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		synced := true
+		for _, n := range cl.nodes {
+			if n.Eth.BlockChain().CurrentBlock().NumberU64() < number {
+				synced = false
+				break
+			}
+		}
+		if synced {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
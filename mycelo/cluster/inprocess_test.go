@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/accounts/abi"
+	bind "github.com/celo-org/celo-blockchain/accounts/abi/bind_v2"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/types"
+	blscrypto "github.com/celo-org/celo-blockchain/crypto/bls"
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	"github.com/celo-org/celo-blockchain/mycelo/genesis"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+	. "github.com/onsi/gomega"
+)
+
+// testGenesis creates a genesis block for the environment validators which
+// funds the developer accounts, without the core contracts (which require
+// the monorepo build)
+func testGenesis(e *env.Environment) *core.Genesis {
+	cfg := genesis.BaseConfig()
+	cfg.ChainID = e.Config.ChainID
+	cfg.Istanbul = params.IstanbulConfig{
+		Epoch:          100,
+		ProposerPolicy: 2,
+		LookbackWindow: 3,
+		BlockPeriod:    1,
+		RequestTimeout: 3000,
+	}
+
+	validators := e.ValidatorAccounts()
+	istExtra := types.IstanbulExtra{
+		AddedValidators:           make([]common.Address, len(validators)),
+		AddedValidatorsPublicKeys: make([]blscrypto.SerializedPublicKey, len(validators)),
+		RemovedValidators:         big.NewInt(0),
+		Seal:                      []byte{},
+	}
+	for i, validator := range validators {
+		blsKey, err := validator.BLSPublicKey()
+		Ω(err).ShouldNot(HaveOccurred())
+		istExtra.AddedValidators[i] = validator.Address
+		istExtra.AddedValidatorsPublicKeys[i] = blsKey
+	}
+	payload, err := rlp.EncodeToBytes(&istExtra)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	alloc := make(core.GenesisAlloc)
+	for _, dev := range e.DeveloperAccounts() {
+		alloc[dev.Address] = core.GenesisAccount{Balance: common.MustBigInt("1000000000000000000000")}
+	}
+	return &core.Genesis{
+		Config:    cfg.ChainConfig(),
+		ExtraData: append(make([]byte, types.IstanbulExtraVanity), payload...),
+		Timestamp: uint64(time.Now().Unix()),
+		Alloc:     alloc,
+	}
+}
+
+func TestInProcessCluster(t *testing.T) {
+	RegisterTestingT(t)
+
+	workdir, err := ioutil.TempDir("", "mycelo-inprocess-")
+	Ω(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(workdir)
+
+	e, err := env.New(workdir, &env.Config{
+		ChainID:            big.NewInt(1500),
+		Mnemonic:           env.MustNewMnemonic(),
+		InitialValidators:  4,
+		ValidatorsPerGroup: 1,
+		DeveloperAccounts:  1,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	cl := NewInProcess(e, &InProcessConfig{Genesis: testGenesis(e)})
+	Ω(cl.Start()).Should(Succeed())
+	defer cl.Stop()
+	Ω(cl.Nodes()).Should(HaveLen(4))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	Ω(cl.WaitForBlock(ctx, 2)).Should(Succeed())
+
+	// Send a transfer through the first validator and check it through the last one
+	sender, recipient := e.DeveloperAccounts()[0], env.MustGenerateRandomAccount()
+	client, err := cl.Client(0)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer client.Close()
+
+	transactor := bind.NewKeyedTransactor(sender.PrivateKey)
+	transactor.Context = ctx
+	transactor.Value = big.NewInt(1000)
+	transactor.GasLimit = params.TxGas
+	tx, err := bind.NewBoundContract(recipient.Address, abi.ABI{}, client).Transfer(transactor)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(receipt.Status).Should(Equal(types.ReceiptStatusSuccessful))
+	Ω(cl.WaitForBlock(ctx, receipt.BlockNumber.Uint64())).Should(Succeed())
+
+	other, err := cl.Client(3)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer other.Close()
+	balance, err := other.BalanceAt(ctx, recipient.Address, receipt.BlockNumber)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(balance).Should(Equal(big.NewInt(1000)))
+
+	// Once stopped, another cluster can be started in the process, but only one can run at a time
+	Ω(cl.Stop()).Should(Succeed())
+	next := NewInProcess(e, &InProcessConfig{Genesis: testGenesis(e)})
+	Ω(next.Start()).Should(Succeed())
+	defer next.Stop()
+	Ω(next.WaitForBlock(ctx, 2)).Should(Succeed())
+
+	concurrent := NewInProcess(e, &InProcessConfig{Genesis: testGenesis(e)})
+	Ω(concurrent.Start()).Should(MatchError(ContainSubstring("only one in-process cluster can run at a time")))
+	Ω(concurrent.Nodes()).Should(BeEmpty())
+}
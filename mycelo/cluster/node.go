@@ -23,14 +23,32 @@ import (
 	"github.com/celo-org/celo-blockchain/common"
 )
 
+// NodeKind is the role of a node within the cluster
+type NodeKind string
+
+// Kinds of nodes
+const (
+	ValidatorNode        NodeKind = "validator"   // Validators, mining blocks
+	ProxyNode            NodeKind = "proxy"       // Proxies, connecting a validator to the network
+	FullNode             NodeKind = "fullnode"    // Non validating full nodes
+	LightServerNode      NodeKind = "lightserver" // Full nodes serving light clients
+	LightClientNode      NodeKind = "light"       // Light clients
+	UltralightClientNode NodeKind = "ultralight"  // Ultralight clients
+)
+
 // NodeConfig represents the configuration of a celo-blockchain node runner
 type NodeConfig struct {
 	GethPath      string
 	ChainID       *big.Int
 	Number        int
+	Name          string   // Name of the node (e.g. validator-00)
+	Kind          NodeKind // Role of the node (default: validator)
 	Account       env.Account
 	OtherAccounts []env.Account
 	Datadir       string
+
+	Proxies          []*Node        // Proxies of a validator
+	ProxiedValidator common.Address // Validator of a proxy
 }
 
 // RPCPort is the rpc port this node will use
@@ -43,6 +61,24 @@ func (nc *NodeConfig) NodePort() int64 {
 	return int64(30303 + nc.Number)
 }
 
+// ProxyPort is the port a proxy will use for its validator
+func (nc *NodeConfig) ProxyPort() int64 {
+	return int64(30503 + nc.Number)
+}
+
+// IsPublic returns whether the node connects to the nodes of the network, as
+// opposed to proxied validators and light clients
+func (nc *NodeConfig) IsPublic() bool {
+	switch nc.Kind {
+	case ValidatorNode, "":
+		return len(nc.Proxies) == 0
+	case ProxyNode, FullNode, LightServerNode:
+		return true
+	default:
+		return false
+	}
+}
+
 // Node represents a Node runner
 type Node struct {
 	*NodeConfig
@@ -73,15 +109,42 @@ func (n *Node) SetStaticNodes(enodeUrls ...string) error {
 
 // EnodeURL returns the enode url used by the node
 func (n *Node) EnodeURL() (string, error) {
+	return n.enodeURL(n.NodePort())
+}
+
+// ProxyEnodeURL returns the enode url a proxy uses for its validator
+func (n *Node) ProxyEnodeURL() (string, error) {
+	return n.enodeURL(n.ProxyPort())
+}
+
+func (n *Node) enodeURL(port int64) (string, error) {
 	nodekey, err := crypto.LoadECDSA(n.keyFile())
 	if err != nil {
 		return "", err
 	}
 	ip := net.IP{127, 0, 0, 1}
-	en := enode.NewV4(&nodekey.PublicKey, ip, int(n.NodePort()), int(n.NodePort()))
+	en := enode.NewV4(&nodekey.PublicKey, ip, int(port), int(port))
 	return en.URLv4(), nil
 }
 
+// proxyEnodeURLPairs returns the internal and external enode urls of the
+// validator's proxies, in the format of --proxy.proxyenodeurlpairs
+func (n *Node) proxyEnodeURLPairs() (string, error) {
+	pairs := make([]string, len(n.Proxies))
+	for i, proxy := range n.Proxies {
+		internal, err := proxy.ProxyEnodeURL()
+		if err != nil {
+			return "", err
+		}
+		external, err := proxy.EnodeURL()
+		if err != nil {
+			return "", err
+		}
+		pairs[i] = internal + ";" + external
+	}
+	return strings.Join(pairs, ","), nil
+}
+
 // AccountAddresses retrieves the list of accounts currently configured in the node
 func (n *Node) AccountAddresses() []common.Address {
 	ks := keystore.NewKeyStore(path.Join(n.Datadir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
@@ -118,8 +181,10 @@ func (n *Node) Init(GenesisJSON string) error {
 
 	// Add Accounts
 	ks := keystore.NewKeyStore(path.Join(n.Datadir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
-	if _, err := ks.ImportECDSA(n.Account.PrivateKey, ""); err != nil {
-		return err
+	if n.Account.PrivateKey != nil {
+		if _, err := ks.ImportECDSA(n.Account.PrivateKey, ""); err != nil {
+			return err
+		}
 	}
 	for _, acc := range n.OtherAccounts {
 		if _, err := ks.ImportECDSA(acc.PrivateKey, ""); err != nil {
@@ -143,19 +208,10 @@ func (n *Node) generateNodeKey() error {
 
 // Run will run the node
 func (n *Node) Run(ctx context.Context) error {
-
-	var addressToUnlock string
-	for _, addr := range n.AccountAddresses() {
-		addressToUnlock += "," + addr.Hex()
-	}
-
 	args := []string{
 		"--datadir", n.Datadir,
 		"--verbosity", "4",
 		"--networkid", n.ChainID.String(),
-		"--syncmode", "full",
-		"--mine",
-		"--allow-insecure-unlock",
 		"--nodiscover",
 		"--nat", "extip:127.0.0.1",
 		"--port", strconv.FormatInt(n.NodePort(), 10),
@@ -164,9 +220,23 @@ func (n *Node) Run(ctx context.Context) error {
 		"--rpcport", strconv.FormatInt(n.RPCPort(), 10),
 		"--rpcapi", "eth,net,web3,debug,admin,personal",
 		// "--nodiscover", "--nousb ",
-		"--etherbase", n.Account.Address.Hex(),
-		"--unlock", addressToUnlock,
-		"--password", n.pwdFile(),
+	}
+	kindArgs, err := n.kindArgs()
+	if err != nil {
+		return err
+	}
+	args = append(args, kindArgs...)
+
+	var addressToUnlock string
+	for _, addr := range n.AccountAddresses() {
+		addressToUnlock += "," + addr.Hex()
+	}
+	if addressToUnlock != "" {
+		args = append(args,
+			"--allow-insecure-unlock",
+			"--unlock", addressToUnlock,
+			"--password", n.pwdFile(),
+		)
 	}
 	cmd := exec.Command(n.GethPath, args...) // #nosec G204
 
@@ -201,6 +271,55 @@ func (n *Node) Run(ctx context.Context) error {
 	return cmd.Wait()
 }
 
+// kindArgs returns the geth flags of the node's role
+func (n *Node) kindArgs() ([]string, error) {
+	switch n.Kind {
+	case ValidatorNode, "":
+		args := []string{
+			"--syncmode", "full",
+			"--mine",
+			"--etherbase", n.Account.Address.Hex(),
+		}
+		if len(n.Proxies) > 0 {
+			pairs, err := n.proxyEnodeURLPairs()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args,
+				"--proxy.proxied",
+				"--proxy.proxyenodeurlpairs", pairs,
+				"--proxy.allowprivateip",
+			)
+		}
+		return args, nil
+	case ProxyNode:
+		return []string{
+			"--syncmode", "full",
+			"--proxy.proxy",
+			"--proxy.proxiedvalidatoraddress", n.ProxiedValidator.Hex(),
+			"--proxy.internalendpoint", "127.0.0.1:" + strconv.FormatInt(n.ProxyPort(), 10),
+		}, nil
+	case FullNode:
+		return []string{
+			"--syncmode", "full",
+			"--etherbase", n.Account.Address.Hex(),
+		}, nil
+	case LightServerNode:
+		return []string{
+			"--syncmode", "full",
+			"--etherbase", n.Account.Address.Hex(),
+			"--light.serve", "90",
+			"--light.maxpeers", "10",
+		}, nil
+	case LightClientNode:
+		return []string{"--syncmode", "light"}, nil
+	case UltralightClientNode:
+		return []string{"--syncmode", "lightest"}, nil
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", n.Kind)
+	}
+}
+
 func (n *Node) pwdFile() string         { return path.Join(n.Datadir, "password") }
 func (n *Node) logFile() string         { return path.Join(n.Datadir, "geth.log") }
 func (n *Node) keyFile() string         { return path.Join(n.Datadir, "celo/nodekey") }
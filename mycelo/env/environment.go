@@ -95,6 +95,12 @@ func (env *Environment) ValidatorDatadir(idx int) string { return env.paths.vali
 // ValidatorIPC returns the ipc path to validator-[idx]
 func (env *Environment) ValidatorIPC(idx int) string { return env.paths.validatorIPC(idx) }
 
+// NodeDatadir returns the datadir that mycelo uses to run the node with the name
+func (env *Environment) NodeDatadir(name string) string { return env.paths.nodeDatadir(name) }
+
+// NodeIPC returns the ipc path to the node with the name
+func (env *Environment) NodeIPC(name string) string { return env.paths.nodeIPC(name) }
+
 // IPC returns the IPC path to the first validator
 func (env *Environment) IPC() string { return env.paths.validatorIPC(0) }
 
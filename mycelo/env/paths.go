@@ -19,9 +19,17 @@ func (p paths) envJSON() string {
 }
 
 func (p paths) validatorDatadir(idx int) string {
-	return path.Join(p.Workdir, fmt.Sprintf("validator-%02d", idx))
+	return p.nodeDatadir(fmt.Sprintf("validator-%02d", idx))
 }
 
 func (p paths) validatorIPC(idx int) string {
-	return path.Join(p.Workdir, fmt.Sprintf("validator-%02d/geth.ipc", idx))
+	return p.nodeIPC(fmt.Sprintf("validator-%02d", idx))
+}
+
+func (p paths) nodeDatadir(name string) string {
+	return path.Join(p.Workdir, name)
+}
+
+func (p paths) nodeIPC(name string) string {
+	return path.Join(p.Workdir, name, "geth.ipc")
 }
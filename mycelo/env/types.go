@@ -17,4 +17,11 @@ type Config struct {
 	InitialValidators  int      `json:"initialValidators"`  // Number of initial validators
 	ValidatorsPerGroup int      `json:"validatorsPerGroup"` // Number of validators per group in the initial set
 	DeveloperAccounts  int      `json:"developerAccounts"`  // Number of developers accounts
+
+	// Topology of the nodes run along the validators
+	ProxiesPerValidator int `json:"proxiesPerValidator,omitempty"` // Number of proxies in front of each validator
+	FullNodes           int `json:"fullNodes,omitempty"`           // Number of non validating full nodes
+	LightServers        int `json:"lightServers,omitempty"`        // Number of full nodes serving light clients
+	LightClients        int `json:"lightClients,omitempty"`        // Number of light clients
+	UltralightClients   int `json:"ultralightClients,omitempty"`   // Number of ultralight clients
 }
@@ -75,13 +75,15 @@ func (cfg *Config) ChainConfig() *params.ChainConfig {
 
 		ChurritoBlock: cfg.Hardforks.ChurritoBlock,
 		DonutBlock:    cfg.Hardforks.DonutBlock,
+		EspressoBlock: cfg.Hardforks.EspressoBlock,
 
 		Istanbul: &params.IstanbulConfig{
-			Epoch:          cfg.Istanbul.Epoch,
-			ProposerPolicy: cfg.Istanbul.ProposerPolicy,
-			LookbackWindow: cfg.Istanbul.LookbackWindow,
-			BlockPeriod:    cfg.Istanbul.BlockPeriod,
-			RequestTimeout: cfg.Istanbul.RequestTimeout,
+			Epoch:            cfg.Istanbul.Epoch,
+			ProposerPolicy:   cfg.Istanbul.ProposerPolicy,
+			ProposerSelector: cfg.Istanbul.ProposerSelector,
+			LookbackWindow:   cfg.Istanbul.LookbackWindow,
+			BlockPeriod:      cfg.Istanbul.BlockPeriod,
+			RequestTimeout:   cfg.Istanbul.RequestTimeout,
 		},
 	}
 }
@@ -90,6 +92,7 @@ func (cfg *Config) ChainConfig() *params.ChainConfig {
 type HardforkConfig struct {
 	ChurritoBlock *big.Int `json:"churritoBlock"`
 	DonutBlock    *big.Int `json:"donutBlock"`
+	EspressoBlock *big.Int `json:"espressoBlock"`
 }
 
 // MultiSigParameters are the initial configuration parameters for a MultiSig contract
@@ -0,0 +1,393 @@
+package genesis
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/crypto"
+	"github.com/celo-org/celo-blockchain/ethdb"
+	"github.com/celo-org/celo-blockchain/mycelo/contract"
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	"github.com/celo-org/celo-blockchain/params"
+	"github.com/celo-org/celo-blockchain/rlp"
+	"github.com/celo-org/celo-blockchain/trie"
+)
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// ownedContracts are the core contracts whose ownership is transferred to the
+// admin account when replacing the owners of a forked chain
+var ownedContracts = []string{
+	"Accounts",
+	"Attestations",
+	"BlockchainParameters",
+	"DoubleSigningSlasher",
+	"DowntimeSlasher",
+	"Election",
+	"EpochRewards",
+	"Escrow",
+	"Exchange",
+	"FeeCurrencyWhitelist",
+	"Freezer",
+	"GasPriceMinimum",
+	"GoldToken",
+	"Governance",
+	"GovernanceSlasher",
+	"LockedGold",
+	"Random",
+	"Reserve",
+	"SortedOracles",
+	"StableToken",
+	"TransferWhitelist",
+	"Validators",
+}
+
+// multiSigContracts are the multisigs whose owners are replaced in a forked chain
+var multiSigContracts = []string{
+	"ReserveSpenderMultiSig",
+	"GovernanceApproverMultiSig",
+}
+
+// GenerateGenesisFromChain will create a new genesis block with the state of an existing
+// celo chain at the given block, validated by the new validators.
+// If owners are given, the admin account becomes the owner of the core contracts, and
+// the owners replace the owners of the multisigs (including the governance approver).
+func GenerateGenesisFromChain(db ethdb.Database, number uint64, admin env.Account, validators []env.Account, owners []env.Account, cfg *Config) (*core.Genesis, error) {
+
+	extraData, err := generateGenesisExtraData(validators)
+	if err != nil {
+		return nil, err
+	}
+
+	statedb, err := readChainState(db, number)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisAlloc, err := newDeployment(cfg, admin, statedb, "").fork(owners)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.Genesis{
+		Config:    cfg.ChainConfig(),
+		ExtraData: extraData,
+		Coinbase:  admin.Address,
+		Timestamp: cfg.GenesisTimestamp,
+		Alloc:     genesisAlloc,
+	}, nil
+}
+
+// readChainState copies the state of a canonical block into an in-memory statedb.
+// The addresses and storage keys are recovered from the preimages of the chain's
+// database, so the state must have been written by a full sync.
+func readChainState(db ethdb.Database, number uint64) (*state.StateDB, error) {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	header := rawdb.ReadHeader(db, hash, number)
+	if header == nil {
+		return nil, fmt.Errorf("header of block %d not found", number)
+	}
+
+	source := state.NewDatabase(db)
+	accounts, err := source.OpenTrie(header.Root)
+	if err != nil {
+		return nil, fmt.Errorf("state of block %d not available: %w", number, err)
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	it := trie.NewIterator(accounts.NodeIterator(nil))
+	for it.Next() {
+		var data state.Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		preimage := accounts.GetKey(it.Key)
+		if preimage == nil {
+			return nil, fmt.Errorf("missing preimage of account hash %x", it.Key)
+		}
+		address, addrHash := common.BytesToAddress(preimage), common.BytesToHash(it.Key)
+
+		statedb.SetBalance(address, data.Balance)
+		statedb.SetNonce(address, data.Nonce)
+		if codeHash := common.BytesToHash(data.CodeHash); codeHash != emptyCodeHash {
+			code, err := source.ContractCode(addrHash, codeHash)
+			if err != nil {
+				return nil, fmt.Errorf("code of %s not available: %w", address.Hex(), err)
+			}
+			statedb.SetCode(address, code)
+		}
+		if data.Root == types.EmptyRootHash {
+			continue
+		}
+
+		storage, err := source.OpenStorageTrie(addrHash, data.Root)
+		if err != nil {
+			return nil, fmt.Errorf("storage of %s not available: %w", address.Hex(), err)
+		}
+		storageIt := trie.NewIterator(storage.NodeIterator(nil))
+		for storageIt.Next() {
+			key := storage.GetKey(storageIt.Key)
+			if key == nil {
+				return nil, fmt.Errorf("missing preimage of %s storage key hash %x", address.Hex(), storageIt.Key)
+			}
+			_, value, _, err := rlp.Split(storageIt.Value)
+			if err != nil {
+				return nil, err
+			}
+			statedb.SetState(address, common.BytesToHash(key), common.BytesToHash(value))
+		}
+		if storageIt.Err != nil {
+			return nil, fmt.Errorf("storage of %s not available: %w", address.Hex(), storageIt.Err)
+		}
+	}
+	if it.Err != nil {
+		return nil, fmt.Errorf("state of block %d not available: %w", number, it.Err)
+	}
+
+	return statedb, nil
+}
+
+// fork rewrites the state of a forked chain to run it with the new validators
+func (ctx *deployContext) fork(owners []env.Account) (core.GenesisAlloc, error) {
+	if len(ctx.statedb.GetCode(params.RegistrySmartContractAddress)) == 0 {
+		return nil, fmt.Errorf("no registry found at %s, the chain is not a celo chain", params.RegistrySmartContractAddress.Hex())
+	}
+
+	ctx.fundAdminAccount()
+
+	forkSteps := [](func() error){
+		ctx.keepValidatorSet,
+		ctx.fundInitialBalances,
+	}
+	if len(owners) > 0 {
+		forkSteps = append(forkSteps,
+			ctx.replaceContractOwners,
+			func() error { return ctx.replaceMultiSigOwners(owners) },
+		)
+	}
+
+	for i, step := range forkSteps {
+		ctx.logger.Info("Running fork step", "number", i)
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Flush Changes
+	if _, err := ctx.statedb.Commit(true); err != nil {
+		return nil, err
+	}
+	ctx.statedb.IntermediateRoot(true)
+
+	return dumpGenesisAlloc(ctx.statedb), nil
+}
+
+// keepValidatorSet prevents the election of the forked chain's validators at
+// the end of the epochs, by requiring more validators than the registered
+// ones. The election then fails, and the genesis validators keep validating,
+// as with a fresh genesis.
+func (ctx *deployContext) keepValidatorSet() error {
+	validators, err := ctx.registryContract("Validators")
+	if err != nil {
+		return err
+	}
+	var registered []common.Address
+	if _, err := validators.Query(&registered, "getRegisteredValidators"); err != nil {
+		return err
+	}
+
+	election, err := ctx.registryContract("Election")
+	if err != nil {
+		return err
+	}
+	owner, err := ctx.ownerOf(election)
+	if err != nil {
+		return err
+	}
+	electable := big.NewInt(int64(len(registered) + 1))
+	ctx.logger.Info("Setting electable validators", "min", electable, "max", electable)
+	return ctx.callAs(owner, election, "setElectableValidators", electable, electable)
+}
+
+// fundInitialBalances adds the genesis config initial balances to the forked ones
+func (ctx *deployContext) fundInitialBalances() error {
+	for _, bal := range ctx.GenesisConfig.GoldToken.InitialBalances {
+		ctx.statedb.AddBalance(bal.Account, bal.Amount)
+	}
+	if len(ctx.GenesisConfig.StableToken.InitialBalances) == 0 {
+		return nil
+	}
+
+	// Only the exchange (among other core contracts) can mint stable tokens
+	stableToken, err := ctx.registryContract("StableToken")
+	if err != nil {
+		return err
+	}
+	exchange, err := ctx.registryAddressFor("Exchange")
+	if err != nil {
+		return err
+	}
+	for _, bal := range ctx.GenesisConfig.StableToken.InitialBalances {
+		if err := ctx.callAs(exchange, stableToken, "mint", bal.Account, bal.Amount); err != nil {
+			return fmt.Errorf("can't mint StableToken for %s: %w", bal.Account.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// replaceContractOwners makes the admin account the owner of the core contracts and their proxies
+func (ctx *deployContext) replaceContractOwners() error {
+	registry := contract.CoreContract(ctx.runtimeConfig, "Registry", params.RegistrySmartContractAddress)
+	if err := ctx.transferOwnership("Registry", registry); err != nil {
+		return err
+	}
+
+	for _, name := range ownedContracts {
+		address, err := ctx.registryAddressFor(name)
+		if err != nil {
+			return err
+		}
+		if address == common.ZeroAddress {
+			ctx.logger.Warn("Contract not found in registry", "contract", name)
+			continue
+		}
+		if err := ctx.transferOwnership(name, contract.CoreContract(ctx.runtimeConfig, name, address)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctx *deployContext) transferOwnership(name string, c *contract.EVMBackend) error {
+	owner, err := ctx.ownerOf(c)
+	if err != nil {
+		return fmt.Errorf("can't read %s owner: %w", name, err)
+	}
+	ctx.logger.Info("Transfer ownership", "contract", name, "owner", owner.Hex())
+	if err := ctx.callAs(owner, c, "transferOwnership", ctx.adminAccount.Address); err != nil {
+		return fmt.Errorf("can't transfer %s ownership: %w", name, err)
+	}
+	ctx.statedb.SetState(c.Address, proxyOwnerStorageLocation, ctx.adminAccount.Address.Hash())
+	return nil
+}
+
+// replaceMultiSigOwners replaces the owners of the multisigs, and lets any of them
+// confirm the transactions
+func (ctx *deployContext) replaceMultiSigOwners(owners []env.Account) error {
+	var multiSigs []common.Address
+	for _, name := range multiSigContracts {
+		address, err := ctx.registryAddressFor(name)
+		if err != nil {
+			return err
+		}
+		if address != common.ZeroAddress {
+			multiSigs = append(multiSigs, address)
+		}
+	}
+
+	// The governance approver is usually a multisig, even if it isn't registered.
+	// Mycelo chains have no governance.
+	governance, err := ctx.registryAddressFor("Governance")
+	if err != nil {
+		return err
+	}
+	if governance != common.ZeroAddress {
+		var approver common.Address
+		if _, err := contract.CoreContract(ctx.runtimeConfig, "Governance", governance).Query(&approver, "approver"); err != nil {
+			return err
+		}
+		if len(ctx.statedb.GetCode(approver)) > 0 {
+			multiSigs = append(multiSigs, approver)
+		}
+	}
+
+	done := make(map[common.Address]bool)
+	for _, address := range multiSigs {
+		if done[address] {
+			continue
+		}
+		done[address] = true
+		if err := ctx.replaceOwnersOf(contract.CoreContract(ctx.runtimeConfig, "ReserveSpenderMultiSig", address), owners); err != nil {
+			return fmt.Errorf("can't replace multisig %s owners: %w", address.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func (ctx *deployContext) replaceOwnersOf(multiSig *contract.EVMBackend, owners []env.Account) error {
+	var current []common.Address
+	if _, err := multiSig.Query(&current, "getOwners"); err != nil {
+		return err
+	}
+	ctx.logger.Info("Replace multisig owners", "multisig", multiSig.Address.Hex(), "owners", len(current))
+
+	// The multisig only accepts changes from itself
+	isOwner := make(map[common.Address]bool)
+	for _, owner := range owners {
+		isOwner[owner.Address] = true
+		if err := ctx.callAs(multiSig.Address, multiSig, "addOwner", owner.Address); err != nil {
+			return err
+		}
+	}
+	for _, owner := range current {
+		if isOwner[owner] {
+			continue
+		}
+		if err := ctx.callAs(multiSig.Address, multiSig, "removeOwner", owner); err != nil {
+			return err
+		}
+	}
+	if err := ctx.callAs(multiSig.Address, multiSig, "changeRequirement", big.NewInt(1)); err != nil {
+		return err
+	}
+	// Older multisigs don't have an internal requirement
+	if err := ctx.callAs(multiSig.Address, multiSig, "changeInternalRequirement", big.NewInt(1)); err != nil {
+		ctx.logger.Warn("Can't change multisig internal requirement", "multisig", multiSig.Address.Hex(), "err", err)
+	}
+	return nil
+}
+
+// registryAddressFor returns the address of a contract in the forked chain's registry
+func (ctx *deployContext) registryAddressFor(name string) (common.Address, error) {
+	var address common.Address
+	registry := contract.CoreContract(ctx.runtimeConfig, "Registry", params.RegistrySmartContractAddress)
+	if _, err := registry.Query(&address, "getAddressForString", name); err != nil {
+		return common.ZeroAddress, fmt.Errorf("can't read %s address from registry: %w", name, err)
+	}
+	return address, nil
+}
+
+func (ctx *deployContext) registryContract(name string) (*contract.EVMBackend, error) {
+	address, err := ctx.registryAddressFor(name)
+	if err != nil {
+		return nil, err
+	}
+	if address == common.ZeroAddress {
+		return nil, fmt.Errorf("%s not found in registry", name)
+	}
+	return contract.CoreContract(ctx.runtimeConfig, name, address), nil
+}
+
+func (ctx *deployContext) ownerOf(c *contract.EVMBackend) (common.Address, error) {
+	var owner common.Address
+	_, err := c.Query(&owner, "owner")
+	return owner, err
+}
+
+// callAs makes an evm call with the sender as origin. As no transaction is
+// signed, the calls can be made on behalf of any account or contract.
+func (ctx *deployContext) callAs(sender common.Address, c *contract.EVMBackend, method string, args ...interface{}) error {
+	origin := ctx.runtimeConfig.Origin
+	ctx.runtimeConfig.Origin = sender
+	defer func() { ctx.runtimeConfig.Origin = origin }()
+
+	return c.SimpleCall(method, args...)
+}
@@ -0,0 +1,148 @@
+package genesis
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/internal/fileutils"
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	"github.com/celo-org/celo-blockchain/params"
+	. "github.com/onsi/gomega"
+)
+
+func TestReadChainState(t *testing.T) {
+	RegisterTestingT(t)
+
+	var (
+		user     = common.HexToAddress("0x1000")
+		contract = common.HexToAddress("0x2000")
+		slot     = common.HexToHash("0x01")
+	)
+	db := rawdb.NewMemoryDatabase()
+	(&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			user: {Balance: big.NewInt(1000), Nonce: 3},
+			contract: {
+				Balance: big.NewInt(0),
+				Code:    []byte{0x60, 0x00},
+				Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x2a")},
+			},
+		},
+	}).MustCommit(db)
+
+	statedb, err := readChainState(db, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(statedb.GetBalance(user)).Should(Equal(big.NewInt(1000)))
+	Ω(statedb.GetNonce(user)).Should(Equal(uint64(3)))
+	Ω(statedb.GetCode(contract)).Should(Equal([]byte{0x60, 0x00}))
+	Ω(statedb.GetState(contract, slot)).Should(Equal(common.HexToHash("0x2a")))
+
+	_, err = readChainState(db, 1)
+	Ω(err).Should(MatchError("block 1 not found"))
+
+	// Only celo chains, with core contracts, can be forked
+	admin := env.MustGenerateRandomAccount()
+	_, err = GenerateGenesisFromChain(db, 0, admin, nil, nil, BaseConfig())
+	Ω(err).Should(MatchError(ContainSubstring("not a celo chain")))
+}
+
+func TestGenerateGenesisFromChain(t *testing.T) {
+	RegisterTestingT(t)
+
+	buildPath := filepath.Join(os.Getenv("CELO_MONOREPO"), "packages/protocol/build/contracts")
+	if !fileutils.FileExists(buildPath) {
+		t.Skip("the core contracts build is required, set CELO_MONOREPO to run this test")
+	}
+
+	var (
+		admin     = env.MustGenerateRandomAccount()
+		validator = env.MustGenerateRandomAccount()
+		user      = env.MustGenerateRandomAccount()
+		signers   = []common.Address{admin.Address, env.MustGenerateRandomAccount().Address}
+	)
+	cfg := BaseConfig()
+	cfg.ChainID = big.NewInt(1500)
+	cfg.ReserveSpenderMultiSig = MultiSigParameters{Signatories: signers, NumRequiredConfirmations: 2, NumInternalRequiredConfirmations: 2}
+	cfg.GovernanceApproverMultiSig = cfg.ReserveSpenderMultiSig
+	cfg.GoldToken.InitialBalances = BalanceList{{Account: user.Address, Amount: big.NewInt(1000)}}
+
+	chain, err := GenerateGenesis(admin, []env.Account{validator}, cfg, buildPath)
+	Ω(err).ShouldNot(HaveOccurred())
+	db := rawdb.NewMemoryDatabase()
+	chain.MustCommit(db)
+
+	var (
+		newAdmin     = env.MustGenerateRandomAccount()
+		newValidator = env.MustGenerateRandomAccount()
+		owners       = []env.Account{env.MustGenerateRandomAccount(), env.MustGenerateRandomAccount()}
+	)
+	forkCfg := BaseConfig()
+	forkCfg.ChainID = big.NewInt(1501)
+	forkCfg.GoldToken.InitialBalances = BalanceList{{Account: user.Address, Amount: big.NewInt(500)}}
+	forkCfg.StableToken.InitialBalances = BalanceList{{Account: user.Address, Amount: big.NewInt(200)}}
+
+	fork, err := GenerateGenesisFromChain(db, 0, newAdmin, []env.Account{newValidator}, owners, forkCfg)
+	Ω(err).ShouldNot(HaveOccurred())
+	forkDb := rawdb.NewMemoryDatabase()
+	fork.MustCommit(forkDb)
+	statedb, err := readChainState(forkDb, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+	ctx := newDeployment(forkCfg, newAdmin, statedb, "")
+
+	// keepValidatorSet: one more validator than the registered ones is required
+	var registered []common.Address
+	validators, err := ctx.registryContract("Validators")
+	Ω(err).ShouldNot(HaveOccurred())
+	_, err = validators.Query(&registered, "getRegisteredValidators")
+	Ω(err).ShouldNot(HaveOccurred())
+	var electable [2]*big.Int
+	election, err := ctx.registryContract("Election")
+	Ω(err).ShouldNot(HaveOccurred())
+	_, err = election.Query(&electable, "getElectableValidators")
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(electable[0].Int64()).Should(Equal(int64(len(registered) + 1)))
+	Ω(electable[1].Int64()).Should(Equal(int64(len(registered) + 1)))
+
+	// fundInitialBalances: the fork balances are added to the forked ones
+	Ω(statedb.GetBalance(user.Address)).Should(Equal(big.NewInt(1500)))
+	var stableBalance *big.Int
+	stableToken, err := ctx.registryContract("StableToken")
+	Ω(err).ShouldNot(HaveOccurred())
+	_, err = stableToken.Query(&stableBalance, "balanceOf", user.Address)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(stableBalance).Should(Equal(big.NewInt(200)))
+
+	// replaceContractOwners: the new admin owns the core contracts and their proxies
+	for _, name := range []string{"Election", "Exchange", "GoldToken", "StableToken", "Validators"} {
+		c, err := ctx.registryContract(name)
+		Ω(err).ShouldNot(HaveOccurred())
+		owner, err := ctx.ownerOf(c)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(owner).Should(Equal(newAdmin.Address), name)
+		Ω(statedb.GetState(c.Address, proxyOwnerStorageLocation)).Should(Equal(newAdmin.Address.Hash()), name)
+	}
+
+	// replaceMultiSigOwners: the owners replace the signers, and any of them can confirm
+	for _, name := range multiSigContracts {
+		multiSig, err := ctx.registryContract(name)
+		Ω(err).ShouldNot(HaveOccurred())
+		var current []common.Address
+		_, err = multiSig.Query(&current, "getOwners")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(current).Should(ConsistOf(owners[0].Address, owners[1].Address), name)
+
+		var required, internalRequired *big.Int
+		_, err = multiSig.Query(&required, "required")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(required.Int64()).Should(Equal(int64(1)), name)
+		_, err = multiSig.Query(&internalRequired, "internalRequired")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(internalRequired.Int64()).Should(Equal(int64(1)), name)
+	}
+}
@@ -32,15 +32,13 @@ type deployContext struct {
 }
 
 func generateGenesisState(adminAccount env.Account, cfg *Config, buildPath string) (core.GenesisAlloc, error) {
-	deployment := newDeployment(cfg, adminAccount, buildPath)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	deployment := newDeployment(cfg, adminAccount, statedb, buildPath)
 	return deployment.deploy()
 }
 
-// NewDeployment generates a new deployment
-func newDeployment(genesisConfig *Config, adminAccount env.Account, buildPath string) *deployContext {
-
-	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-
+// NewDeployment generates a new deployment on top of the statedb
+func newDeployment(genesisConfig *Config, adminAccount env.Account, statedb *state.StateDB, buildPath string) *deployContext {
 	return &deployContext{
 		GenesisConfig: genesisConfig,
 		adminAccount:  adminAccount,
@@ -167,10 +165,15 @@ func (ctx *deployContext) deploy() (core.GenesisAlloc, error) {
 		return nil, err
 	}
 
-	dump := ctx.statedb.RawDump(false, false, true).Accounts
+	return dumpGenesisAlloc(ctx.statedb), nil
+}
+
+// dumpGenesisAlloc returns the accounts of a committed statedb
+func dumpGenesisAlloc(statedb *state.StateDB) core.GenesisAlloc {
+	dump := statedb.RawDump(false, false, true).Accounts
 	genesisAlloc := make(map[common.Address]core.GenesisAccount)
 	for acc, dumpAcc := range dump {
-		var account core.GenesisAccount
+		account := core.GenesisAccount{Nonce: dumpAcc.Nonce}
 
 		if dumpAcc.Balance != "" {
 			account.Balance, _ = new(big.Int).SetString(dumpAcc.Balance, 10)
@@ -191,7 +194,7 @@ func (ctx *deployContext) deploy() (core.GenesisAlloc, error) {
 
 	}
 
-	return genesisAlloc, nil
+	return genesisAlloc
 }
 
 // Initialize Admin
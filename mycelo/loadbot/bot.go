@@ -3,15 +3,18 @@ package loadbot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"math/rand"
+	"sync"
 	"time"
 
 	bind "github.com/celo-org/celo-blockchain/accounts/abi/bind_v2"
 	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/types"
 
 	"github.com/celo-org/celo-blockchain/ethclient"
-	"github.com/celo-org/celo-blockchain/mycelo/contract"
 	"github.com/celo-org/celo-blockchain/mycelo/env"
 	"golang.org/x/sync/errgroup"
 )
@@ -29,16 +32,46 @@ type Config struct {
 	TransactionsPerSecond int
 	Clients               []*ethclient.Client
 	Verbose               bool
+	Profile               *Profile      // Workloads to run (nil = DefaultProfile)
+	Duration              time.Duration // Duration of the run (0 = until the context is done)
 }
 
-// Start will start loads bots
-func Start(ctx context.Context, cfg *Config) error {
-	group, ctx := errgroup.WithContext(ctx)
+// Start will start loads bots, and returns the report of the run once the
+// context is done or the run duration elapsed
+func Start(ctx context.Context, cfg *Config) (*Report, error) {
+	profile := cfg.Profile
+	if profile == nil {
+		profile = DefaultProfile()
+	}
+	workloads, err := newMix(profile, cfg.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	for i, workload := range workloads.workloads {
+		if err := workload.Setup(ctx, cfg.Accounts[0], cfg.Clients[0]); err != nil {
+			return nil, fmt.Errorf("failed to setup workload %s: %w", workloads.names[i], err)
+		}
+	}
+	startHeader, err := cfg.Clients[0].HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
 
+	stats := newStats()
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	var idxMu sync.Mutex
 	idx := len(cfg.Accounts) / 2
-	nextTransfer := func() (common.Address, *big.Int) {
+	nextRecipient := func() common.Address {
+		idxMu.Lock()
+		defer idxMu.Unlock()
 		idx++
-		return cfg.Accounts[idx%len(cfg.Accounts)].Address, cfg.Amount
+		return cfg.Accounts[idx%len(cfg.Accounts)].Address
 	}
 
 	// developer accounts / TPS = duration in seconds.
@@ -50,50 +83,68 @@ func Start(ctx context.Context, cfg *Config) error {
 		// Spread out client load across different diallers
 		client := cfg.Clients[i%len(cfg.Clients)]
 		acc := acc
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
 
-		err := waitFor(ctx, startDelay)
-		if err != nil {
-			return err
+		if err = waitFor(groupCtx, startDelay); err != nil {
+			break
 		}
 		group.Go(func() error {
-			return runBot(ctx, acc, cfg.Verbose, delay, client, nextTransfer)
+			return runBot(groupCtx, acc, cfg.Verbose, delay, client, nextRecipient, workloads, rnd, stats)
 		})
 	}
-
-	return group.Wait()
+	if groupErr := group.Wait(); err == nil {
+		err = groupErr
+	}
+	report := stats.finish()
+
+	// The run ends with the context, so gather the blocks with a fresh one
+	blocksCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if endHeader, headerErr := cfg.Clients[0].HeaderByNumber(blocksCtx, nil); headerErr == nil {
+		headerErr = report.addBlocks(blocksCtx, cfg.Clients[0], startHeader.Number.Uint64(), endHeader.Number.Uint64())
+		if headerErr != nil {
+			fmt.Printf("Error retrieving the blocks of the run: %v\n", headerErr)
+		}
+	}
+	// Reaching the end of the run is no error
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		err = nil
+	}
+	return report, err
 }
 
-func runBot(ctx context.Context, acc env.Account, verbose bool, sleepTime time.Duration, client bind.ContractBackend, nextTransfer func() (common.Address, *big.Int)) error {
-	abi := contract.AbiFor("StableToken")
-	stableToken := bind.NewBoundContract(env.MustProxyAddressFor("StableToken"), *abi, client)
-
-	transactor := bind.NewKeyedTransactor(acc.PrivateKey)
-	transactor.Context = ctx
-
-	stableTokenAddress := env.MustProxyAddressFor("StableToken")
-	transactor.FeeCurrency = &stableTokenAddress
-
+func runBot(ctx context.Context, acc env.Account, verbose bool, sleepTime time.Duration, client bind.ContractBackend, nextRecipient func() common.Address, workloads *mix, rnd *rand.Rand, stats *stats) error {
 	for {
 		txSentTime := time.Now()
-		recipient, value := nextTransfer()
-		tx, err := stableToken.TxObj(transactor, "transferWithComment", recipient, value, "need to proivde some long comment to make it similar to an encrypted comment").Send()
-		if err != nil {
-			if err != context.Canceled {
-				fmt.Printf("Error sending transaction: %v\n", err)
-			}
-			return fmt.Errorf("Error sending transaction: %w", err)
-		}
-		if verbose {
-			fmt.Printf("cusd transfer generated: from: %s to: %s amount: %s\ttxhash: %s\n", acc.Address.Hex(), recipient.Hex(), value.String(), tx.Transaction.Hash().Hex())
-			printJSON(tx)
-		}
+		recipient := nextRecipient()
+		i := workloads.pick(rnd)
+		name := workloads.names[i]
 
-		_, err = tx.WaitMined(ctx)
+		tx, err := workloads.workloads[i].Send(ctx, acc, recipient, client)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		stats.sent(name)
 		if err != nil {
-			if err != context.Canceled {
+			fmt.Printf("Error sending %s transaction: %v\n", name, err)
+			stats.failed(name, err.Error())
+		} else {
+			if verbose {
+				fmt.Printf("%s transaction generated: from: %s to: %s\ttxhash: %s\n", name, acc.Address.Hex(), recipient.Hex(), tx.Hash().Hex())
+				printJSON(tx)
+			}
+			receipt, err := bind.WaitMined(ctx, client, tx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 				fmt.Printf("Error waiting for tx: %v\n", err)
+				stats.failed(name, err.Error())
+			} else if receipt.Status == types.ReceiptStatusFailed {
+				stats.failed(name, "reverted")
+			} else {
+				stats.mined(name, time.Since(txSentTime), receipt)
 			}
-			return fmt.Errorf("Error waiting for tx: %w", err)
 		}
 
 		nextSendTime := txSentTime.Add(sleepTime)
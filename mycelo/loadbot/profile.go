@@ -0,0 +1,186 @@
+package loadbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Defaults of the workload configs
+var (
+	DefaultGatewayFee   = big.NewInt(10000)
+	DefaultStorageSlots = 10
+)
+
+// Profile is a weighted mix of the workloads run by the load bot
+type Profile struct {
+	Workloads []WorkloadConfig `json:"workloads"`
+}
+
+// WorkloadConfig configures a workload of a profile
+type WorkloadConfig struct {
+	Name       string   `json:"name,omitempty"`       // Name of the workload in the report (default: kind)
+	Kind       string   `json:"kind"`                 // Kind of the workload
+	Weight     uint     `json:"weight"`               // Relative frequency of the workload transactions
+	Amount     *big.Int `json:"amount,omitempty"`     // Value of the transfers (default: bot's amount)
+	GatewayFee *big.Int `json:"gatewayFee,omitempty"` // Gateway fee of the gateway transfers
+	Slots      int      `json:"slots,omitempty"`      // Number of storage slots written per call
+}
+
+// DefaultProfile returns the profile with only cUSD transfers
+func DefaultProfile() *Profile {
+	return &Profile{
+		Workloads: []WorkloadConfig{{Kind: StableTransferWorkload, Weight: 1}},
+	}
+}
+
+// LoadProfile reads a profile from a JSON file, or a YAML one if the file
+// extension is .yaml or .yml
+func LoadProfile(path string) (*Profile, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if raw, err = yamlToJSON(raw); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	var profile Profile
+	if err := dec.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+// yamlToJSON converts a YAML document to JSON, so profiles are decoded the
+// same way regardless of their format
+func yamlToJSON(raw []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	var convert func(v interface{}) (interface{}, error)
+	convert = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case map[interface{}]interface{}:
+			m := make(map[string]interface{}, len(v))
+			for key, value := range v {
+				str, ok := key.(string)
+				if !ok {
+					return nil, fmt.Errorf("non string key %v", key)
+				}
+				converted, err := convert(value)
+				if err != nil {
+					return nil, err
+				}
+				m[str] = converted
+			}
+			return m, nil
+		case []interface{}:
+			for i, value := range v {
+				converted, err := convert(value)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = converted
+			}
+			return v, nil
+		default:
+			return v, nil
+		}
+	}
+	doc, err := convert(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// mix picks the workloads of a profile at random, according to their weights
+type mix struct {
+	names     []string
+	workloads []Workload
+	weights   []uint // Cumulative weights of the workloads
+}
+
+// newMix creates the workloads of the profile. The transfers send the amount
+// unless their config sets another one.
+func newMix(profile *Profile, amount *big.Int) (*mix, error) {
+	if len(profile.Workloads) == 0 {
+		return nil, fmt.Errorf("profile has no workloads")
+	}
+	m := new(mix)
+	var total uint
+	for i, cfg := range profile.Workloads {
+		workload, err := newWorkload(&cfg, amount)
+		if err != nil {
+			return nil, fmt.Errorf("workload %d: %w", i, err)
+		}
+		name := cfg.Name
+		if name == "" {
+			name = cfg.Kind
+		}
+		total += cfg.Weight
+		m.names = append(m.names, name)
+		m.workloads = append(m.workloads, workload)
+		m.weights = append(m.weights, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("profile workloads have no weight")
+	}
+	return m, nil
+}
+
+// newWorkload creates the workload of a config
+func newWorkload(cfg *WorkloadConfig, amount *big.Int) (Workload, error) {
+	if cfg.Amount != nil {
+		amount = cfg.Amount
+	}
+	switch cfg.Kind {
+	case TransferWorkload:
+		return &transferWorkload{amount: amount}, nil
+	case GatewayTransferWorkload:
+		gatewayFee := cfg.GatewayFee
+		if gatewayFee == nil {
+			gatewayFee = DefaultGatewayFee
+		}
+		return &transferWorkload{amount: amount, gatewayFee: gatewayFee}, nil
+	case StableTransferWorkload:
+		return &stableTransferWorkload{amount: amount}, nil
+	case DeployWorkload:
+		return &deployWorkload{}, nil
+	case StorageWriteWorkload:
+		slots := cfg.Slots
+		if slots == 0 {
+			slots = DefaultStorageSlots
+		}
+		if slots < 0 {
+			return nil, fmt.Errorf("invalid number of storage slots %d", slots)
+		}
+		return &storageWriteWorkload{slots: slots}, nil
+	default:
+		return nil, fmt.Errorf("unknown workload kind %q", cfg.Kind)
+	}
+}
+
+// pick returns the index of a random workload
+func (m *mix) pick(rnd *rand.Rand) int {
+	n := uint(rnd.Int63n(int64(m.weights[len(m.weights)-1])))
+	for i, weight := range m.weights {
+		if n < weight {
+			return i
+		}
+	}
+	return len(m.weights) - 1
+}
@@ -0,0 +1,98 @@
+package loadbot
+
+import (
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func writeProfile(dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	Ω(ioutil.WriteFile(path, []byte(content), 0644)).Should(Succeed())
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "loadbot")
+	Ω(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	expected := &Profile{
+		Workloads: []WorkloadConfig{
+			{Kind: StableTransferWorkload, Weight: 6, Amount: big.NewInt(1000)},
+			{Name: "fees", Kind: GatewayTransferWorkload, Weight: 2, GatewayFee: big.NewInt(50)},
+			{Kind: StorageWriteWorkload, Weight: 1, Slots: 20},
+		},
+	}
+
+	jsonPath := writeProfile(dir, "profile.json", `{
+		"workloads": [
+			{"kind": "stable-transfer", "weight": 6, "amount": 1000},
+			{"name": "fees", "kind": "gateway-transfer", "weight": 2, "gatewayFee": 50},
+			{"kind": "storage-write", "weight": 1, "slots": 20}
+		]
+	}`)
+	profile, err := LoadProfile(jsonPath)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(profile).Should(Equal(expected))
+
+	yamlPath := writeProfile(dir, "profile.yaml", `
+workloads:
+  - kind: stable-transfer
+    weight: 6
+    amount: 1000
+  - name: fees
+    kind: gateway-transfer
+    weight: 2
+    gatewayFee: 50
+  - kind: storage-write
+    weight: 1
+    slots: 20
+`)
+	profile, err = LoadProfile(yamlPath)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(profile).Should(Equal(expected))
+
+	_, err = LoadProfile(writeProfile(dir, "typo.json", `{"workloads": [{"kind": "transfer", "wieght": 1}]}`))
+	Ω(err).Should(HaveOccurred())
+}
+
+func TestNewMix(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := newMix(&Profile{}, big.NewInt(1))
+	Ω(err).Should(HaveOccurred())
+	_, err = newMix(&Profile{Workloads: []WorkloadConfig{{Kind: "unknown", Weight: 1}}}, big.NewInt(1))
+	Ω(err).Should(HaveOccurred())
+	_, err = newMix(&Profile{Workloads: []WorkloadConfig{{Kind: TransferWorkload}}}, big.NewInt(1))
+	Ω(err).Should(HaveOccurred())
+
+	m, err := newMix(&Profile{
+		Workloads: []WorkloadConfig{
+			{Kind: TransferWorkload, Weight: 3},
+			{Kind: DeployWorkload, Weight: 0},
+			{Name: "writes", Kind: StorageWriteWorkload, Weight: 1},
+		},
+	}, big.NewInt(1))
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(m.names).Should(Equal([]string{TransferWorkload, DeployWorkload, "writes"}))
+	Ω(m.workloads[0]).Should(Equal(&transferWorkload{amount: big.NewInt(1)}))
+	Ω(m.workloads[2]).Should(Equal(&storageWriteWorkload{slots: DefaultStorageSlots}))
+
+	// Workloads are picked according to their weights
+	counts := make([]int, len(m.workloads))
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		counts[m.pick(rnd)]++
+	}
+	Ω(counts[1]).Should(BeZero())
+	Ω(counts[0]).Should(BeNumerically("~", 3000, 150))
+	Ω(counts[2]).Should(BeNumerically("~", 1000, 150))
+}
@@ -0,0 +1,186 @@
+package loadbot
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/ethclient"
+)
+
+// Report summarizes a load bot run
+type Report struct {
+	Duration time.Duration `json:"duration"`
+	Sent     int           `json:"sent"`   // Transactions sent
+	Mined    int           `json:"mined"`  // Transactions mined successfully
+	Failed   int           `json:"failed"` // Transactions which couldn't be sent or reverted
+	TPS      float64       `json:"tps"`    // Transactions mined successfully per second
+
+	// Submit to receipt latency percentiles of the mined transactions
+	LatencyP50 time.Duration `json:"latencyP50"`
+	LatencyP95 time.Duration `json:"latencyP95"`
+	LatencyP99 time.Duration `json:"latencyP99"`
+
+	Failures  map[string]int             `json:"failures"`  // Number of failed transactions by reason
+	Workloads map[string]*WorkloadReport `json:"workloads"` // Results by workload name
+
+	// Gas used by the blocks produced during the run, by all their transactions
+	Blocks         uint64 `json:"blocks"`
+	GasPerBlock    uint64 `json:"gasPerBlock"`
+	MaxGasPerBlock uint64 `json:"maxGasPerBlock"`
+}
+
+// WorkloadReport summarizes the transactions of a workload
+type WorkloadReport struct {
+	Sent    int    `json:"sent"`
+	Mined   int    `json:"mined"`
+	Failed  int    `json:"failed"`
+	GasUsed uint64 `json:"gasUsed"` // Gas used by the mined transactions
+}
+
+// String returns a human readable summary of the report
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Duration:      %v\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "Transactions:  %d sent, %d mined, %d failed\n", r.Sent, r.Mined, r.Failed)
+	fmt.Fprintf(&b, "Achieved TPS:  %.2f\n", r.TPS)
+	fmt.Fprintf(&b, "Latency:       p50 %v, p95 %v, p99 %v\n", r.LatencyP50.Round(time.Millisecond), r.LatencyP95.Round(time.Millisecond), r.LatencyP99.Round(time.Millisecond))
+	fmt.Fprintf(&b, "Gas per block: %d average, %d max over %d blocks\n", r.GasPerBlock, r.MaxGasPerBlock, r.Blocks)
+
+	names := make([]string, 0, len(r.Workloads))
+	for name := range r.Workloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w := r.Workloads[name]
+		var gasPerTx uint64
+		if w.Mined > 0 {
+			gasPerTx = w.GasUsed / uint64(w.Mined)
+		}
+		fmt.Fprintf(&b, "Workload %s: %d sent, %d mined, %d failed, %d gas per transaction\n", name, w.Sent, w.Mined, w.Failed, gasPerTx)
+	}
+
+	reasons := make([]string, 0, len(r.Failures))
+	for reason := range r.Failures {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "Failure %q: %d\n", reason, r.Failures[reason])
+	}
+	return b.String()
+}
+
+// stats collects the results of the transactions sent by the bots
+type stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	latencies []time.Duration
+	report    Report
+}
+
+func newStats() *stats {
+	return &stats{
+		start: time.Now(),
+		report: Report{
+			Failures:  make(map[string]int),
+			Workloads: make(map[string]*WorkloadReport),
+		},
+	}
+}
+
+func (s *stats) workload(name string) *WorkloadReport {
+	w, ok := s.report.Workloads[name]
+	if !ok {
+		w = new(WorkloadReport)
+		s.report.Workloads[name] = w
+	}
+	return w
+}
+
+// sent records a transaction sent by a workload
+func (s *stats) sent(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report.Sent++
+	s.workload(name).Sent++
+}
+
+// mined records the receipt of a successful transaction, with its latency
+func (s *stats) mined(name string, latency time.Duration, receipt *types.Receipt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report.Mined++
+	s.latencies = append(s.latencies, latency)
+	w := s.workload(name)
+	w.Mined++
+	w.GasUsed += receipt.GasUsed
+}
+
+// failed records a transaction which couldn't be sent or reverted
+func (s *stats) failed(name string, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report.Failed++
+	s.report.Failures[reason]++
+	s.workload(name).Failed++
+}
+
+// finish computes the report of the transactions recorded so far
+func (s *stats) finish() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := s.report
+	report.Duration = time.Since(s.start)
+	if report.Duration > 0 {
+		report.TPS = float64(report.Mined) / report.Duration.Seconds()
+	}
+	latencies := append([]time.Duration{}, s.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.LatencyP50 = percentile(latencies, 50)
+	report.LatencyP95 = percentile(latencies, 95)
+	report.LatencyP99 = percentile(latencies, 99)
+	return &report
+}
+
+// percentile returns the nearest rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// addBlocks adds the gas used by the blocks after from, up to and including to
+func (r *Report) addBlocks(ctx context.Context, client *ethclient.Client, from, to uint64) error {
+	var total uint64
+	for number := from + 1; number <= to; number++ {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		total += header.GasUsed
+		if header.GasUsed > r.MaxGasPerBlock {
+			r.MaxGasPerBlock = header.GasUsed
+		}
+		r.Blocks++
+	}
+	if r.Blocks > 0 {
+		r.GasPerBlock = total / r.Blocks
+	}
+	return nil
+}
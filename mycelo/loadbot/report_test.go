@@ -0,0 +1,51 @@
+package loadbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	. "github.com/onsi/gomega"
+)
+
+func TestPercentile(t *testing.T) {
+	RegisterTestingT(t)
+
+	Ω(percentile(nil, 50)).Should(BeZero())
+
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	Ω(percentile(sorted, 50)).Should(Equal(50 * time.Millisecond))
+	Ω(percentile(sorted, 95)).Should(Equal(95 * time.Millisecond))
+	Ω(percentile(sorted, 99)).Should(Equal(99 * time.Millisecond))
+	Ω(percentile(sorted[:1], 99)).Should(Equal(time.Millisecond))
+}
+
+func TestStatsReport(t *testing.T) {
+	RegisterTestingT(t)
+
+	s := newStats()
+	for i := 1; i <= 3; i++ {
+		s.sent("transfer")
+		s.mined("transfer", time.Duration(4-i)*time.Second, &types.Receipt{GasUsed: 21000})
+	}
+	s.sent("storage-write")
+	s.failed("storage-write", "reverted")
+	s.failed("transfer", "nonce too low")
+
+	report := s.finish()
+	Ω(report.Sent).Should(Equal(4))
+	Ω(report.Mined).Should(Equal(3))
+	Ω(report.Failed).Should(Equal(2))
+	Ω(report.TPS).Should(BeNumerically(">", 0))
+	Ω(report.LatencyP50).Should(Equal(2 * time.Second))
+	Ω(report.LatencyP99).Should(Equal(3 * time.Second))
+	Ω(report.Failures).Should(Equal(map[string]int{"reverted": 1, "nonce too low": 1}))
+	Ω(report.Workloads).Should(Equal(map[string]*WorkloadReport{
+		"transfer":      {Sent: 3, Mined: 3, Failed: 1, GasUsed: 63000},
+		"storage-write": {Sent: 1, Failed: 1},
+	}))
+	Ω(report.String()).Should(ContainSubstring("Workload transfer: 3 sent, 3 mined, 1 failed, 21000 gas per transaction"))
+}
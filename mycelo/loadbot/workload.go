@@ -0,0 +1,121 @@
+package loadbot
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/celo-org/celo-blockchain/accounts/abi"
+	bind "github.com/celo-org/celo-blockchain/accounts/abi/bind_v2"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/celo-org/celo-blockchain/mycelo/contract"
+	"github.com/celo-org/celo-blockchain/mycelo/env"
+	"github.com/celo-org/celo-blockchain/params"
+)
+
+// Workload kinds to use in load bot profiles
+const (
+	TransferWorkload        = "transfer"         // CELO transfers
+	StableTransferWorkload  = "stable-transfer"  // cUSD transfers paying fees in cUSD
+	GatewayTransferWorkload = "gateway-transfer" // CELO transfers paying a gateway fee to the recipient
+	DeployWorkload          = "deploy"           // Contract deployments
+	StorageWriteWorkload    = "storage-write"    // Contract calls writing new storage slots
+)
+
+// storageWriterCode is the creation code of a contract which writes 1 to the
+// storage slots given as 32 bytes words of its call data.
+var storageWriterCode = common.FromHex("601780600b6000396000f3" + "60005b3681101560155760018135556020016002565b00")
+
+// Workload generates the transactions of a kind of load
+type Workload interface {
+	// Setup prepares the chain for the workload, e.g. deploying the contracts it
+	// calls, with transactions sent from the account.
+	Setup(ctx context.Context, acc env.Account, client bind.ContractBackend) error
+
+	// Send sends the next transaction of the workload from the account. The
+	// recipient receives the value of transfers.
+	Send(ctx context.Context, acc env.Account, recipient common.Address, client bind.ContractBackend) (*types.Transaction, error)
+}
+
+func newTransactor(ctx context.Context, acc env.Account) *bind.TransactOpts {
+	transactor := bind.NewKeyedTransactor(acc.PrivateKey)
+	transactor.Context = ctx
+	return transactor
+}
+
+// transferWorkload sends CELO transfers, optionally paying a gateway fee
+type transferWorkload struct {
+	amount     *big.Int
+	gatewayFee *big.Int // nil = no gateway fee
+}
+
+func (w *transferWorkload) Setup(ctx context.Context, acc env.Account, client bind.ContractBackend) error {
+	return nil
+}
+
+func (w *transferWorkload) Send(ctx context.Context, acc env.Account, recipient common.Address, client bind.ContractBackend) (*types.Transaction, error) {
+	transactor := newTransactor(ctx, acc)
+	transactor.Value = w.amount
+	transactor.GasLimit = params.TxGas
+	if w.gatewayFee != nil {
+		transactor.GatewayFeeRecipient = &recipient
+		transactor.GatewayFee = w.gatewayFee
+	}
+	return bind.NewBoundContract(recipient, abi.ABI{}, client).Transfer(transactor)
+}
+
+// stableTransferWorkload sends cUSD transfers with a comment, paying fees in cUSD
+type stableTransferWorkload struct {
+	amount *big.Int
+}
+
+func (w *stableTransferWorkload) Setup(ctx context.Context, acc env.Account, client bind.ContractBackend) error {
+	return nil
+}
+
+func (w *stableTransferWorkload) Send(ctx context.Context, acc env.Account, recipient common.Address, client bind.ContractBackend) (*types.Transaction, error) {
+	stableTokenAddress := env.MustProxyAddressFor("StableToken")
+	stableToken := bind.NewBoundContract(stableTokenAddress, *contract.AbiFor("StableToken"), client)
+
+	transactor := newTransactor(ctx, acc)
+	transactor.FeeCurrency = &stableTokenAddress
+	return stableToken.Transact(transactor, "transferWithComment", recipient, w.amount, "need to proivde some long comment to make it similar to an encrypted comment")
+}
+
+// deployWorkload deploys storage writer contracts
+type deployWorkload struct{}
+
+func (w *deployWorkload) Setup(ctx context.Context, acc env.Account, client bind.ContractBackend) error {
+	return nil
+}
+
+func (w *deployWorkload) Send(ctx context.Context, acc env.Account, recipient common.Address, client bind.ContractBackend) (*types.Transaction, error) {
+	_, tx, _, err := bind.DeployContract(newTransactor(ctx, acc), abi.ABI{}, storageWriterCode, client)
+	return tx, err
+}
+
+// storageWriteWorkload calls a storage writer contract, deployed on setup, to
+// write a number of new storage slots
+type storageWriteWorkload struct {
+	slots    int
+	contract common.Address
+}
+
+func (w *storageWriteWorkload) Setup(ctx context.Context, acc env.Account, client bind.ContractBackend) error {
+	_, tx, _, err := bind.DeployContract(newTransactor(ctx, acc), abi.ABI{}, storageWriterCode, client)
+	if err != nil {
+		return err
+	}
+	w.contract, err = bind.WaitDeployed(ctx, client, tx)
+	return err
+}
+
+func (w *storageWriteWorkload) Send(ctx context.Context, acc env.Account, recipient common.Address, client bind.ContractBackend) (*types.Transaction, error) {
+	// Random slots are new ones, which are the most expensive to write
+	slots := make([]byte, w.slots*common.HashLength)
+	if _, err := rand.Read(slots); err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(w.contract, abi.ABI{}, client).RawTransact(newTransactor(ctx, acc), slots)
+}
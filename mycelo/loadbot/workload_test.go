@@ -0,0 +1,35 @@
+package loadbot
+
+import (
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/rawdb"
+	"github.com/celo-org/celo-blockchain/core/state"
+	"github.com/celo-org/celo-blockchain/core/vm/runtime"
+	. "github.com/onsi/gomega"
+)
+
+func TestStorageWriterCode(t *testing.T) {
+	RegisterTestingT(t)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	cfg := &runtime.Config{State: statedb}
+
+	_, address, _, err := runtime.Create(storageWriterCode, cfg)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(statedb.GetCode(address)).ShouldNot(BeEmpty())
+
+	slots := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0xabcdef")}
+	var input []byte
+	for _, slot := range slots {
+		input = append(input, slot.Bytes()...)
+	}
+	_, _, err = runtime.Call(address, input, cfg)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	for _, slot := range slots {
+		Ω(statedb.GetState(address, slot)).Should(Equal(common.BigToHash(common.Big1)))
+	}
+	Ω(statedb.GetState(address, common.HexToHash("0x02"))).Should(Equal(common.Hash{}))
+}
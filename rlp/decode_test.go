@@ -284,6 +284,33 @@ func TestStreamRaw(t *testing.T) {
 	}
 }
 
+// dataEOFReader returns EOF along with the final data, like readers are allowed to.
+type dataEOFReader struct{ *bytes.Reader }
+
+func (r dataEOFReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	if err == nil && r.Len() == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// This test verifies that reading the last value from a reader which returns
+// EOF along with the final data succeeds.
+func TestStreamReadFullEOF(t *testing.T) {
+	input := unhex("C58401010101")
+	s := NewStream(dataEOFReader{bytes.NewReader(input)}, 0)
+	s.List()
+
+	raw, err := s.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex("8401010101"); !bytes.Equal(want, raw) {
+		t.Errorf("raw mismatch: got %x, want %x", raw, want)
+	}
+}
+
 func TestDecodeErrors(t *testing.T) {
 	r := bytes.NewReader(nil)
 